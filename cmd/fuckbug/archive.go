@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fuckbug/api/internal/logger"
+	moduleArchive "github.com/fuckbug/api/internal/modules/archive"
+)
+
+const restoreCommand = "restore"
+
+// runRestore re-imports archived events, as in
+//
+//	fuckbug -config config.json restore -project <id> -from 2024-01-01 -to 2024-01-31 -kind logs
+func runRestore(ctx context.Context, db *sqlx.DB, appLogger *logger.Logger, config archiveConf, args []string) error {
+	flags := flag.NewFlagSet(restoreCommand, flag.ContinueOnError)
+	projectID := flags.String("project", "", "ID of the project to restore")
+	from := flags.String("from", "", "First day to restore, as 2024-01-01")
+	to := flags.String("to", "", "Last day to restore, the first day when empty")
+	kind := flags.String("kind", "", "Restore only errors or logs")
+	batchSize := flags.Int("batch-size", 0, "Number of events inserted by a single statement")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *projectID == "" || *from == "" {
+		return errors.New("-project and -from are required")
+	}
+	if *to == "" {
+		to = from
+	}
+
+	params := moduleArchive.RestoreParams{
+		ProjectID: *projectID,
+		Kinds:     []moduleArchive.Kind{moduleArchive.KindErrors, moduleArchive.KindLogs},
+	}
+
+	switch moduleArchive.Kind(*kind) {
+	case "":
+	case moduleArchive.KindErrors, moduleArchive.KindLogs:
+		params.Kinds = []moduleArchive.Kind{moduleArchive.Kind(*kind)}
+	default:
+		return fmt.Errorf("unknown kind %q, expected errors or logs", *kind)
+	}
+
+	var err error
+	if params.From, err = time.Parse(time.DateOnly, *from); err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	if params.To, err = time.Parse(time.DateOnly, *to); err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	store, err := newBlobStore("archive", config.Storage, config.Path, config.S3)
+	if err != nil {
+		return err
+	}
+
+	restorer := moduleArchive.NewRestorer(store, moduleArchive.NewRepository(db, appLogger), appLogger, *batchSize)
+
+	report, err := restorer.Restore(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	appLogger.Info(fmt.Sprintf(
+		"restore done: %d files, %d events read, %d restored", report.Files, report.Events, report.Restored,
+	))
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fuckbug/api/internal/storage/blob"
+)
+
+const (
+	blobStorageFilesystem = "filesystem"
+	blobStorageS3         = "s3"
+)
+
+// newBlobStore returns the store configured for the archive or the attachments, named in the errors.
+func newBlobStore(name, storage, path string, s3 s3Conf) (blob.Store, error) {
+	switch storage {
+	case blobStorageFilesystem, "":
+		if path == "" {
+			return nil, fmt.Errorf("%s path is required", name)
+		}
+		return blob.NewFilesystemStore(path), nil
+	case blobStorageS3:
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			UseSSL:    s3.UseSSL,
+			Prefix:    s3.Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown %s storage %q", name, storage)
+	}
+}
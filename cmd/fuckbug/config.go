@@ -2,25 +2,172 @@ package main
 
 import (
 	"strings"
+	"time"
 
+	"github.com/fuckbug/api/internal/storage"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Logger   loggerConf
-	Port     int
-	Postgres postgresConf
-	Domain   string
+	Logger      loggerConf
+	Port        int
+	Database    databaseConf
+	Postgres    postgresConf
+	SQLite      sqliteConf
+	Domain      string
+	Errors      errorsConf
+	Anomaly     anomalyConf
+	Alerts      alertsConf
+	Webhooks    webhooksConf
+	SMTP        smtpConf
+	Digest      digestConf
+	Chat        chatConf
+	Snooze      snoozeConf
+	Bulk        bulkConf
+	Stream      streamConf
+	Partitions  partitionsConf
+	Retention   retentionConf
+	Archive     archiveConf
+	Attachments attachmentsConf
 }
 
 type loggerConf struct {
 	Level string
 }
 
+// databaseConf selects the storage: postgres, the default, or sqlite3 for single-binary instances.
+type databaseConf struct {
+	Driver string
+}
+
 type postgresConf struct {
 	Dsn string
 }
 
+type sqliteConf struct {
+	Dsn string
+}
+
+// errorsConf keeps Samples whole errors per group and window, the others sharing their payload.
+type errorsConf struct {
+	Samples      int
+	SampleWindow time.Duration
+}
+
+type anomalyConf struct {
+	Enabled        bool
+	Interval       time.Duration
+	Window         time.Duration
+	BaselineWindow time.Duration
+	QuietPeriod    time.Duration
+	Multiplier     float64
+	MinEvents      int
+}
+
+type alertsConf struct {
+	Workers   int
+	QueueSize int
+}
+
+type webhooksConf struct {
+	Enabled     bool
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Timeout     time.Duration
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+type smtpConf struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      bool
+	Timeout  time.Duration
+}
+
+type digestConf struct {
+	Enabled     bool
+	Interval    time.Duration
+	GroupsLimit int
+}
+
+type chatConf struct {
+	TelegramAPIURL string
+	GroupURL       string
+	Timeout        time.Duration
+}
+
+type snoozeConf struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+type bulkConf struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+	Lease     time.Duration
+}
+
+type streamConf struct {
+	BufferSize  int
+	ReplayLimit int
+}
+
+type partitionsConf struct {
+	Enabled     bool
+	Interval    time.Duration
+	Granularity string
+	Ahead       int
+	Retention   time.Duration
+}
+
+type retentionConf struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+}
+
+type archiveConf struct {
+	Enabled bool
+	Storage string
+	Path    string
+	S3      s3Conf
+}
+
+// attachmentsConf stores the files of the errors, MaxFileSize and MaxErrorSize being the limits
+// in bytes of the projects setting none.
+type attachmentsConf struct {
+	Storage         string
+	Path            string
+	S3              s3Conf
+	MaxFileSize     int64
+	MaxErrorSize    int64
+	CleanupInterval time.Duration
+}
+
+type s3Conf struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	Prefix    string
+}
+
+// DatabaseDsn returns the DSN of the selected database driver.
+func (c Config) DatabaseDsn() string {
+	if c.Database.Driver == storage.DriverSQLite {
+		return c.SQLite.Dsn
+	}
+	return c.Postgres.Dsn
+}
+
 func LoadConfig(path string) (Config, error) {
 	config := Config{}
 
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fuckbug/api/internal/logger"
+	moduleError "github.com/fuckbug/api/internal/modules/errors"
+	moduleImporter "github.com/fuckbug/api/internal/modules/importer"
+	moduleLog "github.com/fuckbug/api/internal/modules/log"
+)
+
+const importCommand = "import"
+
+// runImport imports the events of other systems from files, or from the standard input for "-", as in
+//
+//	fuckbug -config config.json import -project <id> -format sentry events.json
+//	fuckbug -config config.json import -project <id> -format ndjson -kind logs logs.ndjson
+func runImport(ctx context.Context, db *sqlx.DB, appLogger *logger.Logger, args []string) error {
+	flags := flag.NewFlagSet(importCommand, flag.ContinueOnError)
+	projectID := flags.String("project", "", "ID of the project to import into")
+	format := flags.String("format", "", "Format of the files, ndjson or sentry")
+	kind := flags.String("kind", "", "Kind of the NDJSON records, errors or logs")
+	progressEvery := flags.Int("progress", 0, "Number of records read between two progress reports")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *projectID == "" || *format == "" || flags.NArg() == 0 {
+		return errors.New("-project, -format and at least one file are required")
+	}
+
+	// Imported events are not published, the services do without the event bus.
+	importer := moduleImporter.NewImporter(
+		moduleError.NewService(moduleError.NewRepository(db, appLogger, moduleError.Config{}), appLogger, nil),
+		moduleLog.NewService(moduleLog.NewRepository(db, appLogger), appLogger, nil),
+		appLogger,
+		moduleImporter.Config{ProgressEvery: *progressEvery},
+	)
+
+	params := moduleImporter.Params{
+		ProjectID: *projectID,
+		Format:    moduleImporter.Format(*format),
+		Kind:      moduleImporter.Kind(*kind),
+	}
+
+	for _, path := range flags.Args() {
+		if err := importFile(ctx, importer, path, params); err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func importFile(ctx context.Context, importer *moduleImporter.Importer, path string, params moduleImporter.Params) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	_, err := importer.Import(ctx, r, params)
+	return err
+}
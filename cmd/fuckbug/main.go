@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,16 +14,30 @@ import (
 
 	_ "github.com/fuckbug/api/docs" // for swagger
 
+	"github.com/fuckbug/api/internal/events"
 	"github.com/fuckbug/api/internal/modules/app"
+	"github.com/fuckbug/api/internal/storage"
 	"github.com/fuckbug/api/internal/storage/sql"
 
 	"github.com/fuckbug/api/internal/logger"
+	moduleActivity "github.com/fuckbug/api/internal/modules/activity"
+	moduleAlert "github.com/fuckbug/api/internal/modules/alert"
+	moduleAnomaly "github.com/fuckbug/api/internal/modules/anomaly"
+	moduleArchive "github.com/fuckbug/api/internal/modules/archive"
+	moduleAttachment "github.com/fuckbug/api/internal/modules/attachment"
+	moduleBulk "github.com/fuckbug/api/internal/modules/bulk"
+	moduleChannel "github.com/fuckbug/api/internal/modules/channel"
 	moduleError "github.com/fuckbug/api/internal/modules/errors"
 	moduleGroupError "github.com/fuckbug/api/internal/modules/errorsGroup"
+	moduleImporter "github.com/fuckbug/api/internal/modules/importer"
 	moduleLog "github.com/fuckbug/api/internal/modules/log"
 	moduleGroupLog "github.com/fuckbug/api/internal/modules/logGroup"
+	moduleNotification "github.com/fuckbug/api/internal/modules/notification"
 	moduleProject "github.com/fuckbug/api/internal/modules/project"
+	moduleRetention "github.com/fuckbug/api/internal/modules/retention"
+	moduleStream "github.com/fuckbug/api/internal/modules/stream"
 	moduleUser "github.com/fuckbug/api/internal/modules/users"
+	moduleWebhook "github.com/fuckbug/api/internal/modules/webhook"
 	server "github.com/fuckbug/api/internal/server/http"
 )
 
@@ -61,7 +76,7 @@ func main() {
 
 	appLogger := logger.New(config.Logger.Level, nil)
 
-	db, err := sqlx.Connect("postgres", config.Postgres.Dsn)
+	db, err := storage.Open(config.Database.Driver, config.DatabaseDsn())
 	if err != nil {
 		appLogger.Error(fmt.Sprintf("failed to connect to database: %v", err))
 		return
@@ -76,15 +91,223 @@ func main() {
 		return
 	}
 
+	if flag.Arg(0) == restoreCommand {
+		if err := runRestore(ctx, db, appLogger, config.Archive, flag.Args()[1:]); err != nil {
+			appLogger.Error(fmt.Sprintf("failed to restore archive: %v", err))
+			os.Exit(1) //nolint:gocritic
+		}
+		return
+	}
+
+	if flag.Arg(0) == importCommand {
+		if err := runImport(ctx, db, appLogger, flag.Args()[1:]); err != nil {
+			appLogger.Error(fmt.Sprintf("failed to import: %v", err))
+			os.Exit(1) //nolint:gocritic
+		}
+		return
+	}
+
 	jwtKey := []byte("teststringjwt") // todo
 
+	bus := events.NewBus()
+
 	appService := app.New(appLogger)
 	userService := moduleUser.NewService(moduleUser.NewRepository(db, appLogger), jwtKey, appLogger)
-	logService := moduleLog.NewService(moduleLog.NewRepository(db, appLogger), appLogger)
-	logGroupService := moduleGroupLog.NewService(moduleGroupLog.NewRepository(db, appLogger), appLogger)
-	errorService := moduleError.NewService(moduleError.NewRepository(db, appLogger), appLogger)
-	errorGroupService := moduleGroupError.NewService(moduleGroupError.NewRepository(db, appLogger), appLogger)
+	logService := moduleLog.NewService(moduleLog.NewRepository(db, appLogger), appLogger, bus)
+	logGroupService := moduleGroupLog.NewService(moduleGroupLog.NewRepository(db, appLogger), appLogger, bus)
+	errorRepository := moduleError.NewRepository(db, appLogger, moduleError.Config{
+		Samples:      config.Errors.Samples,
+		SampleWindow: config.Errors.SampleWindow,
+	})
+	errorService := moduleError.NewService(errorRepository, appLogger, bus)
+	errorGroupService := moduleGroupError.NewService(
+		moduleGroupError.NewRepository(db, appLogger), appLogger, bus,
+	)
 	projectService := moduleProject.NewService(moduleProject.NewRepository(db, appLogger), appLogger, config.Domain)
+	anomalyRepository := moduleAnomaly.NewRepository(db, appLogger)
+	anomalyService := moduleAnomaly.NewService(anomalyRepository, appLogger)
+
+	var mailer moduleNotification.Mailer
+	if config.SMTP.Host != "" {
+		mailer = moduleNotification.NewSMTPMailer(moduleNotification.SMTPConfig{
+			Host:     config.SMTP.Host,
+			Port:     config.SMTP.Port,
+			Username: config.SMTP.Username,
+			Password: config.SMTP.Password,
+			From:     config.SMTP.From,
+			TLS:      config.SMTP.TLS,
+			Timeout:  config.SMTP.Timeout,
+		})
+	}
+
+	notificationConfig := moduleNotification.Config{
+		Interval:    config.Digest.Interval,
+		GroupsLimit: config.Digest.GroupsLimit,
+	}
+	notificationRepository := moduleNotification.NewRepository(db, appLogger)
+	notificationService := moduleNotification.NewService(
+		notificationRepository, mailer, errorService, logService, appLogger, notificationConfig,
+	)
+
+	webhookRepository := moduleWebhook.NewRepository(db, appLogger)
+	webhookService := moduleWebhook.NewService(webhookRepository, appLogger)
+
+	alertNotifiers := map[moduleAlert.ActionType]moduleAlert.Notifier{
+		moduleAlert.ActionWebhook: webhookService,
+	}
+	if mailer != nil {
+		alertNotifiers[moduleAlert.ActionEmail] = notificationService
+	}
+
+	alertService := moduleAlert.NewService(
+		moduleAlert.NewRepository(db, appLogger), appLogger, bus, alertNotifiers, moduleAlert.Config{
+			Workers:   config.Alerts.Workers,
+			QueueSize: config.Alerts.QueueSize,
+		},
+	)
+
+	chatClient := &http.Client{Timeout: config.Chat.Timeout}
+	channelService := moduleChannel.NewService(
+		moduleChannel.NewRepository(db, appLogger),
+		map[moduleChannel.Type]moduleChannel.Sender{
+			moduleChannel.TypeSlack:    moduleChannel.NewSlackSender(chatClient),
+			moduleChannel.TypeTelegram: moduleChannel.NewTelegramSender(chatClient, config.Chat.TelegramAPIURL),
+		},
+		appLogger,
+		moduleChannel.Config{GroupURL: config.Chat.GroupURL},
+	)
+
+	activityService := moduleActivity.NewService(moduleActivity.NewRepository(db, appLogger), appLogger)
+
+	bulkRepository := moduleBulk.NewRepository(db, appLogger)
+	bulkExecutors := map[moduleBulk.Target]moduleBulk.Executor{
+		moduleBulk.TargetErrorGroups: moduleBulk.NewErrorGroupExecutor(errorGroupService),
+		moduleBulk.TargetLogGroups:   moduleBulk.NewLogGroupExecutor(logGroupService),
+		moduleBulk.TargetErrors:      moduleBulk.NewErrorExecutor(errorService),
+		moduleBulk.TargetLogs:        moduleBulk.NewLogExecutor(logService),
+	}
+	bulkService := moduleBulk.NewService(bulkRepository, bulkExecutors, appLogger)
+
+	streamHub := moduleStream.NewHub(config.Stream.BufferSize)
+	streamService := moduleStream.NewService(
+		streamHub,
+		map[moduleStream.Kind]moduleStream.History{
+			moduleStream.KindError: moduleStream.NewErrorHistory(errorService),
+			moduleStream.KindLog:   moduleStream.NewLogHistory(logService),
+		},
+		appLogger,
+		moduleStream.Config{BufferSize: config.Stream.BufferSize, ReplayLimit: config.Stream.ReplayLimit},
+	)
+
+	retentionConfig := moduleRetention.Config{
+		Interval:  config.Retention.Interval,
+		BatchSize: config.Retention.BatchSize,
+	}
+	var archiver moduleRetention.Archiver
+	if config.Archive.Enabled {
+		archiveStore, err := newBlobStore("archive", config.Archive.Storage, config.Archive.Path, config.Archive.S3)
+		if err != nil {
+			appLogger.Error(fmt.Sprintf("failed to create archive storage: %v", err))
+			return
+		}
+		archiver = moduleArchive.NewArchiver(archiveStore, appLogger)
+	}
+
+	retentionService := moduleRetention.NewService(
+		moduleRetention.NewRepository(db, appLogger), archiver, appLogger, retentionConfig,
+	)
+
+	importService := moduleImporter.NewImporter(errorService, logService, appLogger, moduleImporter.Config{})
+
+	attachmentStore, err := newBlobStore(
+		"attachments", config.Attachments.Storage, config.Attachments.Path, config.Attachments.S3,
+	)
+	if err != nil {
+		appLogger.Error(fmt.Sprintf("failed to create attachment storage: %v", err))
+		return
+	}
+	attachmentConfig := moduleAttachment.Config{
+		MaxFileSize:     config.Attachments.MaxFileSize,
+		MaxErrorSize:    config.Attachments.MaxErrorSize,
+		CleanupInterval: config.Attachments.CleanupInterval,
+	}
+	attachmentService := moduleAttachment.NewService(
+		moduleAttachment.NewRepository(db, appLogger), attachmentStore, appLogger, attachmentConfig,
+	)
+
+	bus.Subscribe(activityService.HandleEvent)
+	bus.Subscribe(alertService.Evaluate)
+	go alertService.Run(ctx)
+	bus.Subscribe(webhookService.Enqueue)
+	bus.Subscribe(channelService.HandleEvent)
+	bus.Subscribe(streamHub.HandleEvent)
+
+	if mailer != nil {
+		bus.Subscribe(notificationService.HandleEvent)
+
+		if config.Digest.Enabled {
+			scheduler := moduleNotification.NewDigestScheduler(
+				notificationRepository, notificationService, appLogger, notificationConfig,
+			)
+			go scheduler.Run(ctx)
+		}
+	}
+
+	if config.Anomaly.Enabled {
+		detector := moduleAnomaly.NewDetector(anomalyRepository, bus, appLogger, moduleAnomaly.Config{
+			Interval:       config.Anomaly.Interval,
+			Window:         config.Anomaly.Window,
+			BaselineWindow: config.Anomaly.BaselineWindow,
+			QuietPeriod:    config.Anomaly.QuietPeriod,
+			Multiplier:     config.Anomaly.Multiplier,
+			MinEvents:      config.Anomaly.MinEvents,
+		})
+		go detector.Run(ctx)
+	}
+
+	if config.Snooze.Enabled {
+		wakerConfig := moduleGroupError.WakerConfig{Interval: config.Snooze.Interval}
+		go moduleGroupError.NewWaker(errorGroupService, appLogger, wakerConfig).Run(ctx)
+		go moduleGroupLog.NewWaker(logGroupService, appLogger, moduleGroupLog.WakerConfig(wakerConfig)).Run(ctx)
+	}
+
+	if config.Bulk.Enabled {
+		runner := moduleBulk.NewRunner(bulkRepository, bulkExecutors, appLogger, moduleBulk.Config{
+			Interval:  config.Bulk.Interval,
+			BatchSize: config.Bulk.BatchSize,
+			Lease:     config.Bulk.Lease,
+		})
+		go runner.Run(ctx)
+	}
+
+	// SQLite has no partitions, its instances are kept small by the retention policies.
+	if config.Partitions.Enabled && !storage.DialectOf(db).SQLite() {
+		maintainer := sql.NewPartitionMaintainer(db, appLogger, sql.PartitionConfig{
+			Interval:    config.Partitions.Interval,
+			Granularity: config.Partitions.Granularity,
+			Ahead:       config.Partitions.Ahead,
+			Retention:   config.Partitions.Retention,
+		})
+		go maintainer.Run(ctx)
+	}
+
+	if config.Retention.Enabled {
+		go moduleRetention.NewPurger(retentionService, appLogger, retentionConfig).Run(ctx)
+	}
+
+	go moduleAttachment.NewCleaner(attachmentService, appLogger, attachmentConfig).Run(ctx)
+
+	if config.Webhooks.Enabled {
+		dispatcher := moduleWebhook.NewDispatcher(webhookRepository, appLogger, moduleWebhook.Config{
+			Interval:    config.Webhooks.Interval,
+			BatchSize:   config.Webhooks.BatchSize,
+			MaxAttempts: config.Webhooks.MaxAttempts,
+			Timeout:     config.Webhooks.Timeout,
+			BaseBackoff: config.Webhooks.BaseBackoff,
+			MaxBackoff:  config.Webhooks.MaxBackoff,
+		})
+		go dispatcher.Run(ctx)
+	}
 
 	s := server.New(
 		appLogger,
@@ -95,6 +318,17 @@ func main() {
 		errorService,
 		errorGroupService,
 		projectService,
+		anomalyService,
+		alertService,
+		webhookService,
+		notificationService,
+		channelService,
+		activityService,
+		bulkService,
+		streamService,
+		retentionService,
+		importService,
+		attachmentService,
 		"",
 		config.Port,
 		jwtKey,
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+type Type string
+
+const (
+	TypeErrorCreated       Type = "error.created"
+	TypeLogCreated         Type = "log.created"
+	TypeGroupCreated       Type = "group.created"
+	TypeGroupRegressed     Type = "group.regressed"
+	TypeGroupStatusChanged Type = "group.status_changed"
+	TypeGroupAssigned      Type = "group.assigned"
+	TypeGroupUnsnoozed     Type = "group.unsnoozed"
+	TypeGroupMerged        Type = "group.merged"
+	TypeAnomalyDetected    Type = "anomaly.detected"
+	TypeAlertFired         Type = "alert.fired"
+)
+
+const (
+	GroupKindError = "error"
+	GroupKindLog   = "log"
+)
+
+type Event struct {
+	Type      Type
+	ProjectID string
+	GroupID   string
+	GroupKind string
+	Level     string
+	Message   string
+	Time      int64 // Unix timestamp in milliseconds
+	// ActorID is the user who triggered the event, empty for events caused by ingestion
+	ActorID string
+	Change  *Change
+	Payload interface{}
+}
+
+// Change describes the update of a group attribute.
+type Change struct {
+	Field string
+	From  string
+	To    string
+}
+
+type Handler func(ctx context.Context, event Event)
+
+// Bus is an in-process publish/subscribe hub. Handlers are called synchronously
+// from Publish, so they must hand slow work off to their own goroutines.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
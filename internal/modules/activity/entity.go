@@ -0,0 +1,40 @@
+package activity
+
+type Type string
+
+const (
+	TypeCreated       Type = "created"
+	TypeStatusChanged Type = "status_changed"
+	TypeAssigned      Type = "assigned"
+	TypeCommented     Type = "commented"
+	TypeRegressed     Type = "regressed"
+	TypeMerged        Type = "merged"
+)
+
+type Activity struct {
+	ID        string  `db:"id"`
+	ProjectID string  `db:"project_id"`
+	GroupID   string  `db:"group_id"`
+	GroupKind string  `db:"group_kind"`
+	Type      Type    `db:"type"`
+	ActorID   *string `db:"actor_id"`
+	CommentID *string `db:"comment_id"`
+	Data      *string `db:"data"` // JSON encoded Change of the attribute
+	CreatedAt int64   `db:"created_at"`
+
+	// Joined on read
+	ActorEmail       *string `db:"actor_email"`
+	CommentBody      *string `db:"comment_body"`
+	CommentUpdatedAt *int64  `db:"comment_updated_at"`
+}
+
+type Comment struct {
+	ID        string `db:"id"`
+	ProjectID string `db:"project_id"`
+	GroupID   string `db:"group_id"`
+	GroupKind string `db:"group_kind"`
+	AuthorID  string `db:"author_id"`
+	Body      string `db:"body"`
+	CreatedAt int64  `db:"created_at"`
+	UpdatedAt int64  `db:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package activity
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type GetTimelineParams struct {
+	GroupKind string
+	GroupID   string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type CreateComment struct {
+	// Markdown body of the comment
+	Body string `json:"body" validate:"required,max=10000" example:"Caused by the **payment** gateway timeout, see #42"`
+}
+
+type UpdateComment struct {
+	Body string `json:"body" validate:"required,max=10000" example:"Caused by the **payment** gateway timeout, see #42"`
+}
+
+type Change struct {
+	Field string `json:"field" example:"status"`
+	From  string `json:"from" example:"unresolved"`
+	To    string `json:"to" example:"resolved"`
+}
+
+type Entity struct {
+	ID        string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	GroupID   string `json:"groupId" example:"6e1ff2e1ad1ed16e2b0dc4a0ac1c0e9b8dd82e1b0cb8e3ef3a4c2f73c9a1b6f2"`
+	GroupKind string `json:"groupKind" example:"error" enums:"error,log"`
+	Type      string `json:"type" example:"commented" enums:"created,status_changed,assigned,commented,regressed,merged"`
+	// Empty for activity caused by ingestion
+	ActorID    *string        `json:"actorId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ActorEmail *string        `json:"actorEmail" example:"user@example.com"`
+	Change     *Change        `json:"change,omitempty"`
+	Comment    *CommentEntity `json:"comment,omitempty"`
+	CreatedAt  int64          `json:"createdAt" example:"1745446888"`
+}
+
+type CommentEntity struct {
+	ID        string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	AuthorID  string `json:"authorId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Body      string `json:"body" example:"Caused by the **payment** gateway timeout, see #42"`
+	CreatedAt int64  `json:"createdAt" example:"1745446888"`
+	UpdatedAt int64  `json:"updatedAt" example:"1745446888"`
+}
+
+type EntityList struct {
+	Items []Entity `json:"items"`
+	Count int      `json:"count" example:"1"`
+}
@@ -0,0 +1,240 @@
+package activity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrUnknownGroupKind = errors.New("unknown group kind")
+)
+
+var groupTables = map[string]string{
+	events.GroupKindError: "error_groups",
+	events.GroupKindLog:   "log_groups",
+}
+
+type Repository interface {
+	GetGroupProjectID(ctx context.Context, groupKind, groupID string) (string, error)
+	GetTimeline(ctx context.Context, params GetTimelineParams) ([]*Activity, error)
+	CountTimeline(ctx context.Context, groupKind, groupID string) (int, error)
+	CreateActivity(ctx context.Context, activity *Activity) error
+	CreateComment(ctx context.Context, comment *Comment) error
+	GetComment(ctx context.Context, groupKind, groupID, id string) (*Comment, error)
+	UpdateComment(ctx context.Context, id, body string) error
+	DeleteComment(ctx context.Context, id string) error
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *repository) GetGroupProjectID(ctx context.Context, groupKind, groupID string) (string, error) {
+	table, ok := groupTables[groupKind]
+	if !ok {
+		return "", ErrUnknownGroupKind
+	}
+
+	query := `SELECT project_id FROM ` + table + ` WHERE id = $1`
+
+	var projectID string
+	err := r.db.GetContext(ctx, &projectID, query, groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrGroupNotFound
+		}
+		return "", fmt.Errorf("failed to get group project: %w", err)
+	}
+	return projectID, nil
+}
+
+func (r *repository) GetTimeline(ctx context.Context, params GetTimelineParams) ([]*Activity, error) {
+	query := `
+        SELECT
+            a.id, a.project_id, a.group_id, a.group_kind, a.type, a.actor_id, a.comment_id, a.data, a.created_at,
+            u.email AS actor_email, c.body AS comment_body, c.updated_at AS comment_updated_at
+        FROM group_activities a
+        LEFT JOIN users u ON u.id = a.actor_id
+        LEFT JOIN group_comments c ON c.id = a.comment_id
+        WHERE a.group_kind = :groupKind AND a.group_id = :groupId
+        ORDER BY a.created_at ` + params.SortOrder + `, a.id ` + params.SortOrder + `
+        LIMIT :limit OFFSET :offset
+    `
+
+	args := map[string]interface{}{
+		"groupKind": params.GroupKind,
+		"groupId":   params.GroupID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var activities []*Activity
+	err = r.db.SelectContext(ctx, &activities, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group activity: %w", err)
+	}
+	return activities, nil
+}
+
+func (r *repository) CountTimeline(ctx context.Context, groupKind, groupID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM group_activities WHERE group_kind = $1 AND group_id = $2`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, groupKind, groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) CreateActivity(ctx context.Context, activity *Activity) error {
+	return createActivity(ctx, r.db, activity)
+}
+
+// CreateComment stores the comment along with its entry in the group timeline.
+func (r *repository) CreateComment(ctx context.Context, comment *Comment) error {
+	const query = `
+        INSERT INTO group_comments (id, project_id, group_id, group_kind, author_id, body, created_at, updated_at)
+        VALUES (:id, :project_id, :group_id, :group_kind, :author_id, :body, :created_at, :updated_at)
+    `
+
+	if comment.ID == "" {
+		comment.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.NamedExecContext(ctx, query, comment); err != nil {
+		return fmt.Errorf("failed to create group comment: %w", err)
+	}
+
+	err = createActivity(ctx, tx, &Activity{
+		ProjectID: comment.ProjectID,
+		GroupID:   comment.GroupID,
+		GroupKind: comment.GroupKind,
+		Type:      TypeCommented,
+		ActorID:   &comment.AuthorID,
+		CommentID: &comment.ID,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetComment(ctx context.Context, groupKind, groupID, id string) (*Comment, error) {
+	const query = `
+        SELECT id, project_id, group_id, group_kind, author_id, body, created_at, updated_at
+        FROM group_comments
+        WHERE id = $1 AND group_kind = $2 AND group_id = $3
+    `
+
+	var comment Comment
+	err := r.db.GetContext(ctx, &comment, query, id, groupKind, groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get group comment by id: %w", err)
+	}
+	return &comment, nil
+}
+
+func (r *repository) UpdateComment(ctx context.Context, id, body string) error {
+	const query = `UPDATE group_comments SET body = $1, updated_at = $2 WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, body, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update group comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteComment removes the comment, its timeline entry is removed by the cascading foreign key.
+func (r *repository) DeleteComment(ctx context.Context, id string) error {
+	const query = `DELETE FROM group_comments WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func createActivity(ctx context.Context, db sqlx.ExtContext, activity *Activity) error {
+	const query = `
+        INSERT INTO group_activities (id, project_id, group_id, group_kind, type, actor_id, comment_id, data, created_at)
+        VALUES (:id, :project_id, :group_id, :group_kind, :type, :actor_id, :comment_id, :data, :created_at)
+    `
+
+	if activity.ID == "" {
+		activity.ID = uuid.New().String()
+	}
+	if activity.CreatedAt == 0 {
+		activity.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := sqlx.NamedExecContext(ctx, db, query, activity)
+	if err != nil {
+		return fmt.Errorf("failed to create group activity: %w", err)
+	}
+	return nil
+}
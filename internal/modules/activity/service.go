@@ -0,0 +1,236 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/middleware"
+)
+
+const recordTimeout = 10 * time.Second
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("only the author can change a comment")
+)
+
+// recordedEvents maps the group events to the activity they add to the timeline.
+var recordedEvents = map[events.Type]Type{
+	events.TypeGroupCreated:       TypeCreated,
+	events.TypeGroupRegressed:     TypeRegressed,
+	events.TypeGroupStatusChanged: TypeStatusChanged,
+	events.TypeGroupAssigned:      TypeAssigned,
+	events.TypeGroupUnsnoozed:     TypeStatusChanged,
+	events.TypeGroupMerged:        TypeMerged,
+}
+
+type Service interface {
+	GetTimeline(ctx context.Context, params GetTimelineParams) ([]*Entity, int, error)
+	AddComment(ctx context.Context, groupKind, groupID string, req *CreateComment) (*CommentEntity, error)
+	UpdateComment(ctx context.Context, groupKind, groupID, id string, req *UpdateComment) (*CommentEntity, error)
+	DeleteComment(ctx context.Context, groupKind, groupID, id string) error
+	HandleEvent(ctx context.Context, event events.Event)
+}
+
+type service struct {
+	repo   Repository
+	logger Logger
+}
+
+func NewService(repo Repository, logger Logger) Service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *service) GetTimeline(ctx context.Context, params GetTimelineParams) ([]*Entity, int, error) {
+	if _, err := s.repo.GetGroupProjectID(ctx, params.GroupKind, params.GroupID); err != nil {
+		return nil, 0, err
+	}
+
+	activities, err := s.repo.GetTimeline(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountTimeline(ctx, params.GroupKind, params.GroupID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(activities))
+	for _, activity := range activities {
+		responses = append(responses, toResponse(activity))
+	}
+	return responses, total, nil
+}
+
+func (s *service) AddComment(
+	ctx context.Context,
+	groupKind, groupID string,
+	req *CreateComment,
+) (*CommentEntity, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	projectID, err := s.repo.GetGroupProjectID(ctx, groupKind, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &Comment{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		GroupKind: groupKind,
+		AuthorID:  userID,
+		Body:      req.Body,
+	}
+
+	if err := s.repo.CreateComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return toCommentResponse(comment), nil
+}
+
+func (s *service) UpdateComment(
+	ctx context.Context,
+	groupKind, groupID, id string,
+	req *UpdateComment,
+) (*CommentEntity, error) {
+	comment, err := s.authoredComment(ctx, groupKind, groupID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateComment(ctx, comment.ID, req.Body); err != nil {
+		return nil, err
+	}
+
+	return s.getComment(ctx, groupKind, groupID, id)
+}
+
+func (s *service) DeleteComment(ctx context.Context, groupKind, groupID, id string) error {
+	comment, err := s.authoredComment(ctx, groupKind, groupID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteComment(ctx, comment.ID)
+}
+
+// HandleEvent records the group events in the timeline, in the background and detached from the
+// request that caused them.
+func (s *service) HandleEvent(ctx context.Context, event events.Event) {
+	activityType, ok := recordedEvents[event.Type]
+	if !ok {
+		return
+	}
+
+	activity := &Activity{
+		ProjectID: event.ProjectID,
+		GroupID:   event.GroupID,
+		GroupKind: event.GroupKind,
+		Type:      activityType,
+	}
+
+	if event.ActorID != "" {
+		activity.ActorID = &event.ActorID
+	}
+
+	if event.Change != nil {
+		data, err := json.Marshal(Change{Field: event.Change.Field, From: event.Change.From, To: event.Change.To})
+		if err != nil {
+			s.logger.Error("failed to encode group activity change: " + err.Error())
+			return
+		}
+		encoded := string(data)
+		activity.Data = &encoded
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+
+		if err := s.repo.CreateActivity(ctx, activity); err != nil {
+			s.logger.Error("failed to record group activity: " + err.Error())
+		}
+	}()
+}
+
+func (s *service) authoredComment(ctx context.Context, groupKind, groupID, id string) (*Comment, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	comment, err := s.repo.GetComment(ctx, groupKind, groupID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.AuthorID != userID {
+		return nil, ErrForbidden
+	}
+	return comment, nil
+}
+
+func (s *service) getComment(ctx context.Context, groupKind, groupID, id string) (*CommentEntity, error) {
+	comment, err := s.repo.GetComment(ctx, groupKind, groupID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toCommentResponse(comment), nil
+}
+
+func toResponse(a *Activity) *Entity {
+	entity := &Entity{
+		ID:         a.ID,
+		GroupID:    a.GroupID,
+		GroupKind:  a.GroupKind,
+		Type:       string(a.Type),
+		ActorID:    a.ActorID,
+		ActorEmail: a.ActorEmail,
+		CreatedAt:  a.CreatedAt,
+	}
+
+	if a.Data != nil {
+		var change Change
+		if err := json.Unmarshal([]byte(*a.Data), &change); err == nil {
+			entity.Change = &change
+		}
+	}
+
+	if a.CommentID != nil && a.CommentBody != nil {
+		entity.Comment = &CommentEntity{
+			ID:        *a.CommentID,
+			Body:      *a.CommentBody,
+			CreatedAt: a.CreatedAt,
+			UpdatedAt: a.CreatedAt,
+		}
+		if a.ActorID != nil {
+			entity.Comment.AuthorID = *a.ActorID
+		}
+		if a.CommentUpdatedAt != nil {
+			entity.Comment.UpdatedAt = *a.CommentUpdatedAt
+		}
+	}
+
+	return entity
+}
+
+func toCommentResponse(c *Comment) *CommentEntity {
+	return &CommentEntity{
+		ID:        c.ID,
+		AuthorID:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,47 @@
+package alert
+
+type ConditionType string
+
+const (
+	ConditionNewGroup   ConditionType = "new_group"
+	ConditionRegression ConditionType = "regression"
+	ConditionFrequency  ConditionType = "frequency"
+	ConditionLogLevel   ConditionType = "log_level"
+	ConditionAnomaly    ConditionType = "anomaly"
+)
+
+type ActionType string
+
+const (
+	ActionWebhook ActionType = "webhook"
+	ActionEmail   ActionType = "email"
+)
+
+type Rule struct {
+	ID              string        `db:"id"`
+	ProjectID       string        `db:"project_id"`
+	Name            string        `db:"name"`
+	Enabled         bool          `db:"enabled"`
+	ConditionType   ConditionType `db:"condition_type"`
+	GroupKind       string        `db:"group_kind"`
+	Threshold       int           `db:"threshold"`
+	WindowSeconds   int           `db:"window_seconds"`
+	Level           string        `db:"level"`
+	Pattern         string        `db:"pattern"`
+	Actions         string        `db:"actions"`
+	CooldownSeconds int           `db:"cooldown_seconds"`
+	CreatedAt       int64         `db:"created_at"`
+	UpdatedAt       int64         `db:"updated_at"`
+}
+
+type Firing struct {
+	ID        string  `db:"id"`
+	RuleID    string  `db:"rule_id"`
+	ProjectID string  `db:"project_id"`
+	GroupID   string  `db:"group_id"`
+	GroupKind string  `db:"group_kind"`
+	EventType string  `db:"event_type"`
+	Message   string  `db:"message"`
+	Results   *string `db:"results"`
+	FiredAt   int64   `db:"fired_at"`
+}
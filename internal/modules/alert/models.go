@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/fuckbug/api/internal/events"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event)
+}
+
+// Notifier delivers a fired alert to the target of a rule action.
+type Notifier interface {
+	Notify(ctx context.Context, target string, firing *FiringEntity) error
+}
+
+type Config struct {
+	// Number of events evaluated at the same time
+	Workers int
+	// Number of events waiting for a worker, the next ones are dropped
+	QueueSize int
+}
+
+type GetAllParams struct {
+	ProjectID string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type GetFiringsParams struct {
+	ProjectID string
+	RuleID    string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type Condition struct {
+	Type string `json:"type" validate:"required,oneof=new_group regression frequency log_level anomaly" example:"frequency"`
+	// Restricts the rule to error or log groups, both when empty
+	GroupKind string `json:"groupKind" validate:"omitempty,oneof=error log" example:"error"`
+	// Number of events of a group within the window for frequency conditions
+	Threshold int `json:"threshold" validate:"gte=0" example:"100"`
+	// Window in seconds for frequency conditions
+	WindowSeconds int `json:"windowSeconds" validate:"gte=0" example:"3600"`
+	// Minimum log level for log_level conditions
+	Level string `json:"level" validate:"omitempty,oneof=DEBUG INFO WARN ERROR FATAL" example:"ERROR"`
+	// Regular expression the log message has to match for log_level conditions
+	Pattern string `json:"pattern" example:"timeout|deadline exceeded"`
+}
+
+type Action struct {
+	Type string `json:"type" validate:"required,oneof=webhook email" example:"webhook"`
+	// ID of a webhook of the project, which signs and retries the delivery, or email address
+	Target string `json:"target" validate:"required" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+}
+
+type Create struct {
+	Name      string    `json:"name" validate:"required" example:"Checkout errors"`
+	Enabled   *bool     `json:"enabled" example:"true"`
+	Condition Condition `json:"condition"`
+	Actions   []Action  `json:"actions" validate:"required,min=1,dive"`
+	// Minimum delay in seconds between two firings of the rule for the same group
+	CooldownSeconds int    `json:"cooldownSeconds" validate:"gte=0" example:"600"`
+	ProjectID       string `json:"-"`
+}
+
+type Update struct {
+	Name            string    `json:"name" validate:"required" example:"Checkout errors"`
+	Enabled         *bool     `json:"enabled" example:"true"`
+	Condition       Condition `json:"condition"`
+	Actions         []Action  `json:"actions" validate:"required,min=1,dive"`
+	CooldownSeconds int       `json:"cooldownSeconds" validate:"gte=0" example:"600"`
+}
+
+type Entity struct {
+	ID              string    `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Name            string    `json:"name" example:"Checkout errors"`
+	Enabled         bool      `json:"enabled" example:"true"`
+	Condition       Condition `json:"condition"`
+	Actions         []Action  `json:"actions"`
+	CooldownSeconds int       `json:"cooldownSeconds" example:"600"`
+	CreatedAt       int64     `json:"createdAt" example:"1704067200"`
+	UpdatedAt       int64     `json:"updatedAt" example:"1704067200"`
+}
+
+type EntityList struct {
+	Count int      `json:"count"`
+	Items []Entity `json:"items"`
+}
+
+type ActionResult struct {
+	Type   string `json:"type" example:"webhook"`
+	Target string `json:"target" example:"https://example.com/hooks/fuckbug"`
+	Error  string `json:"error,omitempty" example:"unexpected status code 500"`
+}
+
+type FiringEntity struct {
+	ID        string         `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	RuleID    string         `json:"ruleId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	RuleName  string         `json:"ruleName,omitempty" example:"Checkout errors"`
+	ProjectID string         `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	GroupID   string         `json:"groupId" example:"5d41402abc4b2a76b9719d911017c592"`
+	GroupKind string         `json:"groupKind" example:"error"`
+	EventType string         `json:"eventType" example:"group.created"`
+	Message   string         `json:"message" example:"Division by zero in calculate()"`
+	Results   []ActionResult `json:"results"`
+	FiredAt   int64          `json:"firedAt" example:"1704067200"`
+}
+
+type FiringList struct {
+	Count int            `json:"count"`
+	Items []FiringEntity `json:"items"`
+}
@@ -0,0 +1,339 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrUnknownGroupKind = errors.New("unknown group kind")
+)
+
+// countTables count the events of the groups by minute.
+var countTables = map[string]string{
+	events.GroupKindError: "error_counts_minute",
+	events.GroupKindLog:   "log_counts_minute",
+}
+
+type Repository interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Rule, error)
+	Count(ctx context.Context, projectID string) (int, error)
+	GetEnabled(ctx context.Context, projectID string) ([]*Rule, error)
+	GetByID(ctx context.Context, projectID, id string) (*Rule, error)
+	Create(ctx context.Context, rule *Rule) error
+	Update(ctx context.Context, projectID, id string, rule *Rule) error
+	Delete(ctx context.Context, projectID, id string) error
+	GetFirings(ctx context.Context, params GetFiringsParams) ([]*Firing, error)
+	CountFirings(ctx context.Context, params GetFiringsParams) (int, error)
+	CreateFiring(ctx context.Context, firing *Firing) error
+	UpdateFiringResults(ctx context.Context, id string, results string) error
+	LastFiredAt(ctx context.Context, ruleID, groupID string) (int64, error)
+	CountGroupEvents(ctx context.Context, groupKind, projectID, groupID string, since int64) (int, error)
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const ruleColumns = `id, project_id, name, enabled, condition_type, group_kind, threshold, window_seconds,
+	level, pattern, actions, cooldown_seconds, created_at, updated_at`
+
+func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM alert_rules WHERE project_id = :projectId`
+
+	args := map[string]interface{}{
+		"projectId": params.ProjectID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query += " ORDER BY created_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var rules []*Rule
+	err = r.db.SelectContext(ctx, &rules, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *repository) Count(ctx context.Context, projectID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM alert_rules WHERE project_id = $1`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetEnabled(ctx context.Context, projectID string) ([]*Rule, error) {
+	const query = `SELECT ` + ruleColumns + ` FROM alert_rules WHERE project_id = $1 AND enabled`
+
+	var rules []*Rule
+	err := r.db.SelectContext(ctx, &rules, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabled alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, projectID, id string) (*Rule, error) {
+	const query = `SELECT ` + ruleColumns + ` FROM alert_rules WHERE id = $1 AND project_id = $2`
+
+	var rule Rule
+	err := r.db.GetContext(ctx, &rule, query, id, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get alert rule by id: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *repository) Create(ctx context.Context, rule *Rule) error {
+	const query = `
+		INSERT INTO alert_rules (
+			id, project_id, name, enabled, condition_type, group_kind, threshold, window_seconds,
+			level, pattern, actions, cooldown_seconds, created_at, updated_at
+		) VALUES (
+			:id, :project_id, :name, :enabled, :condition_type, :group_kind, :threshold, :window_seconds,
+			:level, :pattern, :actions, :cooldown_seconds, :created_at, :updated_at
+		)
+	`
+
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, rule)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, projectID, id string, updated *Rule) error {
+	const query = `
+		UPDATE
+			alert_rules
+		SET
+			name = :name,
+			enabled = :enabled,
+			condition_type = :condition_type,
+			group_kind = :group_kind,
+			threshold = :threshold,
+			window_seconds = :window_seconds,
+			level = :level,
+			pattern = :pattern,
+			actions = :actions,
+			cooldown_seconds = :cooldown_seconds,
+			updated_at = :updated_at
+		WHERE
+			id = :id AND project_id = :project_id
+	`
+
+	updated.ID = id
+	updated.ProjectID = projectID
+	updated.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.NamedExecContext(ctx, query, updated)
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, projectID, id string) error {
+	const query = `DELETE FROM alert_rules WHERE id = $1 AND project_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) GetFirings(ctx context.Context, params GetFiringsParams) ([]*Firing, error) {
+	query := `
+		SELECT id, rule_id, project_id, group_id, group_kind, event_type, message, results, fired_at
+		FROM alert_firings
+		WHERE 1=1
+	`
+
+	args := map[string]interface{}{
+		"limit":  params.Limit,
+		"offset": params.Offset,
+	}
+
+	query, args = applyFiringFilters(query, params, args)
+
+	query += " ORDER BY fired_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var firings []*Firing
+	err = r.db.SelectContext(ctx, &firings, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert firings: %w", err)
+	}
+	return firings, nil
+}
+
+func (r *repository) CountFirings(ctx context.Context, params GetFiringsParams) (int, error) {
+	query := "SELECT COUNT(*) FROM alert_firings WHERE 1=1"
+	query, args := applyFiringFilters(query, params, make(map[string]interface{}))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	var count int
+	err = r.db.GetContext(ctx, &count, query, namedArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) CreateFiring(ctx context.Context, firing *Firing) error {
+	const query = `
+		INSERT INTO alert_firings (id, rule_id, project_id, group_id, group_kind, event_type, message, results, fired_at)
+		VALUES (:id, :rule_id, :project_id, :group_id, :group_kind, :event_type, :message, :results, :fired_at)
+	`
+
+	if firing.ID == "" {
+		firing.ID = uuid.New().String()
+	}
+
+	_, err := r.db.NamedExecContext(ctx, query, firing)
+	if err != nil {
+		return fmt.Errorf("failed to create alert firing: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateFiringResults(ctx context.Context, id string, results string) error {
+	const query = `UPDATE alert_firings SET results = $1 WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, results, id)
+	if err != nil {
+		return fmt.Errorf("failed to update alert firing results: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) LastFiredAt(ctx context.Context, ruleID, groupID string) (int64, error) {
+	const query = `SELECT COALESCE(MAX(fired_at), 0) FROM alert_firings WHERE rule_id = $1 AND group_id = $2`
+
+	var firedAt int64
+	err := r.db.GetContext(ctx, &firedAt, query, ruleID, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last alert firing: %w", err)
+	}
+	return firedAt, nil
+}
+
+// CountGroupEvents adds up the counts by minute of the group since the minute of the given time.
+func (r *repository) CountGroupEvents(
+	ctx context.Context,
+	groupKind, projectID, groupID string,
+	since int64,
+) (int, error) {
+	table, ok := countTables[groupKind]
+	if !ok {
+		return 0, ErrUnknownGroupKind
+	}
+
+	query := `SELECT COALESCE(SUM(count), 0) FROM ` + table + `
+		WHERE project_id = $1 AND fingerprint = $2 AND bucket >= $3`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID, groupID, storage.Bucket(since, time.Minute))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count group events: %w", err)
+	}
+	return count, nil
+}
+
+func applyFiringFilters(
+	baseQuery string,
+	params GetFiringsParams,
+	args map[string]interface{},
+) (string, map[string]interface{}) {
+	query := baseQuery
+
+	if params.ProjectID != "" {
+		query += " AND project_id = :projectId"
+		args["projectId"] = params.ProjectID
+	}
+
+	if params.RuleID != "" {
+		query += " AND rule_id = :ruleId"
+		args["ruleId"] = params.RuleID
+	}
+
+	return query, args
+}
@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryCountGroupEvents(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, sql.RunMigrations(db, logger.New("ERROR", nil)))
+
+	repo := NewRepository(db, logger.New("ERROR", nil))
+
+	now := storage.Bucket(time.Now().UnixMilli(), time.Minute)
+	for _, bucket := range []struct {
+		projectID string
+		bucket    int64
+		count     int
+	}{
+		{projectID: "project", bucket: now, count: 3},
+		{projectID: "project", bucket: now - time.Minute.Milliseconds(), count: 2},
+		{projectID: "project", bucket: now - time.Hour.Milliseconds(), count: 5},
+		{projectID: "other", bucket: now, count: 7},
+	} {
+		_, err := db.Exec(`
+			INSERT INTO error_counts_minute (project_id, fingerprint, bucket, count) VALUES ($1, 'group', $2, $3)
+		`, bucket.projectID, bucket.bucket, bucket.count)
+		require.NoError(t, err)
+	}
+
+	count, err := repo.CountGroupEvents(ctx, events.GroupKindError, "project", "group", now-time.Minute.Milliseconds()+1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	count, err = repo.CountGroupEvents(ctx, events.GroupKindLog, "project", "group", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = repo.CountGroupEvents(ctx, "unknown", "project", "group", 0)
+	assert.ErrorIs(t, err, ErrUnknownGroupKind)
+}
@@ -0,0 +1,589 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCondition = errors.New("invalid alert condition")
+	ErrInvalidPattern   = errors.New("invalid alert pattern")
+	ErrInvalidAction    = errors.New("invalid alert action")
+)
+
+const (
+	rulesCacheTTL = 30 * time.Second
+	notifyTimeout = 10 * time.Second
+
+	defaultWorkers   = 4
+	defaultQueueSize = 1000
+	// cooldownsSweepInterval is the interval between two evictions of the elapsed cooldowns
+	cooldownsSweepInterval = time.Minute
+)
+
+var levelRanks = map[string]int{
+	"DEBUG": 0,
+	"INFO":  1,
+	"WARN":  2,
+	"ERROR": 3,
+	"FATAL": 4,
+}
+
+type Service interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetByID(ctx context.Context, projectID, id string) (*Entity, error)
+	Create(ctx context.Context, req *Create) (*Entity, error)
+	Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error)
+	Delete(ctx context.Context, projectID, id string) error
+	GetFirings(ctx context.Context, params GetFiringsParams) ([]*FiringEntity, int, error)
+	Evaluate(ctx context.Context, event events.Event)
+	Run(ctx context.Context)
+}
+
+type service struct {
+	repo      Repository
+	logger    Logger
+	publisher Publisher
+	notifiers map[ActionType]Notifier
+	config    Config
+	queue     chan events.Event
+
+	mu    sync.Mutex
+	rules map[string]*cachedRules
+	// cooldowns holds the end of the cooldown of the rules that fired for a group
+	cooldowns map[cooldownKey]int64
+}
+
+// compiledRule is an enabled rule with its actions decoded and its pattern compiled.
+type compiledRule struct {
+	rule    *Rule
+	actions []Action
+	pattern *regexp.Regexp
+}
+
+type cachedRules struct {
+	rules    []*compiledRule
+	loadedAt time.Time
+}
+
+type cooldownKey struct {
+	ruleID  string
+	groupID string
+}
+
+func NewService(
+	repo Repository,
+	logger Logger,
+	publisher Publisher,
+	notifiers map[ActionType]Notifier,
+	config Config,
+) Service {
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+
+	return &service{
+		repo:      repo,
+		logger:    logger,
+		publisher: publisher,
+		notifiers: notifiers,
+		config:    config,
+		queue:     make(chan events.Event, config.QueueSize),
+		rules:     make(map[string]*cachedRules),
+		cooldowns: make(map[cooldownKey]int64),
+	}
+}
+
+func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+	rules, err := s.repo.GetAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx, params.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(rules))
+	for _, rule := range rules {
+		responses = append(responses, toResponse(rule))
+	}
+	return responses, total, nil
+}
+
+func (s *service) GetByID(ctx context.Context, projectID, id string) (*Entity, error) {
+	rule, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(rule), nil
+}
+
+func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
+	rule, err := buildRule(req.Name, req.Enabled, req.Condition, req.Actions, req.CooldownSeconds)
+	if err != nil {
+		return nil, err
+	}
+	rule.ProjectID = req.ProjectID
+
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(rule.ProjectID)
+
+	return toResponse(rule), nil
+}
+
+func (s *service) Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error) {
+	rule, err := buildRule(req.Name, req.Enabled, req.Condition, req.Actions, req.CooldownSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, projectID, id, rule); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(projectID)
+
+	return s.GetByID(ctx, projectID, id)
+}
+
+func (s *service) Delete(ctx context.Context, projectID, id string) error {
+	if err := s.repo.Delete(ctx, projectID, id); err != nil {
+		return err
+	}
+
+	s.invalidate(projectID)
+
+	return nil
+}
+
+func (s *service) GetFirings(ctx context.Context, params GetFiringsParams) ([]*FiringEntity, int, error) {
+	firings, err := s.repo.GetFirings(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountFirings(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*FiringEntity, 0, len(firings))
+	for _, firing := range firings {
+		responses = append(responses, toFiringResponse(firing, ""))
+	}
+	return responses, total, nil
+}
+
+// Evaluate queues an event to be matched against the enabled rules of its project. It is meant
+// to be subscribed to the event bus, so the evaluation runs outside of the ingestion request.
+// The events are dropped while the queue is full.
+func (s *service) Evaluate(_ context.Context, event events.Event) {
+	if event.Type == events.TypeAlertFired || event.ProjectID == "" {
+		return
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		s.logger.Warn(fmt.Sprintf("alert queue is full, dropping %s event of project %s", event.Type, event.ProjectID))
+	}
+}
+
+// Run evaluates the queued events with a fixed number of workers until the context is done.
+func (s *service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range s.config.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-s.queue:
+					s.evaluate(ctx, event)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(cooldownsSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			s.evictCooldowns()
+		}
+	}
+}
+
+func (s *service) evaluate(ctx context.Context, event events.Event) {
+	rules, err := s.enabledRules(ctx, event.ProjectID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to load alert rules: %v", err))
+		return
+	}
+
+	for _, rule := range rules {
+		matched, err := s.matches(ctx, rule, event)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to evaluate alert rule %s: %v", rule.rule.ID, err))
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		ok, err := s.acquire(ctx, rule.rule, event.GroupID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to check alert rule %s cooldown: %v", rule.rule.ID, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		s.fire(ctx, rule, event)
+	}
+}
+
+func (s *service) matches(ctx context.Context, rule *compiledRule, event events.Event) (bool, error) {
+	r := rule.rule
+
+	if r.GroupKind != "" && r.GroupKind != event.GroupKind {
+		return false, nil
+	}
+
+	switch r.ConditionType {
+	case ConditionNewGroup:
+		return event.Type == events.TypeGroupCreated, nil
+	case ConditionRegression:
+		return event.Type == events.TypeGroupRegressed, nil
+	case ConditionAnomaly:
+		return event.Type == events.TypeAnomalyDetected, nil
+	case ConditionLogLevel:
+		if event.Type != events.TypeLogCreated {
+			return false, nil
+		}
+		if levelRanks[event.Level] < levelRanks[r.Level] {
+			return false, nil
+		}
+		return rule.pattern == nil || rule.pattern.MatchString(event.Message), nil
+	case ConditionFrequency:
+		if event.Type != events.TypeErrorCreated && event.Type != events.TypeLogCreated {
+			return false, nil
+		}
+		since := time.Now().Add(-time.Duration(r.WindowSeconds) * time.Second).UnixMilli()
+		count, err := s.repo.CountGroupEvents(ctx, event.GroupKind, event.ProjectID, event.GroupID, since)
+		if err != nil {
+			return false, err
+		}
+		return count >= r.Threshold, nil
+	}
+
+	return false, nil
+}
+
+// acquire reserves a firing of the rule for the group unless it is still cooling down.
+// Frequency rules keep matching while the threshold is exceeded, so they fire at most
+// once per window even without a cooldown.
+func (s *service) acquire(ctx context.Context, rule *Rule, groupID string) (bool, error) {
+	cooldown := int64(rule.CooldownSeconds)
+	if rule.ConditionType == ConditionFrequency && int64(rule.WindowSeconds) > cooldown {
+		cooldown = int64(rule.WindowSeconds)
+	}
+	if cooldown <= 0 {
+		return true, nil
+	}
+
+	now := time.Now().Unix()
+	key := cooldownKey{ruleID: rule.ID, groupID: groupID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.cooldowns[key]
+	if !ok {
+		lastFired, err := s.repo.LastFiredAt(ctx, rule.ID, groupID)
+		if err != nil {
+			return false, err
+		}
+		until = lastFired + cooldown
+	}
+	if now < until {
+		s.cooldowns[key] = until
+		return false, nil
+	}
+
+	s.cooldowns[key] = now + cooldown
+	return true, nil
+}
+
+// evictCooldowns forgets the cooldowns that elapsed, the next firing of their rule checks the
+// last one recorded.
+func (s *service) evictCooldowns() {
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, until := range s.cooldowns {
+		if now >= until {
+			delete(s.cooldowns, key)
+		}
+	}
+}
+
+func (s *service) fire(ctx context.Context, rule *compiledRule, event events.Event) {
+	firing := &Firing{
+		RuleID:    rule.rule.ID,
+		ProjectID: rule.rule.ProjectID,
+		GroupID:   event.GroupID,
+		GroupKind: event.GroupKind,
+		EventType: string(event.Type),
+		Message:   event.Message,
+		FiredAt:   time.Now().Unix(),
+	}
+
+	if err := s.repo.CreateFiring(ctx, firing); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to record alert firing: %v", err))
+		return
+	}
+
+	entity := toFiringResponse(firing, rule.rule.Name)
+
+	s.publisher.Publish(ctx, events.Event{
+		Type:      events.TypeAlertFired,
+		ProjectID: firing.ProjectID,
+		GroupID:   firing.GroupID,
+		GroupKind: firing.GroupKind,
+		Message:   firing.Message,
+		Time:      time.Unix(firing.FiredAt, 0).UnixMilli(),
+		Payload:   entity,
+	})
+
+	results := make([]ActionResult, 0, len(rule.actions))
+	for _, action := range rule.actions {
+		result := ActionResult{Type: action.Type, Target: action.Target}
+		if err := s.notify(ctx, action, entity); err != nil {
+			s.logger.Warn(fmt.Sprintf("alert %s action %s failed: %v", firing.ID, action.Type, err))
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to marshal alert action results: %v", err))
+		return
+	}
+
+	if err := s.repo.UpdateFiringResults(ctx, firing.ID, string(data)); err != nil {
+		s.logger.Error(err.Error())
+	}
+}
+
+func (s *service) notify(ctx context.Context, action Action, firing *FiringEntity) error {
+	notifier, ok := s.notifiers[ActionType(action.Type)]
+	if !ok || notifier == nil {
+		return fmt.Errorf("%s notifications are not configured", action.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	return notifier.Notify(ctx, action.Target, firing)
+}
+
+func (s *service) enabledRules(ctx context.Context, projectID string) ([]*compiledRule, error) {
+	s.mu.Lock()
+	cached, ok := s.rules[projectID]
+	s.mu.Unlock()
+
+	if ok && time.Since(cached.loadedAt) < rulesCacheTTL {
+		return cached.rules, nil
+	}
+
+	rules, err := s.repo.GetEnabled(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("skipping alert rule %s: %v", rule.ID, err))
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	s.mu.Lock()
+	s.rules[projectID] = &cachedRules{rules: compiled, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return compiled, nil
+}
+
+func (s *service) invalidate(projectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.rules, projectID)
+}
+
+func buildRule(name string, enabled *bool, condition Condition, actions []Action, cooldown int) (*Rule, error) {
+	if err := validateCondition(&condition); err != nil {
+		return nil, err
+	}
+
+	for _, action := range actions {
+		if err := validateAction(action); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alert actions: %w", err)
+	}
+
+	rule := &Rule{
+		Name:            name,
+		Enabled:         enabled == nil || *enabled,
+		ConditionType:   ConditionType(condition.Type),
+		GroupKind:       condition.GroupKind,
+		Threshold:       condition.Threshold,
+		WindowSeconds:   condition.WindowSeconds,
+		Level:           condition.Level,
+		Pattern:         condition.Pattern,
+		Actions:         string(data),
+		CooldownSeconds: cooldown,
+	}
+	return rule, nil
+}
+
+func validateCondition(c *Condition) error {
+	switch ConditionType(c.Type) {
+	case ConditionFrequency:
+		if c.Threshold <= 0 || c.WindowSeconds <= 0 {
+			return fmt.Errorf("%w: frequency requires a positive threshold and window", ErrInvalidCondition)
+		}
+	case ConditionLogLevel:
+		if c.Level == "" {
+			return fmt.Errorf("%w: log_level requires a level", ErrInvalidCondition)
+		}
+		if c.GroupKind == events.GroupKindError {
+			return fmt.Errorf("%w: log_level only applies to log groups", ErrInvalidCondition)
+		}
+		c.GroupKind = events.GroupKindLog
+	}
+
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+		}
+	}
+
+	return nil
+}
+
+func validateAction(a Action) error {
+	switch ActionType(a.Type) {
+	case ActionWebhook:
+		if _, err := uuid.Parse(a.Target); err != nil {
+			return fmt.Errorf("%w: webhook target must be the ID of a webhook of the project", ErrInvalidAction)
+		}
+	case ActionEmail:
+		if _, err := mail.ParseAddress(a.Target); err != nil {
+			return fmt.Errorf("%w: email target must be an email address", ErrInvalidAction)
+		}
+	}
+	return nil
+}
+
+func compileRule(rule *Rule) (*compiledRule, error) {
+	var actions []Action
+	if err := json.Unmarshal([]byte(rule.Actions), &actions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal actions: %w", err)
+	}
+
+	compiled := &compiledRule{rule: rule, actions: actions}
+	if rule.Pattern != "" {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled.pattern = pattern
+	}
+	return compiled, nil
+}
+
+func toResponse(r *Rule) *Entity {
+	var actions []Action
+	if err := json.Unmarshal([]byte(r.Actions), &actions); err != nil || actions == nil {
+		actions = []Action{}
+	}
+
+	return &Entity{
+		ID:      r.ID,
+		Name:    r.Name,
+		Enabled: r.Enabled,
+		Condition: Condition{
+			Type:          string(r.ConditionType),
+			GroupKind:     r.GroupKind,
+			Threshold:     r.Threshold,
+			WindowSeconds: r.WindowSeconds,
+			Level:         r.Level,
+			Pattern:       r.Pattern,
+		},
+		Actions:         actions,
+		CooldownSeconds: r.CooldownSeconds,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+func toFiringResponse(f *Firing, ruleName string) *FiringEntity {
+	results := []ActionResult{}
+	if f.Results != nil {
+		if err := json.Unmarshal([]byte(*f.Results), &results); err != nil {
+			results = []ActionResult{}
+		}
+	}
+
+	return &FiringEntity{
+		ID:        f.ID,
+		RuleID:    f.RuleID,
+		RuleName:  ruleName,
+		ProjectID: f.ProjectID,
+		GroupID:   f.GroupID,
+		GroupKind: f.GroupKind,
+		EventType: f.EventType,
+		Message:   f.Message,
+		Results:   results,
+		FiredAt:   f.FiredAt,
+	}
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, config Config) *service {
+	t.Helper()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, sql.RunMigrations(db, logger.New("ERROR", nil)))
+
+	appLogger := logger.New("ERROR", nil)
+	return NewService(NewRepository(db, appLogger), appLogger, events.NewBus(), nil, config).(*service)
+}
+
+func TestServiceCooldowns(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, Config{})
+	rule := &Rule{ID: "rule", CooldownSeconds: 60}
+
+	ok, err := s.acquire(ctx, rule, "group")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.acquire(ctx, rule, "group")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = s.acquire(ctx, &Rule{ID: "uncooled"}, "group")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, s.cooldowns, 1)
+
+	s.evictCooldowns()
+	assert.Len(t, s.cooldowns, 1)
+
+	s.cooldowns[cooldownKey{ruleID: "rule", groupID: "group"}] = time.Now().Unix()
+	s.evictCooldowns()
+	assert.Empty(t, s.cooldowns)
+}
+
+func TestServiceEvaluateDropsEventsWhenQueueIsFull(t *testing.T) {
+	s := newTestService(t, Config{QueueSize: 1})
+	event := events.Event{Type: events.TypeErrorCreated, ProjectID: "project"}
+
+	s.Evaluate(context.Background(), event)
+	s.Evaluate(context.Background(), event)
+	s.Evaluate(context.Background(), events.Event{Type: events.TypeAlertFired, ProjectID: "project"})
+
+	assert.Len(t, s.queue, 1)
+}
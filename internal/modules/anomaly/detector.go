@@ -0,0 +1,186 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+)
+
+const (
+	defaultInterval       = time.Minute
+	defaultWindow         = 5 * time.Minute
+	defaultBaselineWindow = 24 * time.Hour
+	defaultQuietPeriod    = 7 * 24 * time.Hour
+	defaultMultiplier     = 5
+	defaultMinEvents      = 10
+)
+
+// Detector periodically compares the recent event rate of every active group against
+// its baseline, and records spikes and groups that reappear after a quiet period.
+type Detector struct {
+	repo      Repository
+	publisher Publisher
+	logger    Logger
+	config    Config
+}
+
+type anomalyKey struct {
+	groupKind string
+	groupID   string
+	kind      Kind
+}
+
+func NewDetector(repo Repository, publisher Publisher, logger Logger, config Config) *Detector {
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+	if config.BaselineWindow <= 0 {
+		config.BaselineWindow = defaultBaselineWindow
+	}
+	if config.QuietPeriod <= 0 {
+		config.QuietPeriod = defaultQuietPeriod
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = defaultMultiplier
+	}
+	if config.MinEvents <= 0 {
+		config.MinEvents = defaultMinEvents
+	}
+
+	return &Detector{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+		config:    config,
+	}
+}
+
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Detect(ctx, time.Now()); err != nil {
+			d.logger.Error(fmt.Sprintf("anomaly detection failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Detector) Detect(ctx context.Context, now time.Time) error {
+	open, err := d.repo.GetOpen(ctx)
+	if err != nil {
+		return err
+	}
+
+	opened := make(map[anomalyKey]*Anomaly, len(open))
+	for _, a := range open {
+		opened[anomalyKey{groupKind: a.GroupKind, groupID: a.GroupID, kind: a.Kind}] = a
+	}
+
+	windowFrom := now.Add(-d.config.Window)
+	baselineFrom := windowFrom.Add(-d.config.BaselineWindow)
+	quietFrom := windowFrom.Add(-d.config.QuietPeriod)
+
+	lookbackFrom := baselineFrom
+	if quietFrom.Before(lookbackFrom) {
+		lookbackFrom = quietFrom
+	}
+
+	detected := make(map[anomalyKey]bool)
+
+	for _, groupKind := range []string{events.GroupKindError, events.GroupKindLog} {
+		rates, err := d.repo.GetRates(
+			ctx, groupKind, windowFrom.UnixMilli(), baselineFrom.UnixMilli(), lookbackFrom.UnixMilli(),
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, rate := range rates {
+			expected := float64(rate.Baseline) * float64(d.config.Window) / float64(d.config.BaselineWindow)
+
+			if rate.Recent >= d.config.MinEvents && float64(rate.Recent) > d.config.Multiplier*math.Max(expected, 1) {
+				key := anomalyKey{groupKind: groupKind, groupID: rate.GroupID, kind: KindSpike}
+				detected[key] = true
+				d.open(ctx, opened, key, rate, expected, windowFrom)
+			}
+
+			if rate.PreviousSeenAt < quietFrom.UnixMilli() && rate.FirstSeenAt < quietFrom.Unix() {
+				key := anomalyKey{groupKind: groupKind, groupID: rate.GroupID, kind: KindReappeared}
+				detected[key] = true
+				d.open(ctx, opened, key, rate, expected, windowFrom)
+			}
+		}
+	}
+
+	for key, a := range opened {
+		if detected[key] {
+			continue
+		}
+
+		if err := d.repo.Close(ctx, a.ID, now.Unix()); err != nil {
+			d.logger.Error(err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (d *Detector) open(
+	ctx context.Context,
+	opened map[anomalyKey]*Anomaly,
+	key anomalyKey,
+	rate *GroupRate,
+	expected float64,
+	startedAt time.Time,
+) {
+	if _, ok := opened[key]; ok {
+		return
+	}
+
+	a := &Anomaly{
+		ProjectID: rate.ProjectID,
+		GroupID:   rate.GroupID,
+		GroupKind: key.groupKind,
+		Kind:      key.kind,
+		Events:    rate.Recent,
+		Baseline:  math.Round(expected*100) / 100,
+		StartedAt: startedAt.Unix(),
+	}
+
+	if err := d.repo.Create(ctx, a); err != nil {
+		d.logger.Error(err.Error())
+		return
+	}
+
+	opened[key] = a
+
+	message := fmt.Sprintf("%s group reappeared with %d events after a quiet period", key.groupKind, a.Events)
+	if key.kind == KindSpike {
+		message = fmt.Sprintf(
+			"%s group spiked to %d events in %s (expected %.2f)", key.groupKind, a.Events, d.config.Window, a.Baseline,
+		)
+	}
+
+	d.publisher.Publish(ctx, events.Event{
+		Type:      events.TypeAnomalyDetected,
+		ProjectID: a.ProjectID,
+		GroupID:   a.GroupID,
+		GroupKind: a.GroupKind,
+		Message:   message,
+		Time:      startedAt.UnixMilli(),
+		Payload:   toResponse(a),
+	})
+}
@@ -0,0 +1,33 @@
+package anomaly
+
+type Kind string
+
+const (
+	KindSpike      Kind = "spike"
+	KindReappeared Kind = "reappeared"
+)
+
+type Anomaly struct {
+	ID        string  `db:"id"`
+	ProjectID string  `db:"project_id"`
+	GroupID   string  `db:"group_id"`
+	GroupKind string  `db:"group_kind"`
+	Kind      Kind    `db:"kind"`
+	Events    int     `db:"events"`
+	Baseline  float64 `db:"baseline"`
+	StartedAt int64   `db:"started_at"`
+	EndedAt   *int64  `db:"ended_at"`
+	CreatedAt int64   `db:"created_at"`
+	UpdatedAt int64   `db:"updated_at"`
+}
+
+// GroupRate is the number of events a group received during the detection window,
+// and during the baseline window that precedes it.
+type GroupRate struct {
+	ProjectID      string `db:"project_id"`
+	GroupID        string `db:"group_id"`
+	Recent         int    `db:"recent"`
+	Baseline       int    `db:"baseline"`
+	PreviousSeenAt int64  `db:"previous_seen_at"`
+	FirstSeenAt    int64  `db:"first_seen_at"`
+}
@@ -0,0 +1,59 @@
+package anomaly
+
+import (
+	"context"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event)
+}
+
+type Config struct {
+	// Interval between two detection runs
+	Interval time.Duration
+	// Window is the recent period whose rate is compared against the baseline
+	Window time.Duration
+	// BaselineWindow is the period preceding Window used to compute the usual rate
+	BaselineWindow time.Duration
+	// QuietPeriod is how long a group has to be silent to be reported when it reappears
+	QuietPeriod time.Duration
+	// Multiplier of the baseline rate above which the recent rate is a spike
+	Multiplier float64
+	// MinEvents is the minimum number of recent events for a spike
+	MinEvents int
+}
+
+type GetAllParams struct {
+	ProjectID string
+	GroupID   string
+	Active    bool
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type Entity struct {
+	ID        string  `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	GroupID   string  `json:"groupId" example:"5d41402abc4b2a76b9719d911017c592"`
+	GroupKind string  `json:"groupKind" example:"error" enums:"error,log"`
+	Kind      string  `json:"kind" example:"spike" enums:"spike,reappeared"`
+	Events    int     `json:"events" example:"120"`
+	Baseline  float64 `json:"baseline" example:"3.5"`
+	StartedAt int64   `json:"startedAt" example:"1704067200"`
+	EndedAt   *int64  `json:"endedAt" example:"1704070800"`
+}
+
+type EntityList struct {
+	Count int      `json:"count"`
+	Items []Entity `json:"items"`
+}
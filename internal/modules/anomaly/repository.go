@@ -0,0 +1,264 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrUnknownGroupKind = errors.New("unknown group kind")
+
+// countTables maps a group kind to the prefix of the tables counting its events by minute and by
+// hour, and to its groups table.
+var countTables = map[string][2]string{
+	events.GroupKindError: {"error_counts", "error_groups"},
+	events.GroupKindLog:   {"log_counts", "log_groups"},
+}
+
+type Repository interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Anomaly, error)
+	Count(ctx context.Context, params GetAllParams) (int, error)
+	GetOpen(ctx context.Context) ([]*Anomaly, error)
+	GetRates(ctx context.Context, groupKind string, windowFrom, baselineFrom, lookbackFrom int64) ([]*GroupRate, error)
+	Create(ctx context.Context, anomaly *Anomaly) error
+	Close(ctx context.Context, id string, endedAt int64) error
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Anomaly, error) {
+	query := `
+        SELECT id, project_id, group_id, group_kind, kind, events, baseline, started_at, ended_at, created_at, updated_at
+        FROM anomalies
+        WHERE 1=1
+    `
+
+	args := map[string]interface{}{
+		"limit":  params.Limit,
+		"offset": params.Offset,
+	}
+
+	query, args = applyFilters(query, params, args)
+
+	query += " ORDER BY started_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var entities []*Anomaly
+	err = r.db.SelectContext(ctx, &entities, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get anomalies: %w", err)
+	}
+	return entities, nil
+}
+
+func (r *repository) Count(ctx context.Context, params GetAllParams) (int, error) {
+	query := "SELECT COUNT(*) FROM anomalies WHERE 1=1"
+	query, args := applyFilters(query, params, make(map[string]interface{}))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var count int
+	err = r.db.GetContext(ctx, &count, query, namedArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetOpen(ctx context.Context) ([]*Anomaly, error) {
+	const query = `
+        SELECT id, project_id, group_id, group_kind, kind, events, baseline, started_at, ended_at, created_at, updated_at
+        FROM anomalies
+        WHERE ended_at IS NULL
+    `
+
+	var entities []*Anomaly
+	err := r.db.SelectContext(ctx, &entities, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open anomalies: %w", err)
+	}
+	return entities, nil
+}
+
+// GetRates adds up the counts of the groups counted during the window rather than scanning their
+// events. The parts of the baseline and of the lookback covering whole hours are read from the
+// counts by hour, the others from the counts by minute, and the times are aligned to the minute.
+// The previous time a group was seen is the end of the bucket it was last counted in.
+func (r *repository) GetRates(
+	ctx context.Context,
+	groupKind string,
+	windowFrom, baselineFrom, lookbackFrom int64,
+) ([]*GroupRate, error) {
+	tables, ok := countTables[groupKind]
+	if !ok {
+		return nil, ErrUnknownGroupKind
+	}
+
+	query := strings.NewReplacer("{minute}", tables[0]+"_minute", "{hour}", tables[0]+"_hour", "{groups}", tables[1]).
+		Replace(`
+            SELECT
+                recent.project_id,
+                recent.fingerprint AS group_id,
+                recent.count AS recent,
+                (
+                    SELECT COALESCE(SUM(m.count), 0) FROM {minute} m
+                    WHERE m.project_id = recent.project_id AND m.fingerprint = recent.fingerprint
+                        AND (
+                            (m.bucket >= :baselineFrom AND m.bucket < :baselineHourFrom)
+                            OR (m.bucket >= :baselineHourTo AND m.bucket < :windowFrom)
+                        )
+                ) + (
+                    SELECT COALESCE(SUM(h.count), 0) FROM {hour} h
+                    WHERE h.project_id = recent.project_id AND h.fingerprint = recent.fingerprint
+                        AND h.bucket >= :baselineHourFrom AND h.bucket < :baselineHourTo
+                ) AS baseline,
+                COALESCE(
+                    (
+                        SELECT MAX(m.bucket) + :minute FROM {minute} m
+                        WHERE m.project_id = recent.project_id AND m.fingerprint = recent.fingerprint
+                            AND m.bucket >= :lastHour AND m.bucket < :windowFrom
+                    ),
+                    (
+                        SELECT MAX(h.bucket) + :hour FROM {hour} h
+                        WHERE h.project_id = recent.project_id AND h.fingerprint = recent.fingerprint
+                            AND h.bucket >= :lookbackFrom AND h.bucket < :lastHour
+                    ),
+                    0
+                ) AS previous_seen_at,
+                g.first_seen_at
+            FROM (
+                SELECT project_id, fingerprint, SUM(count) AS count
+                FROM {minute}
+                WHERE bucket >= :windowFrom
+                GROUP BY project_id, fingerprint
+            ) recent
+            JOIN {groups} g ON g.id = recent.fingerprint
+            WHERE recent.count > 0
+        `)
+
+	windowFrom = storage.Bucket(windowFrom, time.Minute)
+	baselineFrom = storage.Bucket(baselineFrom, time.Minute)
+
+	// The whole hours of the baseline, none when it ends before its first hour does
+	baselineHourFrom := storage.Bucket(baselineFrom+time.Hour.Milliseconds()-1, time.Hour)
+	baselineHourTo := storage.Bucket(windowFrom, time.Hour)
+	if baselineHourFrom > baselineHourTo {
+		baselineHourFrom, baselineHourTo = windowFrom, windowFrom
+	}
+
+	args := map[string]interface{}{
+		"windowFrom":       windowFrom,
+		"baselineFrom":     baselineFrom,
+		"baselineHourFrom": baselineHourFrom,
+		"baselineHourTo":   baselineHourTo,
+		"lastHour":         storage.Bucket(windowFrom, time.Hour),
+		"lookbackFrom":     storage.Bucket(lookbackFrom, time.Hour),
+		"minute":           time.Minute.Milliseconds(),
+		"hour":             time.Hour.Milliseconds(),
+	}
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var rates []*GroupRate
+	err = r.db.SelectContext(ctx, &rates, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s group rates: %w", groupKind, err)
+	}
+	return rates, nil
+}
+
+func (r *repository) Create(ctx context.Context, a *Anomaly) error {
+	const query = `
+        INSERT INTO anomalies (
+            id, project_id, group_id, group_kind, kind, events, baseline, started_at, ended_at, created_at, updated_at
+        ) VALUES (
+            :id, :project_id, :group_id, :group_kind, :kind, :events, :baseline, :started_at, :ended_at,
+            :created_at, :updated_at
+        )
+    `
+
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, a)
+	if err != nil {
+		return fmt.Errorf("failed to create anomaly: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Close(ctx context.Context, id string, endedAt int64) error {
+	const query = `UPDATE anomalies SET ended_at = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, endedAt, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to close anomaly: %w", err)
+	}
+	return nil
+}
+
+func applyFilters(baseQuery string, params GetAllParams, args map[string]interface{}) (string, map[string]interface{}) {
+	query := baseQuery
+
+	if params.ProjectID != "" {
+		query += " AND project_id = :projectId"
+		args["projectId"] = params.ProjectID
+	}
+
+	if params.GroupID != "" {
+		query += " AND group_id = :groupId"
+		args["groupId"] = params.GroupID
+	}
+
+	if params.Active {
+		query += " AND ended_at IS NULL"
+	}
+
+	return query, args
+}
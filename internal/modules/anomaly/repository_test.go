@@ -0,0 +1,67 @@
+package anomaly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryGetRates(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	appLogger := logger.New("ERROR", nil)
+	require.NoError(t, sql.RunMigrations(db, appLogger))
+
+	errorRepository := errors.NewRepository(db, appLogger, errors.Config{})
+	now := time.Date(2024, time.January, 10, 12, 30, 0, 0, time.UTC)
+	store := func(fingerprint string, ago time.Duration) {
+		_, err := errorRepository.Create(ctx, &errors.Error{
+			ID: uuid.New().String(), ProjectID: "p", Fingerprint: fingerprint, Message: "m", File: "main.go", Line: 1,
+			Time: now.Add(-ago).UnixMilli(),
+		})
+		require.NoError(t, err)
+	}
+
+	// f1 spikes over a baseline spread across whole and partial hours, f2 reappears after 3 days
+	for _, ago := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		store("f1", ago)
+	}
+	for _, ago := range []time.Duration{10 * time.Minute, 90 * time.Minute, 23*time.Hour + 50*time.Minute, 30 * time.Hour} {
+		store("f1", ago)
+	}
+	store("f2", time.Minute)
+	store("f2", 72*time.Hour)
+	store("f3", time.Hour)
+
+	windowFrom := now.Add(-5 * time.Minute)
+	rates, err := NewRepository(db, appLogger).GetRates(ctx, events.GroupKindError,
+		windowFrom.UnixMilli(), windowFrom.Add(-24*time.Hour).UnixMilli(), windowFrom.Add(-7*24*time.Hour).UnixMilli())
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	byGroup := make(map[string]*GroupRate)
+	for _, rate := range rates {
+		byGroup[rate.GroupID] = rate
+	}
+
+	assert.Equal(t, 3, byGroup["f1"].Recent)
+	assert.Equal(t, 3, byGroup["f1"].Baseline)
+	assert.Equal(t, now.Add(-9*time.Minute).UnixMilli(), byGroup["f1"].PreviousSeenAt)
+
+	assert.Equal(t, 1, byGroup["f2"].Recent)
+	assert.Equal(t, 0, byGroup["f2"].Baseline)
+	assert.Equal(t, now.Add(-71*time.Hour-30*time.Minute).UnixMilli(), byGroup["f2"].PreviousSeenAt)
+}
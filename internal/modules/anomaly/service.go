@@ -0,0 +1,50 @@
+package anomaly
+
+import "context"
+
+type Service interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+}
+
+type service struct {
+	repo   Repository
+	logger Logger
+}
+
+func NewService(repo Repository, logger Logger) Service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+	entities, err := s.repo.GetAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, toResponse(entity))
+	}
+	return responses, total, nil
+}
+
+func toResponse(a *Anomaly) *Entity {
+	return &Entity{
+		ID:        a.ID,
+		GroupID:   a.GroupID,
+		GroupKind: a.GroupKind,
+		Kind:      string(a.Kind),
+		Events:    a.Events,
+		Baseline:  a.Baseline,
+		StartedAt: a.StartedAt,
+		EndedAt:   a.EndedAt,
+	}
+}
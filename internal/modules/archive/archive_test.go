@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string) {}
+func (nopLogger) Info(string)  {}
+func (nopLogger) Warn(string)  {}
+func (nopLogger) Error(string) {}
+
+// memoryRepository stores the documents once, as the insert skipping the stored events.
+type memoryRepository struct {
+	documents map[Kind]map[string]bool
+	batches   int
+}
+
+func (r *memoryRepository) Insert(_ context.Context, kind Kind, documents [][]byte) (int, error) {
+	r.batches++
+	if r.documents[kind] == nil {
+		r.documents[kind] = map[string]bool{}
+	}
+
+	inserted := 0
+	for _, document := range documents {
+		if !r.documents[kind][string(document)] {
+			r.documents[kind][string(document)] = true
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+func TestArchiveRestore(t *testing.T) {
+	ctx := context.Background()
+	store := blob.NewFilesystemStore(t.TempDir())
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var events []*Event
+	for i := 0; i < 5; i++ {
+		at := day.Add(time.Duration(i) * 12 * time.Hour)
+		events = append(events, &Event{
+			Time:     at.UnixMilli(),
+			Document: []byte(fmt.Sprintf(`{"id":"%d","time":%d}`, i, at.UnixMilli())),
+		})
+	}
+
+	archiver := NewArchiver(store, nopLogger{})
+	require.NoError(t, archiver.Archive(ctx, KindLogs, "p1", events))
+	require.NoError(t, archiver.Archive(ctx, KindErrors, "p1", events[:1]))
+
+	// Events are partitioned by day
+	keys, err := store.List(ctx, "p1/")
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	assert.Regexp(t, `^p1/2024-01-01/errors-[0-9a-f-]+\.ndjson\.gz$`, keys[0])
+	assert.Regexp(t, `^p1/2024-01-01/logs-`, keys[1])
+	assert.Regexp(t, `^p1/2024-01-02/logs-`, keys[2])
+	assert.Regexp(t, `^p1/2024-01-03/logs-`, keys[3])
+
+	repo := &memoryRepository{documents: map[Kind]map[string]bool{}}
+	restorer := NewRestorer(store, repo, nopLogger{}, 1)
+	params := RestoreParams{ProjectID: "p1", Kinds: []Kind{KindLogs}, From: day, To: day.AddDate(0, 0, 1)}
+
+	report, err := restorer.Restore(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, &RestoreReport{Files: 2, Events: 4, Restored: 4}, report)
+	assert.Equal(t, 4, repo.batches)
+	assert.True(t, repo.documents[KindLogs][`{"id":"3","time":1704196800000}`])
+
+	// Restoring again skips the stored events
+	report, err = restorer.Restore(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, &RestoreReport{Files: 2, Events: 4, Restored: 0}, report)
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage/blob"
+	"github.com/google/uuid"
+)
+
+const (
+	extension   = ".ndjson.gz"
+	contentType = "application/gzip"
+)
+
+// Archiver writes events to gzip compressed NDJSON blobs, partitioned by project and day, as
+// a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c/2024-01-01/logs-<uuid>.ndjson.gz.
+type Archiver struct {
+	store  blob.Store
+	logger Logger
+}
+
+func NewArchiver(store blob.Store, logger Logger) *Archiver {
+	return &Archiver{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Archive writes one blob per day of the events, which are only stored once all were written.
+func (a *Archiver) Archive(ctx context.Context, kind Kind, projectID string, events []*Event) error {
+	days := make(map[string][]*Event)
+	for _, event := range events {
+		day := time.UnixMilli(event.Time).UTC().Format(time.DateOnly)
+		days[day] = append(days[day], event)
+	}
+
+	keys := make([]string, 0, len(days))
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Strings(keys)
+
+	for _, day := range keys {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		for _, event := range days[day] {
+			if _, err := writer.Write(event.Document); err != nil {
+				return fmt.Errorf("failed to compress archive: %w", err)
+			}
+			if _, err := writer.Write([]byte("\n")); err != nil {
+				return fmt.Errorf("failed to compress archive: %w", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to compress archive: %w", err)
+		}
+
+		key := fmt.Sprintf("%s/%s/%s-%s%s", projectID, day, kind, uuid.New().String(), extension)
+		if err := a.store.Put(ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+			return err
+		}
+
+		a.logger.Debug(fmt.Sprintf("archived %d %s to %s", len(days[day]), kind, key))
+	}
+	return nil
+}
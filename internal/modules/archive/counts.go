@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// countedColumns return the part of the restored events their counts depend on. Logs stored
+// before their fingerprint was introduced belong to no group and are not counted.
+var countedColumns = map[Kind]string{
+	KindErrors: `project_id, fingerprint, '' AS level, time`,
+	KindLogs:   `project_id, COALESCE(fingerprint, '') AS fingerprint, CAST(level AS TEXT) AS level, time`,
+}
+
+// countTables are the prefixes of the tables counting the events of the kind by minute and by hour.
+var countTables = map[Kind]string{
+	KindErrors: "error_counts",
+	KindLogs:   "log_counts",
+}
+
+type countedEvent struct {
+	ProjectID   string `db:"project_id"`
+	Fingerprint string `db:"fingerprint"`
+	Level       string `db:"level"`
+	Time        int64  `db:"time"`
+}
+
+type countKey struct {
+	projectID   string
+	fingerprint string
+	level       string
+	bucket      int64
+}
+
+// insertCounted runs an insert of events and adds the inserted events to the counts of their
+// groups, as the events stored by the API are.
+func (r *repository) insertCounted(ctx context.Context, kind Kind, query string, args ...interface{}) (inserted int, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	query += " RETURNING " + countedColumns[kind]
+	r.logger.Debug(query)
+
+	var events []countedEvent
+	if err = tx.SelectContext(ctx, &events, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to restore %s: %w", kind, err)
+	}
+
+	for _, resolution := range []struct {
+		name     string
+		duration time.Duration
+	}{{"minute", time.Minute}, {"hour", time.Hour}} {
+		if err = r.count(ctx, tx, kind, countTables[kind]+"_"+resolution.name, resolution.duration, events); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(events), nil
+}
+
+func (r *repository) count(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	kind Kind,
+	table string,
+	resolution time.Duration,
+	events []countedEvent,
+) error {
+	counts := make(map[countKey]int)
+	var keys []countKey
+	for _, e := range events {
+		if e.Fingerprint == "" {
+			continue
+		}
+		key := countKey{e.ProjectID, e.Fingerprint, e.Level, storage.Bucket(e.Time, resolution)}
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+
+	// The counts are updated in the same order as the API updates them, which cannot deadlock
+	slices.SortFunc(keys, func(a, b countKey) int {
+		return cmp.Or(
+			strings.Compare(a.projectID, b.projectID),
+			strings.Compare(a.fingerprint, b.fingerprint),
+			cmp.Compare(a.bucket, b.bucket),
+		)
+	})
+
+	for _, key := range keys {
+		query := `
+			INSERT INTO ` + table + ` (count, project_id, fingerprint, bucket)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (project_id, fingerprint, bucket) DO UPDATE SET count = ` + table + `.count + EXCLUDED.count
+		`
+		args := []interface{}{counts[key], key.projectID, key.fingerprint, key.bucket}
+		if kind == KindLogs {
+			query = `
+				INSERT INTO ` + table + ` (count, project_id, fingerprint, bucket, level)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (project_id, fingerprint, bucket) DO UPDATE SET count = ` + table + `.count + EXCLUDED.count
+			`
+			args = append(args, key.level)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to count restored %s: %w", kind, err)
+		}
+	}
+	return nil
+}
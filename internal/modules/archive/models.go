@@ -0,0 +1,40 @@
+package archive
+
+import "time"
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// Kind is the table of the archived events.
+type Kind string
+
+const (
+	KindErrors Kind = "errors"
+	KindLogs   Kind = "logs"
+)
+
+// Event is an archived event, the JSON document of its row.
+type Event struct {
+	// Unix timestamp in milliseconds
+	Time     int64  `db:"time"`
+	Document []byte `db:"document"`
+}
+
+type RestoreParams struct {
+	ProjectID string
+	Kinds     []Kind
+	// Days of the restored events, both included
+	From time.Time
+	To   time.Time
+}
+
+type RestoreReport struct {
+	Files int
+	// Events read from the archives, and the ones restored, the others already being stored
+	Events   int
+	Restored int
+}
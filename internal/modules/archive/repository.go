@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	// Insert stores the archived events that are not stored yet, counting them, and returns their number.
+	Insert(ctx context.Context, kind Kind, documents [][]byte) (int, error)
+}
+
+// insertColumns are the columns restored from the documents, generated ones excluded.
+var insertColumns = map[Kind]string{
+	KindErrors: `id, project_id, fingerprint, message, stacktrace, file, line, context,
+		ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+		time, created_at, updated_at, payload_id`,
+	KindLogs: `id, project_id, fingerprint, level, message, context, time, created_at, updated_at`,
+}
+
+// jsonColumns are the columns of the events holding JSON documents.
+var jsonColumns = map[string]bool{
+	"context": true, "headers": true, "query_params": true, "body_params": true,
+	"cookies": true, "session": true, "files": true, "env": true,
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	r := &repository{
+		db:     db,
+		logger: logger,
+	}
+
+	if storage.DialectOf(db).SQLite() {
+		return &sqliteRepository{r}
+	}
+	return r
+}
+
+func (r *repository) Insert(ctx context.Context, kind Kind, documents [][]byte) (int, error) {
+	columns, ok := insertColumns[kind]
+	if !ok {
+		return 0, fmt.Errorf("unsupported kind %q", kind)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s)
+		SELECT %[2]s FROM jsonb_populate_recordset(CAST(NULL AS %[1]s), CAST($1 AS JSONB))
+		ON CONFLICT DO NOTHING
+	`, kind, columns)
+
+	return r.insertCounted(ctx, kind, query, documentArray(documents))
+}
+
+// documentArray joins the documents into a JSON array.
+func documentArray(documents [][]byte) string {
+	array := append([]byte("["), bytes.Join(documents, []byte(","))...)
+	return string(append(array, ']'))
+}
+
+// columnNames splits a list of columns.
+func columnNames(columns string) []string {
+	names := strings.Split(columns, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	return names
+}
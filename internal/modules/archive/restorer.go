@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage/blob"
+)
+
+const defaultRestoreBatchSize = 500
+
+// Restorer re-imports archived events. Events still stored are skipped, so an archive may be
+// restored again after an interruption. Groups pruned since are not recreated.
+type Restorer struct {
+	store     blob.Store
+	repo      Repository
+	logger    Logger
+	batchSize int
+}
+
+func NewRestorer(store blob.Store, repo Repository, logger Logger, batchSize int) *Restorer {
+	if batchSize <= 0 {
+		batchSize = defaultRestoreBatchSize
+	}
+
+	return &Restorer{
+		store:     store,
+		repo:      repo,
+		logger:    logger,
+		batchSize: batchSize,
+	}
+}
+
+func (r *Restorer) Restore(ctx context.Context, params RestoreParams) (*RestoreReport, error) {
+	report := &RestoreReport{}
+
+	for day := params.From.UTC(); !day.After(params.To.UTC()); day = day.AddDate(0, 0, 1) {
+		for _, kind := range params.Kinds {
+			prefix := fmt.Sprintf("%s/%s/%s-", params.ProjectID, day.Format(time.DateOnly), kind)
+
+			keys, err := r.store.List(ctx, prefix)
+			if err != nil {
+				return report, err
+			}
+
+			for _, key := range keys {
+				events, restored, err := r.restoreFile(ctx, kind, key)
+				report.Events += events
+				report.Restored += restored
+				if err != nil {
+					return report, err
+				}
+				report.Files++
+
+				r.logger.Info(fmt.Sprintf(
+					"restored %s: %d of %d %s (%d files, %d events so far)",
+					key, restored, events, kind, report.Files, report.Events,
+				))
+			}
+		}
+	}
+	return report, nil
+}
+
+func (r *Restorer) restoreFile(ctx context.Context, kind Kind, key string) (int, int, error) {
+	body, err := r.store.Get(ctx, key)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer body.Close()
+
+	decompressed, err := gzip.NewReader(body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	defer decompressed.Close()
+
+	// Lines are not bounded in length, stacktraces and contexts may be large
+	reader := bufio.NewReader(decompressed)
+
+	var (
+		events, restored int
+		batch            [][]byte
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, err := r.repo.Insert(ctx, kind, batch)
+		if err != nil {
+			return err
+		}
+		restored += inserted
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			events++
+			batch = append(batch, line)
+			if len(batch) >= r.batchSize {
+				if err := flush(); err != nil {
+					return events, restored, err
+				}
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return events, restored, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+
+	return events, restored, flush()
+}
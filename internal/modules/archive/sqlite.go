@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// sqliteRepository restores the archived events into SQLite, which has no records to populate
+// from JSON and reads the documents field by field.
+type sqliteRepository struct {
+	*repository
+}
+
+func (r *sqliteRepository) Insert(ctx context.Context, kind Kind, documents [][]byte) (int, error) {
+	columns, ok := insertColumns[kind]
+	if !ok {
+		return 0, fmt.Errorf("unsupported kind %q", kind)
+	}
+
+	fields := make([]string, 0, len(columnNames(columns)))
+	for _, name := range columnNames(columns) {
+		if jsonColumns[name] {
+			fields = append(fields, "nullif(value -> '$."+name+"', 'null')")
+		} else {
+			fields = append(fields, "value ->> '$."+name+"'")
+		}
+	}
+
+	// The WHERE clause keeps SQLite from reading ON CONFLICT as a join constraint.
+	query := fmt.Sprintf(`
+		INSERT INTO %s (%s)
+		SELECT %s FROM json_each($1)
+		WHERE true
+		ON CONFLICT DO NOTHING
+	`, kind, columns, strings.Join(fields, ", "))
+
+	return r.insertCounted(ctx, kind, query, documentArray(documents))
+}
+
+// SQLiteDocument is the SQL building the JSON document of an event of the kind in SQLite, which
+// has no row to JSON conversion. The documents match those of PostgreSQL so the archives of both
+// restore into either.
+func SQLiteDocument(kind Kind) string {
+	names := columnNames(insertColumns[kind])
+
+	fields := make([]string, 0, len(names))
+	for _, name := range names {
+		if jsonColumns[name] {
+			fields = append(fields, "'"+name+"', json("+name+")")
+		} else {
+			fields = append(fields, "'"+name+"', "+name)
+		}
+	}
+	return "json_object(" + strings.Join(fields, ", ") + ")"
+}
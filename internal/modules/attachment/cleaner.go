@@ -0,0 +1,50 @@
+package attachment
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultCleanupInterval = time.Hour
+
+// Cleaner periodically removes the attachments of the errors deleted by users, retention or
+// dropped partitions.
+type Cleaner struct {
+	service  Service
+	logger   Logger
+	interval time.Duration
+}
+
+func NewCleaner(service Service, logger Logger, config Config) *Cleaner {
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = defaultCleanupInterval
+	}
+
+	return &Cleaner{
+		service:  service,
+		logger:   logger,
+		interval: config.CleanupInterval,
+	}
+}
+
+func (c *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := c.service.DeleteOrphans(ctx)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("failed to delete attachments of deleted errors: %v", err))
+		}
+		if deleted > 0 {
+			c.logger.Info(fmt.Sprintf("deleted %d attachments of deleted errors", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
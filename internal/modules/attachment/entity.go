@@ -0,0 +1,20 @@
+package attachment
+
+type Attachment struct {
+	ID          string `db:"id"`
+	ErrorID     string `db:"error_id"`
+	ProjectID   string `db:"project_id"`
+	Name        string `db:"name"`
+	ContentType string `db:"content_type"`
+	Size        int64  `db:"size"`
+	CreatedAt   int64  `db:"created_at"`
+}
+
+// Limits override the size limits of the instance for a project, those left null keeping them.
+type Limits struct {
+	ProjectID    string `db:"project_id"`
+	MaxFileSize  *int64 `db:"max_file_size"`
+	MaxErrorSize *int64 `db:"max_error_size"`
+	CreatedAt    int64  `db:"created_at"`
+	UpdatedAt    int64  `db:"updated_at"`
+}
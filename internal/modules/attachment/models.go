@@ -0,0 +1,71 @@
+package attachment
+
+import (
+	"io"
+	"time"
+
+	"github.com/fuckbug/api/internal/modules/errors"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Config struct {
+	// Size in bytes of the largest file, unless the project sets its own
+	MaxFileSize int64
+	// Size in bytes of all the files of an error, unless the project sets its own
+	MaxErrorSize int64
+	// Interval between two removals of the attachments of deleted errors
+	CleanupInterval time.Duration
+	// Number of attachments removed at once
+	BatchSize int
+}
+
+// Upload is a file attached to an error, read from Body.
+type Upload struct {
+	ProjectID   string
+	ErrorID     string
+	Name        string
+	ContentType string
+	Body        io.Reader
+}
+
+type Entity struct {
+	ID          string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ErrorID     string `json:"errorId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Name        string `json:"name" example:"app.log"`
+	ContentType string `json:"contentType" example:"text/plain"`
+	Size        int64  `json:"size" example:"5120"`
+	CreatedAt   int64  `json:"createdAt" example:"1745446888"`
+}
+
+type EntityList struct {
+	Items []Entity `json:"items"`
+	Count int      `json:"count" example:"1"`
+}
+
+// Ingested is an error created along with its attachments. Rejected lists the names of the files
+// over the size limits, which were not stored.
+type Ingested struct {
+	*errors.Entity
+	Attachments []*Entity `json:"attachments"`
+	Rejected    []string  `json:"rejected"`
+}
+
+type UpdateLimits struct {
+	// Size in bytes of the largest file, the limit of the instance when null
+	MaxFileSize *int64 `json:"maxFileSize" validate:"omitempty,min=1" example:"10485760"`
+	// Size in bytes of all the files of an error, the limit of the instance when null
+	MaxErrorSize *int64 `json:"maxErrorSize" validate:"omitempty,min=1" example:"26214400"`
+}
+
+// LimitsEntity are the size limits applying to a project, its own or those of the instance.
+type LimitsEntity struct {
+	ProjectID    string `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	MaxFileSize  int64  `json:"maxFileSize" example:"10485760"`
+	MaxErrorSize int64  `json:"maxErrorSize" example:"26214400"`
+}
@@ -0,0 +1,158 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Repository interface {
+	Create(ctx context.Context, attachment *Attachment) error
+	GetByID(ctx context.Context, id string) (*Attachment, error)
+	GetByErrorID(ctx context.Context, errorID string) ([]*Attachment, error)
+	SumSizes(ctx context.Context, errorID string) (int64, error)
+	GetLimits(ctx context.Context, projectID string) (*Limits, error)
+	UpsertLimits(ctx context.Context, limits *Limits) error
+	// GetOrphans returns the attachments of the errors deleted since they were uploaded.
+	GetOrphans(ctx context.Context, limit int) ([]*Attachment, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
+}
+
+type repository struct {
+	db      *sqlx.DB
+	dialect storage.Dialect
+	logger  Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:      db,
+		dialect: storage.DialectOf(db),
+		logger:  logger,
+	}
+}
+
+const attachmentColumns = `id, error_id, project_id, name, content_type, size, created_at`
+
+func (r *repository) Create(ctx context.Context, a *Attachment) error {
+	const query = `
+		INSERT INTO error_attachments (` + attachmentColumns + `)
+		VALUES (:id, :error_id, :project_id, :name, :content_type, :size, :created_at)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, a); err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*Attachment, error) {
+	const query = `SELECT ` + attachmentColumns + ` FROM error_attachments WHERE id = $1`
+
+	var attachment Attachment
+	err := r.db.GetContext(ctx, &attachment, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+func (r *repository) GetByErrorID(ctx context.Context, errorID string) ([]*Attachment, error) {
+	const query = `SELECT ` + attachmentColumns + ` FROM error_attachments WHERE error_id = $1 ORDER BY created_at, name`
+
+	var attachments []*Attachment
+	if err := r.db.SelectContext(ctx, &attachments, query, errorID); err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *repository) SumSizes(ctx context.Context, errorID string) (int64, error) {
+	const query = `SELECT COALESCE(SUM(size), 0) FROM error_attachments WHERE error_id = $1`
+
+	var size int64
+	if err := r.db.GetContext(ctx, &size, query, errorID); err != nil {
+		return 0, fmt.Errorf("failed to sum attachment sizes: %w", err)
+	}
+	return size, nil
+}
+
+func (r *repository) GetLimits(ctx context.Context, projectID string) (*Limits, error) {
+	const query = `
+		SELECT project_id, max_file_size, max_error_size, created_at, updated_at
+		FROM attachment_limits WHERE project_id = $1
+	`
+
+	var limits Limits
+	err := r.db.GetContext(ctx, &limits, query, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment limits: %w", err)
+	}
+	return &limits, nil
+}
+
+func (r *repository) UpsertLimits(ctx context.Context, l *Limits) error {
+	const query = `
+		INSERT INTO attachment_limits (project_id, max_file_size, max_error_size, created_at, updated_at)
+		VALUES (:project_id, :max_file_size, :max_error_size, :created_at, :updated_at)
+		ON CONFLICT (project_id) DO UPDATE
+		SET
+			max_file_size = EXCLUDED.max_file_size,
+			max_error_size = EXCLUDED.max_error_size,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	now := time.Now().Unix()
+	if l.CreatedAt == 0 {
+		l.CreatedAt = now
+	}
+	l.UpdatedAt = now
+
+	if _, err := r.db.NamedExecContext(ctx, query, l); err != nil {
+		return fmt.Errorf("failed to upsert attachment limits: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOrphans(ctx context.Context, limit int) ([]*Attachment, error) {
+	const query = `
+		SELECT ` + attachmentColumns + ` FROM error_attachments a
+		WHERE NOT EXISTS (SELECT 1 FROM errors e WHERE e.id = a.error_id)
+		ORDER BY created_at
+		LIMIT $1
+	`
+
+	var attachments []*Attachment
+	if err := r.db.SelectContext(ctx, &attachments, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to get orphan attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *repository) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	query := `DELETE FROM error_attachments WHERE ` + r.dialect.In("id", "$1")
+
+	result, err := r.db.ExecContext(ctx, query, r.dialect.List(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete attachments: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,278 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fuckbug/api/internal/storage/blob"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMaxFileSize  = 10 << 20
+	defaultMaxErrorSize = 25 << 20
+	defaultBatchSize    = 100
+
+	defaultContentType = "application/octet-stream"
+	defaultName        = "attachment"
+	maxNameLength      = 255
+)
+
+var ErrTooLarge = errors.New("attachment exceeds the size limits")
+
+type Service interface {
+	Attach(ctx context.Context, upload *Upload) (*Entity, error)
+	GetAll(ctx context.Context, errorID string) ([]*Entity, error)
+	// Open returns an attachment of the error and its content, which the caller closes.
+	Open(ctx context.Context, errorID, id string) (*Entity, io.ReadCloser, error)
+	GetLimits(ctx context.Context, projectID string) (*LimitsEntity, error)
+	UpdateLimits(ctx context.Context, projectID string, req *UpdateLimits) (*LimitsEntity, error)
+	// DeleteOrphans removes the attachments of the deleted errors and returns how many were.
+	DeleteOrphans(ctx context.Context) (int, error)
+}
+
+type service struct {
+	repo   Repository
+	store  blob.Store
+	logger Logger
+	config Config
+}
+
+func NewService(repo Repository, store blob.Store, logger Logger, config Config) Service {
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = defaultMaxFileSize
+	}
+	if config.MaxErrorSize <= 0 {
+		config.MaxErrorSize = defaultMaxErrorSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+
+	return &service{
+		repo:   repo,
+		store:  store,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Attach stores the file as long as it fits in the limits of its project, reading no more of
+// it than they allow. ErrTooLarge is returned otherwise, and nothing is stored.
+func (s *service) Attach(ctx context.Context, upload *Upload) (*Entity, error) {
+	limits, err := s.GetLimits(ctx, upload.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := s.repo.SumSizes(ctx, upload.ErrorID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := min(limits.MaxFileSize, limits.MaxErrorSize-used)
+	if allowed <= 0 {
+		return nil, ErrTooLarge
+	}
+
+	attachment := &Attachment{
+		ID:          uuid.New().String(),
+		ErrorID:     upload.ErrorID,
+		ProjectID:   upload.ProjectID,
+		Name:        sanitizeName(upload.Name),
+		ContentType: upload.ContentType,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if attachment.ContentType == "" {
+		attachment.ContentType = defaultContentType
+	}
+
+	body := &limitedReader{r: upload.Body, remaining: allowed}
+	err = s.store.Put(ctx, key(attachment), body, -1, attachment.ContentType)
+	if body.exceeded {
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	attachment.Size = body.read
+
+	if err := s.repo.Create(ctx, attachment); err != nil {
+		if deleteErr := s.store.Delete(ctx, key(attachment)); deleteErr != nil {
+			s.logger.Warn(fmt.Sprintf("failed to delete attachment %s: %v", attachment.ID, deleteErr))
+		}
+		return nil, err
+	}
+
+	return toResponse(attachment), nil
+}
+
+func (s *service) GetAll(ctx context.Context, errorID string) ([]*Entity, error) {
+	attachments, err := s.repo.GetByErrorID(ctx, errorID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*Entity, 0, len(attachments))
+	for _, attachment := range attachments {
+		responses = append(responses, toResponse(attachment))
+	}
+	return responses, nil
+}
+
+func (s *service) Open(ctx context.Context, errorID, id string) (*Entity, io.ReadCloser, error) {
+	attachment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment.ErrorID != errorID {
+		return nil, nil, ErrNotFound
+	}
+
+	content, err := s.store.Get(ctx, key(attachment))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	return toResponse(attachment), content, nil
+}
+
+// GetLimits returns the limits of the project, those of the instance where it set none.
+func (s *service) GetLimits(ctx context.Context, projectID string) (*LimitsEntity, error) {
+	limits, err := s.repo.GetLimits(ctx, projectID)
+	if errors.Is(err, ErrNotFound) {
+		return s.toLimitsResponse(&Limits{ProjectID: projectID}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLimitsResponse(limits), nil
+}
+
+func (s *service) UpdateLimits(ctx context.Context, projectID string, req *UpdateLimits) (*LimitsEntity, error) {
+	limits, err := s.repo.GetLimits(ctx, projectID)
+	if errors.Is(err, ErrNotFound) {
+		limits = &Limits{ProjectID: projectID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	limits.MaxFileSize = req.MaxFileSize
+	limits.MaxErrorSize = req.MaxErrorSize
+
+	if err := s.repo.UpsertLimits(ctx, limits); err != nil {
+		return nil, err
+	}
+
+	return s.toLimitsResponse(limits), nil
+}
+
+func (s *service) DeleteOrphans(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		orphans, err := s.repo.GetOrphans(ctx, s.config.BatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		if len(orphans) == 0 {
+			return deleted, nil
+		}
+
+		// The rows go last, a blob failing to be deleted is retried by the next cleanup
+		ids := make([]string, 0, len(orphans))
+		for _, orphan := range orphans {
+			if err := s.store.Delete(ctx, key(orphan)); err != nil {
+				return deleted, fmt.Errorf("failed to delete attachment %s: %w", orphan.ID, err)
+			}
+			ids = append(ids, orphan.ID)
+		}
+
+		n, err := s.repo.DeleteByIDs(ctx, ids)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if len(orphans) < s.config.BatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// key is where the content of the attachment is kept in the blob store.
+func key(a *Attachment) string {
+	return a.ProjectID + "/" + a.ErrorID + "/" + a.ID
+}
+
+// sanitizeName keeps the base name of the uploaded file, which clients may send as a full path.
+func sanitizeName(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.ToValidUTF8(name, ""), `\`, "/"))
+	if name == "." || name == "/" {
+		return defaultName
+	}
+
+	for len(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// limitedReader reads at most remaining bytes, failing with ErrTooLarge past them.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// One byte more than allowed tells a file of the exact size from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return 0, ErrTooLarge
+	}
+	l.remaining -= int64(n)
+	l.read += int64(n)
+	return n, err
+}
+
+func toResponse(a *Attachment) *Entity {
+	return &Entity{
+		ID:          a.ID,
+		ErrorID:     a.ErrorID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+func (s *service) toLimitsResponse(l *Limits) *LimitsEntity {
+	response := &LimitsEntity{
+		ProjectID:    l.ProjectID,
+		MaxFileSize:  s.config.MaxFileSize,
+		MaxErrorSize: s.config.MaxErrorSize,
+	}
+	if l.MaxFileSize != nil {
+		response.MaxFileSize = *l.MaxFileSize
+	}
+	if l.MaxErrorSize != nil {
+		response.MaxErrorSize = *l.MaxErrorSize
+	}
+	return response
+}
@@ -0,0 +1,108 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/blob"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, config Config) (Service, blob.Store) {
+	t.Helper()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	appLogger := logger.New("ERROR", nil)
+	require.NoError(t, sql.RunMigrations(db, appLogger))
+
+	store := blob.NewFilesystemStore(t.TempDir())
+	return NewService(NewRepository(db, appLogger), store, appLogger, config), store
+}
+
+func upload(name, content string) *Upload {
+	return &Upload{ProjectID: "p", ErrorID: "e", Name: name, Body: strings.NewReader(content)}
+}
+
+func TestServiceAttach(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestService(t, Config{MaxFileSize: 4, MaxErrorSize: 6})
+
+	attached, err := service.Attach(ctx, upload(`C:\logs\app.log`, "1234"))
+	require.NoError(t, err)
+	assert.Equal(t, "app.log", attached.Name)
+	assert.Equal(t, int64(4), attached.Size)
+	assert.Equal(t, "application/octet-stream", attached.ContentType)
+
+	// Over the size of a file, then over what is left for the error
+	_, err = service.Attach(ctx, upload("dump.bin", "12345"))
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = service.Attach(ctx, upload("dump.bin", "123"))
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	keys, err := store.List(ctx, "p/e/")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	_, err = service.Attach(ctx, upload("screen.png", "12"))
+	require.NoError(t, err)
+
+	all, err := service.GetAll(ctx, "e")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	found, content, err := service.Open(ctx, "e", attached.ID)
+	require.NoError(t, err)
+	defer content.Close()
+	body, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "app.log", found.Name)
+	assert.Equal(t, "1234", string(body))
+
+	_, _, err = service.Open(ctx, "other", attached.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestServiceLimits(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService(t, Config{MaxFileSize: 4, MaxErrorSize: 6})
+
+	limits, err := service.GetLimits(ctx, "p")
+	require.NoError(t, err)
+	assert.Equal(t, &LimitsEntity{ProjectID: "p", MaxFileSize: 4, MaxErrorSize: 6}, limits)
+
+	maxFileSize := int64(8)
+	limits, err = service.UpdateLimits(ctx, "p", &UpdateLimits{MaxFileSize: &maxFileSize})
+	require.NoError(t, err)
+	assert.Equal(t, &LimitsEntity{ProjectID: "p", MaxFileSize: 8, MaxErrorSize: 6}, limits)
+
+	_, err = service.Attach(ctx, upload("app.log", "123456"))
+	require.NoError(t, err)
+}
+
+func TestServiceDeleteOrphans(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestService(t, Config{BatchSize: 1})
+
+	for _, name := range []string{"a.log", "b.log"} {
+		_, err := service.Attach(ctx, upload(name, "log"))
+		require.NoError(t, err)
+	}
+
+	// The error the attachments belong to was never stored
+	deleted, err := service.DeleteOrphans(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	keys, err := store.List(ctx, "p/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
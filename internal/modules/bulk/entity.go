@@ -0,0 +1,49 @@
+package bulk
+
+type Target string
+
+const (
+	TargetErrorGroups Target = "error-groups"
+	TargetLogGroups   Target = "log-groups"
+	TargetErrors      Target = "errors"
+	TargetLogs        Target = "logs"
+)
+
+type Action string
+
+const (
+	ActionStatus Action = "status"
+	ActionAssign Action = "assign"
+	ActionDelete Action = "delete"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+type Job struct {
+	ID         string  `db:"id"`
+	ProjectID  string  `db:"project_id"`
+	CreatedBy  *string `db:"created_by"`
+	Target     Target  `db:"target"`
+	Action     Action  `db:"action"`
+	Filter     string  `db:"filter"` // Query string of the target list
+	NewStatus  string  `db:"new_status"`
+	AssigneeID *string `db:"assignee_id"`
+	Status     Status  `db:"status"`
+	Total      int     `db:"total"`
+	Processed  int     `db:"processed"`
+	Affected   int     `db:"affected"`
+	Cursor     string  `db:"cursor"` // Last processed ID
+	Error      *string `db:"error"`
+	LeaseUntil int64   `db:"lease_until"`
+	CreatedAt  int64   `db:"created_at"`
+	UpdatedAt  int64   `db:"updated_at"`
+	FinishedAt *int64  `db:"finished_at"`
+}
@@ -0,0 +1,178 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	errorsModule "github.com/fuckbug/api/internal/modules/errors"
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	logModule "github.com/fuckbug/api/internal/modules/log"
+	logGroup "github.com/fuckbug/api/internal/modules/logGroup"
+)
+
+var (
+	errUnsupportedAction = errors.New("unsupported action")
+	errInvalidFilter     = errors.New("filter does not select the project of the job")
+)
+
+type errorGroupExecutor struct {
+	service errorsGroup.Service
+}
+
+func NewErrorGroupExecutor(service errorsGroup.Service) Executor {
+	return &errorGroupExecutor{service: service}
+}
+
+func (e *errorGroupExecutor) Supports(Action) bool {
+	return true
+}
+
+func (e *errorGroupExecutor) Count(ctx context.Context, filter url.Values) (int, error) {
+	params, err := errorsGroup.ParseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return e.service.Count(ctx, params)
+}
+
+func (e *errorGroupExecutor) GetIDs(ctx context.Context, filter url.Values, afterID string, limit int) ([]string, error) {
+	params, err := errorsGroup.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return e.service.GetIDs(ctx, params, afterID, limit)
+}
+
+func (e *errorGroupExecutor) Apply(ctx context.Context, job *Job, ids []string) (int, error) {
+	switch job.Action {
+	case ActionStatus:
+		return e.service.BulkUpdateStatus(ctx, ids, errorsGroup.Status(job.NewStatus), valueOf(job.CreatedBy))
+	case ActionAssign:
+		return e.service.BulkAssign(ctx, ids, job.AssigneeID, valueOf(job.CreatedBy))
+	case ActionDelete:
+		return e.service.DeleteByIDs(ctx, ids)
+	default:
+		return 0, errUnsupportedAction
+	}
+}
+
+type logGroupExecutor struct {
+	service logGroup.Service
+}
+
+func NewLogGroupExecutor(service logGroup.Service) Executor {
+	return &logGroupExecutor{service: service}
+}
+
+func (e *logGroupExecutor) Supports(Action) bool {
+	return true
+}
+
+func (e *logGroupExecutor) Count(ctx context.Context, filter url.Values) (int, error) {
+	params, err := logGroup.ParseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return e.service.Count(ctx, params)
+}
+
+func (e *logGroupExecutor) GetIDs(ctx context.Context, filter url.Values, afterID string, limit int) ([]string, error) {
+	params, err := logGroup.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return e.service.GetIDs(ctx, params, afterID, limit)
+}
+
+func (e *logGroupExecutor) Apply(ctx context.Context, job *Job, ids []string) (int, error) {
+	switch job.Action {
+	case ActionStatus:
+		return e.service.BulkUpdateStatus(ctx, ids, logGroup.Status(job.NewStatus), valueOf(job.CreatedBy))
+	case ActionAssign:
+		return e.service.BulkAssign(ctx, ids, job.AssigneeID, valueOf(job.CreatedBy))
+	case ActionDelete:
+		return e.service.DeleteByIDs(ctx, ids)
+	default:
+		return 0, errUnsupportedAction
+	}
+}
+
+type errorExecutor struct {
+	service errorsModule.Service
+}
+
+func NewErrorExecutor(service errorsModule.Service) Executor {
+	return &errorExecutor{service: service}
+}
+
+// Supports only allows deletion, statuses and assignees belong to groups.
+func (e *errorExecutor) Supports(action Action) bool {
+	return action == ActionDelete
+}
+
+func (e *errorExecutor) Count(ctx context.Context, filter url.Values) (int, error) {
+	params, err := errorsModule.ParseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return e.service.Count(ctx, params)
+}
+
+func (e *errorExecutor) GetIDs(ctx context.Context, filter url.Values, afterID string, limit int) ([]string, error) {
+	params, err := errorsModule.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return e.service.GetIDs(ctx, params, afterID, limit)
+}
+
+func (e *errorExecutor) Apply(ctx context.Context, job *Job, ids []string) (int, error) {
+	if job.Action != ActionDelete {
+		return 0, errUnsupportedAction
+	}
+	return e.service.DeleteByIDs(ctx, ids)
+}
+
+type logExecutor struct {
+	service logModule.Service
+}
+
+func NewLogExecutor(service logModule.Service) Executor {
+	return &logExecutor{service: service}
+}
+
+// Supports only allows deletion, statuses and assignees belong to groups.
+func (e *logExecutor) Supports(action Action) bool {
+	return action == ActionDelete
+}
+
+func (e *logExecutor) Count(ctx context.Context, filter url.Values) (int, error) {
+	params, err := logModule.ParseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return e.service.Count(ctx, params)
+}
+
+func (e *logExecutor) GetIDs(ctx context.Context, filter url.Values, afterID string, limit int) ([]string, error) {
+	params, err := logModule.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return e.service.GetIDs(ctx, params, afterID, limit)
+}
+
+func (e *logExecutor) Apply(ctx context.Context, job *Job, ids []string) (int, error) {
+	if job.Action != ActionDelete {
+		return 0, errUnsupportedAction
+	}
+	return e.service.DeleteByIDs(ctx, ids)
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,82 @@
+package bulk
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// Executor applies the actions of jobs to one target, a batch of IDs at a time.
+type Executor interface {
+	Supports(action Action) bool
+	// Count and GetIDs take the query parameters of the target list endpoint as filter
+	Count(ctx context.Context, filter url.Values) (int, error)
+	GetIDs(ctx context.Context, filter url.Values, afterID string, limit int) ([]string, error)
+	// Apply runs the action of the job on the IDs and returns the number of affected items
+	Apply(ctx context.Context, job *Job, ids []string) (int, error)
+}
+
+type Config struct {
+	// Interval between two checks for pending jobs
+	Interval time.Duration
+	// Number of items processed by a single statement
+	BatchSize int
+	// Time a job stays claimed by a runner without progress before another one takes it over
+	Lease time.Duration
+}
+
+type GetAllParams struct {
+	ProjectID string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type Create struct {
+	// Filter selects the items with the query parameters of the target list endpoint
+	Filter url.Values `json:"-"`
+	// Status changes and assignments only apply to groups
+	Action string `json:"action" validate:"required,oneof=status assign delete" example:"status"`
+	// New status of the groups, for the status action
+	Status string `json:"status" validate:"required_if=Action status,omitempty,oneof=unresolved resolved ignored" example:"resolved"`
+	// Assignee of the groups for the assign action, unassigned when null
+	AssigneeID *string `json:"assigneeId" validate:"omitempty,uuid" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Target     Target  `json:"-"`
+}
+
+type Entity struct {
+	ID        string  `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ProjectID string  `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	CreatedBy *string `json:"createdBy" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Target    string  `json:"target" example:"error-groups" enums:"error-groups,log-groups,errors,logs"`
+	Action    string  `json:"action" example:"status" enums:"status,assign,delete"`
+	// Query string of the target list endpoint selecting the items
+	Filter     string  `json:"filter" example:"projectId=a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c&search=timeout"`
+	NewStatus  string  `json:"newStatus,omitempty" example:"resolved"`
+	AssigneeID *string `json:"assigneeId,omitempty" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Status     string  `json:"status" example:"running" enums:"pending,running,succeeded,failed,canceled"`
+	// Number of items matching the filter when the job started
+	Total int `json:"total" example:"1200"`
+	// Number of items processed so far
+	Processed int `json:"processed" example:"500"`
+	// Number of items actually changed or deleted
+	Affected int `json:"affected" example:"480"`
+	// Percentage of the items processed
+	Progress   float64 `json:"progress" example:"41.67"`
+	Error      *string `json:"error,omitempty" example:"failed to update error group statuses"`
+	CreatedAt  int64   `json:"createdAt" example:"1745446888"`
+	UpdatedAt  int64   `json:"updatedAt" example:"1745446888"`
+	FinishedAt *int64  `json:"finishedAt,omitempty" example:"1745446900"`
+}
+
+type EntityList struct {
+	Items []Entity `json:"items"`
+	Count int      `json:"count" example:"1"`
+}
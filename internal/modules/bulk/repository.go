@@ -0,0 +1,255 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrNotRunning is returned when the job was canceled or taken over while running
+	ErrNotRunning = errors.New("job is not running")
+)
+
+type Repository interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Job, error)
+	Count(ctx context.Context, projectID string) (int, error)
+	GetByID(ctx context.Context, id string) (*Job, error)
+	Create(ctx context.Context, job *Job) error
+	Claim(ctx context.Context, now, leaseUntil int64) (*Job, error)
+	UpdateProgress(ctx context.Context, job *Job) error
+	Finish(ctx context.Context, job *Job) error
+	Cancel(ctx context.Context, id string) error
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	r := &repository{
+		db:     db,
+		logger: logger,
+	}
+
+	if storage.DialectOf(db).SQLite() {
+		return &sqliteRepository{r}
+	}
+	return r
+}
+
+const jobColumns = `id, project_id, created_by, target, action, filter, new_status, assignee_id, status,
+	total, processed, affected, cursor, error, lease_until, created_at, updated_at, finished_at`
+
+func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM bulk_jobs WHERE project_id = :projectId`
+
+	args := map[string]interface{}{
+		"projectId": params.ProjectID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query += " ORDER BY created_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var jobs []*Job
+	err = r.db.SelectContext(ctx, &jobs, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *repository) Count(ctx context.Context, projectID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM bulk_jobs WHERE project_id = $1`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*Job, error) {
+	const query = `SELECT ` + jobColumns + ` FROM bulk_jobs WHERE id = $1`
+
+	var job Job
+	err := r.db.GetContext(ctx, &job, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get bulk job by id: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *repository) Create(ctx context.Context, job *Job) error {
+	const query = `
+		INSERT INTO bulk_jobs (
+			id, project_id, created_by, target, action, filter, new_status, assignee_id, status, created_at, updated_at
+		) VALUES (
+			:id, :project_id, :created_by, :target, :action, :filter, :new_status, :assignee_id, :status,
+			:created_at, :updated_at
+		)
+	`
+
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	job.Status = StatusPending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, job)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk job: %w", err)
+	}
+	return nil
+}
+
+// Claim leases the oldest pending job, or a running one whose runner stopped making progress.
+// It returns nil when there is no job to run.
+func (r *repository) Claim(ctx context.Context, now, leaseUntil int64) (*Job, error) {
+	const query = `
+		WITH due AS (
+			SELECT id
+			FROM bulk_jobs
+			WHERE status IN ('pending', 'running') AND lease_until <= $1
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE bulk_jobs j
+		SET status = 'running', lease_until = $2, updated_at = $1
+		FROM due
+		WHERE j.id = due.id
+		RETURNING
+			j.id, j.project_id, j.created_by, j.target, j.action, j.filter, j.new_status, j.assignee_id, j.status,
+			j.total, j.processed, j.affected, j.cursor, j.error, j.lease_until, j.created_at, j.updated_at,
+			j.finished_at
+	`
+
+	var job Job
+	err := r.db.GetContext(ctx, &job, query, now, leaseUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim bulk job: %w", err)
+	}
+	return &job, nil
+}
+
+// UpdateProgress saves the progress of a running job and extends its lease.
+func (r *repository) UpdateProgress(ctx context.Context, job *Job) error {
+	const query = `
+		UPDATE
+			bulk_jobs
+		SET
+			total = :total,
+			processed = :processed,
+			affected = :affected,
+			cursor = :cursor,
+			lease_until = :lease_until,
+			updated_at = :updated_at
+		WHERE
+			id = :id AND status = 'running'
+	`
+
+	job.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.NamedExecContext(ctx, query, job)
+	if err != nil {
+		return fmt.Errorf("failed to update bulk job progress: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func (r *repository) Finish(ctx context.Context, job *Job) error {
+	const query = `
+		UPDATE
+			bulk_jobs
+		SET
+			status = :status,
+			total = :total,
+			processed = :processed,
+			affected = :affected,
+			cursor = :cursor,
+			error = :error,
+			updated_at = :updated_at,
+			finished_at = :finished_at
+		WHERE
+			id = :id AND status = 'running'
+	`
+
+	now := time.Now().Unix()
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+
+	result, err := r.db.NamedExecContext(ctx, query, job)
+	if err != nil {
+		return fmt.Errorf("failed to finish bulk job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func (r *repository) Cancel(ctx context.Context, id string) error {
+	const query = `
+		UPDATE bulk_jobs
+		SET status = 'canceled', updated_at = $1, finished_at = $1
+		WHERE id = $2 AND status IN ('pending', 'running')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel bulk job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotRunning
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultInterval  = 5 * time.Second
+	defaultBatchSize = 500
+	defaultLease     = time.Minute
+)
+
+// Runner applies the queued jobs batch by batch, saving its progress after each batch so
+// that a job interrupted by a restart resumes where it stopped once its lease expires.
+type Runner struct {
+	repo      Repository
+	executors map[Target]Executor
+	logger    Logger
+	config    Config
+}
+
+func NewRunner(repo Repository, executors map[Target]Executor, logger Logger, config Config) *Runner {
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.Lease <= 0 {
+		config.Lease = defaultLease
+	}
+
+	return &Runner{
+		repo:      repo,
+		executors: executors,
+		logger:    logger,
+		config:    config,
+	}
+}
+
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.RunPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunPending runs the claimable jobs one after the other until there is none left.
+func (r *Runner) RunPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := r.repo.Claim(ctx, now.Unix(), now.Add(r.config.Lease).Unix())
+		if err != nil {
+			r.logger.Error(err.Error())
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		r.process(ctx, job)
+	}
+}
+
+func (r *Runner) process(ctx context.Context, job *Job) {
+	err := r.apply(ctx, job)
+
+	switch {
+	case errors.Is(err, ErrNotRunning):
+		r.logger.Info(fmt.Sprintf("bulk job %s was canceled", job.ID))
+		return
+	case ctx.Err() != nil:
+		// Stopped by shutdown, the job is resumed once its lease expires.
+		return
+	case err != nil:
+		message := err.Error()
+		job.Status = StatusFailed
+		job.Error = &message
+		r.logger.Error(fmt.Sprintf("bulk job %s failed: %s", job.ID, message))
+	default:
+		job.Status = StatusSucceeded
+	}
+
+	if err := r.repo.Finish(ctx, job); err != nil && !errors.Is(err, ErrNotRunning) {
+		r.logger.Error(err.Error())
+	}
+}
+
+func (r *Runner) apply(ctx context.Context, job *Job) error {
+	executor, ok := r.executors[job.Target]
+	if !ok {
+		return ErrUnsupportedTarget
+	}
+
+	filter, err := url.ParseQuery(job.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to decode bulk job filter: %w", err)
+	}
+	if filter.Get("projectId") != job.ProjectID {
+		return errInvalidFilter
+	}
+
+	if job.Processed == 0 {
+		total, err := executor.Count(ctx, filter)
+		if err != nil {
+			return err
+		}
+		job.Total = total
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ids, err := executor.GetIDs(ctx, filter, job.Cursor, r.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		affected, err := executor.Apply(ctx, job, ids)
+		if err != nil {
+			return err
+		}
+
+		job.Processed += len(ids)
+		job.Affected += affected
+		job.Cursor = ids[len(ids)-1]
+		// Items created after the job started can match the filter too.
+		if job.Processed > job.Total {
+			job.Total = job.Processed
+		}
+		job.LeaseUntil = time.Now().Add(r.config.Lease).Unix()
+
+		if err := r.repo.UpdateProgress(ctx, job); err != nil {
+			return err
+		}
+	}
+}
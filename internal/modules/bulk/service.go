@@ -0,0 +1,148 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/fuckbug/api/internal/middleware"
+)
+
+var (
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrUnsupportedTarget = errors.New("unsupported target")
+	ErrUnsupportedAction = errors.New("action is not supported for this target")
+	ErrNotCancelable     = errors.New("only pending and running jobs can be canceled")
+)
+
+type Service interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetByID(ctx context.Context, id string) (*Entity, error)
+	Create(ctx context.Context, req *Create) (*Entity, error)
+	Cancel(ctx context.Context, id string) (*Entity, error)
+}
+
+type service struct {
+	repo      Repository
+	executors map[Target]Executor
+	logger    Logger
+}
+
+func NewService(repo Repository, executors map[Target]Executor, logger Logger) Service {
+	return &service{
+		repo:      repo,
+		executors: executors,
+		logger:    logger,
+	}
+}
+
+func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+	jobs, err := s.repo.GetAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx, params.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, toResponse(job))
+	}
+	return responses, total, nil
+}
+
+func (s *service) GetByID(ctx context.Context, id string) (*Entity, error) {
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(job), nil
+}
+
+// Create queues a job, the runner applies it in the background.
+func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	executor, ok := s.executors[req.Target]
+	if !ok {
+		return nil, ErrUnsupportedTarget
+	}
+
+	action := Action(req.Action)
+	if !executor.Supports(action) {
+		return nil, ErrUnsupportedAction
+	}
+
+	job := &Job{
+		ProjectID: req.Filter.Get("projectId"),
+		CreatedBy: &userID,
+		Target:    req.Target,
+		Action:    action,
+		Filter:    req.Filter.Encode(),
+	}
+
+	switch action {
+	case ActionStatus:
+		job.NewStatus = req.Status
+	case ActionAssign:
+		job.AssigneeID = req.AssigneeID
+	case ActionDelete:
+	}
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return toResponse(job), nil
+}
+
+func (s *service) Cancel(ctx context.Context, id string) (*Entity, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Cancel(ctx, id); err != nil {
+		if errors.Is(err, ErrNotRunning) {
+			return nil, ErrNotCancelable
+		}
+		return nil, err
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+func toResponse(j *Job) *Entity {
+	entity := &Entity{
+		ID:         j.ID,
+		ProjectID:  j.ProjectID,
+		CreatedBy:  j.CreatedBy,
+		Target:     string(j.Target),
+		Action:     string(j.Action),
+		Filter:     j.Filter,
+		NewStatus:  j.NewStatus,
+		AssigneeID: j.AssigneeID,
+		Status:     string(j.Status),
+		Total:      j.Total,
+		Processed:  j.Processed,
+		Affected:   j.Affected,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+
+	switch {
+	case j.Status == StatusSucceeded:
+		entity.Progress = 100
+	case j.Total > 0:
+		entity.Progress = math.Round(float64(j.Processed)/float64(j.Total)*10000) / 100
+	}
+
+	return entity
+}
@@ -0,0 +1,38 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// sqliteRepository stores the bulk jobs in SQLite. Its transactions take the write lock from
+// their start, so it claims the jobs without skipping locked rows.
+type sqliteRepository struct {
+	*repository
+}
+
+func (r *sqliteRepository) Claim(ctx context.Context, now, leaseUntil int64) (*Job, error) {
+	const query = `
+		UPDATE bulk_jobs
+		SET status = 'running', updated_at = $1, lease_until = $2
+		WHERE id = (
+			SELECT id
+			FROM bulk_jobs
+			WHERE status IN ('pending', 'running') AND lease_until <= $1
+			ORDER BY created_at
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	var job Job
+	err := r.db.GetContext(ctx, &job, query, now, leaseUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim bulk job: %w", err)
+	}
+	return &job, nil
+}
@@ -0,0 +1,22 @@
+package channel
+
+type Type string
+
+const (
+	TypeSlack    Type = "slack"
+	TypeTelegram Type = "telegram"
+)
+
+type Channel struct {
+	ID        string `db:"id"`
+	ProjectID string `db:"project_id"`
+	Type      Type   `db:"type"`
+	Name      string `db:"name"`
+	// Target is the incoming webhook URL for Slack and the chat ID for Telegram
+	Target    string `db:"target"`
+	Token     string `db:"token"`
+	Events    string `db:"events"` // Comma separated event types
+	Enabled   bool   `db:"enabled"`
+	CreatedAt int64  `db:"created_at"`
+	UpdatedAt int64  `db:"updated_at"`
+}
@@ -0,0 +1,78 @@
+package channel
+
+import "context"
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// Sender formats and posts a message to a channel of its type.
+type Sender interface {
+	Send(ctx context.Context, channel *Channel, message *Message) error
+}
+
+type Config struct {
+	// GroupURL is the link to a group, with {projectId}, {groupKind} and {groupId} placeholders
+	GroupURL string
+}
+
+// Message is the channel agnostic content of a notification.
+type Message struct {
+	Title    string
+	Text     string
+	Fields   []Field
+	GroupURL string
+}
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+type GetAllParams struct {
+	ProjectID string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type Create struct {
+	Type string `json:"type" validate:"required,oneof=slack telegram" example:"slack"`
+	Name string `json:"name" validate:"required" example:"On-call"`
+	// Incoming webhook URL for Slack, chat ID for Telegram
+	Target string `json:"target" validate:"required" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	// Bot token, required for Telegram
+	Token string `json:"token" example:"123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"`
+	// Events the channel is notified of
+	Events    []string `json:"events" validate:"required,min=1,dive,oneof=alert.fired group.created group.unsnoozed" example:"alert.fired,group.created"`
+	Enabled   *bool    `json:"enabled" example:"true"`
+	ProjectID string   `json:"-"`
+}
+
+type Update struct {
+	Name   string `json:"name" validate:"required" example:"On-call"`
+	Target string `json:"target" validate:"required" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	// Bot token, kept when empty
+	Token   string   `json:"token" example:"123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"`
+	Events  []string `json:"events" validate:"required,min=1,dive,oneof=alert.fired group.created group.unsnoozed" example:"alert.fired,group.created"`
+	Enabled *bool    `json:"enabled" example:"true"`
+}
+
+type Entity struct {
+	ID        string   `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Type      string   `json:"type" example:"slack"`
+	Name      string   `json:"name" example:"On-call"`
+	Target    string   `json:"target" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	Events    []string `json:"events" example:"alert.fired,group.created"`
+	Enabled   bool     `json:"enabled" example:"true"`
+	CreatedAt int64    `json:"createdAt" example:"1704067200"`
+	UpdatedAt int64    `json:"updatedAt" example:"1704067200"`
+}
+
+type EntityList struct {
+	Count int      `json:"count"`
+	Items []Entity `json:"items"`
+}
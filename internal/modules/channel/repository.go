@@ -0,0 +1,181 @@
+package channel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+const channelColumns = `id, project_id, type, name, target, token, events, enabled, created_at, updated_at`
+
+type Repository interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Channel, error)
+	Count(ctx context.Context, projectID string) (int, error)
+	GetByID(ctx context.Context, projectID, id string) (*Channel, error)
+	GetSubscribed(ctx context.Context, projectID, eventType string) ([]*Channel, error)
+	Create(ctx context.Context, channel *Channel) error
+	Update(ctx context.Context, projectID, id string, channel *Channel) error
+	Delete(ctx context.Context, projectID, id string) error
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Channel, error) {
+	query := `SELECT ` + channelColumns + ` FROM channels WHERE project_id = :projectId`
+
+	args := map[string]interface{}{
+		"projectId": params.ProjectID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query += " ORDER BY created_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var channels []*Channel
+	err = r.db.SelectContext(ctx, &channels, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (r *repository) Count(ctx context.Context, projectID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM channels WHERE project_id = $1`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, projectID, id string) (*Channel, error) {
+	const query = `SELECT ` + channelColumns + ` FROM channels WHERE id = $1 AND project_id = $2`
+
+	var channel Channel
+	err := r.db.GetContext(ctx, &channel, query, id, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get channel by id: %w", err)
+	}
+	return &channel, nil
+}
+
+func (r *repository) GetSubscribed(ctx context.Context, projectID, eventType string) ([]*Channel, error) {
+	const query = `
+		SELECT ` + channelColumns + `
+		FROM channels
+		WHERE project_id = $1 AND enabled AND ',' || events || ',' LIKE '%,' || $2 || ',%'
+	`
+
+	var channels []*Channel
+	err := r.db.SelectContext(ctx, &channels, query, projectID, eventType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribed channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (r *repository) Create(ctx context.Context, channel *Channel) error {
+	const query = `
+		INSERT INTO channels (` + channelColumns + `)
+		VALUES (:id, :project_id, :type, :name, :target, :token, :events, :enabled, :created_at, :updated_at)
+	`
+
+	if channel.ID == "" {
+		channel.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, channel)
+	if err != nil {
+		return fmt.Errorf("failed to create channel: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, projectID, id string, updated *Channel) error {
+	const query = `
+		UPDATE
+			channels
+		SET
+			name = :name,
+			target = :target,
+			token = :token,
+			events = :events,
+			enabled = :enabled,
+			updated_at = :updated_at
+		WHERE
+			id = :id AND project_id = :project_id
+	`
+
+	updated.ID = id
+	updated.ProjectID = projectID
+	updated.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.NamedExecContext(ctx, query, updated)
+	if err != nil {
+		return fmt.Errorf("failed to update channel: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, projectID, id string) error {
+	const query = `DELETE FROM channels WHERE id = $1 AND project_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
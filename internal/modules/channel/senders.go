@@ -0,0 +1,142 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org"
+
+	errorExcerptLength = 512
+)
+
+type slackSender struct {
+	client *http.Client
+}
+
+// NewSlackSender posts messages to Slack compatible incoming webhooks.
+func NewSlackSender(client *http.Client) Sender {
+	return &slackSender{client: client}
+}
+
+func (s *slackSender) Send(ctx context.Context, channel *Channel, message *Message) error {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": message.Title},
+		},
+		{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": "```" + escapeSlack(message.Text) + "```"},
+		},
+	}
+
+	if len(message.Fields) > 0 {
+		fields := make([]map[string]interface{}, 0, len(message.Fields))
+		for _, field := range message.Fields {
+			fields = append(fields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": "*" + escapeSlack(field.Name) + "*\n" + escapeSlack(field.Value),
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+
+	if message.GroupURL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []map[string]interface{}{
+				{
+					"type": "button",
+					"text": map[string]interface{}{"type": "plain_text", "text": "Open group"},
+					"url":  message.GroupURL,
+				},
+			},
+		})
+	}
+
+	payload := map[string]interface{}{
+		"text":   message.Title + ": " + message.Text,
+		"blocks": blocks,
+	}
+
+	return post(ctx, s.client, channel.Target, payload)
+}
+
+type telegramSender struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewTelegramSender sends messages through the Telegram Bot API served at baseURL.
+func NewTelegramSender(client *http.Client, baseURL string) Sender {
+	if baseURL == "" {
+		baseURL = defaultTelegramAPIURL
+	}
+	return &telegramSender{client: client, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *telegramSender) Send(ctx context.Context, channel *Channel, message *Message) error {
+	var text strings.Builder
+	text.WriteString("<b>" + html.EscapeString(message.Title) + "</b>\n")
+	text.WriteString("<pre>" + html.EscapeString(message.Text) + "</pre>\n")
+	for _, field := range message.Fields {
+		text.WriteString("<b>" + html.EscapeString(field.Name) + ":</b> " + html.EscapeString(field.Value) + "\n")
+	}
+	if message.GroupURL != "" {
+		text.WriteString(`<a href="` + html.EscapeString(message.GroupURL) + `">Open group</a>`)
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":                  channel.Target,
+		"text":                     text.String(),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+
+	return post(ctx, s.client, s.baseURL+"/bot"+channel.Token+"/sendMessage", payload)
+}
+
+func post(ctx context.Context, client *http.Client, target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may embed a bot token, keep it out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, errorExcerptLength))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(excerpt)))
+	}
+	return nil
+}
+
+// escapeSlack escapes the characters Slack reserves for control sequences.
+func escapeSlack(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
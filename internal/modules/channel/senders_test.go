@@ -0,0 +1,77 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramSender(t *testing.T) {
+	var (
+		path    string
+		payload map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	sender := NewTelegramSender(server.Client(), server.URL+"/")
+	err := sender.Send(context.Background(), &Channel{Target: "-100123", Token: "42:secret"}, &Message{
+		Title:    "New error group",
+		Text:     "a < b",
+		Fields:   []Field{{Name: "Group", Value: "abc"}},
+		GroupURL: "https://fuckbug.io/groups/abc",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/bot42:secret/sendMessage", path)
+	assert.Equal(t, "-100123", payload["chat_id"])
+	assert.Equal(t, "HTML", payload["parse_mode"])
+	assert.Contains(t, payload["text"], "<pre>a &lt; b</pre>")
+	assert.Contains(t, payload["text"], `<a href="https://fuckbug.io/groups/abc">Open group</a>`)
+}
+
+func TestSlackSender(t *testing.T) {
+	var payload struct {
+		Text   string                   `json:"text"`
+		Blocks []map[string]interface{} `json:"blocks"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	sender := NewSlackSender(server.Client())
+	err := sender.Send(context.Background(), &Channel{Target: server.URL}, &Message{
+		Title:    "New error group",
+		Text:     "boom",
+		GroupURL: "https://fuckbug.io/groups/abc",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "New error group: boom", payload.Text)
+	require.Len(t, payload.Blocks, 3)
+	assert.Equal(t, "actions", payload.Blocks[2]["type"])
+}
+
+func TestSenderReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	sender := NewTelegramSender(server.Client(), server.URL)
+	err := sender.Send(context.Background(), &Channel{Target: "1", Token: "t"}, &Message{Title: "t"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chat not found")
+}
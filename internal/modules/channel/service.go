@@ -0,0 +1,273 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/modules/alert"
+)
+
+var (
+	ErrTokenRequired     = errors.New("token is required for telegram channels")
+	ErrUnsupportedType   = errors.New("unsupported channel type")
+	ErrInvalidWebhookURL = errors.New("target must be an http(s) URL for slack channels")
+)
+
+const (
+	sendTimeout      = 30 * time.Second
+	maxTextLength    = 2000
+	maxTitleLength   = 150
+	timeLayout       = "2006-01-02 15:04:05 MST"
+	testMessageTitle = "FuckBug test message"
+)
+
+// notifiedEvents are the event types channels can be notified of.
+var notifiedEvents = map[events.Type]bool{
+	events.TypeAlertFired:     true,
+	events.TypeGroupCreated:   true,
+	events.TypeGroupUnsnoozed: true,
+}
+
+type Service interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetByID(ctx context.Context, projectID, id string) (*Entity, error)
+	Create(ctx context.Context, req *Create) (*Entity, error)
+	Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error)
+	Delete(ctx context.Context, projectID, id string) error
+	SendTest(ctx context.Context, projectID, id string) error
+	HandleEvent(ctx context.Context, event events.Event)
+}
+
+type service struct {
+	repo     Repository
+	senders  map[Type]Sender
+	logger   Logger
+	groupURL string
+}
+
+func NewService(repo Repository, senders map[Type]Sender, logger Logger, config Config) Service {
+	return &service{
+		repo:     repo,
+		senders:  senders,
+		logger:   logger,
+		groupURL: config.GroupURL,
+	}
+}
+
+func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+	channels, err := s.repo.GetAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx, params.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(channels))
+	for _, channel := range channels {
+		responses = append(responses, toResponse(channel))
+	}
+	return responses, total, nil
+}
+
+func (s *service) GetByID(ctx context.Context, projectID, id string) (*Entity, error) {
+	channel, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(channel), nil
+}
+
+func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
+	channel := &Channel{
+		ProjectID: req.ProjectID,
+		Type:      Type(req.Type),
+		Name:      req.Name,
+		Target:    req.Target,
+		Token:     req.Token,
+		Events:    strings.Join(req.Events, ","),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	}
+
+	if err := validate(channel); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	return toResponse(channel), nil
+}
+
+func (s *service) Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error) {
+	channel, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.Name = req.Name
+	channel.Target = req.Target
+	channel.Events = strings.Join(req.Events, ",")
+	if req.Token != "" {
+		channel.Token = req.Token
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+
+	if err := validate(channel); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, projectID, id, channel); err != nil {
+		return nil, err
+	}
+
+	return toResponse(channel), nil
+}
+
+func (s *service) Delete(ctx context.Context, projectID, id string) error {
+	return s.repo.Delete(ctx, projectID, id)
+}
+
+// SendTest sends a sample message, so the channel configuration can be checked.
+func (s *service) SendTest(ctx context.Context, projectID, id string) error {
+	channel, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, channel, &Message{
+		Title: testMessageTitle,
+		Text:  fmt.Sprintf("The %q channel is set up and will receive the project notifications.", channel.Name),
+		Fields: []Field{
+			{Name: "Events", Value: strings.ReplaceAll(channel.Events, ",", ", ")},
+		},
+	})
+}
+
+// HandleEvent notifies the channels of the project subscribed to the event.
+// It is meant to be subscribed to the event bus.
+func (s *service) HandleEvent(ctx context.Context, event events.Event) {
+	if !notifiedEvents[event.Type] || event.ProjectID == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+		defer cancel()
+
+		channels, err := s.repo.GetSubscribed(ctx, event.ProjectID, string(event.Type))
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		if len(channels) == 0 {
+			return
+		}
+
+		message := s.buildMessage(event)
+		for _, channel := range channels {
+			if err := s.send(ctx, channel, message); err != nil {
+				s.logger.Warn(fmt.Sprintf("failed to notify %s channel %s: %v", channel.Type, channel.ID, err))
+			}
+		}
+	}()
+}
+
+func (s *service) send(ctx context.Context, channel *Channel, message *Message) error {
+	sender, ok := s.senders[channel.Type]
+	if !ok {
+		return ErrUnsupportedType
+	}
+	return sender.Send(ctx, channel, message)
+}
+
+func (s *service) buildMessage(event events.Event) *Message {
+	message := &Message{
+		Text:     truncate(event.Message, maxTextLength),
+		GroupURL: s.buildGroupURL(event),
+	}
+
+	if firing, ok := event.Payload.(*alert.FiringEntity); ok && event.Type == events.TypeAlertFired {
+		message.Title = truncate(fmt.Sprintf("Alert %q fired", firing.RuleName), maxTitleLength)
+		message.Fields = []Field{
+			{Name: "Trigger", Value: firing.EventType},
+			{Name: "Group", Value: event.GroupKind + " " + event.GroupID},
+			{Name: "Time", Value: time.Unix(firing.FiredAt, 0).UTC().Format(timeLayout)},
+		}
+		return message
+	}
+
+	message.Title = fmt.Sprintf("New %s group", event.GroupKind)
+	if event.Type == events.TypeGroupUnsnoozed {
+		message.Title = fmt.Sprintf("Snooze of %s group ended", event.GroupKind)
+	}
+	message.Fields = []Field{
+		{Name: "Group", Value: event.GroupID},
+		{Name: "Time", Value: time.UnixMilli(event.Time).UTC().Format(timeLayout)},
+	}
+	return message
+}
+
+func (s *service) buildGroupURL(event events.Event) string {
+	if s.groupURL == "" || event.GroupID == "" {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{projectId}", event.ProjectID,
+		"{groupKind}", event.GroupKind,
+		"{groupId}", event.GroupID,
+	).Replace(s.groupURL)
+}
+
+func validate(c *Channel) error {
+	switch c.Type {
+	case TypeSlack:
+		if !strings.HasPrefix(c.Target, "https://") && !strings.HasPrefix(c.Target, "http://") {
+			return ErrInvalidWebhookURL
+		}
+	case TypeTelegram:
+		if c.Token == "" {
+			return ErrTokenRequired
+		}
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+func truncate(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
+
+// toResponse leaves the bot token out, it is write only.
+func toResponse(c *Channel) *Entity {
+	eventTypes := []string{}
+	if c.Events != "" {
+		eventTypes = strings.Split(c.Events, ",")
+	}
+
+	return &Entity{
+		ID:        c.ID,
+		Type:      string(c.Type),
+		Name:      c.Name,
+		Target:    c.Target,
+		Events:    eventTypes,
+		Enabled:   c.Enabled,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
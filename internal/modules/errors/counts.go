@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// The errors are counted by group in the buckets of a minute and of an hour as they are stored.
+// The stats, the histograms and the group trends add the counts up rather than scanning the errors.
+const (
+	countsByMinute = "error_counts_minute"
+	countsByHour   = "error_counts_hour"
+)
+
+var countResolutions = []time.Duration{time.Minute, time.Hour}
+
+// countedError is the part of an error its counts depend on.
+type countedError struct {
+	ProjectID   string `db:"project_id"`
+	Fingerprint string `db:"fingerprint"`
+	Time        int64  `db:"time"`
+}
+
+type countKey struct {
+	projectID   string
+	fingerprint string
+	bucket      int64
+}
+
+func countsTable(resolution time.Duration) string {
+	if resolution == time.Hour {
+		return countsByHour
+	}
+	return countsByMinute
+}
+
+// countErrors adds delta to the counts of the buckets of the errors, and deletes the buckets left
+// empty. The counts are updated in the same order by every transaction, which cannot deadlock on them.
+func countErrors(ctx context.Context, tx *sqlx.Tx, errs []countedError, delta int) error {
+	for _, resolution := range countResolutions {
+		counts := make(map[countKey]int)
+		for _, e := range errs {
+			counts[countKey{e.ProjectID, e.Fingerprint, storage.Bucket(e.Time, resolution)}] += delta
+		}
+
+		table := countsTable(resolution)
+		query := `
+            INSERT INTO ` + table + ` (count, project_id, fingerprint, bucket)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (project_id, fingerprint, bucket) DO UPDATE SET count = ` + table + `.count + EXCLUDED.count
+        `
+		if delta < 0 {
+			// The errors taken off were counted, their buckets exist
+			query = `UPDATE ` + table + ` SET count = count + $1 WHERE project_id = $2 AND fingerprint = $3 AND bucket = $4`
+		}
+
+		for _, key := range slices.SortedFunc(maps.Keys(counts), compareCountKeys) {
+			if _, err := tx.ExecContext(ctx, query, counts[key], key.projectID, key.fingerprint, key.bucket); err != nil {
+				return fmt.Errorf("failed to count errors: %w", err)
+			}
+			if delta >= 0 {
+				continue
+			}
+
+			const emptyQuery = `DELETE FROM %s WHERE project_id = $1 AND fingerprint = $2 AND bucket = $3 AND count <= 0`
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(emptyQuery, table), key.projectID, key.fingerprint, key.bucket)
+			if err != nil {
+				return fmt.Errorf("failed to delete empty error counts: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func compareCountKeys(a, b countKey) int {
+	return cmp.Or(
+		strings.Compare(a.projectID, b.projectID),
+		strings.Compare(a.fingerprint, b.fingerprint),
+		cmp.Compare(a.bucket, b.bucket),
+	)
+}
@@ -22,4 +22,26 @@ type Error struct {
 	Time        int64   `db:"time"`
 	CreatedAt   int64   `db:"created_at"`
 	UpdatedAt   int64   `db:"updated_at"`
+	// PayloadID is the payload shared by an error stored beyond the samples of its group
+	PayloadID *string `db:"payload_id"`
+	// Relevance and highlighted message of a full-text search
+	Rank      *float64 `db:"rank"`
+	Highlight *string  `db:"highlight"`
+}
+
+// Payload is the stacktrace and the request of the errors stored beyond the samples of their group,
+// shared by the errors of the same payload.
+type Payload struct {
+	ID          string  `db:"id"`
+	ProjectID   string  `db:"project_id"`
+	Stacktrace  string  `db:"stacktrace"`
+	Headers     *string `db:"headers"`
+	QueryParams *string `db:"query_params"`
+	BodyParams  *string `db:"body_params"`
+	Cookies     *string `db:"cookies"`
+	Session     *string `db:"session"`
+	Files       *string `db:"files"`
+	Env         *string `db:"env"`
+	LatestTime  int64   `db:"latest_time"`
+	CreatedAt   int64   `db:"created_at"`
 }
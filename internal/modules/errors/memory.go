@@ -0,0 +1,416 @@
+package errors
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps the errors in memory, for the tests and the instances without database.
+// The errors are counted in the groups of an errorsGroup.MemoryRepository, as they would be in the
+// database.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	errors map[string]Error
+	groups *errorsGroup.MemoryRepository
+}
+
+func NewMemoryRepository(groups *errorsGroup.MemoryRepository) *MemoryRepository {
+	r := &MemoryRepository{
+		errors: make(map[string]Error),
+		groups: groups,
+	}
+	groups.SetEvents(r)
+	return r
+}
+
+func (r *MemoryRepository) GetAll(_ context.Context, params GetAllParams) ([]*Error, error) {
+	// No rank without full-text index, the latest come first
+	desc := params.SortOrder == "desc" || params.SortOrder == SortRelevance
+
+	var entities []*Error
+	for _, e := range r.filter(params.FilterParams) {
+		if params.Cursor == nil || afterCursor(e, params.Cursor, params.SortOrder != "asc") {
+			entities = append(entities, e)
+		}
+	}
+
+	sortByTime(entities, desc)
+	return utils.Page(entities, params.Offset, params.Limit), nil
+}
+
+func (r *MemoryRepository) Export(_ context.Context, params ExportParams, handle func(*Error) error) error {
+	entities := r.filter(params.FilterParams)
+	sortByTime(entities, params.SortOrder == "desc")
+
+	for _, e := range entities {
+		if err := handle(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) Count(_ context.Context, params FilterParams) (int, error) {
+	return len(r.filter(params)), nil
+}
+
+func (r *MemoryRepository) CountUpTo(_ context.Context, params FilterParams, limit int) (int, error) {
+	return min(len(r.filter(params)), limit), nil
+}
+
+// EstimateCount counts exactly, there is no planner to estimate from.
+func (r *MemoryRepository) EstimateCount(ctx context.Context, params FilterParams) (int, error) {
+	return r.Count(ctx, params)
+}
+
+func (r *MemoryRepository) GetStats(_ context.Context, projectID string, fingerprint string) (*Stats, error) {
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour).UnixMilli()
+	weekAgo := now.AddDate(0, 0, -7).UnixMilli()
+	monthAgo := now.AddDate(0, 0, -30).UnixMilli()
+
+	var stats Stats
+	for _, e := range r.filter(FilterParams{ProjectID: projectID, Fingerprint: fingerprint, TimeFrom: monthAgo}) {
+		stats.Last30d++
+		if e.Time >= weekAgo {
+			stats.Last7d++
+		}
+		if e.Time >= dayAgo {
+			stats.Last24h++
+		}
+	}
+	return &stats, nil
+}
+
+func (r *MemoryRepository) GetHistogram(_ context.Context, params HistogramParams) ([]*HistogramPoint, error) {
+	location, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	filter := FilterParams{
+		ProjectID:   params.ProjectID,
+		Fingerprint: params.Fingerprint,
+		TimeFrom:    params.TimeFrom,
+		TimeTo:      params.TimeTo,
+	}
+
+	counts := make(map[int64]int)
+	for _, e := range r.filter(filter) {
+		counts[e.Time/time.Minute.Milliseconds()*time.Minute.Milliseconds()]++
+	}
+
+	starts, totals := storage.Buckets(params.TimeFrom, params.TimeTo, params.Interval, location, counts)
+
+	points := make([]*HistogramPoint, 0, len(starts))
+	for i, start := range starts {
+		points = append(points, &HistogramPoint{Time: start, Count: totals[i]})
+	}
+	return points, nil
+}
+
+func (r *MemoryRepository) GetByID(_ context.Context, id string) (*Error, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.errors[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &e, nil
+}
+
+func (r *MemoryRepository) Create(_ context.Context, e *Error) (*errorsGroup.Occurrence, error) {
+	e.Fingerprint = r.groups.MergedInto(e.Fingerprint)
+
+	now := time.Now().Unix()
+	occurrence := r.groups.Upsert(&errorsGroup.Group{
+		ID:          e.Fingerprint,
+		ProjectID:   e.ProjectID,
+		File:        e.File,
+		Line:        e.Line,
+		Message:     e.Message,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	})
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	r.mu.Lock()
+	r.errors[e.ID] = *e
+	r.mu.Unlock()
+
+	if occurrence.Status == errorsGroup.StatusSnoozed {
+		if err := r.wakeSnoozed(e.Fingerprint, occurrence, now); err != nil {
+			return nil, err
+		}
+	}
+	return occurrence, nil
+}
+
+// Import inserts an error unless it was imported before, and counts it in its group.
+func (r *MemoryRepository) Import(_ context.Context, e *Error) (bool, error) {
+	e.Fingerprint = r.groups.MergedInto(e.Fingerprint)
+
+	now := time.Now().Unix()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	r.mu.Lock()
+	if _, ok := r.errors[e.ID]; ok {
+		r.mu.Unlock()
+		return false, nil
+	}
+	r.errors[e.ID] = *e
+	r.mu.Unlock()
+
+	seenAt := time.UnixMilli(e.Time).Unix()
+	r.groups.Merge(&errorsGroup.Group{
+		ID:          e.Fingerprint,
+		ProjectID:   e.ProjectID,
+		File:        e.File,
+		Line:        e.Line,
+		Message:     e.Message,
+		FirstSeenAt: seenAt,
+		LastSeenAt:  seenAt,
+	})
+	return true, nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, id string, updated *Error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.errors[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated.ID = id
+	updated.UpdatedAt = time.Now().Unix()
+
+	e.Fingerprint = updated.Fingerprint
+	e.Message = updated.Message
+	e.Stacktrace = updated.Stacktrace
+	e.File = updated.File
+	e.Line = updated.Line
+	e.Context = updated.Context
+	e.Time = updated.Time
+	e.UpdatedAt = updated.UpdatedAt
+	r.errors[id] = e
+	return nil
+}
+
+func (r *MemoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.errors[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.errors, id)
+	return nil
+}
+
+func (r *MemoryRepository) GetIDs(_ context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	var ids []string
+	for _, e := range r.filter(params) {
+		if afterID == "" || e.ID > afterID {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	slices.Sort(ids)
+	return utils.Page(ids, 0, limit), nil
+}
+
+func (r *MemoryRepository) DeleteByIDs(_ context.Context, ids []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := r.errors[id]; ok {
+			delete(r.errors, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// EventTimes returns the times of the errors of the group, which the group counts.
+func (r *MemoryRepository) EventTimes(groupID string) []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var times []int64
+	for _, e := range r.errors {
+		if e.Fingerprint == groupID {
+			times = append(times, e.Time)
+		}
+	}
+	return times
+}
+
+// DeleteGroupEvents deletes the errors of the groups, which are deleted along with them.
+func (r *MemoryRepository) DeleteGroupEvents(groupIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, e := range r.errors {
+		if slices.Contains(groupIDs, e.Fingerprint) {
+			delete(r.errors, id)
+		}
+	}
+}
+
+// MoveGroupEvents moves the errors of the groups to the group they are merged into.
+func (r *MemoryRepository) MoveGroupEvents(groupIDs []string, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for errorID, e := range r.errors {
+		if slices.Contains(groupIDs, e.Fingerprint) {
+			e.Fingerprint = id
+			r.errors[errorID] = e
+		}
+	}
+}
+
+// wakeSnoozed returns the snoozed group of a new error to unresolved once a condition of its snooze is met.
+func (r *MemoryRepository) wakeSnoozed(groupID string, occurrence *errorsGroup.Occurrence, now int64) error {
+	users := 0
+	if occurrence.SnoozeConditions.Users != nil && occurrence.SnoozedAt != nil {
+		// Users are told apart by the IP address of the requests that raised the errors.
+		snoozedAt := time.Unix(*occurrence.SnoozedAt, 0).UnixMilli()
+		ips := make(map[string]bool)
+		for _, e := range r.filter(FilterParams{Fingerprint: groupID, TimeFrom: snoozedAt}) {
+			if e.IP != nil {
+				ips[*e.IP] = true
+			}
+		}
+		users = len(ips)
+	}
+
+	if !occurrence.SnoozeConditions.Expired(now, occurrence.Counter, users) {
+		return nil
+	}
+
+	if err := r.groups.UpdateStatus(context.Background(), groupID, errorsGroup.StatusUnresolved); err != nil {
+		return fmt.Errorf("failed to wake snoozed error group: %w", err)
+	}
+
+	occurrence.Status = errorsGroup.StatusUnresolved
+	occurrence.SnoozeConditions = errorsGroup.SnoozeConditions{}
+	occurrence.Unsnoozed = true
+	return nil
+}
+
+func (r *MemoryRepository) filter(params FilterParams) []*Error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entities []*Error
+	for _, e := range r.errors {
+		if matchFilters(&e, params) {
+			entities = append(entities, &e)
+		}
+	}
+	return entities
+}
+
+func matchFilters(e *Error, params FilterParams) bool {
+	switch {
+	case params.ProjectID != "" && e.ProjectID != params.ProjectID:
+		return false
+	case params.Fingerprint != "" && e.Fingerprint != params.Fingerprint:
+		return false
+	case params.TimeFrom != 0 && e.Time < params.TimeFrom:
+		return false
+	case params.TimeTo != 0 && e.Time > params.TimeTo:
+		return false
+	case params.Search != "" && !query.MatchSearch(params.Search, params.SearchMode, e.Message):
+		return false
+	case params.Query != nil && !params.Query.Match(e.column):
+		return false
+	default:
+		return true
+	}
+}
+
+// column returns the values of the columns of QueryFields.
+func (e *Error) column(name string) interface{} {
+	switch name {
+	case "message":
+		return e.Message
+	case "file":
+		return e.File
+	case "line":
+		return e.Line
+	case "url":
+		return e.URL
+	case "method":
+		return e.Method
+	case "ip":
+		return e.IP
+	case "fingerprint":
+		return e.Fingerprint
+	case "time":
+		return e.Time
+	case "context":
+		return e.Context
+	case "headers":
+		return e.Headers
+	case "query_params":
+		return e.QueryParams
+	case "body_params":
+		return e.BodyParams
+	case "cookies":
+		return e.Cookies
+	case "session":
+		return e.Session
+	case "env":
+		return e.Env
+	default:
+		return nil
+	}
+}
+
+// afterCursor reports whether the error comes after the cursor in (time, id) order.
+func afterCursor(e *Error, cursor *utils.Cursor, desc bool) bool {
+	if e.Time == cursor.Time {
+		if desc {
+			return e.ID < cursor.ID
+		}
+		return e.ID > cursor.ID
+	}
+	if desc {
+		return e.Time < cursor.Time
+	}
+	return e.Time > cursor.Time
+}
+
+func sortByTime(entities []*Error, desc bool) {
+	slices.SortFunc(entities, func(a, b *Error) int {
+		if desc {
+			a, b = b, a
+		}
+		return cmp.Or(cmp.Compare(a.Time, b.Time), strings.Compare(a.ID, b.ID))
+	})
+}
@@ -1,5 +1,16 @@
 package errors
 
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
+)
+
 type Logger interface {
 	Debug(msg string)
 	Info(msg string)
@@ -7,21 +18,104 @@ type Logger interface {
 	Error(msg string)
 }
 
+// Config stores the errors of a group beyond the samples of a window with a payload shared by the
+// errors of the same stacktrace and request, which the searches on the payload do not match.
+type Config struct {
+	// Samples is the number of errors of a group stored whole per window, all of them when zero
+	Samples int
+	// SampleWindow is the duration of the windows, aligned on the epoch
+	SampleWindow time.Duration
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event)
+}
+
 type FilterParams struct {
 	ProjectID   string
 	Fingerprint string
 	TimeFrom    int64
 	TimeTo      int64
 	Search      string
+	// SearchMode is query.SearchContains or query.SearchFullText, contains by default
+	SearchMode string
+	Query      *query.Query
+}
+
+// ParseFilter reads the filters of the error list from its query parameters. Times are in seconds
+// and the group fingerprint is the groupId parameter.
+func ParseFilter(values url.Values) (FilterParams, error) {
+	searchMode, err := query.ParseSearchMode(values.Get("searchMode"))
+	if err != nil {
+		return FilterParams{}, err
+	}
+
+	parsed, err := query.ParseValues(values, QueryFields)
+	if err != nil {
+		return FilterParams{}, err
+	}
+
+	timeFrom, _ := utils.ParseTimeParam(values.Get("timeFrom"))
+	timeTo, _ := utils.ParseTimeParam(values.Get("timeTo"))
+
+	return FilterParams{
+		ProjectID:   values.Get("projectId"),
+		Fingerprint: values.Get("groupId"),
+		TimeFrom:    utils.SecondsToMilliseconds(timeFrom),
+		TimeTo:      utils.SecondsToMilliseconds(timeTo),
+		Search:      values.Get("search"),
+		SearchMode:  searchMode,
+		Query:       parsed,
+	}, nil
+}
+
+// QueryFields are the fields of the query language searching errors.
+var QueryFields = query.Fields{
+	"message":     {Column: "message", Type: query.TypeText},
+	"file":        {Column: "file", Type: query.TypeText},
+	"line":        {Column: "line", Type: query.TypeNumber},
+	"url":         {Column: "url", Type: query.TypeText},
+	"method":      {Column: "method", Type: query.TypeKeyword, Uppercase: true},
+	"ip":          {Column: "ip", Type: query.TypeKeyword},
+	"fingerprint": {Column: "fingerprint", Type: query.TypeKeyword},
+	"time":        {Column: "time", Type: query.TypeTime},
+	"context":     {Column: "context", Type: query.TypeJSON},
+	"headers":     {Column: "headers", Type: query.TypeJSON},
+	"query":       {Column: "query_params", Type: query.TypeJSON},
+	"body":        {Column: "body_params", Type: query.TypeJSON},
+	"cookies":     {Column: "cookies", Type: query.TypeJSON},
+	"session":     {Column: "session", Type: query.TypeJSON},
+	"env":         {Column: "env", Type: query.TypeJSON},
 }
 
 type GetAllParams struct {
 	FilterParams
-	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	// SortOrder is asc, desc or relevance, which requires a full-text search
+	SortOrder string `validate:"omitempty,oneof=asc desc relevance"`
 	Limit     int
 	Offset    int
+	// Cursor continues the list after the last item of a previous page, Offset is then ignored
+	Cursor    *utils.Cursor
+	CountMode string `validate:"omitempty,oneof=exact estimate none"`
 }
 
+const SortRelevance = "relevance"
+
+// ExportParams selects every matching event, without paging.
+type ExportParams struct {
+	FilterParams
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+}
+
+const (
+	// CountExact counts every matching row
+	CountExact = "exact"
+	// CountEstimate counts up to a cap and falls back to the planner estimate above it
+	CountEstimate = "estimate"
+	// CountNone skips counting
+	CountNone = "none"
+)
+
 type Create struct {
 	Time       int64        `json:"time" validate:"required" example:"1704067200000" format:"int64"`
 	Message    string       `json:"message" validate:"required" example:"Division by zero in calculate()"`
@@ -82,6 +176,13 @@ type Create struct {
 	ProjectID string                  `json:"-"`
 }
 
+// Import is an error coming from another system, kept with its original ID and time.
+type Import struct {
+	Create
+	// ID makes the import idempotent, an error already imported being skipped
+	ID string
+}
+
 type Update struct {
 	Message    string       `json:"message" validate:"required" example:"Error message"`
 	Stacktrace *interface{} `json:"stacktrace" validate:"required"`
@@ -120,23 +221,58 @@ type Entity struct {
 	//   },
 	//   example={"key":"value"}
 	// )
-	Context     *interface{}            `json:"context"`
-	IP          *string                 `json:"ip" example:"192.168.1.1"`
-	URL         *string                 `json:"url" example:"https://example.com/api/v1/calculate"`
-	Method      *string                 `json:"method" example:"POST"`
-	Headers     *map[string]interface{} `json:"headers"`
-	QueryParams *map[string]interface{} `json:"queryParams"`
-	BodyParams  *map[string]interface{} `json:"bodyParams"`
-	Cookies     *map[string]interface{} `json:"cookies"`
-	Session     *map[string]interface{} `json:"session"`
-	Files       *map[string]interface{} `json:"files"`
-	Env         *map[string]interface{} `json:"env"`
-	Time        int64                   `json:"time" example:"1704067200000"` // Unix timestamp in milliseconds
+	Context     *interface{}    `json:"context"`
+	IP          *string         `json:"ip" example:"192.168.1.1"`
+	URL         *string         `json:"url" example:"https://example.com/api/v1/calculate"`
+	Method      *string         `json:"method" example:"POST"`
+	Headers     json.RawMessage `json:"headers" swaggertype:"object"`
+	QueryParams json.RawMessage `json:"queryParams" swaggertype:"object"`
+	BodyParams  json.RawMessage `json:"bodyParams" swaggertype:"object"`
+	Cookies     json.RawMessage `json:"cookies" swaggertype:"object"`
+	Session     json.RawMessage `json:"session" swaggertype:"object"`
+	Files       json.RawMessage `json:"files" swaggertype:"object"`
+	Env         json.RawMessage `json:"env" swaggertype:"object"`
+	Time        int64           `json:"time" example:"1704067200000"` // Unix timestamp in milliseconds
+	// Rank is the relevance of a full-text search
+	Rank float64 `json:"rank,omitempty" example:"0.0607927"`
+	// Highlight is the message with the matched words wrapped in <mark> tags, for full-text searches
+	Highlight string `json:"highlight,omitempty" example:"Division by <mark>zero</mark> in calculate()"`
 }
 
 type EntityList struct {
-	Count int      `json:"count"`
-	Items []Entity `json:"items"`
+	// Count is null when counting was skipped
+	Count *int `json:"count"`
+	// Estimated is set when Count is approximate
+	Estimated bool      `json:"estimated,omitempty"`
+	Items     []*Entity `json:"items"`
+	// NextCursor lists the following page, it is empty on the last one
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+const (
+	IntervalMinute = "minute"
+	IntervalHour   = "hour"
+	IntervalDay    = "day"
+)
+
+type HistogramParams struct {
+	ProjectID   string
+	Fingerprint string
+	TimeFrom    int64
+	TimeTo      int64
+	Interval    string
+	Timezone    string
+}
+
+type HistogramPoint struct {
+	Time  int64 `json:"time" db:"time" example:"1704067200000"` // Unix timestamp in milliseconds
+	Count int   `json:"count" db:"count" example:"12"`
+}
+
+type Histogram struct {
+	Interval string           `json:"interval" example:"hour"`
+	Timezone string           `json:"timezone" example:"UTC"`
+	Items    []HistogramPoint `json:"items"`
 }
 
 type Stats struct {
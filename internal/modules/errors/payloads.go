@@ -0,0 +1,158 @@
+package errors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// sharedColumns are the columns of the payload of an error. The errors stored beyond the samples
+// of their group leave them empty and share them with the errors of the same payload.
+var sharedColumns = []string{"stacktrace", "headers", "query_params", "body_params", "cookies", "session", "files", "env"}
+
+// ownColumns are the columns every error stores itself.
+var ownColumns = []string{
+	"id", "project_id", "fingerprint", "message", "file", "line", "context", "ip", "url", "method",
+	"time", "created_at", "updated_at", "payload_id",
+}
+
+// errorsTable selects the errors with the payload they share merged into their columns. It is
+// named after the table, so the queries reading the errors see the shared columns as their own.
+func errorsTable(dialect storage.Dialect) string {
+	columns := make([]string, 0, len(ownColumns)+len(sharedColumns)+1)
+	for _, name := range ownColumns {
+		columns = append(columns, "errors."+name)
+	}
+	for _, name := range sharedColumns {
+		columns = append(columns, sharedColumn(name))
+	}
+	if !dialect.SQLite() {
+		columns = append(columns, "errors.search_vector", "p.search_vector AS payload_search_vector")
+	}
+
+	return `(
+            SELECT ` + strings.Join(columns, ", ") + `
+            FROM errors LEFT JOIN error_payloads p ON p.id = errors.payload_id
+        ) errors`
+}
+
+// sharedColumn selects a column of the payload of an error from the payload it shares, if any.
+// The payload of a restored error may have been deleted, its stacktrace is left empty.
+func sharedColumn(name string) string {
+	if name == "stacktrace" {
+		return "CASE WHEN errors.payload_id IS NULL THEN errors.stacktrace ELSE COALESCE(p.stacktrace, '') END AS stacktrace"
+	}
+	return "COALESCE(errors." + name + ", p." + name + ") AS " + name
+}
+
+// newPayload returns the payload of the error, identified by its content within the project.
+func newPayload(e *Error, now int64) (*Payload, error) {
+	content, err := json.Marshal([]interface{}{
+		e.ProjectID, e.Stacktrace, e.Headers, e.QueryParams, e.BodyParams, e.Cookies, e.Session, e.Files, e.Env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	hash := sha256.Sum256(content)
+	return &Payload{
+		ID:          hex.EncodeToString(hash[:]),
+		ProjectID:   e.ProjectID,
+		Stacktrace:  e.Stacktrace,
+		Headers:     e.Headers,
+		QueryParams: e.QueryParams,
+		BodyParams:  e.BodyParams,
+		Cookies:     e.Cookies,
+		Session:     e.Session,
+		Files:       e.Files,
+		Env:         e.Env,
+		LatestTime:  e.Time,
+		CreatedAt:   now,
+	}, nil
+}
+
+// stored returns the error to store: the error itself while its group has fewer samples than
+// configured in the window of the error, or else a copy of it sharing its payload.
+func (r *repository) stored(ctx context.Context, tx *sqlx.Tx, e *Error, now int64) (*Error, error) {
+	if r.config.Samples <= 0 {
+		return e, nil
+	}
+
+	from := storage.Bucket(e.Time, r.config.SampleWindow)
+
+	const samplesQuery = `
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM errors
+			WHERE fingerprint = $1 AND time >= $2 AND time < $3 AND payload_id IS NULL
+			LIMIT $4
+		) samples
+	`
+
+	var samples int
+	err := tx.GetContext(
+		ctx, &samples, samplesQuery, e.Fingerprint, from, from+r.config.SampleWindow.Milliseconds(), r.config.Samples,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count error samples: %w", err)
+	}
+	if samples < r.config.Samples {
+		return e, nil
+	}
+
+	payload, err := newPayload(e, now)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadQuery := `
+		INSERT INTO error_payloads (
+			id, project_id, stacktrace, headers, query_params, body_params, cookies, session, files, env,
+			latest_time, created_at
+		) VALUES (
+			:id, :project_id, :stacktrace, :headers, :query_params, :body_params, :cookies, :session, :files, :env,
+			:latest_time, :created_at
+		)
+		ON CONFLICT (id) DO UPDATE
+		SET latest_time = ` + r.dialect.Greatest("error_payloads.latest_time", "EXCLUDED.latest_time")
+
+	if _, err = tx.NamedExecContext(ctx, payloadQuery, payload); err != nil {
+		return nil, fmt.Errorf("failed to store error payload: %w", err)
+	}
+
+	shared := *e
+	shared.Stacktrace = ""
+	shared.Headers = nil
+	shared.QueryParams = nil
+	shared.BodyParams = nil
+	shared.Cookies = nil
+	shared.Session = nil
+	shared.Files = nil
+	shared.Env = nil
+	shared.PayloadID = &payload.ID
+	return &shared, nil
+}
+
+// deleteUnusedPayloads deletes the payloads among ids no error shares anymore, once the errors
+// sharing them have been deleted.
+func deleteUnusedPayloads(ctx context.Context, tx *sqlx.Tx, dialect storage.Dialect, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		DELETE FROM error_payloads
+		WHERE ` + dialect.In("id", "$1") + `
+			AND NOT EXISTS (SELECT 1 FROM errors e WHERE e.payload_id = error_payloads.id)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, dialect.List(ids)); err != nil {
+		return fmt.Errorf("failed to delete unused error payloads: %w", err)
+	}
+	return nil
+}
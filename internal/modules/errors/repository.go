@@ -3,58 +3,126 @@ package errors
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=3"
+
 var ErrNotFound = errors.New("not found")
 
+const defaultSampleWindow = time.Hour
+
+// exportBatchSize is the number of rows fetched at once from the export cursor.
+const exportBatchSize = 1000
+
+// errorColumns select the errors from errorsTable, along with the payload they share.
+const errorColumns = `
+            id, project_id, fingerprint, message, stacktrace, file, line, context,
+            ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+            time, created_at, updated_at, payload_id`
+
+const insertQuery = `
+		INSERT INTO errors (
+			id, project_id, fingerprint, message, stacktrace, file, line, context,
+			ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+			time, created_at, updated_at, payload_id
+		) VALUES (
+			:id, :project_id, :fingerprint, :message, :stacktrace, :file, :line, :context,
+			:ip, :url, :method, :headers, :query_params, :body_params, :cookies, :session, :files, :env,
+			:time, :created_at, :updated_at, :payload_id
+		)
+	`
+
 type Repository interface {
 	GetAll(ctx context.Context, params GetAllParams) ([]*Error, error)
+	Export(ctx context.Context, params ExportParams, handle func(*Error) error) error
 	Count(ctx context.Context, params FilterParams) (int, error)
+	CountUpTo(ctx context.Context, params FilterParams, limit int) (int, error)
+	EstimateCount(ctx context.Context, params FilterParams) (int, error)
 	GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error)
+	GetHistogram(ctx context.Context, params HistogramParams) ([]*HistogramPoint, error)
 	GetByID(ctx context.Context, id string) (*Error, error)
-	Create(ctx context.Context, entity *Error) error
+	Create(ctx context.Context, entity *Error) (*errorsGroup.Occurrence, error)
+	Import(ctx context.Context, entity *Error) (bool, error)
 	Update(ctx context.Context, id string, entity *Error) error
 	Delete(ctx context.Context, id string) error
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
 }
 
 type repository struct {
-	db     *sqlx.DB
-	logger Logger
+	db      *sqlx.DB
+	logger  Logger
+	dialect storage.Dialect
+	config  Config
+	// table reads the errors with their shared payload
+	table string
 }
 
-func NewRepository(db *sqlx.DB, logger Logger) Repository {
-	return &repository{
-		db:     db,
-		logger: logger,
+func NewRepository(db *sqlx.DB, logger Logger, config Config) Repository {
+	if config.SampleWindow <= 0 {
+		config.SampleWindow = defaultSampleWindow
+	}
+
+	r := &repository{
+		db:      db,
+		logger:  logger,
+		dialect: storage.DialectOf(db),
+		config:  config,
 	}
+	r.table = errorsTable(r.dialect)
+
+	if r.dialect.SQLite() {
+		return &sqliteRepository{r}
+	}
+	return r
 }
 
 func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Error, error) {
-	query := `
-        SELECT 
-            id, project_id, fingerprint, message, stacktrace, file, line, context,
-            ip, url, method, headers, query_params, body_params, cookies, session, files, env,
-            time, created_at, updated_at 
-        FROM
-            errors 
-        WHERE 1=1
-    `
-
 	args := map[string]interface{}{
 		"limit":  params.Limit,
 		"offset": params.Offset,
 	}
 
-	query, args = applyFilters(query, params.FilterParams, args)
+	columns := errorColumns
+	order := " ORDER BY time " + params.SortOrder + ", id " + params.SortOrder
 
-	query += " ORDER BY time " + params.SortOrder
+	if tsquery := r.fullTextQuery(params.FilterParams, args); tsquery != "" {
+		columns += fullTextColumns(tsquery)
+		args["headlineOptions"] = headlineOptions
+		if params.SortOrder == SortRelevance {
+			order = " ORDER BY rank DESC, time DESC, id DESC"
+		}
+	} else if params.SortOrder == SortRelevance {
+		// SQLite does not rank the matches, the latest come first
+		order = " ORDER BY time DESC, id DESC"
+	}
+
+	query, args := r.applyFilters("SELECT "+columns+" FROM "+r.table+" WHERE 1=1", params.FilterParams, args)
+
+	if params.Cursor != nil {
+		operator := "<"
+		if params.SortOrder == "asc" {
+			operator = ">"
+		}
+		// The row comparison does not prune partitions, the bound on time alone does
+		query += " AND time " + operator + "= :cursorTime"
+		query += " AND (time, id) " + operator + " (:cursorTime, " + r.dialect.UUID(":cursorId") + ")"
+		args["cursorTime"] = params.Cursor.Time
+		args["cursorId"] = params.Cursor.ID
+	}
+
+	query += order
 	query += " LIMIT :limit OFFSET :offset"
 
 	query, namedArgs, err := sqlx.Named(query, args)
@@ -74,9 +142,67 @@ func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Error,
 	return entities, nil
 }
 
+// Export hands every error matching the filters to handle. The rows are fetched in batches from a
+// server-side cursor, so the export does not hold them all in memory.
+func (r *repository) Export(ctx context.Context, params ExportParams, handle func(*Error) error) (err error) {
+	query := "SELECT " + errorColumns + " FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params.FilterParams, make(map[string]interface{}))
+	query += " ORDER BY time " + params.SortOrder + ", id " + params.SortOrder
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = "DECLARE export_cursor NO SCROLL CURSOR FOR " + r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	// A cursor only lives within its transaction
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, query, namedArgs...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportBatchSize)
+	for {
+		var batch []*Error
+		if err = tx.SelectContext(ctx, &batch, fetch); err != nil {
+			return fmt.Errorf("failed to fetch errors: %w", err)
+		}
+
+		for _, item := range batch {
+			if err = handle(item); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			break
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 func (r *repository) Count(ctx context.Context, params FilterParams) (int, error) {
-	query := "SELECT COUNT(*) FROM errors WHERE 1=1"
-	query, args := applyFilters(query, params, make(map[string]interface{}))
+	query := "SELECT COUNT(*) FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params, make(map[string]interface{}))
 
 	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
@@ -96,20 +222,73 @@ func (r *repository) Count(ctx context.Context, params FilterParams) (int, error
 	return count, nil
 }
 
+// CountUpTo counts the matching errors but stops at limit, so it stays cheap on large tables.
+func (r *repository) CountUpTo(ctx context.Context, params FilterParams, limit int) (int, error) {
+	query := "SELECT id FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params, map[string]interface{}{"limit": limit})
+	query = "SELECT COUNT(*) FROM (" + query + " LIMIT :limit) AS capped"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var count int
+	err = r.db.GetContext(ctx, &count, query, namedArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count errors: %w", err)
+	}
+
+	return count, nil
+}
+
+// EstimateCount returns the number of matching errors estimated by the query planner.
+func (r *repository) EstimateCount(ctx context.Context, params FilterParams) (int, error) {
+	query := "EXPLAIN (FORMAT JSON) SELECT id FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params, make(map[string]interface{}))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var plan []byte
+	err = r.db.GetContext(ctx, &plan, query, namedArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate errors count: %w", err)
+	}
+
+	return planRows(plan)
+}
+
+// GetStats adds up the hourly counts of the errors, the windows starting at the hour.
 func (r *repository) GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error) {
 	query := `
         SELECT
-            COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '24 HOURS') * 1000)) AS last_24h,
-            COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '7 DAYS') * 1000)) AS last_7d,
-			COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '30 DAYS') * 1000)) AS last_30d
+            COALESCE(SUM(count) FILTER (WHERE bucket >= :dayAgo), 0) AS last_24h,
+            COALESCE(SUM(count) FILTER (WHERE bucket >= :weekAgo), 0) AS last_7d,
+            COALESCE(SUM(count), 0) AS last_30d
         FROM
-            errors
+            ` + countsByHour + `
         WHERE
             project_id = :projectId
+            AND bucket >= :monthAgo
     `
 
+	now := time.Now()
 	args := map[string]interface{}{
 		"projectId": projectID,
+		"dayAgo":    storage.Bucket(now.Add(-24*time.Hour).UnixMilli(), time.Hour),
+		"weekAgo":   storage.Bucket(now.AddDate(0, 0, -7).UnixMilli(), time.Hour),
+		"monthAgo":  storage.Bucket(now.AddDate(0, 0, -30).UnixMilli(), time.Hour),
 	}
 
 	if fingerprint != "" {
@@ -144,14 +323,77 @@ func (r *repository) GetStats(ctx context.Context, projectID string, fingerprint
 	}, nil
 }
 
+// GetHistogram adds up the counts of the errors by minute or by hour into the buckets of the
+// interval, which are counted whole.
+func (r *repository) GetHistogram(ctx context.Context, params HistogramParams) ([]*HistogramPoint, error) {
+	location, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	resolution := storage.HistogramResolution(params.TimeFrom, params.TimeTo, params.Interval, location)
+
+	args := map[string]interface{}{
+		"projectId":  params.ProjectID,
+		"timeFrom":   params.TimeFrom,
+		"timeTo":     params.TimeTo,
+		"bucketFrom": storage.Bucket(params.TimeFrom, resolution),
+		"timezone":   params.Timezone,
+	}
+
+	filters := histogramFilters(params, args)
+
+	// Interval is validated by the service, so it is safe to inline it.
+	query := fmt.Sprintf(`
+        WITH buckets AS (
+            SELECT generate_series(
+                date_trunc('%[1]s', to_timestamp(:timeFrom / 1000.0) AT TIME ZONE :timezone),
+                date_trunc('%[1]s', to_timestamp(:timeTo / 1000.0) AT TIME ZONE :timezone),
+                CAST('1 %[1]s' AS INTERVAL)
+            ) AS bucket
+        ),
+        counts AS (
+            SELECT
+                date_trunc('%[1]s', to_timestamp(bucket / 1000.0) AT TIME ZONE :timezone) AS bucket,
+                SUM(count) AS count
+            FROM
+                %[3]s
+            WHERE
+                project_id = :projectId AND bucket >= :bucketFrom AND bucket <= :timeTo %[2]s
+            GROUP BY 1
+        )
+        SELECT
+            CAST(EXTRACT(EPOCH FROM buckets.bucket AT TIME ZONE :timezone) * 1000 AS BIGINT) AS time,
+            COALESCE(counts.count, 0) AS count
+        FROM
+            buckets
+        LEFT JOIN counts ON counts.bucket = buckets.bucket
+        ORDER BY buckets.bucket
+    `, params.Interval, filters, countsTable(resolution))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var points []*HistogramPoint
+	err = r.db.SelectContext(ctx, &points, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get errors histogram: %w", err)
+	}
+	return points, nil
+}
+
 func (r *repository) GetByID(ctx context.Context, id string) (*Error, error) {
-	const query = `
+	query := `
 		SELECT
-			id, project_id, fingerprint, message, stacktrace, file, line, context,
-			ip, url, method, headers, query_params, body_params, cookies, session, files, env,
-			time, created_at, updated_at 
+			` + errorColumns + `
 		FROM
-		    errors
+		    ` + r.table + `
 		WHERE id = $1
 	`
 
@@ -166,10 +408,21 @@ func (r *repository) GetByID(ctx context.Context, id string) (*Error, error) {
 	return &entity, nil
 }
 
-func (r *repository) Create(ctx context.Context, e *Error) error {
+func (r *repository) Create(ctx context.Context, e *Error) (*errorsGroup.Occurrence, error) {
+	return r.create(ctx, e, r.upsertGroup)
+}
+
+// groupUpserter attaches a new event to its group, creating the group on its first event.
+type groupUpserter func(ctx context.Context, tx *sqlx.Tx, group *errorsGroup.Group) (*errorsGroup.Occurrence, error)
+
+func (r *repository) create(
+	ctx context.Context,
+	e *Error,
+	upsertGroup groupUpserter,
+) (occurrence *errorsGroup.Occurrence, err error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	defer func() {
@@ -180,15 +433,12 @@ func (r *repository) Create(ctx context.Context, e *Error) error {
 		}
 	}()
 
-	const errorGroupQuery = `
-        INSERT INTO error_groups (id, project_id, file, line, message, first_seen_at, last_seen_at, counter)
-        VALUES (:id, :project_id, :file, :line, :message, :first_seen_at, :last_seen_at, 1)
-        ON CONFLICT (id) DO UPDATE 
-        SET counter = error_groups.counter + 1, last_seen_at = EXCLUDED.last_seen_at
-    `
+	if err = mergedInto(ctx, tx, e); err != nil {
+		return nil, err
+	}
 
 	now := time.Now().Unix()
-	errorGroup := errorsGroup.Group{
+	occurrence, err = upsertGroup(ctx, tx, &errorsGroup.Group{
 		ID:          e.Fingerprint,
 		ProjectID:   e.ProjectID,
 		File:        e.File,
@@ -198,44 +448,288 @@ func (r *repository) Create(ctx context.Context, e *Error) error {
 		LastSeenAt:  now,
 		Counter:     0,
 		Status:      errorsGroup.StatusUnresolved,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert error group: %w", err)
+	}
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	stored, err := r.stored(ctx, tx, e, now)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = tx.NamedExecContext(ctx, errorGroupQuery, errorGroup)
+	_, err = tx.NamedExecContext(ctx, insertQuery, stored)
 	if err != nil {
-		return fmt.Errorf("failed to upsert error group: %w", err)
+		return nil, fmt.Errorf("failed to create error: %w", err)
+	}
+
+	if err = countErrors(ctx, tx, []countedError{{e.ProjectID, e.Fingerprint, e.Time}}, 1); err != nil {
+		return nil, err
+	}
+
+	if occurrence.Status == errorsGroup.StatusSnoozed {
+		if err = r.wakeSnoozed(ctx, tx, e, occurrence, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return occurrence, nil
+}
+
+// upsertGroup locks the group to tell whether the event is a regression.
+// A resolved group receiving a new event is a regression and goes back to unresolved.
+func (r *repository) upsertGroup(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	group *errorsGroup.Group,
+) (*errorsGroup.Occurrence, error) {
+	// The previous status is read apart, a locking CTE would only run once the upsert changed it
+	var previous errorsGroup.Status
+	err := tx.GetContext(ctx, &previous, `SELECT status FROM error_groups WHERE id = $1 FOR UPDATE`, group.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
 	}
 
 	const query = `
-		INSERT INTO errors (
-			id, project_id, fingerprint, message, stacktrace, file, line, context,
-			ip, url, method, headers, query_params, body_params, cookies, session, files, env,
-			time, created_at, updated_at
-		) VALUES (
-		  	:id, :project_id, :fingerprint, :message, :stacktrace, :file, :line, :context,
-		  	:ip, :url, :method, :headers, :query_params, :body_params, :cookies, :session, :files, :env,
-		  	:time, :created_at, :updated_at
-		)
-	`
+        INSERT INTO error_groups (id, project_id, file, line, message, first_seen_at, last_seen_at, counter)
+        VALUES (:id, :project_id, :file, :line, :message, :first_seen_at, :last_seen_at, 1)
+        ON CONFLICT (id) DO UPDATE
+        SET
+            counter = error_groups.counter + 1,
+            last_seen_at = EXCLUDED.last_seen_at,
+            status = CASE WHEN error_groups.status = 'resolved' THEN 'unresolved' ELSE error_groups.status END
+        RETURNING
+            (xmax = 0) AS created,
+            counter,
+            status,
+            snoozed_at,
+            snooze_until,
+            snooze_counter,
+            snooze_users
+    `
 
-	if e.ID == "" {
-		e.ID = uuid.New().String()
+	groupQuery, groupArgs, err := tx.BindNamed(query, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	var occurrence errorsGroup.Occurrence
+	if err := tx.GetContext(ctx, &occurrence, groupQuery, groupArgs...); err != nil {
+		return nil, err
+	}
+
+	occurrence.Regressed = previous == errorsGroup.StatusResolved
+	return &occurrence, nil
+}
+
+// mergedInto moves a new error to the group its group was merged into, if any.
+func mergedInto(ctx context.Context, tx *sqlx.Tx, e *Error) error {
+	var groupID string
+	err := tx.GetContext(ctx, &groupID, `SELECT group_id FROM error_group_merges WHERE fingerprint = $1`, e.Fingerprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get merged error group: %w", err)
+	}
+
+	e.Fingerprint = groupID
+	return nil
+}
+
+// Import inserts an error unless it was imported before, and counts it in its group. The group
+// is seen first and last at the times of its events, its status is left as is.
+func (r *repository) Import(ctx context.Context, e *Error) (created bool, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	if err = mergedInto(ctx, tx, e); err != nil {
+		return false, err
+	}
+
+	const query = insertQuery + " ON CONFLICT DO NOTHING"
+
+	now := time.Now().Unix()
 	e.CreatedAt = now
 	e.UpdatedAt = now
 
-	_, err = tx.NamedExecContext(ctx, query, e)
+	result, err := tx.NamedExecContext(ctx, query, e)
+	if err != nil {
+		return false, fmt.Errorf("failed to import error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to create error: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, tx.Commit()
+	}
+
+	errorGroupQuery := `
+        INSERT INTO error_groups (id, project_id, file, line, message, first_seen_at, last_seen_at, counter)
+        VALUES (:id, :project_id, :file, :line, :message, :first_seen_at, :last_seen_at, 1)
+        ON CONFLICT (id) DO UPDATE
+        SET
+            counter = error_groups.counter + 1,
+            first_seen_at = ` + r.dialect.Least("error_groups.first_seen_at", "EXCLUDED.first_seen_at") + `,
+            last_seen_at = ` + r.dialect.Greatest("error_groups.last_seen_at", "EXCLUDED.last_seen_at") + `
+    `
+
+	seenAt := time.UnixMilli(e.Time).Unix()
+	errorGroup := errorsGroup.Group{
+		ID:          e.Fingerprint,
+		ProjectID:   e.ProjectID,
+		File:        e.File,
+		Line:        e.Line,
+		Message:     e.Message,
+		FirstSeenAt: seenAt,
+		LastSeenAt:  seenAt,
+	}
+
+	if _, err = tx.NamedExecContext(ctx, errorGroupQuery, errorGroup); err != nil {
+		return false, fmt.Errorf("failed to upsert error group: %w", err)
+	}
+
+	if err = countErrors(ctx, tx, []countedError{{e.ProjectID, e.Fingerprint, e.Time}}, 1); err != nil {
+		return false, err
 	}
 
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return true, nil
+}
+
+// wakeSnoozed returns the snoozed group of a new event to unresolved once a condition of its snooze is met.
+func (r *repository) wakeSnoozed(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	e *Error,
+	occurrence *errorsGroup.Occurrence,
+	now int64,
+) error {
+	groupID := e.Fingerprint
+
+	users := 0
+	if occurrence.SnoozeConditions.Users != nil && occurrence.SnoozedAt != nil && e.IP != nil {
+		var err error
+		if users, err = r.countSnoozeUsers(ctx, tx, groupID, *occurrence.SnoozedAt, *e.IP, *occurrence.SnoozeConditions.Users); err != nil {
+			return err
+		}
+	}
+
+	if !occurrence.SnoozeConditions.Expired(now, occurrence.Counter, users) {
+		return nil
+	}
+
+	const query = `
+        UPDATE error_groups
+        SET status = 'unresolved', snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL, snooze_users = NULL
+        WHERE id = $1
+    `
+
+	if _, err := tx.ExecContext(ctx, query, groupID); err != nil {
+		return fmt.Errorf("failed to wake snoozed error group: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM error_group_snooze_ips WHERE group_id = $1`, groupID); err != nil {
+		return fmt.Errorf("failed to delete snoozed error group users: %w", err)
+	}
+
+	occurrence.Status = errorsGroup.StatusUnresolved
+	occurrence.SnoozeConditions = errorsGroup.SnoozeConditions{}
+	occurrence.Unsnoozed = true
 	return nil
 }
 
-func (r *repository) Update(ctx context.Context, id string, updated *Error) error {
+// countSnoozeUsers records the IP address of a new error of a snoozed group and returns the number
+// of users of the snooze, up to target, told apart by the IP address of the requests that raised
+// the errors. The users are counted only when the address is new to the snooze, it is 0 otherwise.
+func (r *repository) countSnoozeUsers(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	groupID string,
+	snoozedAt int64,
+	ip string,
+	target int,
+) (int, error) {
+	const insertQuery = `
+		INSERT INTO error_group_snooze_ips (group_id, snoozed_at, ip) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := tx.ExecContext(ctx, insertQuery, groupID, snoozedAt, ip)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record snoozed error group user: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if inserted == 0 {
+		return 0, nil
+	}
+
+	const countQuery = `
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM error_group_snooze_ips WHERE group_id = $1 AND snoozed_at = $2 LIMIT $3
+		) users
+	`
+
+	var users int
+	if err = tx.GetContext(ctx, &users, countQuery, groupID, snoozedAt, target); err != nil {
+		return 0, fmt.Errorf("failed to count snoozed error group users: %w", err)
+	}
+	return users, nil
+}
+
+// Update moves the error between the counts when its fingerprint or its time change. The error
+// is stored whole again, sharing no payload.
+func (r *repository) Update(ctx context.Context, id string, updated *Error) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	var previous countedError
+	err = tx.GetContext(ctx, &previous, `SELECT project_id, fingerprint, time FROM errors WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get error: %w", err)
+	}
+
 	const query = `
 		UPDATE
 			errors 
@@ -246,8 +740,16 @@ func (r *repository) Update(ctx context.Context, id string, updated *Error) erro
 		    file = :file,
 		    line = :line,
 		    context = :context,
+		    headers = :headers,
+		    query_params = :query_params,
+		    body_params = :body_params,
+		    cookies = :cookies,
+		    session = :session,
+		    files = :files,
+		    env = :env,
 		    time = :time,
-		    updated_at = :updated_at
+		    updated_at = :updated_at,
+		    payload_id = NULL
 		WHERE
 		    id = :id
 	`
@@ -255,40 +757,128 @@ func (r *repository) Update(ctx context.Context, id string, updated *Error) erro
 	updated.ID = id
 	updated.UpdatedAt = time.Now().Unix()
 
-	result, err := r.db.NamedExecContext(ctx, query, updated)
-	if err != nil {
+	if _, err = tx.NamedExecContext(ctx, query, updated); err != nil {
 		return fmt.Errorf("failed to update error: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	current := countedError{previous.ProjectID, updated.Fingerprint, updated.Time}
+	if current != previous {
+		if err = countErrors(ctx, tx, []countedError{previous}, -1); err != nil {
+			return err
+		}
+		if err = countErrors(ctx, tx, []countedError{current}, 1); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	deleted, err := r.deleteErrors(ctx, `DELETE FROM errors WHERE id = $1`, id)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete error: %w", err)
 	}
-	if rowsAffected == 0 {
+	if deleted == 0 {
 		return ErrNotFound
 	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id string) error {
-	const query = `DELETE FROM errors WHERE id = $1`
+// GetIDs pages over the IDs matching the filters in ID order, starting after afterID.
+func (r *repository) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	query := "SELECT id FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params, map[string]interface{}{"limit": limit})
+
+	if afterID != "" {
+		query += " AND id > :afterId"
+		args["afterId"] = afterID
+	}
+
+	query += " ORDER BY id LIMIT :limit"
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
-		return fmt.Errorf("failed to delete error: %w", err)
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var ids []string
+	err = r.db.SelectContext(ctx, &ids, query, namedArgs...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get errors ids: %w", err)
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
+	return ids, nil
+}
+
+func (r *repository) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	deleted, err := r.deleteErrors(ctx, `DELETE FROM errors WHERE id = ANY(CAST($1 AS UUID[]))`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete errors: %w", err)
 	}
-	return nil
+	return deleted, nil
+}
+
+// deleteErrors runs a delete of errors and takes the deleted errors off the counts.
+func (r *repository) deleteErrors(ctx context.Context, query string, args ...interface{}) (deleted int, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	query += " RETURNING project_id, fingerprint, time, payload_id"
+	r.logger.Debug(query)
+
+	var deletedErrs []struct {
+		countedError
+		PayloadID *string `db:"payload_id"`
+	}
+	if err = tx.SelectContext(ctx, &deletedErrs, query, args...); err != nil {
+		return 0, err
+	}
+
+	errs := make([]countedError, 0, len(deletedErrs))
+	payloadIDs := make([]string, 0)
+	for _, e := range deletedErrs {
+		errs = append(errs, e.countedError)
+		if e.PayloadID != nil {
+			payloadIDs = append(payloadIDs, *e.PayloadID)
+		}
+	}
+
+	if err = countErrors(ctx, tx, errs, -1); err != nil {
+		return 0, err
+	}
+
+	if err = deleteUnusedPayloads(ctx, tx, r.dialect, payloadIDs); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(errs), nil
 }
 
-func applyFilters(baseQuery string, params FilterParams, args map[string]interface{}) (string, map[string]interface{}) {
+func (r *repository) applyFilters(
+	baseQuery string,
+	params FilterParams,
+	args map[string]interface{},
+) (string, map[string]interface{}) {
 	query := baseQuery
 
 	if params.ProjectID != "" {
@@ -312,9 +902,75 @@ func applyFilters(baseQuery string, params FilterParams, args map[string]interfa
 	}
 
 	if params.Search != "" {
-		query += " AND message ILIKE :search"
-		args["search"] = "%" + params.Search + "%"
+		query += r.searchFilter(params, args)
+	}
+
+	if params.Query != nil {
+		if r.dialect.SQLite() {
+			query += " AND " + params.Query.SQLite(args)
+		} else {
+			query += " AND " + params.Query.SQL(args)
+		}
 	}
 
 	return query, args
 }
+
+func planRows(plan []byte) (int, error) {
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("failed to parse query plan: %s", plan)
+	}
+
+	return int(explained[0].Plan.Rows), nil
+}
+
+// searchFilter matches the messages with the search, through the full-text index when asked for,
+// which also covers the stacktraces, the shared ones through the index of the payloads. SQLite has
+// no full-text index, the words are searched within the messages.
+func (r *repository) searchFilter(params FilterParams, args map[string]interface{}) string {
+	switch {
+	case params.SearchMode != query.SearchFullText:
+		args["search"] = "%" + params.Search + "%"
+		return " AND " + r.dialect.ILike("message", ":search")
+	case r.dialect.SQLite():
+		if condition := query.FullTextLike(params.Search, "message", args); condition != "" {
+			return " AND " + condition
+		}
+	default:
+		if tsquery := query.FullText(params.Search, args); tsquery != "" {
+			return " AND (search_vector @@ " + tsquery + " OR payload_search_vector @@ " + tsquery + ")"
+		}
+	}
+	return ""
+}
+
+// fullTextQuery compiles the search when the full-text search mode is used on PostgreSQL, it is
+// empty otherwise.
+func (r *repository) fullTextQuery(params FilterParams, args map[string]interface{}) string {
+	if params.SearchMode != query.SearchFullText || r.dialect.SQLite() {
+		return ""
+	}
+	return query.FullText(params.Search, args)
+}
+
+func histogramFilters(params HistogramParams, args map[string]interface{}) string {
+	filters := ""
+
+	if params.Fingerprint != "" {
+		filters += " AND fingerprint = :fingerprint"
+		args["fingerprint"] = params.Fingerprint
+	}
+
+	return filters
+}
+
+// fullTextColumns selects the relevance and the highlighted message of a full-text search.
+func fullTextColumns(tsquery string) string {
+	return ", ts_rank(search_vector || COALESCE(payload_search_vector, ''::tsvector), " + tsquery + ") AS rank" +
+		", ts_headline('" + query.TextSearchConfig + "', message, " + tsquery + ", :headlineOptions) AS highlight"
+}
@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresDsnEnv names the database the PostgreSQL tests run against, skipped when unset.
+const postgresDsnEnv = "FUCKBUG_TEST_POSTGRES_DSN"
+
+func TestRepositoryRegressed(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testRegressed(t, openDB(t, storage.DriverSQLite, ":memory:"))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresDsnEnv)
+		if dsn == "" {
+			t.Skip(postgresDsnEnv + " is not set")
+		}
+		testRegressed(t, openDB(t, storage.DriverPostgres, dsn))
+	})
+}
+
+// testRegressed resolves a group and stores an error in it again, which regresses it once.
+func testRegressed(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+	repo := NewRepository(db, logger.New("ERROR", nil), Config{})
+
+	projectID := uuid.New().String()
+	fingerprint := uuid.New().String()
+	store := func() (created, regressed bool) {
+		occurrence, err := repo.Create(ctx, &Error{
+			ID: uuid.New().String(), ProjectID: projectID, Fingerprint: fingerprint, Message: "Nil pointer",
+			File: "main.go", Line: 3, Time: time.Now().UnixMilli(),
+		})
+		require.NoError(t, err)
+		return occurrence.Created, occurrence.Regressed
+	}
+
+	created, regressed := store()
+	assert.True(t, created)
+	assert.False(t, regressed)
+
+	_, err := db.ExecContext(ctx, `UPDATE error_groups SET status = 'resolved' WHERE id = $1`, fingerprint)
+	require.NoError(t, err)
+
+	created, regressed = store()
+	assert.False(t, created)
+	assert.True(t, regressed)
+
+	_, regressed = store()
+	assert.False(t, regressed)
+}
@@ -5,30 +5,59 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"time"
 
+	"github.com/fuckbug/api/internal/events"
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultHistogramRange = 24 * time.Hour
+	maxHistogramBuckets   = 1500
+	// Above this number of rows an estimated count is taken from the query planner
+	estimateCountThreshold = 10000
+)
+
+var (
+	ErrInvalidInterval  = errors.New("invalid interval, expected minute, hour or day")
+	ErrInvalidTimezone  = errors.New("invalid timezone")
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrInvalidSort      = errors.New("relevance sort requires a full-text search and no cursor")
+	ErrTooManyBuckets   = errors.New("too many buckets, use a larger interval or a shorter time range")
+)
+
 type Service interface {
 	GetByID(ctx context.Context, id string) (*Entity, error)
-	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetAll(ctx context.Context, params GetAllParams) (*EntityList, error)
+	Export(ctx context.Context, params ExportParams, handle func(*Entity) error) error
 	GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error)
+	GetHistogram(ctx context.Context, params HistogramParams) (*Histogram, error)
 	Create(ctx context.Context, req *Create) (*Entity, error)
+	Import(ctx context.Context, req *Import) (bool, error)
 	Update(ctx context.Context, id string, req *Update) (*Entity, error)
 	Delete(ctx context.Context, id string) error
+	Count(ctx context.Context, params FilterParams) (int, error)
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
 }
 
 type service struct {
-	repo   Repository
-	logger Logger
+	repo      Repository
+	logger    Logger
+	publisher Publisher
 }
 
-func NewService(repo Repository, logger Logger) Service {
+func NewService(repo Repository, logger Logger, publisher Publisher) Service {
 	return &service{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		logger:    logger,
+		publisher: publisher,
 	}
 }
 
@@ -40,22 +69,86 @@ func (s *service) GetByID(ctx context.Context, id string) (*Entity, error) {
 	return toResponse(entity), nil
 }
 
-func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+// GetAll lists a page of errors. One extra row is fetched to tell whether a next page exists.
+func (s *service) GetAll(ctx context.Context, params GetAllParams) (*EntityList, error) {
+	relevance := params.SortOrder == SortRelevance
+	if relevance && (params.SearchMode != query.SearchFullText || params.Search == "" || params.Cursor != nil) {
+		return nil, ErrInvalidSort
+	}
+
+	limit := params.Limit
+	if params.Cursor != nil {
+		params.Offset = 0
+	}
+	params.Limit = limit + 1
+
 	entities, err := s.repo.GetAll(ctx, params)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	total, err := s.repo.Count(ctx, params.FilterParams)
-	if err != nil {
-		return nil, 0, err
+	list := &EntityList{
+		Items: make([]*Entity, 0, len(entities)),
+	}
+
+	if limit > 0 && len(entities) > limit {
+		entities = entities[:limit]
+		// Pages sorted by relevance are only reachable by offset
+		if !relevance {
+			last := entities[limit-1]
+			list.NextCursor = utils.Cursor{Time: last.Time, ID: last.ID}.Encode()
+		}
 	}
 
-	responses := make([]*Entity, 0, len(entities))
 	for _, entity := range entities {
-		responses = append(responses, toResponse(entity))
+		list.Items = append(list.Items, toResponse(entity))
+	}
+
+	list.Count, list.Estimated, err = s.count(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Export hands every matching error to handle, in time order.
+func (s *service) Export(ctx context.Context, params ExportParams, handle func(*Entity) error) error {
+	if params.SortOrder == "" {
+		params.SortOrder = "desc"
+	}
+
+	return s.repo.Export(ctx, params, func(e *Error) error {
+		return handle(toResponse(e))
+	})
+}
+
+func (s *service) count(ctx context.Context, params GetAllParams) (*int, bool, error) {
+	switch params.CountMode {
+	case CountNone:
+		return nil, false, nil
+	case CountEstimate:
+		count, err := s.repo.CountUpTo(ctx, params.FilterParams, estimateCountThreshold)
+		if err != nil {
+			return nil, false, err
+		}
+		if count < estimateCountThreshold {
+			return &count, false, nil
+		}
+
+		estimate, err := s.repo.EstimateCount(ctx, params.FilterParams)
+		if err != nil {
+			return nil, false, err
+		}
+		count = max(count, estimate)
+		return &count, true, nil
+	default:
+		count, err := s.repo.Count(ctx, params.FilterParams)
+		if err != nil {
+			return nil, false, err
+		}
+		return &count, false, nil
 	}
-	return responses, total, nil
 }
 
 func (s *service) GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error) {
@@ -67,7 +160,92 @@ func (s *service) GetStats(ctx context.Context, projectID string, fingerprint st
 	return stats, nil
 }
 
+func (s *service) GetHistogram(ctx context.Context, params HistogramParams) (*Histogram, error) {
+	if params.Interval == "" {
+		params.Interval = IntervalHour
+	}
+
+	step, ok := intervalDuration(params.Interval)
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+
+	// Local is the zone of the API server, unknown to the database aligning the buckets
+	if _, err := time.LoadLocation(params.Timezone); err != nil || params.Timezone == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	if params.TimeTo == 0 {
+		params.TimeTo = time.Now().UnixMilli()
+	}
+
+	if params.TimeFrom == 0 {
+		params.TimeFrom = params.TimeTo - defaultHistogramRange.Milliseconds()
+	}
+
+	if params.TimeFrom > params.TimeTo {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if (params.TimeTo-params.TimeFrom)/step.Milliseconds() > maxHistogramBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
+	points, err := s.repo.GetHistogram(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]HistogramPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, *point)
+	}
+
+	return &Histogram{
+		Interval: params.Interval,
+		Timezone: params.Timezone,
+		Items:    items,
+	}, nil
+}
+
 func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
+	entity, err := newError(req)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrence, err := s.repo.Create(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toResponse(entity)
+	s.publishCreated(ctx, entity, occurrence, response)
+
+	return response, nil
+}
+
+// Import stores an error of another system with its original time. The groups count it without
+// being reopened, and no event is published. created is false when it was imported before.
+func (s *service) Import(ctx context.Context, req *Import) (created bool, err error) {
+	entity, err := newError(&req.Create)
+	if err != nil {
+		return false, err
+	}
+
+	if req.ID != "" {
+		entity.ID = req.ID
+	}
+
+	return s.repo.Import(ctx, entity)
+}
+
+// newError builds the error of a request, along with its fingerprint.
+func newError(req *Create) (*Error, error) {
 	stacktrace, err := stacktraceToString(req.Stacktrace)
 	if err != nil {
 		return nil, err
@@ -136,11 +314,7 @@ func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
 
 	entity.Fingerprint = generateFingerprint(entity)
 
-	if err := s.repo.Create(ctx, entity); err != nil {
-		return nil, err
-	}
-
-	return toResponse(entity), nil
+	return entity, nil
 }
 
 func (s *service) Update(ctx context.Context, id string, req *Update) (*Entity, error) {
@@ -182,6 +356,71 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
 
+func (s *service) Count(ctx context.Context, params FilterParams) (int, error) {
+	return s.repo.Count(ctx, params)
+}
+
+func (s *service) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	return s.repo.GetIDs(ctx, params, afterID, limit)
+}
+
+func (s *service) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	return s.repo.DeleteByIDs(ctx, ids)
+}
+
+func (s *service) publishCreated(
+	ctx context.Context,
+	e *Error,
+	occurrence *errorsGroup.Occurrence,
+	response *Entity,
+) {
+	event := events.Event{
+		ProjectID: e.ProjectID,
+		GroupID:   e.Fingerprint,
+		GroupKind: events.GroupKindError,
+		Message:   e.Message,
+		Time:      e.Time,
+		Payload:   response,
+	}
+
+	if occurrence.Created {
+		event.Type = events.TypeGroupCreated
+		s.publisher.Publish(ctx, event)
+	}
+
+	if occurrence.Regressed {
+		event.Type = events.TypeGroupRegressed
+		s.publisher.Publish(ctx, event)
+	}
+
+	if occurrence.Unsnoozed {
+		unsnoozed := event
+		unsnoozed.Type = events.TypeGroupUnsnoozed
+		unsnoozed.Change = &events.Change{
+			Field: "status",
+			From:  string(errorsGroup.StatusSnoozed),
+			To:    string(errorsGroup.StatusUnresolved),
+		}
+		s.publisher.Publish(ctx, unsnoozed)
+	}
+
+	event.Type = events.TypeErrorCreated
+	s.publisher.Publish(ctx, event)
+}
+
+func intervalDuration(interval string) (time.Duration, bool) {
+	switch interval {
+	case IntervalMinute:
+		return time.Minute, true
+	case IntervalHour:
+		return time.Hour, true
+	case IntervalDay:
+		return 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
+
 func generateFingerprint(e *Error) string {
 	cleanMsg := regexp.MustCompile(`\d+|0x[0-9a-f]+`).ReplaceAllString(e.Message, "*")
 
@@ -209,57 +448,44 @@ func toResponse(e *Error) *Entity {
 		Time:    e.Time,
 	}
 
-	if err := parseJSONField(&e.Stacktrace, &response.Stacktrace); err != nil {
-		*response.Stacktrace = e.Stacktrace
+	if e.Rank != nil {
+		response.Rank = *e.Rank
 	}
-
-	if err := parseJSONField(e.Context, &response.Context); err != nil {
-		*response.Context = e.Context
+	if e.Highlight != nil {
+		response.Highlight = *e.Highlight
 	}
 
-	if err := parseJSONField(e.Headers, &response.Headers); err != nil {
-		*response.Headers = map[string]interface{}{
-			"Headers": e.Headers,
-		}
-	}
-
-	if err := parseJSONField(e.QueryParams, &response.QueryParams); err != nil {
-		*response.QueryParams = map[string]interface{}{
-			"QueryParams": e.QueryParams,
-		}
-	}
-
-	if err := parseJSONField(e.BodyParams, &response.BodyParams); err != nil {
-		*response.BodyParams = map[string]interface{}{
-			"BodyParams": e.BodyParams,
-		}
+	if err := parseJSONField(&e.Stacktrace, &response.Stacktrace); err != nil {
+		*response.Stacktrace = e.Stacktrace
 	}
 
-	if err := parseJSONField(e.Cookies, &response.Cookies); err != nil {
-		*response.Cookies = map[string]interface{}{
-			"Cookies": e.Cookies,
-		}
-	}
+	// JSONB values are valid JSON, they are passed through without being decoded
+	response.Context = rawJSONValue(e.Context)
+	response.Headers = rawJSON(e.Headers)
+	response.QueryParams = rawJSON(e.QueryParams)
+	response.BodyParams = rawJSON(e.BodyParams)
+	response.Cookies = rawJSON(e.Cookies)
+	response.Session = rawJSON(e.Session)
+	response.Files = rawJSON(e.Files)
+	response.Env = rawJSON(e.Env)
 
-	if err := parseJSONField(e.Session, &response.Session); err != nil {
-		*response.Session = map[string]interface{}{
-			"Session": e.Session,
-		}
-	}
+	return response
+}
 
-	if err := parseJSONField(e.Files, &response.Files); err != nil {
-		*response.Files = map[string]interface{}{
-			"Files": e.Files,
-		}
+func rawJSON(value *string) json.RawMessage {
+	if value == nil {
+		return nil
 	}
+	return json.RawMessage(*value)
+}
 
-	if err := parseJSONField(e.Env, &response.Env); err != nil {
-		*response.Env = map[string]interface{}{
-			"Env": e.Env,
-		}
+// rawJSONValue wraps a JSONB value in the interface the Context fields are typed as.
+func rawJSONValue(value *string) *interface{} {
+	if value == nil {
+		return nil
 	}
-
-	return response
+	var raw interface{} = json.RawMessage(*value)
+	return &raw
 }
 
 func parseJSONField(src *string, dest interface{}) error {
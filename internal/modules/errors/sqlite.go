@@ -0,0 +1,160 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// sqliteRepository stores the errors in SQLite. It runs its own queries where PostgreSQL ones
+// have no SQLite equivalent and shares the others.
+type sqliteRepository struct {
+	*repository
+}
+
+// Export reads the rows of a plain query, which SQLite hands over as they are stepped through.
+func (r *sqliteRepository) Export(ctx context.Context, params ExportParams, handle func(*Error) error) error {
+	query := "SELECT " + errorColumns + " FROM " + r.table + " WHERE 1=1"
+	query, args := r.applyFilters(query, params.FilterParams, make(map[string]interface{}))
+	query += " ORDER BY time " + params.SortOrder + ", id " + params.SortOrder
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	rows, err := r.db.QueryxContext(ctx, query, namedArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to export errors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item Error
+		if err := rows.StructScan(&item); err != nil {
+			return fmt.Errorf("failed to scan error: %w", err)
+		}
+		if err := handle(&item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EstimateCount counts exactly, SQLite has no planner estimates and its databases stay small.
+func (r *sqliteRepository) EstimateCount(ctx context.Context, params FilterParams) (int, error) {
+	return r.Count(ctx, params)
+}
+
+// GetHistogram reads the counts of the errors by minute or by hour and adds them up into the
+// buckets of the time zone.
+func (r *sqliteRepository) GetHistogram(ctx context.Context, params HistogramParams) ([]*HistogramPoint, error) {
+	location, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	resolution := storage.HistogramResolution(params.TimeFrom, params.TimeTo, params.Interval, location)
+
+	args := map[string]interface{}{
+		"projectId":  params.ProjectID,
+		"bucketFrom": storage.Bucket(params.TimeFrom, resolution),
+		"timeTo":     params.TimeTo,
+	}
+
+	query := `
+        SELECT bucket AS time, SUM(count) AS count
+        FROM ` + countsTable(resolution) + `
+        WHERE project_id = :projectId AND bucket >= :bucketFrom AND bucket <= :timeTo` + histogramFilters(params, args) + `
+        GROUP BY bucket
+    `
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var rows []*HistogramPoint
+	err = r.db.SelectContext(ctx, &rows, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get errors histogram: %w", err)
+	}
+
+	counts := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		counts[row.Time] = row.Count
+	}
+
+	starts, totals := storage.Buckets(params.TimeFrom, params.TimeTo, params.Interval, location, counts)
+
+	points := make([]*HistogramPoint, 0, len(starts))
+	for i, start := range starts {
+		points = append(points, &HistogramPoint{Time: start, Count: totals[i]})
+	}
+	return points, nil
+}
+
+func (r *sqliteRepository) Create(ctx context.Context, e *Error) (*errorsGroup.Occurrence, error) {
+	return r.create(ctx, e, r.upsertGroup)
+}
+
+// upsertGroup reads the status of the group before the upsert, SQLite transactions holding the
+// write lock from their start.
+func (r *sqliteRepository) upsertGroup(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	group *errorsGroup.Group,
+) (*errorsGroup.Occurrence, error) {
+	var previous errorsGroup.Status
+	err := tx.GetContext(ctx, &previous, `SELECT status FROM error_groups WHERE id = $1`, group.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	const query = `
+        INSERT INTO error_groups (id, project_id, file, line, message, first_seen_at, last_seen_at, counter)
+        VALUES (:id, :project_id, :file, :line, :message, :first_seen_at, :last_seen_at, 1)
+        ON CONFLICT (id) DO UPDATE
+        SET
+            counter = error_groups.counter + 1,
+            last_seen_at = EXCLUDED.last_seen_at,
+            status = CASE WHEN error_groups.status = 'resolved' THEN 'unresolved' ELSE error_groups.status END
+        RETURNING counter, status, snoozed_at, snooze_until, snooze_counter, snooze_users
+    `
+
+	groupQuery, groupArgs, err := tx.BindNamed(query, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	var occurrence errorsGroup.Occurrence
+	if err := tx.GetContext(ctx, &occurrence, groupQuery, groupArgs...); err != nil {
+		return nil, err
+	}
+
+	occurrence.Created = previous == ""
+	occurrence.Regressed = previous == errorsGroup.StatusResolved
+	return &occurrence, nil
+}
+
+func (r *sqliteRepository) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	deleted, err := r.deleteErrors(ctx, `DELETE FROM errors WHERE `+r.dialect.In("id", "$1"), r.dialect.List(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete errors: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,277 @@
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/logger"
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteRepository(t *testing.T, config Config) Repository {
+	t.Helper()
+	return NewRepository(openDB(t, storage.DriverSQLite, ":memory:"), logger.New("ERROR", nil), config)
+}
+
+func openDB(t *testing.T, driver, dsn string) *sqlx.DB {
+	t.Helper()
+
+	db, err := storage.Open(driver, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	require.NoError(t, sql.RunMigrations(db, logger.New("ERROR", nil)))
+	return db
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t, Config{})
+
+	now := time.Now()
+	userContext := `{"user": {"id": 42}}`
+
+	occurrence, err := repo.Create(ctx, &Error{
+		ID: "a", ProjectID: "p", Fingerprint: "f1", Message: "Connection timeout", File: "db.go", Line: 10,
+		Context: &userContext, Time: now.UnixMilli(),
+	})
+	require.NoError(t, err)
+	assert.True(t, occurrence.Created)
+
+	occurrence, err = repo.Create(ctx, &Error{
+		ID: "b", ProjectID: "p", Fingerprint: "f1", Message: "Connection timeout", File: "db.go", Line: 10,
+		Time: now.Add(-48 * time.Hour).UnixMilli(),
+	})
+	require.NoError(t, err)
+	assert.False(t, occurrence.Created)
+	assert.Equal(t, 2, occurrence.Counter)
+
+	_, err = repo.Create(ctx, &Error{
+		ID: "c", ProjectID: "p", Fingerprint: "f2", Message: "Nil pointer", File: "api.go", Line: 3,
+		Time: now.UnixMilli(),
+	})
+	require.NoError(t, err)
+
+	q, err := query.Parse("context.user.id:42", QueryFields)
+	require.NoError(t, err)
+
+	found, err := repo.GetAll(ctx, GetAllParams{FilterParams: FilterParams{ProjectID: "p", Query: q}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "a", found[0].ID)
+
+	for _, mode := range []string{query.SearchContains, query.SearchFullText} {
+		count, err := repo.Count(ctx, FilterParams{ProjectID: "p", Search: "TIMEOUT", SearchMode: mode})
+		require.NoError(t, err)
+		assert.Equal(t, 2, count, mode)
+	}
+
+	stats, err := repo.GetStats(ctx, "p", "f1")
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Last24h: 1, Last7d: 2, Last30d: 2}, stats)
+
+	points, err := repo.GetHistogram(ctx, HistogramParams{
+		ProjectID: "p",
+		TimeFrom:  now.Add(-72 * time.Hour).UnixMilli(),
+		TimeTo:    now.UnixMilli(),
+		Interval:  "day",
+		Timezone:  "Europe/Moscow",
+	})
+	require.NoError(t, err)
+	require.Len(t, points, 4)
+	assert.Equal(t, 1, points[1].Count)
+	assert.Equal(t, 2, points[3].Count)
+
+	deleted, err := repo.DeleteByIDs(ctx, []string{"a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	stats, err = repo.GetStats(ctx, "p", "")
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Last24h: 0, Last7d: 1, Last30d: 1}, stats)
+
+	require.NoError(t, repo.Update(ctx, "b", &Error{
+		Fingerprint: "f2", Message: "Nil pointer", File: "api.go", Line: 3, Time: now.UnixMilli(),
+	}))
+
+	stats, err = repo.GetStats(ctx, "p", "f2")
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Last24h: 1, Last7d: 1, Last30d: 1}, stats)
+
+	stats, err = repo.GetStats(ctx, "p", "f1")
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{}, stats)
+}
+
+func TestSQLiteRepositorySamples(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, storage.DriverSQLite, ":memory:")
+	repo := NewRepository(db, logger.New("ERROR", nil), Config{Samples: 2, SampleWindow: time.Hour})
+
+	start := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+	headers := `{"Accept": "*/*"}`
+	offsets := []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute, time.Hour}
+	for i, offset := range offsets {
+		_, err := repo.Create(ctx, &Error{
+			ID: string(rune('a' + i)), ProjectID: "p", Fingerprint: "f", Message: "Nil pointer", Stacktrace: "main.go:3",
+			File: "main.go", Line: 3, Headers: &headers, Time: start.Add(offset).UnixMilli(),
+		})
+		require.NoError(t, err)
+	}
+
+	found, err := repo.GetAll(ctx, GetAllParams{FilterParams: FilterParams{ProjectID: "p"}, SortOrder: "asc", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 5)
+
+	// The errors beyond the 2 samples of their hour share their payload
+	for i, e := range found {
+		assert.Equal(t, i == 2 || i == 3, e.PayloadID != nil, e.ID)
+		assert.Equal(t, "main.go:3", e.Stacktrace, e.ID)
+		assert.Equal(t, headers, *e.Headers, e.ID)
+	}
+	assert.Equal(t, found[2].PayloadID, found[3].PayloadID)
+
+	// The query filters read the shared payloads
+	q, err := query.Parse(`headers.Accept:"*/*"`, QueryFields)
+	require.NoError(t, err)
+
+	count, err := repo.Count(ctx, FilterParams{ProjectID: "p", Query: q})
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	ids, err := repo.GetIDs(ctx, FilterParams{ProjectID: "p", Query: q}, "b", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d", "e"}, ids)
+
+	shared, err := repo.GetByID(ctx, "d")
+	require.NoError(t, err)
+	assert.Equal(t, "main.go:3", shared.Stacktrace)
+
+	// An update stores the error whole again
+	shared.Stacktrace = "main.go:4"
+	require.NoError(t, repo.Update(ctx, "d", shared))
+
+	updated, err := repo.GetByID(ctx, "d")
+	require.NoError(t, err)
+	assert.Nil(t, updated.PayloadID)
+	assert.Equal(t, "main.go:4", updated.Stacktrace)
+	assert.Equal(t, headers, *updated.Headers)
+
+	// The payload goes with the last error sharing it
+	var payloads int
+	_, err = repo.DeleteByIDs(ctx, []string{"c"})
+	require.NoError(t, err)
+	require.NoError(t, db.Get(&payloads, "SELECT COUNT(*) FROM error_payloads"))
+	assert.Equal(t, 0, payloads)
+}
+
+func TestSQLiteRepositoryDeletesEmptyCounts(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, storage.DriverSQLite, ":memory:")
+	repo := NewRepository(db, logger.New("ERROR", nil), Config{})
+
+	now := time.Now()
+	for _, id := range []string{"a", "b"} {
+		_, err := repo.Create(ctx, &Error{
+			ID: id, ProjectID: "p", Fingerprint: "f", Message: "Nil pointer", File: "main.go", Line: 3,
+			Time: now.UnixMilli(),
+		})
+		require.NoError(t, err)
+	}
+
+	countRows := func() int {
+		var rows int
+		require.NoError(t, db.Get(&rows, `SELECT (SELECT COUNT(*) FROM error_counts_minute) + (SELECT COUNT(*) FROM error_counts_hour)`))
+		return rows
+	}
+
+	require.NoError(t, repo.Delete(ctx, "a"))
+	assert.Equal(t, 2, countRows())
+
+	require.NoError(t, repo.Delete(ctx, "b"))
+	assert.Equal(t, 0, countRows())
+}
+
+func TestSQLiteRepositoryWakesSnoozedByUsers(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, storage.DriverSQLite, ":memory:")
+	repo := NewRepository(db, logger.New("ERROR", nil), Config{})
+	groups := errorsGroup.NewRepository(db, logger.New("ERROR", nil))
+
+	now := time.Now()
+	create := func(id, ip string) *errorsGroup.Occurrence {
+		occurrence, err := repo.Create(ctx, &Error{
+			ID: id, ProjectID: "p", Fingerprint: "f", Message: "Nil pointer", File: "main.go", Line: 3,
+			IP: &ip, Time: now.UnixMilli(),
+		})
+		require.NoError(t, err)
+		return occurrence
+	}
+
+	create("a", "10.0.0.1")
+
+	snoozedAt, users := now.Unix(), 2
+	require.NoError(t, groups.Snooze(ctx, "f", errorsGroup.SnoozeConditions{SnoozedAt: &snoozedAt, Users: &users}))
+
+	// The same user is counted once
+	assert.False(t, create("b", "10.0.0.1").Unsnoozed)
+	assert.False(t, create("c", "10.0.0.1").Unsnoozed)
+	assert.True(t, create("d", "10.0.0.2").Unsnoozed)
+
+	var ips int
+	require.NoError(t, db.Get(&ips, "SELECT COUNT(*) FROM error_group_snooze_ips"))
+	assert.Equal(t, 0, ips)
+}
+
+func TestSQLiteRepositoryMergesGroups(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, storage.DriverSQLite, ":memory:")
+	repo := NewRepository(db, logger.New("ERROR", nil), Config{})
+	groups := errorsGroup.NewRepository(db, logger.New("ERROR", nil))
+
+	now := time.Now()
+	create := func(id, fingerprint string) *errorsGroup.Occurrence {
+		occurrence, err := repo.Create(ctx, &Error{
+			ID: id, ProjectID: "p", Fingerprint: fingerprint, Message: "Nil pointer", File: "main.go", Line: 3,
+			Time: now.UnixMilli(),
+		})
+		require.NoError(t, err)
+		return occurrence
+	}
+
+	create("a", "f1")
+	create("b", "f2")
+	create("c", "f2")
+
+	merged, err := groups.MergeInto(ctx, "f1", []string{"f2"})
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	assert.Equal(t, "f2", merged[0].ID)
+
+	group, err := groups.GetByID(ctx, "f1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, group.Counter)
+	assert.Equal(t, 3, group.EventsLastHour)
+
+	_, err = groups.GetByID(ctx, "f2")
+	assert.ErrorIs(t, err, errorsGroup.ErrNotFound)
+
+	// The new errors of the merged group go to the group
+	occurrence := create("d", "f2")
+	assert.False(t, occurrence.Created)
+	assert.Equal(t, 4, occurrence.Counter)
+
+	stats, err := repo.GetStats(ctx, "p", "f1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Last24h)
+
+	_, err = groups.MergeInto(ctx, "f1", []string{"unknown"})
+	assert.ErrorIs(t, err, errorsGroup.ErrNotFound)
+}
@@ -6,16 +6,62 @@ const (
 	StatusUnresolved Status = "unresolved"
 	StatusResolved   Status = "resolved"
 	StatusIgnored    Status = "ignored"
+	StatusSnoozed    Status = "snoozed"
 )
 
 type Group struct {
-	ID          string `db:"id"`
-	ProjectID   string `db:"project_id"`
-	File        string `db:"file"`
-	Line        int    `db:"line"`
-	Message     string `db:"message"`
-	FirstSeenAt int64  `db:"first_seen_at"`
-	LastSeenAt  int64  `db:"last_seen_at"`
-	Counter     int    `db:"counter"`
-	Status      Status `db:"status"`
+	ID          string  `db:"id"`
+	ProjectID   string  `db:"project_id"`
+	File        string  `db:"file"`
+	Line        int     `db:"line"`
+	Message     string  `db:"message"`
+	FirstSeenAt int64   `db:"first_seen_at"`
+	LastSeenAt  int64   `db:"last_seen_at"`
+	Counter     int     `db:"counter"`
+	Status      Status  `db:"status"`
+	AssigneeID  *string `db:"assignee_id"`
+	SnoozeConditions
+
+	EventsLastHour int     `db:"events_last_hour"`
+	EventsLastDay  int     `db:"events_last_day"`
+	Trend          float64 `db:"trend"`
+}
+
+// SnoozeConditions holds the conditions ending the snooze of a group, the first one met wakes it up.
+type SnoozeConditions struct {
+	SnoozedAt *int64 `db:"snoozed_at"`
+	Until     *int64 `db:"snooze_until"`   // Unix timestamp
+	Counter   *int   `db:"snooze_counter"` // Group counter to reach
+	Users     *int   `db:"snooze_users"`   // Distinct users affected since the snooze
+}
+
+// Expired reports whether one of the conditions of the snooze is met.
+func (s SnoozeConditions) Expired(now int64, counter, users int) bool {
+	if s.Until != nil && now >= *s.Until {
+		return true
+	}
+	if s.Counter != nil && counter >= *s.Counter {
+		return true
+	}
+	if s.Users != nil && users >= *s.Users {
+		return true
+	}
+	return false
+}
+
+// Change is a group updated by a bulk action, along with the previous value of the attribute.
+type Change struct {
+	Group
+	Previous *string `db:"previous"`
+}
+
+// Occurrence is the outcome of attaching a new event to its group.
+type Occurrence struct {
+	Created   bool   `db:"created"`
+	Regressed bool   `db:"regressed"`
+	Counter   int    `db:"counter"`
+	Status    Status `db:"status"`
+	SnoozeConditions
+	// Unsnoozed is set when the event ended the snooze of the group
+	Unsnoozed bool `db:"-"`
 }
@@ -5,46 +5,146 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrAssigneeNotFound = errors.New("assignee not found")
+	ErrInvalidMerge     = errors.New("groups can only be merged into another group of their project")
+)
+
+const (
+	groupColumns = `g.id, g.project_id, g.file, g.line, g.message, g.first_seen_at, g.last_seen_at, g.counter, g.status, g.assignee_id,
+		g.snoozed_at, g.snooze_until, g.snooze_counter, g.snooze_users`
+
+	// returnedColumns are the columns of the groups returned by updates, unqualified as SQLite requires.
+	returnedColumns = `id, project_id, file, line, message, first_seen_at, last_seen_at, counter, status, assignee_id,
+		snoozed_at, snooze_until, snooze_counter, snooze_users`
+
+	// statsJoin attaches event counts of the last hour and the last day to every group,
+	// along with the events of the trend baseline window that precedes the last day. They are
+	// added up from the counts by minute of the last hour and by hour of the days before.
+	statsJoin = `
+        LEFT JOIN LATERAL (
+            SELECT
+                (
+                    SELECT COALESCE(SUM(m.count), 0)
+                    FROM error_counts_minute m
+                    WHERE m.project_id = g.project_id AND m.fingerprint = g.id AND m.bucket >= :hourAgo
+                ) AS events_last_hour,
+                COALESCE(SUM(h.count) FILTER (WHERE h.bucket >= :dayAgo), 0) AS events_last_day,
+                COALESCE(SUM(h.count) FILTER (WHERE h.bucket < :dayAgo), 0) AS events_baseline
+            FROM error_counts_hour h
+            WHERE h.project_id = g.project_id AND h.fingerprint = g.id AND h.bucket >= :baselineFrom
+        ) stats ON TRUE
+    `
+
+	// statsColumns exposes the counts, and the trend as the ratio of the last day to the
+	// average daily volume of the 7 days baseline window (smoothed to avoid division by zero).
+	statsColumns = `
+        COALESCE(stats.events_last_hour, 0) AS events_last_hour,
+        COALESCE(stats.events_last_day, 0) AS events_last_day,
+        ROUND((COALESCE(stats.events_last_day, 0) + 1) / (COALESCE(stats.events_baseline, 0) / 7.0 + 1), 2) AS trend
+    `
+
+	trendBaselineDays = 7
+)
+
+var storedSortColumns = map[string]string{
+	SortByLastSeenAt:  "last_seen_at",
+	SortByFirstSeenAt: "first_seen_at",
+	SortByCounter:     "counter",
+}
+
+var statsSortColumns = map[string]string{
+	SortByEventsLastHour: "events_last_hour",
+	SortByEventsLastDay:  "events_last_day",
+	SortByTrend:          "trend",
+}
 
 type Repository interface {
 	GetAll(ctx context.Context, params GetAllParams) ([]*Group, error)
 	Count(ctx context.Context, params FilterParams) (int, error)
 	GetByID(ctx context.Context, id string) (*Group, error)
+	UpdateStatus(ctx context.Context, id string, status Status) error
+	UpdateAssignee(ctx context.Context, id string, assigneeID *string) error
+	Snooze(ctx context.Context, id string, snooze SnoozeConditions) error
+	WakeExpired(ctx context.Context, now int64) ([]*Group, error)
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	UpdateStatuses(ctx context.Context, ids []string, status Status) ([]*Change, error)
+	UpdateAssignees(ctx context.Context, ids []string, assigneeID *string) ([]*Change, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
+	MergeInto(ctx context.Context, id string, sourceIDs []string) ([]*Group, error)
 }
 
 type repository struct {
-	db     *sqlx.DB
-	logger Logger
+	db      *sqlx.DB
+	logger  Logger
+	dialect storage.Dialect
 }
 
 func NewRepository(db *sqlx.DB, logger Logger) Repository {
-	return &repository{
-		db:     db,
-		logger: logger,
+	r := &repository{
+		db:      db,
+		logger:  logger,
+		dialect: storage.DialectOf(db),
+	}
+
+	if r.dialect.SQLite() {
+		return &sqliteRepository{r}
 	}
+	return r
 }
 
 func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Group, error) {
-	query := `
-        SELECT id, project_id, file, line, message, first_seen_at, last_seen_at, counter, status 
-        FROM error_groups 
-        WHERE 1=1
-    `
+	args := statsArgs(time.Now())
+	args["limit"] = params.Limit
+	args["offset"] = params.Offset
 
-	args := map[string]interface{}{
-		"limit":  params.Limit,
-		"offset": params.Offset,
-	}
+	filters, args := r.applyFilters("", params.FilterParams, args)
 
-	query, args = applyFilters(query, params.FilterParams, args)
+	var query string
+	if column, ok := statsSortColumns[params.SortBy]; ok {
+		// Only the counts of the window of the project are read, through their bucket index
+		projectFilter := ""
+		if params.ProjectID != "" {
+			projectFilter = " AND project_id = :projectId"
+		}
+
+		query = `
+            SELECT ` + groupColumns + `, ` + statsColumns + `
+            FROM error_groups g
+        ` + windowStatsJoin(projectFilter) + `
+            WHERE 1=1 ` + filters + `
+            ORDER BY ` + column + ` ` + params.SortOrder + `, g.last_seen_at DESC, g.id DESC
+            LIMIT :limit OFFSET :offset
+        `
+	} else {
+		column, ok = storedSortColumns[params.SortBy]
+		if !ok {
+			column = storedSortColumns[SortByLastSeenAt]
+		}
 
-	query += " ORDER BY last_seen_at " + params.SortOrder
-	query += " LIMIT :limit OFFSET :offset"
+		// Page over the groups table first, so event counts are only computed for the returned page.
+		query = `
+            SELECT ` + groupColumns + `, ` + statsColumns + `
+            FROM (
+                SELECT id, project_id, file, line, message, first_seen_at, last_seen_at, counter, status, assignee_id,
+                    snoozed_at, snooze_until, snooze_counter, snooze_users
+                FROM error_groups
+                WHERE 1=1 ` + filters + `
+                ORDER BY ` + column + ` ` + params.SortOrder + `, id ` + params.SortOrder + `
+                LIMIT :limit OFFSET :offset
+            ) g
+        ` + r.statsJoin() + `
+            ORDER BY g.` + column + ` ` + params.SortOrder + `, g.id ` + params.SortOrder
+	}
 
 	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
@@ -65,7 +165,7 @@ func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Group,
 
 func (r *repository) Count(ctx context.Context, params FilterParams) (int, error) {
 	query := "SELECT COUNT(*) FROM error_groups WHERE 1=1"
-	query, args := applyFilters(query, params, make(map[string]interface{}))
+	query, args := r.applyFilters(query, params, make(map[string]interface{}))
 
 	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
@@ -86,21 +186,384 @@ func (r *repository) Count(ctx context.Context, params FilterParams) (int, error
 }
 
 func (r *repository) GetByID(ctx context.Context, id string) (*Group, error) {
-	const query = `SELECT id, project_id, file, line, message, first_seen_at, last_seen_at, counter, status 
-		FROM error_groups WHERE id = $1`
+	query := `
+        SELECT ` + groupColumns + `, ` + statsColumns + `
+        FROM error_groups g
+    ` + r.statsJoin() + `
+        WHERE g.id = :id
+    `
+
+	args := statsArgs(time.Now())
+	args["id"] = id
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
 
 	var entity Group
-	err := r.db.GetContext(ctx, &entity, query, id)
+	err = r.db.GetContext(ctx, &entity, query, namedArgs...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
-		return nil, fmt.Errorf("failed to get error by id: %w", err)
+		return nil, fmt.Errorf("failed to get error group by id: %w", err)
 	}
 	return &entity, nil
 }
 
-func applyFilters(baseQuery string, params FilterParams, args map[string]interface{}) (string, map[string]interface{}) {
+func (r *repository) UpdateStatus(ctx context.Context, id string, status Status) error {
+	const query = `UPDATE error_groups SET status = $1, snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL, snooze_users = NULL WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update error group status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdateAssignee(ctx context.Context, id string, assigneeID *string) error {
+	const query = `UPDATE error_groups SET assignee_id = $1 WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, assigneeID, id)
+	if err != nil {
+		if storage.IsForeignKeyViolation(err) {
+			return ErrAssigneeNotFound
+		}
+		return fmt.Errorf("failed to update error group assignee: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) Snooze(ctx context.Context, id string, snooze SnoozeConditions) error {
+	query := `
+        UPDATE error_groups
+        SET
+            status = 'snoozed',
+            snoozed_at = :snoozed_at,
+            snooze_until = :snooze_until,
+            snooze_counter = :snooze_counter,
+            snooze_users = :snooze_users
+        WHERE id = :id
+    `
+
+	query, args, err := r.db.BindNamed(query, struct {
+		SnoozeConditions
+		ID string `db:"id"`
+	}{snooze, id})
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to snooze error group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	// The users of the previous snoozes, counted apart, are left over when they ended otherwise than by users.
+	const usersQuery = `DELETE FROM error_group_snooze_ips WHERE group_id = $1 AND snoozed_at <> $2`
+	if _, err := r.db.ExecContext(ctx, usersQuery, id, snooze.SnoozedAt); err != nil {
+		return fmt.Errorf("failed to delete previous snooze users: %w", err)
+	}
+	return nil
+}
+
+// WakeExpired moves the groups whose snooze ended by time back to unresolved. Count and
+// user thresholds are checked when events are ingested.
+func (r *repository) WakeExpired(ctx context.Context, now int64) ([]*Group, error) {
+	query := `
+        UPDATE error_groups
+        SET status = 'unresolved', snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL, snooze_users = NULL
+        WHERE status = 'snoozed' AND snooze_until <= $1
+        RETURNING ` + returnedColumns
+
+	var groups []*Group
+	err := r.db.SelectContext(ctx, &groups, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wake snoozed error groups: %w", err)
+	}
+	return groups, nil
+}
+
+// GetIDs pages over the IDs of the groups matching the filters in ID order, starting after afterID.
+func (r *repository) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	query := "SELECT id FROM error_groups WHERE 1=1"
+	query, args := r.applyFilters(query, params, map[string]interface{}{"limit": limit})
+
+	if afterID != "" {
+		query += " AND id > :afterId"
+		args["afterId"] = afterID
+	}
+
+	query += " ORDER BY id LIMIT :limit"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var ids []string
+	err = r.db.SelectContext(ctx, &ids, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get error group ids: %w", err)
+	}
+	return ids, nil
+}
+
+// UpdateStatuses sets the status of the groups and returns the ones that changed.
+func (r *repository) UpdateStatuses(ctx context.Context, ids []string, status Status) ([]*Change, error) {
+	query := `
+        UPDATE error_groups g
+        SET status = CAST($1 AS error_groups_status), snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL, snooze_users = NULL
+        FROM (
+            SELECT id, status FROM error_groups
+            WHERE id = ANY($2) AND status <> CAST($1 AS error_groups_status)
+            FOR UPDATE
+        ) p
+        WHERE g.id = p.id
+        RETURNING ` + groupColumns + `, CAST(p.status AS TEXT) AS previous`
+
+	var changes []*Change
+	err := r.db.SelectContext(ctx, &changes, query, status, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update error group statuses: %w", err)
+	}
+	return changes, nil
+}
+
+// UpdateAssignees sets the assignee of the groups and returns the ones that changed.
+func (r *repository) UpdateAssignees(ctx context.Context, ids []string, assigneeID *string) ([]*Change, error) {
+	query := `
+        UPDATE error_groups g
+        SET assignee_id = CAST($1 AS UUID)
+        FROM (
+            SELECT id, assignee_id FROM error_groups
+            WHERE id = ANY($2) AND assignee_id IS DISTINCT FROM CAST($1 AS UUID)
+            FOR UPDATE
+        ) p
+        WHERE g.id = p.id
+        RETURNING ` + groupColumns + `, CAST(p.assignee_id AS TEXT) AS previous`
+
+	var changes []*Change
+	err := r.db.SelectContext(ctx, &changes, query, assigneeID, pq.Array(ids))
+	if err != nil {
+		if storage.IsForeignKeyViolation(err) {
+			return nil, ErrAssigneeNotFound
+		}
+		return nil, fmt.Errorf("failed to update error group assignees: %w", err)
+	}
+	return changes, nil
+}
+
+// DeleteByIDs deletes the groups along with their events and the payloads only they shared.
+func (r *repository) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, table := range []string{"error_counts_minute", "error_counts_hour"} {
+		countsQuery := `
+            DELETE FROM ` + table + `
+            WHERE (project_id, fingerprint) IN (
+                SELECT project_id, id FROM error_groups WHERE ` + r.dialect.In("id", "$1") + `
+            )
+        `
+		if _, err := tx.ExecContext(ctx, countsQuery, r.dialect.List(ids)); err != nil {
+			return 0, fmt.Errorf("failed to delete error group counts: %w", err)
+		}
+	}
+
+	payloadsQuery := `
+        SELECT DISTINCT payload_id FROM errors
+        WHERE ` + r.dialect.In("fingerprint", "$1") + ` AND payload_id IS NOT NULL
+    `
+	var payloadIDs []string
+	if err := tx.SelectContext(ctx, &payloadIDs, payloadsQuery, r.dialect.List(ids)); err != nil {
+		return 0, fmt.Errorf("failed to get error group payloads: %w", err)
+	}
+
+	eventsQuery := `DELETE FROM errors WHERE ` + r.dialect.In("fingerprint", "$1")
+	if _, err := tx.ExecContext(ctx, eventsQuery, r.dialect.List(ids)); err != nil {
+		return 0, fmt.Errorf("failed to delete error group events: %w", err)
+	}
+
+	// The payloads may be shared by the errors of other groups
+	if len(payloadIDs) > 0 {
+		unusedPayloadsQuery := `
+            DELETE FROM error_payloads
+            WHERE ` + r.dialect.In("id", "$1") + `
+                AND NOT EXISTS (SELECT 1 FROM errors e WHERE e.payload_id = error_payloads.id)
+        `
+		if _, err := tx.ExecContext(ctx, unusedPayloadsQuery, r.dialect.List(payloadIDs)); err != nil {
+			return 0, fmt.Errorf("failed to delete unused error payloads: %w", err)
+		}
+	}
+
+	groupsQuery := `DELETE FROM error_groups WHERE ` + r.dialect.In("id", "$1")
+	result, err := tx.ExecContext(ctx, groupsQuery, r.dialect.List(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete error groups: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// MergeInto moves the events of the source groups into the group, along with their counts,
+// comments and activity, and deletes the sources, which are returned. The new events of the
+// sources go to the group.
+func (r *repository) MergeInto(ctx context.Context, id string, sourceIDs []string) ([]*Group, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	groupsQuery := `SELECT ` + returnedColumns + ` FROM error_groups WHERE ` + r.dialect.In("id", "$1")
+	if !r.dialect.SQLite() {
+		groupsQuery += " FOR UPDATE"
+	}
+
+	var groups []*Group
+	if err := tx.SelectContext(ctx, &groups, groupsQuery, r.dialect.List(append([]string{id}, sourceIDs...))); err != nil {
+		return nil, fmt.Errorf("failed to get merged error groups: %w", err)
+	}
+
+	target, sources, err := mergedGroups(groups, id, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	moveQueries := []string{
+		`UPDATE errors SET fingerprint = $1 WHERE ` + r.dialect.In("fingerprint", "$2"),
+		`UPDATE group_comments SET group_id = $1 WHERE group_kind = 'error' AND ` + r.dialect.In("group_id", "$2"),
+		`UPDATE group_activities SET group_id = $1 WHERE group_kind = 'error' AND ` + r.dialect.In("group_id", "$2"),
+		`UPDATE error_group_merges SET group_id = $1 WHERE ` + r.dialect.In("group_id", "$2"),
+	}
+	for _, table := range []string{"error_counts_minute", "error_counts_hour"} {
+		moveQueries = append(moveQueries, `
+            INSERT INTO `+table+` (project_id, fingerprint, bucket, count)
+            SELECT project_id, $1, bucket, SUM(count) FROM `+table+`
+            WHERE `+r.dialect.In("fingerprint", "$2")+`
+            GROUP BY project_id, bucket
+            ON CONFLICT (project_id, fingerprint, bucket) DO UPDATE SET count = `+table+`.count + EXCLUDED.count
+        `)
+	}
+
+	for _, query := range moveQueries {
+		if _, err := tx.ExecContext(ctx, query, id, r.dialect.List(sourceIDs)); err != nil {
+			return nil, fmt.Errorf("failed to move merged error groups: %w", err)
+		}
+	}
+
+	for _, table := range []string{"error_counts_minute", "error_counts_hour"} {
+		countsQuery := `DELETE FROM ` + table + ` WHERE ` + r.dialect.In("fingerprint", "$1")
+		if _, err := tx.ExecContext(ctx, countsQuery, r.dialect.List(sourceIDs)); err != nil {
+			return nil, fmt.Errorf("failed to delete merged error group counts: %w", err)
+		}
+	}
+
+	const updateQuery = `UPDATE error_groups SET counter = $1, first_seen_at = $2, last_seen_at = $3 WHERE id = $4`
+	if _, err := tx.ExecContext(ctx, updateQuery, target.Counter, target.FirstSeenAt, target.LastSeenAt, id); err != nil {
+		return nil, fmt.Errorf("failed to update merged error group: %w", err)
+	}
+
+	deleteQuery := `DELETE FROM error_groups WHERE ` + r.dialect.In("id", "$1")
+	if _, err := tx.ExecContext(ctx, deleteQuery, r.dialect.List(sourceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to delete merged error groups: %w", err)
+	}
+
+	const redirectQuery = `
+        INSERT INTO error_group_merges (fingerprint, group_id) VALUES ($1, $2)
+        ON CONFLICT (fingerprint) DO UPDATE SET group_id = EXCLUDED.group_id
+    `
+	for _, source := range sources {
+		if _, err := tx.ExecContext(ctx, redirectQuery, source.ID, id); err != nil {
+			return nil, fmt.Errorf("failed to redirect merged error group: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sources, nil
+}
+
+// mergedGroups returns the group merged into, adding up the events of its sources and spanning
+// their times, along with the sources.
+func mergedGroups(groups []*Group, id string, sourceIDs []string) (*Group, []*Group, error) {
+	var target *Group
+	sources := make([]*Group, 0, len(sourceIDs))
+	for _, group := range groups {
+		if group.ID == id {
+			target = group
+		} else {
+			sources = append(sources, group)
+		}
+	}
+	if target == nil || len(sources) != len(sourceIDs) {
+		return nil, nil, ErrNotFound
+	}
+
+	for _, source := range sources {
+		if source.ProjectID != target.ProjectID {
+			return nil, nil, ErrInvalidMerge
+		}
+		target.Counter += source.Counter
+		target.FirstSeenAt = min(target.FirstSeenAt, source.FirstSeenAt)
+		target.LastSeenAt = max(target.LastSeenAt, source.LastSeenAt)
+	}
+	return target, sources, nil
+}
+
+func (r *repository) applyFilters(
+	baseQuery string,
+	params FilterParams,
+	args map[string]interface{},
+) (string, map[string]interface{}) {
 	query := baseQuery
 
 	if params.ProjectID != "" {
@@ -119,9 +582,85 @@ func applyFilters(baseQuery string, params FilterParams, args map[string]interfa
 	}
 
 	if params.Search != "" {
-		query += " AND message ILIKE :search"
-		args["search"] = "%" + params.Search + "%"
+		query += r.searchFilter(params, args)
+	}
+
+	if params.AssigneeID != "" {
+		query += " AND assignee_id = :assigneeId"
+		args["assigneeId"] = params.AssigneeID
+	}
+
+	if params.Unassigned {
+		query += " AND assignee_id IS NULL"
 	}
 
 	return query, args
 }
+
+// searchFilter matches the messages with the search, through the full-text index when asked for.
+// SQLite has no full-text index, the words are searched within the messages.
+func (r *repository) searchFilter(params FilterParams, args map[string]interface{}) string {
+	switch {
+	case params.SearchMode != query.SearchFullText:
+		args["search"] = "%" + params.Search + "%"
+		return " AND " + r.dialect.ILike("message", ":search")
+	case r.dialect.SQLite():
+		if condition := query.FullTextLike(params.Search, "message", args); condition != "" {
+			return " AND " + condition
+		}
+	default:
+		if tsquery := query.FullText(params.Search, args); tsquery != "" {
+			return " AND search_vector @@ " + tsquery
+		}
+	}
+	return ""
+}
+
+// windowStatsJoin adds up the counts of the window of every group at once, reading only the counts
+// of the projects matched by filter through their bucket index. Sorting by the counts joins them so
+// rather than counting each group of the project apart, and SQLite, having no lateral joins, always
+// does. The groups without events get no counts.
+func windowStatsJoin(filter string) string {
+	return `
+        LEFT JOIN (
+            SELECT
+                c.fingerprint,
+                SUM(c.last_hour) AS events_last_hour,
+                SUM(c.last_day) AS events_last_day,
+                SUM(c.baseline) AS events_baseline
+            FROM (
+                SELECT fingerprint, count AS last_hour, 0 AS last_day, 0 AS baseline
+                FROM error_counts_minute
+                WHERE bucket >= :hourAgo` + filter + `
+                UNION ALL
+                SELECT
+                    fingerprint,
+                    0,
+                    CASE WHEN bucket >= :dayAgo THEN count ELSE 0 END,
+                    CASE WHEN bucket < :dayAgo THEN count ELSE 0 END
+                FROM error_counts_hour
+                WHERE bucket >= :baselineFrom` + filter + `
+            ) c
+            GROUP BY c.fingerprint
+        ) stats ON stats.fingerprint = g.id
+    `
+}
+
+func (r *repository) statsJoin() string {
+	if r.dialect.SQLite() {
+		return windowStatsJoin("")
+	}
+	return statsJoin
+}
+
+// statsArgs starts the last hour at its minute and the last day and the baseline at their
+// hour, as the counts they are added up from.
+func statsArgs(now time.Time) map[string]interface{} {
+	dayAgo := storage.Bucket(now.Add(-24*time.Hour).UnixMilli(), time.Hour)
+
+	return map[string]interface{}{
+		"hourAgo":      storage.Bucket(now.Add(-time.Hour).UnixMilli(), time.Minute),
+		"dayAgo":       dayAgo,
+		"baselineFrom": dayAgo - (trendBaselineDays * 24 * time.Hour).Milliseconds(),
+	}
+}
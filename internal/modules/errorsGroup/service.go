@@ -1,21 +1,40 @@
 package errorsgroup
 
-import "context"
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/middleware"
+)
 
 type Service interface {
 	GetByID(ctx context.Context, id string) (*Entity, error)
 	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	UpdateStatus(ctx context.Context, id string, req *UpdateStatus) (*Entity, error)
+	Assign(ctx context.Context, id string, req *Assign) (*Entity, error)
+	Snooze(ctx context.Context, id string, req *Snooze) (*Entity, error)
+	Merge(ctx context.Context, id string, req *Merge) (*Entity, error)
+	WakeExpired(ctx context.Context, now time.Time) (int, error)
+	Count(ctx context.Context, params FilterParams) (int, error)
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	BulkUpdateStatus(ctx context.Context, ids []string, status Status, actorID string) (int, error)
+	BulkAssign(ctx context.Context, ids []string, assigneeID *string, actorID string) (int, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
 }
 
 type service struct {
-	repo   Repository
-	logger Logger
+	repo      Repository
+	logger    Logger
+	publisher Publisher
 }
 
-func NewService(repo Repository, logger Logger) Service {
+func NewService(repo Repository, logger Logger, publisher Publisher) Service {
 	return &service{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		logger:    logger,
+		publisher: publisher,
 	}
 }
 
@@ -45,8 +64,253 @@ func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, i
 	return responses, total, nil
 }
 
+func (s *service) UpdateStatus(ctx context.Context, id string, req *UpdateStatus) (*Entity, error) {
+	group, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := group.Status
+	group.Status = Status(req.Status)
+
+	if previous == group.Status {
+		return toResponse(group), nil
+	}
+
+	if err := s.repo.UpdateStatus(ctx, id, group.Status); err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, events.Event{
+		Type:      events.TypeGroupStatusChanged,
+		ProjectID: group.ProjectID,
+		GroupID:   group.ID,
+		GroupKind: events.GroupKindError,
+		Message:   "status changed from " + string(previous) + " to " + string(group.Status),
+		Time:      time.Now().UnixMilli(),
+		ActorID:   actorID(ctx),
+		Change:    &events.Change{Field: "status", From: string(previous), To: string(group.Status)},
+		Payload:   toResponse(group),
+	})
+
+	return toResponse(group), nil
+}
+
+func (s *service) Assign(ctx context.Context, id string, req *Assign) (*Entity, error) {
+	group, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := group.AssigneeID
+	group.AssigneeID = req.AssigneeID
+
+	if valueOf(previous) == valueOf(group.AssigneeID) {
+		return toResponse(group), nil
+	}
+
+	if err := s.repo.UpdateAssignee(ctx, id, group.AssigneeID); err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, events.Event{
+		Type:      events.TypeGroupAssigned,
+		ProjectID: group.ProjectID,
+		GroupID:   group.ID,
+		GroupKind: events.GroupKindError,
+		Message:   group.Message,
+		Time:      time.Now().UnixMilli(),
+		ActorID:   actorID(ctx),
+		Change:    &events.Change{Field: "assignee", From: valueOf(previous), To: valueOf(group.AssigneeID)},
+		Payload:   toResponse(group),
+	})
+
+	return toResponse(group), nil
+}
+
+func (s *service) Snooze(ctx context.Context, id string, req *Snooze) (*Entity, error) {
+	group, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	conditions := SnoozeConditions{SnoozedAt: &now}
+	if req.Duration > 0 {
+		until := now + req.Duration
+		conditions.Until = &until
+	}
+	if req.Occurrences > 0 {
+		counter := group.Counter + req.Occurrences
+		conditions.Counter = &counter
+	}
+	if req.Users > 0 {
+		users := req.Users
+		conditions.Users = &users
+	}
+
+	if err := s.repo.Snooze(ctx, id, conditions); err != nil {
+		return nil, err
+	}
+
+	previous := group.Status
+	group.Status = StatusSnoozed
+	group.SnoozeConditions = conditions
+
+	if previous != StatusSnoozed {
+		s.publisher.Publish(ctx, events.Event{
+			Type:      events.TypeGroupStatusChanged,
+			ProjectID: group.ProjectID,
+			GroupID:   group.ID,
+			GroupKind: events.GroupKindError,
+			Message:   "status changed from " + string(previous) + " to " + string(group.Status),
+			Time:      time.Now().UnixMilli(),
+			ActorID:   actorID(ctx),
+			Change:    &events.Change{Field: "status", From: string(previous), To: string(group.Status)},
+			Payload:   toResponse(group),
+		})
+	}
+
+	return toResponse(group), nil
+}
+
+// WakeExpired ends the snoozes whose time elapsed and returns the number of woken groups.
+// Merge merges the groups of the request into the group, recording the merge of each in its activity.
+func (s *service) Merge(ctx context.Context, id string, req *Merge) (*Entity, error) {
+	sourceIDs := make([]string, 0, len(req.GroupIDs))
+	for _, groupID := range req.GroupIDs {
+		if groupID == id {
+			return nil, ErrInvalidMerge
+		}
+		if !slices.Contains(sourceIDs, groupID) {
+			sourceIDs = append(sourceIDs, groupID)
+		}
+	}
+
+	sources, err := s.repo.MergeInto(ctx, id, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		s.publisher.Publish(ctx, events.Event{
+			Type:      events.TypeGroupMerged,
+			ProjectID: group.ProjectID,
+			GroupID:   group.ID,
+			GroupKind: events.GroupKindError,
+			Message:   source.Message,
+			Time:      time.Now().UnixMilli(),
+			ActorID:   actorID(ctx),
+			Change:    &events.Change{Field: "group", From: source.ID, To: group.ID},
+			Payload:   toResponse(group),
+		})
+	}
+
+	return toResponse(group), nil
+}
+
+func (s *service) WakeExpired(ctx context.Context, now time.Time) (int, error) {
+	groups, err := s.repo.WakeExpired(ctx, now.Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, group := range groups {
+		s.publisher.Publish(ctx, events.Event{
+			Type:      events.TypeGroupUnsnoozed,
+			ProjectID: group.ProjectID,
+			GroupID:   group.ID,
+			GroupKind: events.GroupKindError,
+			Message:   group.Message,
+			Time:      now.UnixMilli(),
+			Change:    &events.Change{Field: "status", From: string(StatusSnoozed), To: string(StatusUnresolved)},
+			Payload:   toResponse(group),
+		})
+	}
+
+	return len(groups), nil
+}
+
+func (s *service) Count(ctx context.Context, params FilterParams) (int, error) {
+	return s.repo.Count(ctx, params)
+}
+
+func (s *service) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	return s.repo.GetIDs(ctx, params, afterID, limit)
+}
+
+// BulkUpdateStatus sets the status of the groups on behalf of actorID and returns the number of changed groups.
+func (s *service) BulkUpdateStatus(ctx context.Context, ids []string, status Status, actorID string) (int, error) {
+	changes, err := s.repo.UpdateStatuses(ctx, ids, status)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, change := range changes {
+		previous := valueOf(change.Previous)
+		s.publisher.Publish(ctx, events.Event{
+			Type:      events.TypeGroupStatusChanged,
+			ProjectID: change.ProjectID,
+			GroupID:   change.ID,
+			GroupKind: events.GroupKindError,
+			Message:   "status changed from " + previous + " to " + string(status),
+			Time:      time.Now().UnixMilli(),
+			ActorID:   actorID,
+			Change:    &events.Change{Field: "status", From: previous, To: string(status)},
+			Payload:   toResponse(&change.Group),
+		})
+	}
+
+	return len(changes), nil
+}
+
+// BulkAssign assigns the groups on behalf of actorID and returns the number of changed groups.
+func (s *service) BulkAssign(ctx context.Context, ids []string, assigneeID *string, actorID string) (int, error) {
+	changes, err := s.repo.UpdateAssignees(ctx, ids, assigneeID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, change := range changes {
+		s.publisher.Publish(ctx, events.Event{
+			Type:      events.TypeGroupAssigned,
+			ProjectID: change.ProjectID,
+			GroupID:   change.ID,
+			GroupKind: events.GroupKindError,
+			Message:   change.Message,
+			Time:      time.Now().UnixMilli(),
+			ActorID:   actorID,
+			Change:    &events.Change{Field: "assignee", From: valueOf(change.Previous), To: valueOf(assigneeID)},
+			Payload:   toResponse(&change.Group),
+		})
+	}
+
+	return len(changes), nil
+}
+
+func (s *service) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	return s.repo.DeleteByIDs(ctx, ids)
+}
+
+func actorID(ctx context.Context) string {
+	userID, _ := middleware.GetUserID(ctx)
+	return userID
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func toResponse(g *Group) *Entity {
-	return &Entity{
+	entity := &Entity{
 		ID:          g.ID,
 		Message:     g.Message,
 		File:        g.File,
@@ -54,5 +318,22 @@ func toResponse(g *Group) *Entity {
 		FirstSeenAt: g.FirstSeenAt,
 		LastSeenAt:  g.LastSeenAt,
 		Counter:     g.Counter,
+		Status:      string(g.Status),
+		AssigneeID:  g.AssigneeID,
+
+		EventsLastHour: g.EventsLastHour,
+		EventsLastDay:  g.EventsLastDay,
+		Trend:          g.Trend,
 	}
+
+	if g.Status == StatusSnoozed && g.SnoozedAt != nil {
+		entity.Snooze = &SnoozeEntity{
+			SnoozedAt: *g.SnoozedAt,
+			Until:     g.SnoozeConditions.Until,
+			Counter:   g.SnoozeConditions.Counter,
+			Users:     g.SnoozeConditions.Users,
+		}
+	}
+
+	return entity
 }
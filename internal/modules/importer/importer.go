@@ -0,0 +1,250 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/internal/modules/log"
+	v "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const defaultProgressEvery = 1000
+
+var (
+	ErrInvalidRecord = stdErrors.New("invalid record")
+	ErrKindRequired  = stdErrors.New("the kind of the records is required with the ndjson format")
+	// ErrMalformedInput stops an import, the records following a JSON syntax error cannot be told apart
+	ErrMalformedInput = stdErrors.New("malformed input")
+)
+
+// recordNamespace derives the IDs of the records without one from their content, so importing
+// them again finds the same IDs.
+var recordNamespace = uuid.MustParse("5b0f7c8e-3d61-4a8f-9f3e-2c7a1d9e4b60")
+
+// Importer imports the history of another system. Every event keeps its ID, or one derived from
+// its content, so an import can be run again after a failure without counting anything twice.
+type Importer struct {
+	errors   ErrorService
+	logs     LogService
+	logger   Logger
+	validate *v.Validate
+	config   Config
+}
+
+// errorRecord and logRecord are the errors and logs of an NDJSON dump.
+type errorRecord struct {
+	ID string `json:"id"`
+	errors.Create
+}
+
+type logRecord struct {
+	ID string `json:"id"`
+	log.Create
+}
+
+func NewImporter(errorService ErrorService, logService LogService, logger Logger, config Config) *Importer {
+	if config.ProgressEvery <= 0 {
+		config.ProgressEvery = defaultProgressEvery
+	}
+
+	return &Importer{
+		errors:   errorService,
+		logs:     logService,
+		logger:   logger,
+		validate: v.New(),
+		config:   config,
+	}
+}
+
+// Import reads the records of r, a JSON array or a stream of JSON objects, into the project.
+// Invalid records are skipped and counted, reading stops on malformed JSON or a storage failure.
+func (i *Importer) Import(ctx context.Context, r io.Reader, params Params) (*Report, error) {
+	if err := i.validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if params.Format == FormatNDJSON && params.Kind == "" {
+		return nil, ErrKindRequired
+	}
+
+	records, err := newRecordReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for {
+		raw, err := records.Next()
+		if stdErrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("%w: record %d: %v", ErrMalformedInput, report.Read+1, err)
+		}
+		report.Read++
+
+		created, err := i.importRecord(ctx, raw, params)
+		switch {
+		case stdErrors.Is(err, ErrInvalidRecord), stdErrors.Is(err, log.ErrInvalidLogLevel):
+			report.Failed++
+			i.logger.Warn(fmt.Sprintf("skipped record %d: %v", report.Read, err))
+		case err != nil:
+			return report, fmt.Errorf("failed to import record %d: %w", report.Read, err)
+		case created:
+			report.Imported++
+		default:
+			report.Duplicates++
+		}
+
+		if report.Read%i.config.ProgressEvery == 0 {
+			i.logger.Info(fmt.Sprintf("import progress: %s", report))
+		}
+	}
+
+	i.logger.Info(fmt.Sprintf("import done: %s", report))
+	return report, nil
+}
+
+func (i *Importer) importRecord(ctx context.Context, raw json.RawMessage, params Params) (bool, error) {
+	if params.Format == FormatSentry {
+		return i.importSentryEvent(ctx, raw, params.ProjectID)
+	}
+
+	if params.Kind == KindLogs {
+		var record logRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+		if err := i.validate.Struct(record.Create); err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+
+		id, err := recordID(record.ID, params.ProjectID, raw)
+		if err != nil {
+			return false, err
+		}
+
+		record.ProjectID = params.ProjectID
+		return i.logs.Import(ctx, &log.Import{Create: record.Create, ID: id})
+	}
+
+	var record errorRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	if err := i.validate.Struct(record.Create); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+
+	id, err := recordID(record.ID, params.ProjectID, raw)
+	if err != nil {
+		return false, err
+	}
+
+	record.ProjectID = params.ProjectID
+	return i.errors.Import(ctx, &errors.Import{Create: record.Create, ID: id})
+}
+
+func (i *Importer) importSentryEvent(ctx context.Context, raw json.RawMessage, projectID string) (bool, error) {
+	var event sentryEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+
+	id, err := recordID(event.EventID, projectID, raw)
+	if err != nil {
+		return false, err
+	}
+
+	if event.hasException() {
+		req, err := event.toError()
+		if err != nil {
+			return false, err
+		}
+		req.ProjectID = projectID
+		return i.errors.Import(ctx, &errors.Import{Create: *req, ID: id})
+	}
+
+	req, err := event.toLog()
+	if err != nil {
+		return false, err
+	}
+	req.ProjectID = projectID
+	return i.logs.Import(ctx, &log.Import{Create: *req, ID: id})
+}
+
+// recordID returns the ID of a record, derived from the project and the record when it has none.
+func recordID(id string, projectID string, raw json.RawMessage) (string, error) {
+	if id == "" {
+		return uuid.NewSHA1(recordNamespace, append([]byte(projectID+":"), raw...)).String(), nil
+	}
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("%w: id %q is not a UUID", ErrInvalidRecord, id)
+	}
+	return parsed.String(), nil
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf(
+		"%d records read, %d imported, %d duplicates, %d failed", r.Read, r.Imported, r.Duplicates, r.Failed,
+	)
+}
+
+// recordReader reads the records of a JSON array, or of JSON objects following each other as in NDJSON.
+type recordReader struct {
+	decoder *json.Decoder
+	array   bool
+}
+
+func newRecordReader(r io.Reader) (*recordReader, error) {
+	buffered := bufio.NewReader(r)
+
+	array := false
+	for {
+		b, err := buffered.ReadByte()
+		if stdErrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read records: %w", err)
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+
+		array = b == '['
+		if err := buffered.UnreadByte(); err != nil {
+			return nil, fmt.Errorf("failed to read records: %w", err)
+		}
+		break
+	}
+
+	decoder := json.NewDecoder(buffered)
+	if array {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedInput, err)
+		}
+	}
+
+	return &recordReader{decoder: decoder, array: array}, nil
+}
+
+// Next returns the next record, or io.EOF after the last one.
+func (r *recordReader) Next() (json.RawMessage, error) {
+	if r.array && !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
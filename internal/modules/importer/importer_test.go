@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/internal/modules/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string) {}
+func (nopLogger) Info(string)  {}
+func (nopLogger) Warn(string)  {}
+func (nopLogger) Error(string) {}
+
+// memoryServices keeps the imported events once per ID, as the repositories do.
+type memoryServices struct {
+	errors map[string]*errors.Import
+	logs   map[string]*log.Import
+}
+
+func newMemoryServices() *memoryServices {
+	return &memoryServices{errors: map[string]*errors.Import{}, logs: map[string]*log.Import{}}
+}
+
+func (s *memoryServices) importError(_ context.Context, req *errors.Import) (bool, error) {
+	if _, ok := s.errors[req.ID]; ok {
+		return false, nil
+	}
+	s.errors[req.ID] = req
+	return true, nil
+}
+
+func (s *memoryServices) importLog(_ context.Context, req *log.Import) (bool, error) {
+	if _, ok := s.logs[req.ID]; ok {
+		return false, nil
+	}
+	s.logs[req.ID] = req
+	return true, nil
+}
+
+type errorServiceFunc func(ctx context.Context, req *errors.Import) (bool, error)
+
+func (f errorServiceFunc) Import(ctx context.Context, req *errors.Import) (bool, error) {
+	return f(ctx, req)
+}
+
+type logServiceFunc func(ctx context.Context, req *log.Import) (bool, error)
+
+func (f logServiceFunc) Import(ctx context.Context, req *log.Import) (bool, error) {
+	return f(ctx, req)
+}
+
+func newTestImporter(services *memoryServices) *Importer {
+	return NewImporter(
+		errorServiceFunc(services.importError), logServiceFunc(services.importLog), nopLogger{}, Config{},
+	)
+}
+
+func TestImportNDJSONIsIdempotent(t *testing.T) {
+	dump := `{"id":"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c","level":"INFO","message":"started","time":1704067200000}
+{"level":"WARN","message":"slow query","context":{"ms":1200},"time":1704067260000}
+{"level":"LOUD","message":"invalid level","time":1704067320000}
+`
+
+	services := newMemoryServices()
+	importer := newTestImporter(services)
+	params := Params{ProjectID: "p1", Format: FormatNDJSON, Kind: KindLogs}
+
+	report, err := importer.Import(context.Background(), strings.NewReader(dump), params)
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 3, Imported: 2, Failed: 1}, *report)
+
+	started := services.logs["a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"]
+	require.NotNil(t, started)
+	assert.Equal(t, "p1", started.ProjectID)
+	assert.Equal(t, int64(1704067200000), started.Time)
+
+	// The records without ID get the same derived ID again
+	report, err = importer.Import(context.Background(), strings.NewReader(dump), params)
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 3, Duplicates: 2, Failed: 1}, *report)
+	assert.Len(t, services.logs, 2)
+}
+
+func TestImportRequiresKindOfNDJSON(t *testing.T) {
+	_, err := newTestImporter(newMemoryServices()).Import(
+		context.Background(), strings.NewReader(""), Params{ProjectID: "p1", Format: FormatNDJSON},
+	)
+	assert.ErrorIs(t, err, ErrKindRequired)
+}
+
+func TestImportSentryEvents(t *testing.T) {
+	events := `[
+		{
+			"event_id": "9a1c2b3d4e5f40718293a4b5c6d7e8f9",
+			"timestamp": 1704067200.25,
+			"level": "error",
+			"exception": {"values": [{
+				"type": "ZeroDivisionError",
+				"value": "division by zero",
+				"stacktrace": {"frames": [
+					{"filename": "app.py", "abs_path": "/srv/app.py", "function": "main", "lineno": 10, "in_app": true},
+					{"filename": "calc.py", "abs_path": "/srv/calc.py", "function": "divide", "lineno": 42, "in_app": true},
+					{"filename": "lib.py", "function": "wrap", "lineno": 7, "in_app": false}
+				]}
+			}]},
+			"request": {
+				"url": "https://example.com/divide",
+				"method": "post",
+				"query_string": "a=1&b=0",
+				"headers": [["Content-Type", "application/json"]],
+				"cookies": "session=abc"
+			},
+			"user": {"ip_address": "192.168.1.1"},
+			"tags": {"release": "1.2.0"}
+		},
+		{
+			"event_id": "0b1c2d3e4f5a46b7c8d9e0f1a2b3c4d5",
+			"timestamp": "2024-01-01T00:01:00Z",
+			"level": "warning",
+			"logentry": {"formatted": "cache miss for user 42"}
+		}
+	]`
+
+	services := newMemoryServices()
+	report, err := newTestImporter(services).Import(
+		context.Background(), strings.NewReader(events), Params{ProjectID: "p1", Format: FormatSentry},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, Report{Read: 2, Imported: 2}, *report)
+
+	imported := services.errors["9a1c2b3d-4e5f-4071-8293-a4b5c6d7e8f9"]
+	require.NotNil(t, imported)
+	assert.Equal(t, "ZeroDivisionError: division by zero", imported.Message)
+	assert.Equal(t, int64(1704067200250), imported.Time)
+	assert.Equal(t, "/srv/calc.py", imported.File)
+	assert.Equal(t, 42, imported.Line)
+	assert.Equal(t, "POST", *imported.Method)
+	assert.Equal(t, "192.168.1.1", *imported.IP)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "0"}, *imported.QueryParams)
+	assert.Equal(t, map[string]interface{}{"Content-Type": "application/json"}, *imported.Headers)
+	assert.Equal(t, map[string]interface{}{"session": "abc"}, *imported.Cookies)
+
+	stacktrace, err := json.Marshal(*imported.Stacktrace)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"file": "lib.py", "line": 7, "function": "wrap"},
+		{"file": "/srv/calc.py", "line": 42, "function": "divide"},
+		{"file": "/srv/app.py", "line": 10, "function": "main"}
+	]`, string(stacktrace))
+
+	message := services.logs["0b1c2d3e-4f5a-46b7-c8d9-e0f1a2b3c4d5"]
+	require.NotNil(t, message)
+	assert.Equal(t, "WARN", message.Level)
+	assert.Equal(t, "cache miss for user 42", message.Message)
+	assert.Equal(t, int64(1704067260000), message.Time)
+}
+
+func TestImportStopsOnMalformedInput(t *testing.T) {
+	services := newMemoryServices()
+	report, err := newTestImporter(services).Import(
+		context.Background(),
+		strings.NewReader(`{"level":"INFO","message":"ok","time":1704067200000}`+"\n{\"level\":"),
+		Params{ProjectID: "p1", Format: FormatNDJSON, Kind: KindLogs},
+	)
+	assert.ErrorIs(t, err, ErrMalformedInput)
+	assert.Equal(t, 1, report.Imported)
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"context"
+	"io"
+
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/internal/modules/log"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Service interface {
+	Import(ctx context.Context, r io.Reader, params Params) (*Report, error)
+}
+
+type ErrorService interface {
+	Import(ctx context.Context, req *errors.Import) (bool, error)
+}
+
+type LogService interface {
+	Import(ctx context.Context, req *log.Import) (bool, error)
+}
+
+type Format string
+
+const (
+	// FormatNDJSON reads errors or logs as exported by FuckBug, one JSON object per line
+	FormatNDJSON Format = "ndjson"
+	// FormatSentry reads Sentry events, the ones with an exception becoming errors and the others logs
+	FormatSentry Format = "sentry"
+)
+
+type Kind string
+
+const (
+	KindErrors Kind = "errors"
+	KindLogs   Kind = "logs"
+)
+
+type Config struct {
+	// ProgressEvery is the number of records read between two progress reports
+	ProgressEvery int
+}
+
+type Params struct {
+	ProjectID string `validate:"required"`
+	Format    Format `validate:"required,oneof=ndjson sentry"`
+	// Kind tells what the records of an NDJSON dump are, Sentry events are told apart on their own
+	Kind Kind `validate:"omitempty,oneof=errors logs"`
+}
+
+type Report struct {
+	Read     int `json:"read" example:"1200"`
+	Imported int `json:"imported" example:"1150"`
+	// Duplicates were imported before and skipped
+	Duplicates int `json:"duplicates" example:"40"`
+	// Failed records are invalid, they are skipped
+	Failed int `json:"failed" example:"10"`
+}
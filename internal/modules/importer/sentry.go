@@ -0,0 +1,349 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/internal/modules/log"
+)
+
+// sentryLevels maps the levels of Sentry onto the log levels, an event without level being an error.
+var sentryLevels = map[string]string{
+	"":        string(log.LevelError),
+	"debug":   string(log.LevelDebug),
+	"info":    string(log.LevelInfo),
+	"warning": string(log.LevelWarn),
+	"error":   string(log.LevelError),
+	"fatal":   string(log.LevelFatal),
+}
+
+// sentryEvent is an event of a Sentry export, in the format of its event JSON.
+type sentryEvent struct {
+	EventID     string          `json:"event_id"`
+	Timestamp   sentryTime      `json:"timestamp"`
+	Level       string          `json:"level"`
+	Message     sentryMessage   `json:"message"`
+	LogEntry    sentryMessage   `json:"logentry"`
+	Culprit     string          `json:"culprit"`
+	Transaction string          `json:"transaction"`
+	Exception   *sentryValues   `json:"exception"`
+	Request     *sentryRequest  `json:"request"`
+	User        *sentryUser     `json:"user"`
+	Tags        json.RawMessage `json:"tags"`
+	Extra       json.RawMessage `json:"extra"`
+	Contexts    json.RawMessage `json:"contexts"`
+}
+
+type sentryValues struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace *struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryFrame struct {
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Function string `json:"function"`
+	LineNo   int    `json:"lineno"`
+	InApp    *bool  `json:"in_app"`
+}
+
+type sentryRequest struct {
+	URL         string          `json:"url"`
+	Method      string          `json:"method"`
+	Headers     json.RawMessage `json:"headers"`
+	QueryString json.RawMessage `json:"query_string"`
+	Data        json.RawMessage `json:"data"`
+	Cookies     json.RawMessage `json:"cookies"`
+	Env         json.RawMessage `json:"env"`
+}
+
+type sentryUser struct {
+	IPAddress string `json:"ip_address"`
+}
+
+// stackFrame is a frame of the stack traces of imported errors.
+type stackFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+// sentryTime is a time in seconds, as a number or an ISO 8601 string.
+type sentryTime struct {
+	time.Time
+}
+
+func (t *sentryTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		t.Time = time.UnixMilli(int64(math.Round(seconds * 1000)))
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp %q", value)
+}
+
+// sentryMessage is a message, as a string or an object with the formatted message.
+type sentryMessage string
+
+func (m *sentryMessage) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*m = sentryMessage(value)
+		return nil
+	}
+
+	var object struct {
+		Formatted string `json:"formatted"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil
+	}
+
+	*m = sentryMessage(object.Formatted)
+	if *m == "" {
+		*m = sentryMessage(object.Message)
+	}
+	return nil
+}
+
+func (e *sentryEvent) hasException() bool {
+	return e.Exception != nil && len(e.Exception.Values) > 0
+}
+
+// toError maps an event with an exception. The last exception is the one raised, its innermost
+// frame is the file and line of the error.
+func (e *sentryEvent) toError() (*errors.Create, error) {
+	if e.Timestamp.IsZero() {
+		return nil, fmt.Errorf("%w: event %s has no timestamp", ErrInvalidRecord, e.EventID)
+	}
+
+	exception := e.Exception.Values[len(e.Exception.Values)-1]
+
+	message := exception.Value
+	if exception.Type != "" {
+		message = strings.TrimSuffix(exception.Type+": "+exception.Value, ": ")
+	}
+	if message == "" {
+		message = e.message()
+	}
+	if message == "" {
+		return nil, fmt.Errorf("%w: event %s has no message", ErrInvalidRecord, e.EventID)
+	}
+
+	req := &errors.Create{
+		Time:    e.Timestamp.UnixMilli(),
+		Message: message,
+		File:    e.Culprit,
+		Context: e.context(),
+	}
+
+	var frames []sentryFrame
+	if exception.Stacktrace != nil {
+		frames = exception.Stacktrace.Frames
+	}
+
+	// Sentry lists the frames from the outermost call, they are stored from the innermost one
+	stacktrace := make([]stackFrame, 0, len(frames))
+	for i := len(frames) - 1; i >= 0; i-- {
+		stacktrace = append(stacktrace, stackFrame{
+			File:     frames[i].file(),
+			Line:     frames[i].LineNo,
+			Function: frames[i].Function,
+		})
+	}
+	var trace interface{} = stacktrace
+	req.Stacktrace = &trace
+
+	if frame := crashFrame(frames); frame != nil {
+		req.File = frame.file()
+		req.Line = frame.LineNo
+	}
+
+	if e.Request != nil {
+		if e.Request.URL != "" {
+			req.URL = &e.Request.URL
+		}
+		if e.Request.Method != "" {
+			method := strings.ToUpper(e.Request.Method)
+			req.Method = &method
+		}
+		req.Headers = sentryPairs(e.Request.Headers, nil)
+		req.QueryParams = sentryPairs(e.Request.QueryString, url.ParseQuery)
+		req.BodyParams = sentryPairs(e.Request.Data, nil)
+		req.Cookies = sentryPairs(e.Request.Cookies, parseCookies)
+		req.Env = sentryPairs(e.Request.Env, nil)
+	}
+
+	if e.User != nil && e.User.IPAddress != "" {
+		req.IP = &e.User.IPAddress
+	}
+
+	return req, nil
+}
+
+// toLog maps an event without exception, such as a captured message.
+func (e *sentryEvent) toLog() (*log.Create, error) {
+	if e.Timestamp.IsZero() {
+		return nil, fmt.Errorf("%w: event %s has no timestamp", ErrInvalidRecord, e.EventID)
+	}
+
+	message := e.message()
+	if message == "" {
+		return nil, fmt.Errorf("%w: event %s has no message", ErrInvalidRecord, e.EventID)
+	}
+
+	level, ok := sentryLevels[e.Level]
+	if !ok {
+		return nil, fmt.Errorf("%w: event %s has an unknown level %q", ErrInvalidRecord, e.EventID, e.Level)
+	}
+
+	return &log.Create{
+		Time:    e.Timestamp.UnixMilli(),
+		Level:   level,
+		Message: message,
+		Context: e.context(),
+	}, nil
+}
+
+func (e *sentryEvent) message() string {
+	if e.LogEntry != "" {
+		return string(e.LogEntry)
+	}
+	if e.Message != "" {
+		return string(e.Message)
+	}
+	return e.Transaction
+}
+
+// context keeps the tags, extra data and contexts of the event.
+func (e *sentryEvent) context() *interface{} {
+	context := map[string]json.RawMessage{}
+	for key, value := range map[string]json.RawMessage{"tags": e.Tags, "extra": e.Extra, "contexts": e.Contexts} {
+		if len(value) > 0 && !bytes.Equal(value, []byte("null")) {
+			context[key] = value
+		}
+	}
+
+	if len(context) == 0 {
+		return nil
+	}
+
+	var value interface{} = context
+	return &value
+}
+
+func (f *sentryFrame) file() string {
+	if f.AbsPath != "" {
+		return f.AbsPath
+	}
+	return f.Filename
+}
+
+// crashFrame returns the innermost frame of the application, or the innermost frame when none is
+// marked as such.
+func crashFrame(frames []sentryFrame) *sentryFrame {
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].InApp != nil && *frames[i].InApp {
+			return &frames[i]
+		}
+	}
+	if len(frames) > 0 {
+		return &frames[len(frames)-1]
+	}
+	return nil
+}
+
+// sentryPairs reads the request fields Sentry stores as an object, a list of [key, value] pairs, or
+// a string parsed by parse. Other values are kept whole under a raw key.
+func sentryPairs(data json.RawMessage, parse func(string) (url.Values, error)) *map[string]interface{} {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err == nil {
+		return &object
+	}
+
+	result := map[string]interface{}{}
+
+	var pairs [][]interface{}
+	if err := json.Unmarshal(data, &pairs); err == nil {
+		for _, pair := range pairs {
+			if len(pair) == 2 {
+				result[fmt.Sprint(pair[0])] = pair[1]
+			}
+		}
+		return &result
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+
+	text, ok := value.(string)
+	if !ok || parse == nil {
+		result["raw"] = value
+		return &result
+	}
+
+	values, err := parse(text)
+	if err != nil {
+		result["raw"] = text
+		return &result
+	}
+
+	for key, items := range values {
+		if len(items) == 1 {
+			result[key] = items[0]
+		} else {
+			result[key] = items
+		}
+	}
+	return &result
+}
+
+func parseCookies(value string) (url.Values, error) {
+	cookies, err := http.ParseCookie(value)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	for _, cookie := range cookies {
+		values.Add(cookie.Name, cookie.Value)
+	}
+	return values, nil
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// The logs are counted by group in the buckets of a minute and of an hour as they are stored.
+// The stats, the histograms and the group trends add the counts up rather than scanning the logs.
+const (
+	countsByMinute = "log_counts_minute"
+	countsByHour   = "log_counts_hour"
+)
+
+var countResolutions = []time.Duration{time.Minute, time.Hour}
+
+// countedLog is the part of a log its counts depend on. The level of a group is part of its
+// fingerprint, so the counts of a group all have the same level.
+type countedLog struct {
+	ProjectID   string `db:"project_id"`
+	Fingerprint string `db:"fingerprint"`
+	Level       Level  `db:"level"`
+	Time        int64  `db:"time"`
+}
+
+// countedLogColumns selects a countedLog, logs stored before their fingerprint having none.
+const countedLogColumns = "project_id, COALESCE(fingerprint, '') AS fingerprint, level, time"
+
+type countKey struct {
+	projectID   string
+	fingerprint string
+	level       Level
+	bucket      int64
+}
+
+func countsTable(resolution time.Duration) string {
+	if resolution == time.Hour {
+		return countsByHour
+	}
+	return countsByMinute
+}
+
+// countLogs adds delta to the counts of the buckets of the logs, and deletes the buckets left
+// empty. Logs stored before their fingerprint was introduced belong to no group and are not
+// counted. The counts are updated in the same order by every transaction, which cannot deadlock
+// on them.
+func countLogs(ctx context.Context, tx *sqlx.Tx, logs []countedLog, delta int) error {
+	for _, resolution := range countResolutions {
+		counts := make(map[countKey]int)
+		for _, l := range logs {
+			if l.Fingerprint == "" {
+				continue
+			}
+			counts[countKey{l.ProjectID, l.Fingerprint, l.Level, storage.Bucket(l.Time, resolution)}] += delta
+		}
+
+		table := countsTable(resolution)
+		query := `
+            INSERT INTO ` + table + ` (count, project_id, fingerprint, level, bucket)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (project_id, fingerprint, bucket) DO UPDATE SET count = ` + table + `.count + EXCLUDED.count
+        `
+		if delta < 0 {
+			// The logs taken off were counted, their buckets exist
+			query = `UPDATE ` + table + ` SET count = count + $1 WHERE project_id = $2 AND fingerprint = $3 AND level = $4 AND bucket = $5`
+		}
+
+		for _, key := range slices.SortedFunc(maps.Keys(counts), compareCountKeys) {
+			_, err := tx.ExecContext(ctx, query, counts[key], key.projectID, key.fingerprint, key.level, key.bucket)
+			if err != nil {
+				return fmt.Errorf("failed to count logs: %w", err)
+			}
+			if delta >= 0 {
+				continue
+			}
+
+			const emptyQuery = `DELETE FROM %s WHERE project_id = $1 AND fingerprint = $2 AND bucket = $3 AND count <= 0`
+			_, err = tx.ExecContext(ctx, fmt.Sprintf(emptyQuery, table), key.projectID, key.fingerprint, key.bucket)
+			if err != nil {
+				return fmt.Errorf("failed to delete empty log counts: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func compareCountKeys(a, b countKey) int {
+	return cmp.Or(
+		strings.Compare(a.projectID, b.projectID),
+		strings.Compare(a.fingerprint, b.fingerprint),
+		cmp.Compare(a.bucket, b.bucket),
+	)
+}
@@ -20,4 +20,7 @@ type Log struct {
 	Time        int64   `db:"time"`
 	CreatedAt   int64   `db:"created_at"`
 	UpdatedAt   int64   `db:"updated_at"`
+	// Relevance and highlighted message of a full-text search
+	Rank      *float64 `db:"rank"`
+	Highlight *string  `db:"highlight"`
 }
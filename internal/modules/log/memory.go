@@ -0,0 +1,382 @@
+package log
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	loggroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps the logs in memory, for the tests and the instances without database.
+// The logs are counted in the groups of a loggroup.MemoryRepository, as they would be in the
+// database.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	logs   map[string]Log
+	groups *loggroup.MemoryRepository
+}
+
+func NewMemoryRepository(groups *loggroup.MemoryRepository) *MemoryRepository {
+	r := &MemoryRepository{
+		logs:   make(map[string]Log),
+		groups: groups,
+	}
+	groups.SetEvents(r)
+	return r
+}
+
+func (r *MemoryRepository) GetAll(_ context.Context, params GetAllParams) ([]*Log, error) {
+	// No rank without full-text index, the latest come first
+	desc := params.SortOrder == "desc" || params.SortOrder == SortRelevance
+
+	var entities []*Log
+	for _, l := range r.filter(params.FilterParams) {
+		if params.Cursor == nil || afterCursor(l, params.Cursor, params.SortOrder != "asc") {
+			entities = append(entities, l)
+		}
+	}
+
+	sortByTime(entities, desc)
+	return utils.Page(entities, params.Offset, params.Limit), nil
+}
+
+func (r *MemoryRepository) Export(_ context.Context, params ExportParams, handle func(*Log) error) error {
+	entities := r.filter(params.FilterParams)
+	sortByTime(entities, params.SortOrder == "desc")
+
+	for _, l := range entities {
+		if err := handle(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) Count(_ context.Context, params FilterParams) (int, error) {
+	return len(r.filter(params)), nil
+}
+
+func (r *MemoryRepository) CountUpTo(_ context.Context, params FilterParams, limit int) (int, error) {
+	return min(len(r.filter(params)), limit), nil
+}
+
+// EstimateCount counts exactly, there is no planner to estimate from.
+func (r *MemoryRepository) EstimateCount(ctx context.Context, params FilterParams) (int, error) {
+	return r.Count(ctx, params)
+}
+
+func (r *MemoryRepository) GetStats(_ context.Context, projectID string, fingerprint string) (*Stats, error) {
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour).UnixMilli()
+	weekAgo := now.AddDate(0, 0, -7).UnixMilli()
+	monthAgo := now.AddDate(0, 0, -30).UnixMilli()
+
+	var stats Stats
+	for _, l := range r.filter(FilterParams{ProjectID: projectID, Fingerprint: fingerprint, TimeFrom: monthAgo}) {
+		stats.Last30d++
+		if l.Time >= weekAgo {
+			stats.Last7d++
+		}
+		if l.Time >= dayAgo {
+			stats.Last24h++
+		}
+	}
+	return &stats, nil
+}
+
+func (r *MemoryRepository) GetHistogram(_ context.Context, params HistogramParams) ([]*HistogramPoint, error) {
+	location, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	filter := FilterParams{
+		ProjectID:   params.ProjectID,
+		Fingerprint: params.Fingerprint,
+		Level:       params.Level,
+		TimeFrom:    params.TimeFrom,
+		TimeTo:      params.TimeTo,
+	}
+
+	counts := make(map[int64]int)
+	for _, l := range r.filter(filter) {
+		counts[l.Time/time.Minute.Milliseconds()*time.Minute.Milliseconds()]++
+	}
+
+	starts, totals := storage.Buckets(params.TimeFrom, params.TimeTo, params.Interval, location, counts)
+
+	points := make([]*HistogramPoint, 0, len(starts))
+	for i, start := range starts {
+		points = append(points, &HistogramPoint{Time: start, Count: totals[i]})
+	}
+	return points, nil
+}
+
+func (r *MemoryRepository) GetByID(_ context.Context, id string) (*Log, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.logs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &l, nil
+}
+
+func (r *MemoryRepository) Create(_ context.Context, l *Log) (*loggroup.Occurrence, error) {
+	l.Fingerprint = r.groups.MergedInto(l.Fingerprint)
+
+	now := time.Now().Unix()
+	occurrence := r.groups.Upsert(&loggroup.Group{
+		ID:          l.Fingerprint,
+		ProjectID:   l.ProjectID,
+		Level:       loggroup.Level(l.Level),
+		Message:     l.Message,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	})
+
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+
+	l.CreatedAt = now
+	l.UpdatedAt = now
+
+	r.mu.Lock()
+	r.logs[l.ID] = *l
+	r.mu.Unlock()
+
+	if occurrence.Status == loggroup.StatusSnoozed {
+		if err := r.wakeSnoozed(l.Fingerprint, occurrence, now); err != nil {
+			return nil, err
+		}
+	}
+	return occurrence, nil
+}
+
+// Import inserts a log unless it was imported before, and counts it in its group.
+func (r *MemoryRepository) Import(_ context.Context, l *Log) (bool, error) {
+	l.Fingerprint = r.groups.MergedInto(l.Fingerprint)
+
+	now := time.Now().Unix()
+	l.CreatedAt = now
+	l.UpdatedAt = now
+
+	r.mu.Lock()
+	if _, ok := r.logs[l.ID]; ok {
+		r.mu.Unlock()
+		return false, nil
+	}
+	r.logs[l.ID] = *l
+	r.mu.Unlock()
+
+	seenAt := time.UnixMilli(l.Time).Unix()
+	r.groups.Merge(&loggroup.Group{
+		ID:          l.Fingerprint,
+		ProjectID:   l.ProjectID,
+		Level:       loggroup.Level(l.Level),
+		Message:     l.Message,
+		FirstSeenAt: seenAt,
+		LastSeenAt:  seenAt,
+	})
+	return true, nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, id string, updated *Log) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.logs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated.ID = id
+	updated.UpdatedAt = time.Now().Unix()
+
+	l.Fingerprint = updated.Fingerprint
+	l.Level = updated.Level
+	l.Message = updated.Message
+	l.Context = updated.Context
+	l.Time = updated.Time
+	l.UpdatedAt = updated.UpdatedAt
+	r.logs[id] = l
+	return nil
+}
+
+func (r *MemoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.logs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.logs, id)
+	return nil
+}
+
+func (r *MemoryRepository) GetIDs(_ context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	var ids []string
+	for _, l := range r.filter(params) {
+		if afterID == "" || l.ID > afterID {
+			ids = append(ids, l.ID)
+		}
+	}
+
+	slices.Sort(ids)
+	return utils.Page(ids, 0, limit), nil
+}
+
+func (r *MemoryRepository) DeleteByIDs(_ context.Context, ids []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := r.logs[id]; ok {
+			delete(r.logs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// EventTimes returns the times of the logs of the group, which the group counts.
+func (r *MemoryRepository) EventTimes(groupID string) []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var times []int64
+	for _, l := range r.logs {
+		if l.Fingerprint == groupID {
+			times = append(times, l.Time)
+		}
+	}
+	return times
+}
+
+// DeleteGroupEvents deletes the logs of the groups, which are deleted along with them.
+func (r *MemoryRepository) DeleteGroupEvents(groupIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, l := range r.logs {
+		if slices.Contains(groupIDs, l.Fingerprint) {
+			delete(r.logs, id)
+		}
+	}
+}
+
+// MoveGroupEvents moves the logs of the groups to the group they are merged into.
+func (r *MemoryRepository) MoveGroupEvents(groupIDs []string, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for logID, l := range r.logs {
+		if slices.Contains(groupIDs, l.Fingerprint) {
+			l.Fingerprint = id
+			r.logs[logID] = l
+		}
+	}
+}
+
+// wakeSnoozed returns the snoozed group of a new log to unresolved once a condition of its snooze is met.
+func (r *MemoryRepository) wakeSnoozed(groupID string, occurrence *loggroup.Occurrence, now int64) error {
+	if !occurrence.SnoozeConditions.Expired(now, occurrence.Counter) {
+		return nil
+	}
+
+	if err := r.groups.UpdateStatus(context.Background(), groupID, loggroup.StatusUnresolved); err != nil {
+		return fmt.Errorf("failed to wake snoozed log group: %w", err)
+	}
+
+	occurrence.Status = loggroup.StatusUnresolved
+	occurrence.SnoozeConditions = loggroup.SnoozeConditions{}
+	occurrence.Unsnoozed = true
+	return nil
+}
+
+func (r *MemoryRepository) filter(params FilterParams) []*Log {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entities []*Log
+	for _, l := range r.logs {
+		if matchFilters(&l, params) {
+			entities = append(entities, &l)
+		}
+	}
+	return entities
+}
+
+func matchFilters(l *Log, params FilterParams) bool {
+	switch {
+	case params.ProjectID != "" && l.ProjectID != params.ProjectID:
+		return false
+	case params.Fingerprint != "" && l.Fingerprint != params.Fingerprint:
+		return false
+	case params.TimeFrom != 0 && l.Time < params.TimeFrom:
+		return false
+	case params.TimeTo != 0 && l.Time > params.TimeTo:
+		return false
+	case params.Level != "" && string(l.Level) != params.Level:
+		return false
+	case params.Search != "" && !query.MatchSearch(params.Search, params.SearchMode, l.Message):
+		return false
+	case params.Query != nil && !params.Query.Match(l.column):
+		return false
+	default:
+		return true
+	}
+}
+
+// column returns the values of the columns of QueryFields.
+func (l *Log) column(name string) interface{} {
+	switch name {
+	case "message":
+		return l.Message
+	case "CAST(level AS TEXT)":
+		return string(l.Level)
+	case "fingerprint":
+		return l.Fingerprint
+	case "time":
+		return l.Time
+	case "context":
+		return l.Context
+	default:
+		return nil
+	}
+}
+
+// afterCursor reports whether the log comes after the cursor in (time, id) order.
+func afterCursor(l *Log, cursor *utils.Cursor, desc bool) bool {
+	if l.Time == cursor.Time {
+		if desc {
+			return l.ID < cursor.ID
+		}
+		return l.ID > cursor.ID
+	}
+	if desc {
+		return l.Time < cursor.Time
+	}
+	return l.Time > cursor.Time
+}
+
+func sortByTime(entities []*Log, desc bool) {
+	slices.SortFunc(entities, func(a, b *Log) int {
+		if desc {
+			a, b = b, a
+		}
+		return cmp.Or(cmp.Compare(a.Time, b.Time), strings.Compare(a.ID, b.ID))
+	})
+}
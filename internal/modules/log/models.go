@@ -1,5 +1,14 @@
 package log
 
+import (
+	"context"
+	"net/url"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
+)
+
 type Logger interface {
 	Debug(msg string)
 	Info(msg string)
@@ -7,6 +16,10 @@ type Logger interface {
 	Error(msg string)
 }
 
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event)
+}
+
 type FilterParams struct {
 	ProjectID   string
 	Fingerprint string
@@ -14,15 +27,76 @@ type FilterParams struct {
 	TimeTo      int64
 	Level       string
 	Search      string
+	// SearchMode is query.SearchContains or query.SearchFullText, contains by default
+	SearchMode string
+	Query      *query.Query
+}
+
+// ParseFilter reads the filters of the log list from its query parameters. Times are in seconds
+// and the group fingerprint is the groupId parameter.
+func ParseFilter(values url.Values) (FilterParams, error) {
+	searchMode, err := query.ParseSearchMode(values.Get("searchMode"))
+	if err != nil {
+		return FilterParams{}, err
+	}
+
+	parsed, err := query.ParseValues(values, QueryFields)
+	if err != nil {
+		return FilterParams{}, err
+	}
+
+	timeFrom, _ := utils.ParseTimeParam(values.Get("timeFrom"))
+	timeTo, _ := utils.ParseTimeParam(values.Get("timeTo"))
+
+	return FilterParams{
+		ProjectID:   values.Get("projectId"),
+		Fingerprint: values.Get("groupId"),
+		TimeFrom:    timeFrom,
+		TimeTo:      timeTo,
+		Level:       values.Get("level"),
+		Search:      values.Get("search"),
+		SearchMode:  searchMode,
+		Query:       parsed,
+	}, nil
+}
+
+// QueryFields are the fields of the query language searching logs.
+var QueryFields = query.Fields{
+	"message":     {Column: "message", Type: query.TypeText},
+	"level":       {Column: "CAST(level AS TEXT)", Type: query.TypeKeyword, Uppercase: true},
+	"fingerprint": {Column: "fingerprint", Type: query.TypeKeyword},
+	"time":        {Column: "time", Type: query.TypeTime},
+	"context":     {Column: "context", Type: query.TypeJSON},
 }
 
 type GetAllParams struct {
 	FilterParams
-	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	// SortOrder is asc, desc or relevance, which requires a full-text search
+	SortOrder string `validate:"omitempty,oneof=asc desc relevance"`
 	Limit     int
 	Offset    int
+	// Cursor continues the list after the last item of a previous page, Offset is then ignored
+	Cursor    *utils.Cursor
+	CountMode string `validate:"omitempty,oneof=exact estimate none"`
+}
+
+const SortRelevance = "relevance"
+
+// ExportParams selects every matching event, without paging.
+type ExportParams struct {
+	FilterParams
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
 }
 
+const (
+	// CountExact counts every matching row
+	CountExact = "exact"
+	// CountEstimate counts up to a cap and falls back to the planner estimate above it
+	CountEstimate = "estimate"
+	// CountNone skips counting
+	CountNone = "none"
+)
+
 type Create struct {
 	Time    int64  `json:"time" validate:"required" example:"1704067200000" format:"int64"`
 	Level   string `json:"level" validate:"required,oneof=DEBUG INFO WARN ERROR FATAL"`
@@ -43,6 +117,13 @@ type Create struct {
 	ProjectID string       `json:"-"`
 }
 
+// Import is a log coming from another system, kept with its original ID and time.
+type Import struct {
+	Create
+	// ID makes the import idempotent, a log already imported being skipped
+	ID string
+}
+
 type Update struct {
 	Level   string `json:"level" validate:"omitempty,oneof=DEBUG INFO WARN ERROR FATAL"`
 	Message string `json:"message" validate:"omitempty" example:"updated log message"`
@@ -79,11 +160,47 @@ type Entity struct {
 	// )
 	Context *interface{} `json:"context"`
 	Time    int64        `json:"time" example:"1704067200000"`
+	// Rank is the relevance of a full-text search
+	Rank float64 `json:"rank,omitempty" example:"0.0607927"`
+	// Highlight is the message with the matched words wrapped in <mark> tags, for full-text searches
+	Highlight string `json:"highlight,omitempty" example:"first <mark>log</mark> message"`
 }
 
 type EntityList struct {
-	Count int      `json:"count"`
-	Items []Entity `json:"items"`
+	// Count is null when counting was skipped
+	Count *int `json:"count"`
+	// Estimated is set when Count is approximate
+	Estimated bool      `json:"estimated,omitempty"`
+	Items     []*Entity `json:"items"`
+	// NextCursor lists the following page, it is empty on the last one
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+const (
+	IntervalMinute = "minute"
+	IntervalHour   = "hour"
+	IntervalDay    = "day"
+)
+
+type HistogramParams struct {
+	ProjectID   string
+	Fingerprint string
+	Level       string
+	TimeFrom    int64
+	TimeTo      int64
+	Interval    string
+	Timezone    string
+}
+
+type HistogramPoint struct {
+	Time  int64 `json:"time" db:"time" example:"1704067200000"` // Unix timestamp in milliseconds
+	Count int   `json:"count" db:"count" example:"12"`
+}
+
+type Histogram struct {
+	Interval string           `json:"interval" example:"hour"`
+	Timezone string           `json:"timezone" example:"UTC"`
+	Items    []HistogramPoint `json:"items"`
 }
 
 type Stats struct {
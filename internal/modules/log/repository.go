@@ -3,54 +3,107 @@ package log
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	loggroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/internal/storage"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=3"
+
 var ErrNotFound = errors.New("not found")
 
+// exportBatchSize is the number of rows fetched at once from the export cursor.
+const exportBatchSize = 1000
+
+const logColumns = "id, project_id, level, message, context, time, created_at, updated_at"
+
+const insertQuery = `
+		INSERT INTO logs (
+	  		id, project_id, fingerprint, level, message, context, time, created_at, updated_at
+		) VALUES (
+	  		:id, :project_id, :fingerprint, :level, :message, :context, :time, :created_at, :updated_at
+		)
+	`
+
 type Repository interface {
 	GetAll(ctx context.Context, params GetAllParams) ([]*Log, error)
+	Export(ctx context.Context, params ExportParams, handle func(*Log) error) error
 	Count(ctx context.Context, params FilterParams) (int, error)
+	CountUpTo(ctx context.Context, params FilterParams, limit int) (int, error)
+	EstimateCount(ctx context.Context, params FilterParams) (int, error)
 	GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error)
+	GetHistogram(ctx context.Context, params HistogramParams) ([]*HistogramPoint, error)
 	GetByID(ctx context.Context, id string) (*Log, error)
-	Create(ctx context.Context, log *Log) error
+	Create(ctx context.Context, log *Log) (*loggroup.Occurrence, error)
+	Import(ctx context.Context, log *Log) (bool, error)
 	Update(ctx context.Context, id string, log *Log) error
 	Delete(ctx context.Context, id string) error
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
 }
 
 type repository struct {
-	db     *sqlx.DB
-	logger Logger
+	db      *sqlx.DB
+	logger  Logger
+	dialect storage.Dialect
 }
 
 func NewRepository(db *sqlx.DB, logger Logger) Repository {
-	return &repository{
-		db:     db,
-		logger: logger,
+	r := &repository{
+		db:      db,
+		logger:  logger,
+		dialect: storage.DialectOf(db),
 	}
+
+	if r.dialect.SQLite() {
+		return &sqliteRepository{r}
+	}
+	return r
 }
 
 func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Log, error) {
-	query := `
-        SELECT id, project_id, level, message, context, time, created_at, updated_at 
-        FROM logs 
-        WHERE 1=1
-    `
-
 	args := map[string]interface{}{
 		"limit":  params.Limit,
 		"offset": params.Offset,
 	}
 
-	query, args = applyFilters(query, params.FilterParams, args)
+	columns := logColumns
+	order := " ORDER BY time " + params.SortOrder + ", id " + params.SortOrder
+
+	if tsquery := r.fullTextQuery(params.FilterParams, args); tsquery != "" {
+		columns += fullTextColumns(tsquery)
+		args["headlineOptions"] = headlineOptions
+		if params.SortOrder == SortRelevance {
+			order = " ORDER BY rank DESC, time DESC, id DESC"
+		}
+	} else if params.SortOrder == SortRelevance {
+		// SQLite does not rank the matches, the latest come first
+		order = " ORDER BY time DESC, id DESC"
+	}
+
+	query, args := r.applyFilters("SELECT "+columns+" FROM logs WHERE 1=1", params.FilterParams, args)
 
-	query += " ORDER BY time " + params.SortOrder
+	if params.Cursor != nil {
+		operator := "<"
+		if params.SortOrder == "asc" {
+			operator = ">"
+		}
+		// The row comparison does not prune partitions, the bound on time alone does
+		query += " AND time " + operator + "= :cursorTime"
+		query += " AND (time, id) " + operator + " (:cursorTime, " + r.dialect.UUID(":cursorId") + ")"
+		args["cursorTime"] = params.Cursor.Time
+		args["cursorId"] = params.Cursor.ID
+	}
+
+	query += order
 	query += " LIMIT :limit OFFSET :offset"
 
 	query, namedArgs, err := sqlx.Named(query, args)
@@ -70,9 +123,67 @@ func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Log, e
 	return logs, nil
 }
 
+// Export hands every log matching the filters to handle. The rows are fetched in batches from a
+// server-side cursor, so the export does not hold them all in memory.
+func (r *repository) Export(ctx context.Context, params ExportParams, handle func(*Log) error) (err error) {
+	query := "SELECT " + logColumns + " FROM logs WHERE 1=1"
+	query, args := r.applyFilters(query, params.FilterParams, make(map[string]interface{}))
+	query += " ORDER BY time " + params.SortOrder + ", id " + params.SortOrder
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = "DECLARE export_cursor NO SCROLL CURSOR FOR " + r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	// A cursor only lives within its transaction
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, query, namedArgs...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportBatchSize)
+	for {
+		var batch []*Log
+		if err = tx.SelectContext(ctx, &batch, fetch); err != nil {
+			return fmt.Errorf("failed to fetch logs: %w", err)
+		}
+
+		for _, item := range batch {
+			if err = handle(item); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			break
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 func (r *repository) Count(ctx context.Context, params FilterParams) (int, error) {
 	query := "SELECT COUNT(*) FROM logs WHERE 1=1"
-	query, args := applyFilters(query, params, make(map[string]interface{}))
+	query, args := r.applyFilters(query, params, make(map[string]interface{}))
 
 	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
@@ -92,20 +203,73 @@ func (r *repository) Count(ctx context.Context, params FilterParams) (int, error
 	return count, nil
 }
 
+// CountUpTo counts the matching logs but stops at limit, so it stays cheap on large tables.
+func (r *repository) CountUpTo(ctx context.Context, params FilterParams, limit int) (int, error) {
+	query := "SELECT id FROM logs WHERE 1=1"
+	query, args := r.applyFilters(query, params, map[string]interface{}{"limit": limit})
+	query = "SELECT COUNT(*) FROM (" + query + " LIMIT :limit) AS capped"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var count int
+	err = r.db.GetContext(ctx, &count, query, namedArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count logs: %w", err)
+	}
+
+	return count, nil
+}
+
+// EstimateCount returns the number of matching logs estimated by the query planner.
+func (r *repository) EstimateCount(ctx context.Context, params FilterParams) (int, error) {
+	query := "EXPLAIN (FORMAT JSON) SELECT id FROM logs WHERE 1=1"
+	query, args := r.applyFilters(query, params, make(map[string]interface{}))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var plan []byte
+	err = r.db.GetContext(ctx, &plan, query, namedArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate logs count: %w", err)
+	}
+
+	return planRows(plan)
+}
+
+// GetStats adds up the hourly counts of the logs, the windows starting at the hour.
 func (r *repository) GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error) {
 	query := `
         SELECT
-            COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '24 HOURS') * 1000)) AS last_24h,
-            COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '7 DAYS') * 1000)) AS last_7d,
-			COUNT(*) FILTER (WHERE time >= (EXTRACT(EPOCH FROM NOW() - INTERVAL '30 DAYS') * 1000)) AS last_30d
+            COALESCE(SUM(count) FILTER (WHERE bucket >= :dayAgo), 0) AS last_24h,
+            COALESCE(SUM(count) FILTER (WHERE bucket >= :weekAgo), 0) AS last_7d,
+            COALESCE(SUM(count), 0) AS last_30d
         FROM
-            logs
+            ` + countsByHour + `
         WHERE
             project_id = :projectId
+            AND bucket >= :monthAgo
     `
 
+	now := time.Now()
 	args := map[string]interface{}{
 		"projectId": projectID,
+		"dayAgo":    storage.Bucket(now.Add(-24*time.Hour).UnixMilli(), time.Hour),
+		"weekAgo":   storage.Bucket(now.AddDate(0, 0, -7).UnixMilli(), time.Hour),
+		"monthAgo":  storage.Bucket(now.AddDate(0, 0, -30).UnixMilli(), time.Hour),
 	}
 
 	if fingerprint != "" {
@@ -140,6 +304,71 @@ func (r *repository) GetStats(ctx context.Context, projectID string, fingerprint
 	}, nil
 }
 
+// GetHistogram adds up the counts of the logs by minute or by hour into the buckets of the
+// interval, which are counted whole.
+func (r *repository) GetHistogram(ctx context.Context, params HistogramParams) ([]*HistogramPoint, error) {
+	location, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	resolution := storage.HistogramResolution(params.TimeFrom, params.TimeTo, params.Interval, location)
+
+	args := map[string]interface{}{
+		"projectId":  params.ProjectID,
+		"timeFrom":   params.TimeFrom,
+		"timeTo":     params.TimeTo,
+		"bucketFrom": storage.Bucket(params.TimeFrom, resolution),
+		"timezone":   params.Timezone,
+	}
+
+	filters := histogramFilters(params, args)
+
+	// Interval is validated by the service, so it is safe to inline it.
+	query := fmt.Sprintf(`
+        WITH buckets AS (
+            SELECT generate_series(
+                date_trunc('%[1]s', to_timestamp(:timeFrom / 1000.0) AT TIME ZONE :timezone),
+                date_trunc('%[1]s', to_timestamp(:timeTo / 1000.0) AT TIME ZONE :timezone),
+                CAST('1 %[1]s' AS INTERVAL)
+            ) AS bucket
+        ),
+        counts AS (
+            SELECT
+                date_trunc('%[1]s', to_timestamp(bucket / 1000.0) AT TIME ZONE :timezone) AS bucket,
+                SUM(count) AS count
+            FROM
+                %[3]s
+            WHERE
+                project_id = :projectId AND bucket >= :bucketFrom AND bucket <= :timeTo %[2]s
+            GROUP BY 1
+        )
+        SELECT
+            CAST(EXTRACT(EPOCH FROM buckets.bucket AT TIME ZONE :timezone) * 1000 AS BIGINT) AS time,
+            COALESCE(counts.count, 0) AS count
+        FROM
+            buckets
+        LEFT JOIN counts ON counts.bucket = buckets.bucket
+        ORDER BY buckets.bucket
+    `, params.Interval, filters, countsTable(resolution))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var points []*HistogramPoint
+	err = r.db.SelectContext(ctx, &points, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs histogram: %w", err)
+	}
+	return points, nil
+}
+
 func (r *repository) GetByID(ctx context.Context, id string) (*Log, error) {
 	const query = `SELECT id, level, message, context, time, created_at, updated_at 
 		FROM logs WHERE id = $1`
@@ -155,10 +384,21 @@ func (r *repository) GetByID(ctx context.Context, id string) (*Log, error) {
 	return &entity, nil
 }
 
-func (r *repository) Create(ctx context.Context, l *Log) error {
+func (r *repository) Create(ctx context.Context, l *Log) (*loggroup.Occurrence, error) {
+	return r.create(ctx, l, r.upsertGroup)
+}
+
+// groupUpserter attaches a new event to its group, creating the group on its first event.
+type groupUpserter func(ctx context.Context, tx *sqlx.Tx, group *loggroup.Group) (*loggroup.Occurrence, error)
+
+func (r *repository) create(
+	ctx context.Context,
+	l *Log,
+	upsertGroup groupUpserter,
+) (occurrence *loggroup.Occurrence, err error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	defer func() {
@@ -169,15 +409,12 @@ func (r *repository) Create(ctx context.Context, l *Log) error {
 		}
 	}()
 
-	const logGroupQuery = `
-        INSERT INTO log_groups (id, project_id, level, message, first_seen_at, last_seen_at, counter)
-        VALUES (:id, :project_id, :level, :message, :first_seen_at, :last_seen_at, 1)
-        ON CONFLICT (id) DO UPDATE 
-        SET counter = log_groups.counter + 1, last_seen_at = EXCLUDED.last_seen_at
-    `
+	if err = mergedInto(ctx, tx, l); err != nil {
+		return nil, err
+	}
 
 	now := time.Now().Unix()
-	logGroup := loggroup.Group{
+	occurrence, err = upsertGroup(ctx, tx, &loggroup.Group{
 		ID:          l.Fingerprint,
 		ProjectID:   l.ProjectID,
 		Level:       loggroup.Level(l.Level),
@@ -186,40 +423,224 @@ func (r *repository) Create(ctx context.Context, l *Log) error {
 		LastSeenAt:  now,
 		Counter:     0,
 		Status:      loggroup.StatusUnresolved,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert log group: %w", err)
+	}
+
+	if l.ID == "" {
+		l.ID = uuid.New().String()
 	}
 
-	_, err = tx.NamedExecContext(ctx, logGroupQuery, logGroup)
+	l.CreatedAt = now
+	l.UpdatedAt = now
+
+	_, err = tx.NamedExecContext(ctx, insertQuery, l)
 	if err != nil {
-		return fmt.Errorf("failed to upsert log group: %w", err)
+		return nil, fmt.Errorf("failed to create log: %w", err)
+	}
+
+	if err = countLogs(ctx, tx, []countedLog{{l.ProjectID, l.Fingerprint, l.Level, l.Time}}, 1); err != nil {
+		return nil, err
+	}
+
+	if occurrence.Status == loggroup.StatusSnoozed {
+		if err = r.wakeSnoozed(ctx, tx, l.Fingerprint, occurrence, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return occurrence, nil
+}
+
+// upsertGroup locks the group to tell whether the event is a regression.
+// A resolved group receiving a new event is a regression and goes back to unresolved.
+func (r *repository) upsertGroup(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	group *loggroup.Group,
+) (*loggroup.Occurrence, error) {
+	// The previous status is read apart, a locking CTE would only run once the upsert changed it
+	var previous loggroup.Status
+	err := tx.GetContext(ctx, &previous, `SELECT status FROM log_groups WHERE id = $1 FOR UPDATE`, group.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
 	}
 
 	const query = `
-		INSERT INTO logs (
-	  		id, project_id, fingerprint, level, message, context, time, created_at, updated_at
-		) VALUES (
-	  		:id, :project_id, :fingerprint, :level, :message, :context, :time, :created_at, :updated_at
-		)
-	`
+        INSERT INTO log_groups (id, project_id, level, message, first_seen_at, last_seen_at, counter)
+        VALUES (:id, :project_id, :level, :message, :first_seen_at, :last_seen_at, 1)
+        ON CONFLICT (id) DO UPDATE
+        SET
+            counter = log_groups.counter + 1,
+            last_seen_at = EXCLUDED.last_seen_at,
+            status = CASE WHEN log_groups.status = 'resolved' THEN 'unresolved' ELSE log_groups.status END
+        RETURNING
+            (xmax = 0) AS created,
+            counter,
+            status,
+            snoozed_at,
+            snooze_until,
+            snooze_counter
+    `
 
-	if l.ID == "" {
-		l.ID = uuid.New().String()
+	groupQuery, groupArgs, err := tx.BindNamed(query, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
 	}
 
+	var occurrence loggroup.Occurrence
+	if err := tx.GetContext(ctx, &occurrence, groupQuery, groupArgs...); err != nil {
+		return nil, err
+	}
+
+	occurrence.Regressed = previous == loggroup.StatusResolved
+	return &occurrence, nil
+}
+
+// mergedInto moves a new log to the group its group was merged into, if any.
+func mergedInto(ctx context.Context, tx *sqlx.Tx, l *Log) error {
+	var groupID string
+	err := tx.GetContext(ctx, &groupID, `SELECT group_id FROM log_group_merges WHERE fingerprint = $1`, l.Fingerprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get merged log group: %w", err)
+	}
+
+	l.Fingerprint = groupID
+	return nil
+}
+
+// Import inserts a log unless it was imported before, and counts it in its group. The group
+// is seen first and last at the times of its events, its status is left as is.
+func (r *repository) Import(ctx context.Context, l *Log) (created bool, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	if err = mergedInto(ctx, tx, l); err != nil {
+		return false, err
+	}
+
+	const query = insertQuery + " ON CONFLICT DO NOTHING"
+
+	now := time.Now().Unix()
 	l.CreatedAt = now
 	l.UpdatedAt = now
 
-	_, err = tx.NamedExecContext(ctx, query, l)
+	result, err := tx.NamedExecContext(ctx, query, l)
+	if err != nil {
+		return false, fmt.Errorf("failed to import log: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to create log: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, tx.Commit()
+	}
+
+	logGroupQuery := `
+        INSERT INTO log_groups (id, project_id, level, message, first_seen_at, last_seen_at, counter)
+        VALUES (:id, :project_id, :level, :message, :first_seen_at, :last_seen_at, 1)
+        ON CONFLICT (id) DO UPDATE
+        SET
+            counter = log_groups.counter + 1,
+            first_seen_at = ` + r.dialect.Least("log_groups.first_seen_at", "EXCLUDED.first_seen_at") + `,
+            last_seen_at = ` + r.dialect.Greatest("log_groups.last_seen_at", "EXCLUDED.last_seen_at") + `
+    `
+
+	seenAt := time.UnixMilli(l.Time).Unix()
+	logGroup := loggroup.Group{
+		ID:          l.Fingerprint,
+		ProjectID:   l.ProjectID,
+		Level:       loggroup.Level(l.Level),
+		Message:     l.Message,
+		FirstSeenAt: seenAt,
+		LastSeenAt:  seenAt,
+	}
+
+	if _, err = tx.NamedExecContext(ctx, logGroupQuery, logGroup); err != nil {
+		return false, fmt.Errorf("failed to upsert log group: %w", err)
+	}
+
+	if err = countLogs(ctx, tx, []countedLog{{l.ProjectID, l.Fingerprint, l.Level, l.Time}}, 1); err != nil {
+		return false, err
 	}
 
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// wakeSnoozed returns the snoozed group of a new event to unresolved once a condition of its snooze is met.
+func (r *repository) wakeSnoozed(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	groupID string,
+	occurrence *loggroup.Occurrence,
+	now int64,
+) error {
+	if !occurrence.SnoozeConditions.Expired(now, occurrence.Counter) {
+		return nil
+	}
+
+	const query = `
+        UPDATE log_groups
+        SET status = 'unresolved', snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL
+        WHERE id = $1
+    `
+
+	if _, err := tx.ExecContext(ctx, query, groupID); err != nil {
+		return fmt.Errorf("failed to wake snoozed log group: %w", err)
 	}
+
+	occurrence.Status = loggroup.StatusUnresolved
+	occurrence.SnoozeConditions = loggroup.SnoozeConditions{}
+	occurrence.Unsnoozed = true
 	return nil
 }
 
-func (r *repository) Update(ctx context.Context, id string, updated *Log) error {
+// Update moves the log between the counts when its group or its time change.
+func (r *repository) Update(ctx context.Context, id string, updated *Log) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	var previous countedLog
+	err = tx.GetContext(ctx, &previous, `SELECT `+countedLogColumns+` FROM logs WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get log: %w", err)
+	}
+
 	const query = `
 		UPDATE
 		    logs 
@@ -237,40 +658,112 @@ func (r *repository) Update(ctx context.Context, id string, updated *Log) error
 	updated.ID = id
 	updated.UpdatedAt = time.Now().Unix()
 
-	result, err := r.db.NamedExecContext(ctx, query, updated)
-	if err != nil {
+	if _, err = tx.NamedExecContext(ctx, query, updated); err != nil {
 		return fmt.Errorf("failed to update log: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	current := countedLog{previous.ProjectID, updated.Fingerprint, updated.Level, updated.Time}
+	if current != previous {
+		if err = countLogs(ctx, tx, []countedLog{previous}, -1); err != nil {
+			return err
+		}
+		if err = countLogs(ctx, tx, []countedLog{current}, 1); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	deleted, err := r.deleteLogs(ctx, `DELETE FROM logs WHERE id = $1`, id)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete log: %w", err)
 	}
-	if rowsAffected == 0 {
+	if deleted == 0 {
 		return ErrNotFound
 	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id string) error {
-	const query = `DELETE FROM logs WHERE id = $1`
+// GetIDs pages over the IDs matching the filters in ID order, starting after afterID.
+func (r *repository) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	query := "SELECT id FROM logs WHERE 1=1"
+	query, args := r.applyFilters(query, params, map[string]interface{}{"limit": limit})
+
+	if afterID != "" {
+		query += " AND id > :afterId"
+		args["afterId"] = afterID
+	}
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	query += " ORDER BY id LIMIT :limit"
+
+	query, namedArgs, err := sqlx.Named(query, args)
 	if err != nil {
-		return fmt.Errorf("failed to delete log: %w", err)
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var ids []string
+	err = r.db.SelectContext(ctx, &ids, query, namedArgs...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get logs ids: %w", err)
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
+	return ids, nil
+}
+
+func (r *repository) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	deleted, err := r.deleteLogs(ctx, `DELETE FROM logs WHERE id = ANY(CAST($1 AS UUID[]))`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete logs: %w", err)
 	}
-	return nil
+	return deleted, nil
+}
+
+// deleteLogs runs a delete of logs and takes the deleted logs off the counts.
+func (r *repository) deleteLogs(ctx context.Context, query string, args ...interface{}) (deleted int, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	query += " RETURNING " + countedLogColumns
+	r.logger.Debug(query)
+
+	var logs []countedLog
+	if err = tx.SelectContext(ctx, &logs, query, args...); err != nil {
+		return 0, err
+	}
+
+	if err = countLogs(ctx, tx, logs, -1); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(logs), nil
 }
 
-func applyFilters(baseQuery string, params FilterParams, args map[string]interface{}) (string, map[string]interface{}) {
+func (r *repository) applyFilters(
+	baseQuery string,
+	params FilterParams,
+	args map[string]interface{},
+) (string, map[string]interface{}) {
 	query := baseQuery
 
 	if params.ProjectID != "" {
@@ -299,9 +792,79 @@ func applyFilters(baseQuery string, params FilterParams, args map[string]interfa
 	}
 
 	if params.Search != "" {
-		query += " AND message ILIKE :search"
-		args["search"] = "%" + params.Search + "%"
+		query += r.searchFilter(params, args)
+	}
+
+	if params.Query != nil {
+		if r.dialect.SQLite() {
+			query += " AND " + params.Query.SQLite(args)
+		} else {
+			query += " AND " + params.Query.SQL(args)
+		}
 	}
 
 	return query, args
 }
+
+func planRows(plan []byte) (int, error) {
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("failed to parse query plan: %s", plan)
+	}
+
+	return int(explained[0].Plan.Rows), nil
+}
+
+// searchFilter matches the messages with the search, through the full-text index when asked for.
+// SQLite has no full-text index, the words are searched within the messages.
+func (r *repository) searchFilter(params FilterParams, args map[string]interface{}) string {
+	switch {
+	case params.SearchMode != query.SearchFullText:
+		args["search"] = "%" + params.Search + "%"
+		return " AND " + r.dialect.ILike("message", ":search")
+	case r.dialect.SQLite():
+		if condition := query.FullTextLike(params.Search, "message", args); condition != "" {
+			return " AND " + condition
+		}
+	default:
+		if tsquery := query.FullText(params.Search, args); tsquery != "" {
+			return " AND search_vector @@ " + tsquery
+		}
+	}
+	return ""
+}
+
+// fullTextQuery compiles the search when the full-text search mode is used on PostgreSQL, it is
+// empty otherwise.
+func (r *repository) fullTextQuery(params FilterParams, args map[string]interface{}) string {
+	if params.SearchMode != query.SearchFullText || r.dialect.SQLite() {
+		return ""
+	}
+	return query.FullText(params.Search, args)
+}
+
+func histogramFilters(params HistogramParams, args map[string]interface{}) string {
+	filters := ""
+
+	if params.Fingerprint != "" {
+		filters += " AND fingerprint = :fingerprint"
+		args["fingerprint"] = params.Fingerprint
+	}
+
+	if params.Level != "" {
+		filters += " AND level = :level"
+		args["level"] = params.Level
+	}
+
+	return filters
+}
+
+// fullTextColumns selects the relevance and the highlighted message of a full-text search.
+func fullTextColumns(tsquery string) string {
+	return ", ts_rank(search_vector, " + tsquery + ") AS rank" +
+		", ts_headline('" + query.TextSearchConfig + "', message, " + tsquery + ", :headlineOptions) AS highlight"
+}
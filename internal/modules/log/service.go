@@ -7,30 +7,57 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/fuckbug/api/internal/events"
+	loggroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
 	"github.com/google/uuid"
 )
 
-var ErrInvalidLogLevel = errors.New("invalid log level")
+const (
+	defaultHistogramRange = 24 * time.Hour
+	maxHistogramBuckets   = 1500
+	// Above this number of rows an estimated count is taken from the query planner
+	estimateCountThreshold = 10000
+)
+
+var (
+	ErrInvalidLogLevel  = errors.New("invalid log level")
+	ErrInvalidInterval  = errors.New("invalid interval, expected minute, hour or day")
+	ErrInvalidTimezone  = errors.New("invalid timezone")
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrInvalidSort      = errors.New("relevance sort requires a full-text search and no cursor")
+	ErrTooManyBuckets   = errors.New("too many buckets, use a larger interval or a shorter time range")
+)
 
 type Service interface {
 	GetByID(ctx context.Context, id string) (*Entity, error)
-	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetAll(ctx context.Context, params GetAllParams) (*EntityList, error)
+	Export(ctx context.Context, params ExportParams, handle func(*Entity) error) error
 	GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error)
+	GetHistogram(ctx context.Context, params HistogramParams) (*Histogram, error)
 	Create(ctx context.Context, req *Create) (*Entity, error)
+	Import(ctx context.Context, req *Import) (bool, error)
 	Update(ctx context.Context, id string, req *Update) (*Entity, error)
 	Delete(ctx context.Context, id string) error
+	Count(ctx context.Context, params FilterParams) (int, error)
+	GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error)
+	DeleteByIDs(ctx context.Context, ids []string) (int, error)
 }
 
 type service struct {
-	repo   Repository
-	logger Logger
+	repo      Repository
+	logger    Logger
+	publisher Publisher
 }
 
-func NewService(repo Repository, logger Logger) Service {
+func NewService(repo Repository, logger Logger, publisher Publisher) Service {
 	return &service{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		logger:    logger,
+		publisher: publisher,
 	}
 }
 
@@ -42,23 +69,86 @@ func (s *service) GetByID(ctx context.Context, id string) (*Entity, error) {
 	return toResponse(log), nil
 }
 
-func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+// GetAll lists a page of logs. One extra row is fetched to tell whether a next page exists.
+func (s *service) GetAll(ctx context.Context, params GetAllParams) (*EntityList, error) {
+	relevance := params.SortOrder == SortRelevance
+	if relevance && (params.SearchMode != query.SearchFullText || params.Search == "" || params.Cursor != nil) {
+		return nil, ErrInvalidSort
+	}
+
+	limit := params.Limit
+	if params.Cursor != nil {
+		params.Offset = 0
+	}
+	params.Limit = limit + 1
+
 	logs, err := s.repo.GetAll(ctx, params)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	total, err := s.repo.Count(ctx, params.FilterParams)
-	if err != nil {
-		return nil, 0, err
+	list := &EntityList{
+		Items: make([]*Entity, 0, len(logs)),
+	}
+
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+		// Pages sorted by relevance are only reachable by offset
+		if !relevance {
+			last := logs[limit-1]
+			list.NextCursor = utils.Cursor{Time: last.Time, ID: last.ID}.Encode()
+		}
 	}
 
-	responses := make([]*Entity, 0, len(logs))
 	for _, log := range logs {
-		responses = append(responses, toResponse(log))
+		list.Items = append(list.Items, toResponse(log))
 	}
 
-	return responses, total, nil
+	list.Count, list.Estimated, err = s.count(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Export hands every matching log to handle, in time order.
+func (s *service) Export(ctx context.Context, params ExportParams, handle func(*Entity) error) error {
+	if params.SortOrder == "" {
+		params.SortOrder = "desc"
+	}
+
+	return s.repo.Export(ctx, params, func(log *Log) error {
+		return handle(toResponse(log))
+	})
+}
+
+func (s *service) count(ctx context.Context, params GetAllParams) (*int, bool, error) {
+	switch params.CountMode {
+	case CountNone:
+		return nil, false, nil
+	case CountEstimate:
+		count, err := s.repo.CountUpTo(ctx, params.FilterParams, estimateCountThreshold)
+		if err != nil {
+			return nil, false, err
+		}
+		if count < estimateCountThreshold {
+			return &count, false, nil
+		}
+
+		estimate, err := s.repo.EstimateCount(ctx, params.FilterParams)
+		if err != nil {
+			return nil, false, err
+		}
+		count = max(count, estimate)
+		return &count, true, nil
+	default:
+		count, err := s.repo.Count(ctx, params.FilterParams)
+		if err != nil {
+			return nil, false, err
+		}
+		return &count, false, nil
+	}
 }
 
 func (s *service) GetStats(ctx context.Context, projectID string, fingerprint string) (*Stats, error) {
@@ -70,11 +160,104 @@ func (s *service) GetStats(ctx context.Context, projectID string, fingerprint st
 	return stats, nil
 }
 
+func (s *service) GetHistogram(ctx context.Context, params HistogramParams) (*Histogram, error) {
+	if params.Level != "" && !isValidLogLevel(params.Level) {
+		return nil, ErrInvalidLogLevel
+	}
+
+	if params.Interval == "" {
+		params.Interval = IntervalHour
+	}
+
+	step, ok := intervalDuration(params.Interval)
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+
+	// Local is the zone of the API server, unknown to the database aligning the buckets
+	if _, err := time.LoadLocation(params.Timezone); err != nil || params.Timezone == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	if params.TimeTo == 0 {
+		params.TimeTo = time.Now().UnixMilli()
+	}
+
+	if params.TimeFrom == 0 {
+		params.TimeFrom = params.TimeTo - defaultHistogramRange.Milliseconds()
+	}
+
+	if params.TimeFrom > params.TimeTo {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if (params.TimeTo-params.TimeFrom)/step.Milliseconds() > maxHistogramBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
+	points, err := s.repo.GetHistogram(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]HistogramPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, *point)
+	}
+
+	return &Histogram{
+		Interval: params.Interval,
+		Timezone: params.Timezone,
+		Items:    items,
+	}, nil
+}
+
 func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
 	if !isValidLogLevel(req.Level) {
 		return nil, ErrInvalidLogLevel
 	}
 
+	log, err := newLog(req)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrence, err := s.repo.Create(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toResponse(log)
+	s.publishCreated(ctx, log, occurrence, response)
+
+	return response, nil
+}
+
+// Import stores a log of another system with its original time. The groups count it without
+// being reopened, and no event is published. created is false when it was imported before.
+func (s *service) Import(ctx context.Context, req *Import) (created bool, err error) {
+	if !isValidLogLevel(req.Level) {
+		return false, ErrInvalidLogLevel
+	}
+
+	log, err := newLog(&req.Create)
+	if err != nil {
+		return false, err
+	}
+
+	if req.ID != "" {
+		log.ID = req.ID
+	}
+
+	return s.repo.Import(ctx, log)
+}
+
+// newLog builds the log of a request, along with its fingerprint.
+func newLog(req *Create) (*Log, error) {
 	contextStr, err := contextToStringPtr(req.Context)
 	if err != nil {
 		return nil, err
@@ -91,11 +274,7 @@ func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
 
 	log.Fingerprint = generateFingerprint(log)
 
-	if err := s.repo.Create(ctx, log); err != nil {
-		return nil, err
-	}
-
-	return toResponse(log), nil
+	return log, nil
 }
 
 func (s *service) Update(ctx context.Context, id string, req *Update) (*Entity, error) {
@@ -133,6 +312,59 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
 
+func (s *service) Count(ctx context.Context, params FilterParams) (int, error) {
+	return s.repo.Count(ctx, params)
+}
+
+func (s *service) GetIDs(ctx context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	return s.repo.GetIDs(ctx, params, afterID, limit)
+}
+
+func (s *service) DeleteByIDs(ctx context.Context, ids []string) (int, error) {
+	return s.repo.DeleteByIDs(ctx, ids)
+}
+
+func (s *service) publishCreated(
+	ctx context.Context,
+	l *Log,
+	occurrence *loggroup.Occurrence,
+	response *Entity,
+) {
+	event := events.Event{
+		ProjectID: l.ProjectID,
+		GroupID:   l.Fingerprint,
+		GroupKind: events.GroupKindLog,
+		Level:     string(l.Level),
+		Message:   l.Message,
+		Time:      l.Time,
+		Payload:   response,
+	}
+
+	if occurrence.Created {
+		event.Type = events.TypeGroupCreated
+		s.publisher.Publish(ctx, event)
+	}
+
+	if occurrence.Regressed {
+		event.Type = events.TypeGroupRegressed
+		s.publisher.Publish(ctx, event)
+	}
+
+	if occurrence.Unsnoozed {
+		unsnoozed := event
+		unsnoozed.Type = events.TypeGroupUnsnoozed
+		unsnoozed.Change = &events.Change{
+			Field: "status",
+			From:  string(loggroup.StatusSnoozed),
+			To:    string(loggroup.StatusUnresolved),
+		}
+		s.publisher.Publish(ctx, unsnoozed)
+	}
+
+	event.Type = events.TypeLogCreated
+	s.publisher.Publish(ctx, event)
+}
+
 func isValidLogLevel(level string) bool {
 	switch Level(level) {
 	case LevelFatal, LevelInfo, LevelWarn, LevelError, LevelDebug:
@@ -142,6 +374,19 @@ func isValidLogLevel(level string) bool {
 	}
 }
 
+func intervalDuration(interval string) (time.Duration, bool) {
+	switch interval {
+	case IntervalMinute:
+		return time.Minute, true
+	case IntervalHour:
+		return time.Hour, true
+	case IntervalDay:
+		return 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
+
 func generateFingerprint(e *Log) string {
 	data := fmt.Sprintf(
 		"%s:%s:%s",
@@ -162,18 +407,26 @@ func toResponse(l *Log) *Entity {
 		Time:    l.Time,
 	}
 
-	if err := parseJSONField(l.Context, &response.Context); err != nil {
-		*response.Context = l.Context
+	if l.Rank != nil {
+		response.Rank = *l.Rank
+	}
+	if l.Highlight != nil {
+		response.Highlight = *l.Highlight
 	}
 
+	// JSONB values are valid JSON, they are passed through without being decoded
+	response.Context = rawJSONValue(l.Context)
+
 	return response
 }
 
-func parseJSONField(src *string, dest interface{}) error {
-	if src == nil || *src == "" {
+// rawJSONValue wraps a JSONB value in the interface the Context field is typed as.
+func rawJSONValue(value *string) *interface{} {
+	if value == nil {
 		return nil
 	}
-	return json.Unmarshal([]byte(*src), dest)
+	var raw interface{} = json.RawMessage(*value)
+	return &raw
 }
 
 func contextToStringPtr(context *interface{}) (*string, error) {
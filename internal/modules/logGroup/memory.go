@@ -0,0 +1,454 @@
+package loggroup
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
+)
+
+// MemoryEvents are the events of the groups kept in memory, which the groups are counted from.
+type MemoryEvents interface {
+	// EventTimes returns the times, in milliseconds, of the events of the group.
+	EventTimes(groupID string) []int64
+	// DeleteGroupEvents deletes the events of the groups.
+	DeleteGroupEvents(groupIDs []string)
+	// MoveGroupEvents moves the events of the groups to the group id.
+	MoveGroupEvents(groupIDs []string, id string)
+}
+
+// Assignees tells the users the groups may be assigned to.
+type Assignees interface {
+	Exists(id string) bool
+}
+
+// MemoryRepository keeps the groups in memory, for the tests and the instances without database.
+// The repository of the events attaches itself with SetEvents.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	groups map[string]Group
+	// merges maps the groups merged into another group to that group
+	merges    map[string]string
+	events    MemoryEvents
+	assignees Assignees
+}
+
+// NewMemoryRepository returns an empty repository. Groups may be assigned to anyone when
+// assignees is nil.
+func NewMemoryRepository(assignees Assignees) *MemoryRepository {
+	return &MemoryRepository{
+		groups:    make(map[string]Group),
+		merges:    make(map[string]string),
+		assignees: assignees,
+	}
+}
+
+func (r *MemoryRepository) SetEvents(events MemoryEvents) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = events
+}
+
+func (r *MemoryRepository) GetAll(_ context.Context, params GetAllParams) ([]*Group, error) {
+	groups := r.filter(params.FilterParams)
+	r.countEvents(groups, time.Now())
+
+	desc := params.SortOrder == "desc"
+	switch params.SortBy {
+	case SortByEventsLastHour, SortByEventsLastDay, SortByTrend:
+		slices.SortFunc(groups, func(a, b *Group) int {
+			if c := order(statsSortValue(a, params.SortBy), statsSortValue(b, params.SortBy), desc); c != 0 {
+				return c
+			}
+			if c := order(a.LastSeenAt, b.LastSeenAt, true); c != 0 {
+				return c
+			}
+			return order(a.ID, b.ID, true)
+		})
+	default:
+		slices.SortFunc(groups, func(a, b *Group) int {
+			if c := order(storedSortValue(a, params.SortBy), storedSortValue(b, params.SortBy), desc); c != 0 {
+				return c
+			}
+			return order(a.ID, b.ID, desc)
+		})
+	}
+
+	return utils.Page(groups, params.Offset, params.Limit), nil
+}
+
+func (r *MemoryRepository) Count(_ context.Context, params FilterParams) (int, error) {
+	return len(r.filter(params)), nil
+}
+
+func (r *MemoryRepository) GetByID(_ context.Context, id string) (*Group, error) {
+	r.mu.RLock()
+	group, ok := r.groups[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	r.countEvents([]*Group{&group}, time.Now())
+	return &group, nil
+}
+
+func (r *MemoryRepository) UpdateStatus(_ context.Context, id string, status Status) error {
+	return r.update(id, func(group *Group) error {
+		group.Status = status
+		group.SnoozeConditions = SnoozeConditions{}
+		return nil
+	})
+}
+
+func (r *MemoryRepository) UpdateAssignee(_ context.Context, id string, assigneeID *string) error {
+	if !r.assigneeExists(assigneeID) {
+		return ErrAssigneeNotFound
+	}
+
+	return r.update(id, func(group *Group) error {
+		group.AssigneeID = assigneeID
+		return nil
+	})
+}
+
+func (r *MemoryRepository) Snooze(_ context.Context, id string, snooze SnoozeConditions) error {
+	return r.update(id, func(group *Group) error {
+		group.Status = StatusSnoozed
+		group.SnoozeConditions = snooze
+		return nil
+	})
+}
+
+func (r *MemoryRepository) WakeExpired(_ context.Context, now int64) ([]*Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var woken []*Group
+	for id, group := range r.groups {
+		if group.Status != StatusSnoozed || group.Until == nil || *group.Until > now {
+			continue
+		}
+
+		group.Status = StatusUnresolved
+		group.SnoozeConditions = SnoozeConditions{}
+		r.groups[id] = group
+		woken = append(woken, &group)
+	}
+	return woken, nil
+}
+
+func (r *MemoryRepository) GetIDs(_ context.Context, params FilterParams, afterID string, limit int) ([]string, error) {
+	var ids []string
+	for _, group := range r.filter(params) {
+		if afterID == "" || group.ID > afterID {
+			ids = append(ids, group.ID)
+		}
+	}
+
+	slices.Sort(ids)
+	return utils.Page(ids, 0, limit), nil
+}
+
+func (r *MemoryRepository) UpdateStatuses(_ context.Context, ids []string, status Status) ([]*Change, error) {
+	return r.updateChanged(ids, func(group *Group) (*string, bool) {
+		if group.Status == status {
+			return nil, false
+		}
+
+		previous := string(group.Status)
+		group.Status = status
+		group.SnoozeConditions = SnoozeConditions{}
+		return &previous, true
+	}), nil
+}
+
+func (r *MemoryRepository) UpdateAssignees(_ context.Context, ids []string, assigneeID *string) ([]*Change, error) {
+	if !r.assigneeExists(assigneeID) {
+		return nil, ErrAssigneeNotFound
+	}
+
+	return r.updateChanged(ids, func(group *Group) (*string, bool) {
+		if equalPointers(group.AssigneeID, assigneeID) {
+			return nil, false
+		}
+
+		previous := group.AssigneeID
+		group.AssigneeID = assigneeID
+		return previous, true
+	}), nil
+}
+
+// DeleteByIDs deletes the groups along with their events.
+func (r *MemoryRepository) DeleteByIDs(_ context.Context, ids []string) (int, error) {
+	r.mu.Lock()
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := r.groups[id]; ok {
+			delete(r.groups, id)
+			deleted++
+		}
+	}
+	events := r.events
+	r.mu.Unlock()
+
+	if events != nil {
+		events.DeleteGroupEvents(ids)
+	}
+	return deleted, nil
+}
+
+// MergeInto merges the source groups into the group, as the database repositories do.
+func (r *MemoryRepository) MergeInto(_ context.Context, id string, sourceIDs []string) ([]*Group, error) {
+	r.mu.Lock()
+	groups := make([]*Group, 0, len(sourceIDs)+1)
+	for _, groupID := range append([]string{id}, sourceIDs...) {
+		if group, ok := r.groups[groupID]; ok {
+			groups = append(groups, &group)
+		}
+	}
+
+	target, sources, err := mergedGroups(groups, id, sourceIDs)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	r.groups[id] = *target
+	for fingerprint, groupID := range r.merges {
+		if slices.Contains(sourceIDs, groupID) {
+			r.merges[fingerprint] = id
+		}
+	}
+	for _, source := range sources {
+		delete(r.groups, source.ID)
+		r.merges[source.ID] = id
+	}
+	events := r.events
+	r.mu.Unlock()
+
+	if events != nil {
+		events.MoveGroupEvents(sourceIDs, id)
+	}
+	return sources, nil
+}
+
+// MergedInto returns the group the new events of a group go to, the group it was merged into if
+// any, or else the group itself.
+func (r *MemoryRepository) MergedInto(id string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if groupID, ok := r.merges[id]; ok {
+		return groupID
+	}
+	return id
+}
+
+// Upsert attaches a new event to its group as the event repositories do, creating the group on
+// its first event. A resolved group receiving a new event is a regression and goes back to
+// unresolved.
+func (r *MemoryRepository) Upsert(group *Group) *Occurrence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.groups[group.ID]
+	if !ok {
+		existing = *group
+		existing.Counter = 1
+		existing.Status = StatusUnresolved
+		r.groups[group.ID] = existing
+		return &Occurrence{Created: true, Counter: existing.Counter, Status: existing.Status}
+	}
+
+	regressed := existing.Status == StatusResolved
+	existing.Counter++
+	existing.LastSeenAt = group.LastSeenAt
+	if regressed {
+		existing.Status = StatusUnresolved
+	}
+	r.groups[group.ID] = existing
+
+	return &Occurrence{
+		Regressed:        regressed,
+		Counter:          existing.Counter,
+		Status:           existing.Status,
+		SnoozeConditions: existing.SnoozeConditions,
+	}
+}
+
+// Merge counts an imported event in its group, which is seen first and last at the times of its
+// events. The status of the group is left as is.
+func (r *MemoryRepository) Merge(group *Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.groups[group.ID]
+	if !ok {
+		existing = *group
+		existing.Counter = 1
+		existing.Status = StatusUnresolved
+		r.groups[group.ID] = existing
+		return
+	}
+
+	existing.Counter++
+	existing.FirstSeenAt = min(existing.FirstSeenAt, group.FirstSeenAt)
+	existing.LastSeenAt = max(existing.LastSeenAt, group.LastSeenAt)
+	r.groups[group.ID] = existing
+}
+
+func (r *MemoryRepository) filter(params FilterParams) []*Group {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var groups []*Group
+	for _, group := range r.groups {
+		if matchFilters(&group, params) {
+			groups = append(groups, &group)
+		}
+	}
+	return groups
+}
+
+func (r *MemoryRepository) update(id string, apply func(group *Group) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := apply(&group); err != nil {
+		return err
+	}
+	r.groups[id] = group
+	return nil
+}
+
+// updateChanged applies change to the groups and returns the ones it changed, along with their
+// previous value.
+func (r *MemoryRepository) updateChanged(ids []string, change func(group *Group) (*string, bool)) []*Change {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changes []*Change
+	for _, id := range ids {
+		group, ok := r.groups[id]
+		if !ok {
+			continue
+		}
+
+		previous, changed := change(&group)
+		if !changed {
+			continue
+		}
+
+		r.groups[id] = group
+		changes = append(changes, &Change{Group: group, Previous: previous})
+	}
+	return changes
+}
+
+func (r *MemoryRepository) assigneeExists(assigneeID *string) bool {
+	return assigneeID == nil || r.assignees == nil || r.assignees.Exists(*assigneeID)
+}
+
+// countEvents sets the event counts and the trend of the groups, as the stats join does.
+func (r *MemoryRepository) countEvents(groups []*Group, now time.Time) {
+	r.mu.RLock()
+	events := r.events
+	r.mu.RUnlock()
+
+	if events == nil {
+		return
+	}
+
+	args := statsArgs(now)
+	hourAgo, _ := args["hourAgo"].(int64)
+	dayAgo, _ := args["dayAgo"].(int64)
+	baselineFrom, _ := args["baselineFrom"].(int64)
+
+	for _, group := range groups {
+		baseline := 0
+		for _, t := range events.EventTimes(group.ID) {
+			switch {
+			case t >= hourAgo:
+				group.EventsLastHour++
+				group.EventsLastDay++
+			case t >= dayAgo:
+				group.EventsLastDay++
+			case t >= baselineFrom:
+				baseline++
+			}
+		}
+
+		trend := float64(group.EventsLastDay+1) / (float64(baseline)/trendBaselineDays + 1)
+		group.Trend = math.Round(trend*100) / 100
+	}
+}
+
+func matchFilters(group *Group, params FilterParams) bool {
+	switch {
+	case params.ProjectID != "" && group.ProjectID != params.ProjectID:
+		return false
+	case params.TimeFrom != 0 && group.LastSeenAt < params.TimeFrom:
+		return false
+	case params.TimeTo != 0 && group.LastSeenAt > params.TimeTo:
+		return false
+	case params.Level != "" && string(group.Level) != params.Level:
+		return false
+	case params.Search != "" && !query.MatchSearch(params.Search, params.SearchMode, group.Message):
+		return false
+	case params.AssigneeID != "" && (group.AssigneeID == nil || *group.AssigneeID != params.AssigneeID):
+		return false
+	case params.Unassigned && group.AssigneeID != nil:
+		return false
+	default:
+		return true
+	}
+}
+
+func storedSortValue(group *Group, sortBy string) int64 {
+	switch sortBy {
+	case SortByFirstSeenAt:
+		return group.FirstSeenAt
+	case SortByCounter:
+		return int64(group.Counter)
+	default:
+		return group.LastSeenAt
+	}
+}
+
+func statsSortValue(group *Group, sortBy string) float64 {
+	switch sortBy {
+	case SortByEventsLastHour:
+		return float64(group.EventsLastHour)
+	case SortByEventsLastDay:
+		return float64(group.EventsLastDay)
+	default:
+		return group.Trend
+	}
+}
+
+func order[T cmp.Ordered](a, b T, desc bool) int {
+	if desc {
+		return cmp.Compare(b, a)
+	}
+	return cmp.Compare(a, b)
+}
+
+func equalPointers(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -1,5 +1,16 @@
 package loggroup
 
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/utils"
+	"github.com/google/uuid"
+)
+
 type Logger interface {
 	Debug(msg string)
 	Info(msg string)
@@ -7,28 +18,131 @@ type Logger interface {
 	Error(msg string)
 }
 
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event)
+}
+
 type FilterParams struct {
 	ProjectID string
 	TimeFrom  int64
 	TimeTo    int64
 	Level     string
 	Search    string
+	// SearchMode is query.SearchContains or query.SearchFullText, contains by default
+	SearchMode string
+	// AssigneeID keeps the groups assigned to the user, Unassigned the ones assigned to nobody
+	AssigneeID string
+	Unassigned bool
+}
+
+// AssigneeNone is the assignee filter keeping the groups assigned to nobody.
+const AssigneeNone = "none"
+
+var ErrInvalidAssignee = errors.New("invalid assignee")
+
+// ParseFilter reads the filters of the log group list from its query parameters. Times are in
+// seconds and the assignee is a user ID or AssigneeNone.
+func ParseFilter(values url.Values) (FilterParams, error) {
+	searchMode, err := query.ParseSearchMode(values.Get("searchMode"))
+	if err != nil {
+		return FilterParams{}, err
+	}
+
+	var (
+		assigneeID string
+		unassigned bool
+	)
+	switch assignee := values.Get("assignee"); assignee {
+	case "":
+	case AssigneeNone:
+		unassigned = true
+	default:
+		if _, err := uuid.Parse(assignee); err != nil {
+			return FilterParams{}, ErrInvalidAssignee
+		}
+		assigneeID = assignee
+	}
+
+	timeFrom, _ := utils.ParseTimeParam(values.Get("timeFrom"))
+	timeTo, _ := utils.ParseTimeParam(values.Get("timeTo"))
+
+	return FilterParams{
+		ProjectID:  values.Get("projectId"),
+		TimeFrom:   timeFrom,
+		TimeTo:     timeTo,
+		Level:      values.Get("level"),
+		Search:     values.Get("search"),
+		SearchMode: searchMode,
+		AssigneeID: assigneeID,
+		Unassigned: unassigned,
+	}, nil
 }
 
+const (
+	SortByLastSeenAt     = "lastSeenAt"
+	SortByFirstSeenAt    = "firstSeenAt"
+	SortByCounter        = "counter"
+	SortByEventsLastHour = "eventsLastHour"
+	SortByEventsLastDay  = "eventsLastDay"
+	SortByTrend          = "trend"
+)
+
 type GetAllParams struct {
 	FilterParams
+	SortBy    string `validate:"omitempty,oneof=lastSeenAt firstSeenAt counter eventsLastHour eventsLastDay trend"`
 	SortOrder string `validate:"omitempty,oneof=asc desc"`
 	Limit     int
 	Offset    int
 }
 
+type UpdateStatus struct {
+	Status string `json:"status" validate:"required,oneof=unresolved resolved ignored" example:"resolved"`
+}
+
+type Assign struct {
+	// User the group is assigned to, unassigned when null
+	AssigneeID *string `json:"assigneeId" validate:"omitempty,uuid" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+}
+
+// Snooze silences a group until one of its conditions is met, at least one is required.
+type Snooze struct {
+	// Seconds to snooze the group for
+	Duration int64 `json:"duration" validate:"required_without_all=Occurrences,omitempty,min=1" example:"3600"`
+	// Number of new occurrences of the group
+	Occurrences int `json:"occurrences" validate:"required_without_all=Duration,omitempty,min=1" example:"100"`
+}
+
+// Merge merges other groups of the project into a group, which their events and their new events
+// go to. The merged groups are deleted.
+type Merge struct {
+	GroupIDs []string `json:"groupIds" validate:"required,min=1,max=100,dive,required" example:"6e1ff2e1ad1ed16e2b0dc4a0ac1c0e9b8dd82e1b0cb8e3ef3a4c2f73c9a1b6f2"`
+}
+
+type SnoozeEntity struct {
+	SnoozedAt int64 `json:"snoozedAt" example:"1704067200"`
+	// Time the snooze ends at
+	Until *int64 `json:"until,omitempty" example:"1704070800"`
+	// Counter of the group ending the snooze
+	Counter *int `json:"counter,omitempty" example:"118"`
+}
+
 type Entity struct {
-	ID          string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
-	Level       string `json:"level" example:"INFO"`
-	Message     string `json:"message" validate:"required" example:"Log message"`
-	FirstSeenAt int64  `json:"firstSeenAt" example:"1704067200"`
-	LastSeenAt  int64  `json:"lastSeenAt" example:"1704067200"`
-	Counter     int    `json:"counter" example:"18"`
+	ID          string  `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Level       string  `json:"level" example:"INFO"`
+	Message     string  `json:"message" validate:"required" example:"Log message"`
+	FirstSeenAt int64   `json:"firstSeenAt" example:"1704067200"`
+	LastSeenAt  int64   `json:"lastSeenAt" example:"1704067200"`
+	Counter     int     `json:"counter" example:"18"`
+	Status      string  `json:"status" example:"unresolved" enums:"unresolved,resolved,ignored,snoozed"`
+	AssigneeID  *string `json:"assigneeId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	// Set while the group is snoozed
+	Snooze *SnoozeEntity `json:"snooze,omitempty"`
+	// Number of events received during the last hour
+	EventsLastHour int `json:"eventsLastHour" example:"3"`
+	// Number of events received during the last 24 hours
+	EventsLastDay int `json:"eventsLastDay" example:"42"`
+	// Ratio of the last 24 hours rate to the daily rate of the previous 7 days
+	Trend float64 `json:"trend" example:"2.5"`
 }
 
 type EntityList struct {
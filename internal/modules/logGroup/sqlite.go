@@ -0,0 +1,95 @@
+package loggroup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fuckbug/api/internal/storage"
+)
+
+// sqliteRepository stores the log groups in SQLite. It runs its own queries where PostgreSQL ones
+// have no SQLite equivalent and shares the others.
+type sqliteRepository struct {
+	*repository
+}
+
+// UpdateStatuses sets the status of the groups and returns the ones that changed.
+func (r *sqliteRepository) UpdateStatuses(ctx context.Context, ids []string, status Status) ([]*Change, error) {
+	changes, err := r.updateChanged(ctx, ids, "status", status,
+		"status = $1, snoozed_at = NULL, snooze_until = NULL, snooze_counter = NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update log group statuses: %w", err)
+	}
+	return changes, nil
+}
+
+// UpdateAssignees sets the assignee of the groups and returns the ones that changed.
+func (r *sqliteRepository) UpdateAssignees(ctx context.Context, ids []string, assigneeID *string) ([]*Change, error) {
+	changes, err := r.updateChanged(ctx, ids, "assignee_id", assigneeID, "assignee_id = $1")
+	if err != nil {
+		if storage.IsForeignKeyViolation(err) {
+			return nil, ErrAssigneeNotFound
+		}
+		return nil, fmt.Errorf("failed to update log group assignees: %w", err)
+	}
+	return changes, nil
+}
+
+// updateChanged applies set to the groups whose column is not value yet, and returns them along
+// with their previous value. SQLite returns the updated rows only, the previous values are read
+// first within the transaction.
+func (r *sqliteRepository) updateChanged(
+	ctx context.Context,
+	ids []string,
+	column string,
+	value interface{},
+	set string,
+) (changes []*Change, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	// The value is bound first, SQLite numbering the parameters in the order they appear.
+	filter := ` WHERE ` + column + ` IS NOT $1 AND ` + r.dialect.In("id", "$2")
+
+	var previous []struct {
+		ID       string  `db:"id"`
+		Previous *string `db:"previous"`
+	}
+	query := `SELECT id, CAST(` + column + ` AS TEXT) AS previous FROM log_groups` + filter
+	if err = tx.SelectContext(ctx, &previous, query, value, r.dialect.List(ids)); err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	query = `UPDATE log_groups SET ` + set + filter + ` RETURNING ` + returnedColumns
+	if err = tx.SelectContext(ctx, &groups, query, value, r.dialect.List(ids)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	previousValues := make(map[string]*string, len(previous))
+	for _, group := range previous {
+		previousValues[group.ID] = group.Previous
+	}
+
+	changes = make([]*Change, 0, len(groups))
+	for _, group := range groups {
+		changes = append(changes, &Change{Group: *group, Previous: previousValues[group.ID]})
+	}
+	return changes, nil
+}
@@ -0,0 +1,51 @@
+package loggroup
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultWakeInterval = time.Minute
+
+type WakerConfig struct {
+	// Interval between two checks of the snoozes ending by time
+	Interval time.Duration
+}
+
+// Waker periodically returns the groups whose snooze duration elapsed to unresolved,
+// including the ones that receive no new events.
+type Waker struct {
+	service  Service
+	logger   Logger
+	interval time.Duration
+}
+
+func NewWaker(service Service, logger Logger, config WakerConfig) *Waker {
+	if config.Interval <= 0 {
+		config.Interval = defaultWakeInterval
+	}
+
+	return &Waker{
+		service:  service,
+		logger:   logger,
+		interval: config.Interval,
+	}
+}
+
+func (w *Waker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.service.WakeExpired(ctx, time.Now()); err != nil {
+			w.logger.Error(fmt.Sprintf("failed to wake snoozed log groups: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
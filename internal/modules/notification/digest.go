@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultInterval    = time.Hour
+	defaultGroupsLimit = 10
+)
+
+// DigestScheduler periodically sends the daily and weekly digests that are due.
+type DigestScheduler struct {
+	repo    Repository
+	service Service
+	logger  Logger
+	config  Config
+}
+
+func NewDigestScheduler(repo Repository, service Service, logger Logger, config Config) *DigestScheduler {
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+
+	return &DigestScheduler{
+		repo:    repo,
+		service: service,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+func (d *DigestScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.SendDue(ctx, time.Now()); err != nil {
+			d.logger.Error(fmt.Sprintf("digest scheduling failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *DigestScheduler) SendDue(ctx context.Context, now time.Time) error {
+	for _, digest := range []Digest{DigestDaily, DigestWeekly} {
+		// Digests due before the next check are sent now, so they do not drift by an interval every period.
+		before := now.Add(-digestPeriod(digest) + d.config.Interval).Unix()
+
+		subscriptions, err := d.repo.GetDueDigests(ctx, digest, before)
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range subscriptions {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err := d.service.SendDigest(ctx, subscription, now); err != nil {
+				d.logger.Error(fmt.Sprintf(
+					"failed to send %s digest of project %s to %s: %v",
+					digest, subscription.ProjectID, subscription.Email, err,
+				))
+			}
+		}
+	}
+
+	return nil
+}
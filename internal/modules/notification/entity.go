@@ -0,0 +1,34 @@
+package notification
+
+type Digest string
+
+const (
+	DigestNone   Digest = "none"
+	DigestDaily  Digest = "daily"
+	DigestWeekly Digest = "weekly"
+)
+
+type Preference struct {
+	UserID       string `db:"user_id"`
+	ProjectID    string `db:"project_id"`
+	Alerts       bool   `db:"alerts"`
+	Regressions  bool   `db:"regressions"`
+	Digest       Digest `db:"digest"`
+	LastDigestAt int64  `db:"last_digest_at"`
+	CreatedAt    int64  `db:"created_at"`
+	UpdatedAt    int64  `db:"updated_at"`
+}
+
+// DigestSubscription is a preference that is due for a digest, with the address to send it to.
+type DigestSubscription struct {
+	Preference
+	Email       string `db:"email"`
+	ProjectName string `db:"project_name"`
+}
+
+type DigestGroup struct {
+	ID      string `db:"id"`
+	Kind    string `db:"kind"`
+	Message string `db:"message"`
+	Events  int    `db:"events"`
+}
@@ -0,0 +1,197 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSMTPTimeout = 10 * time.Second
+
+var ErrNoRecipients = errors.New("no recipients")
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// TLS connects over implicit TLS, otherwise STARTTLS is used when the server offers it
+	TLS     bool
+	Timeout time.Duration
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) Mailer {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultSMTPTimeout
+	}
+	return &smtpMailer{config: config}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message *Message) error {
+	if len(message.To) == 0 {
+		return ErrNoRecipients
+	}
+
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	body, err := buildMIME(from, message)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range message.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m *smtpMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: m.config.Timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	deadline := time.Now().Add(m.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	tlsConfig := &tls.Config{ServerName: m.config.Host, MinVersion: tls.VersionTLS12}
+	if m.config.TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+
+	if !m.config.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				_ = client.Close()
+				return nil, fmt.Errorf("smtp STARTTLS failed: %w", err)
+			}
+		}
+	}
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// buildMIME renders a multipart/alternative message with the text and HTML bodies.
+func buildMIME(from *mail.Address, message *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(message.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	header := strings.Join(headers, "\r\n") + "\r\n\r\n"
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message part: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("failed to write message part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write message part: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close message: %w", err)
+	}
+
+	return append([]byte(header), buf.Bytes()...), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+// startSMTPStub accepts a single session speaking the minimal subset of SMTP used by the mailer.
+func startSMTPStub(t *testing.T) (string, int, <-chan receivedMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan receivedMail, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var mail receivedMail
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimSpace(line)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				mail.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				mail.recipients = append(mail.recipients, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				mail.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				received <- mail
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, received := startSMTPStub(t)
+
+	mailer := NewSMTPMailer(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "FuckBug <noreply@fuckbug.io>",
+	})
+
+	text, html, err := render(templateRegression, regressionData{
+		ProjectName: "Shop",
+		GroupKind:   "error",
+		GroupID:     "5d41402abc4b2a76b9719d911017c592",
+		Message:     "Division by zero <in> calculate()",
+		Time:        "2024-01-01 00:00:00 UTC",
+	})
+	require.NoError(t, err)
+
+	err = mailer.Send(context.Background(), &Message{
+		To:      []string{"dev@example.com", "ops@example.com"},
+		Subject: "[Shop] Regression: Division by zero",
+		Text:    text,
+		HTML:    html,
+	})
+	require.NoError(t, err)
+
+	mail := <-received
+	assert.Equal(t, "noreply@fuckbug.io", mail.from)
+	assert.Equal(t, []string{"dev@example.com", "ops@example.com"}, mail.recipients)
+	assert.Contains(t, mail.data, "Subject: [Shop] Regression: Division by zero")
+	assert.Contains(t, mail.data, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, mail.data, "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, mail.data, "Content-Type: text/html; charset=utf-8")
+	assert.Contains(t, mail.data, "Message: Division by zero <in> calculate()")
+	assert.Contains(t, html, "Division by zero &lt;in&gt; calculate()")
+}
+
+func TestSMTPMailerRequiresRecipients(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: 25, From: "noreply@fuckbug.io"})
+
+	err := mailer.Send(context.Background(), &Message{Subject: "test"})
+	assert.ErrorIs(t, err, ErrNoRecipients)
+}
+
+func TestRenderDigest(t *testing.T) {
+	text, html, err := render(templateDigest, digestData{
+		Title:       "Weekly",
+		ProjectName: "Shop",
+		From:        "2024-01-01",
+		To:          "2024-01-08",
+		Errors:      42,
+		Logs:        1337,
+		NewGroups:   []*DigestGroup{{ID: "a", Kind: "error", Message: "boom", Events: 3}},
+		TopGroups:   []*DigestGroup{{ID: "b", Kind: "log", Message: "slow query", Events: 99}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, text, "Weekly digest of Shop")
+	assert.Contains(t, text, "Errors: 42")
+	assert.Contains(t, text, "[error] boom")
+	assert.Contains(t, text, "99 x [log] slow query")
+	assert.Contains(t, html, "<li>[error] boom</li>")
+	assert.Contains(t, html, strconv.Itoa(1337))
+}
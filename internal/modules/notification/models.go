@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	errorsModule "github.com/fuckbug/api/internal/modules/errors"
+	logModule "github.com/fuckbug/api/internal/modules/log"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// Mailer sends a rendered message.
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+type ErrorStats interface {
+	GetStats(ctx context.Context, projectID string, fingerprint string) (*errorsModule.Stats, error)
+}
+
+type LogStats interface {
+	GetStats(ctx context.Context, projectID string, fingerprint string) (*logModule.Stats, error)
+}
+
+type Config struct {
+	// Interval between two checks for due digests
+	Interval time.Duration
+	// GroupsLimit is the number of new and top groups listed in a digest
+	GroupsLimit int
+}
+
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type UpdatePreference struct {
+	// Receive an email for every alert fired in the project
+	Alerts bool `json:"alerts" example:"true"`
+	// Receive an email when a resolved group regresses
+	Regressions bool `json:"regressions" example:"true"`
+	// Digest frequency
+	Digest string `json:"digest" validate:"required,oneof=none daily weekly" example:"weekly"`
+}
+
+type PreferenceEntity struct {
+	ProjectID   string `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Alerts      bool   `json:"alerts" example:"true"`
+	Regressions bool   `json:"regressions" example:"true"`
+	Digest      string `json:"digest" example:"weekly"`
+	// Time of the last digest sent, unix seconds
+	LastDigestAt int64 `json:"lastDigestAt" example:"1704067200"`
+}
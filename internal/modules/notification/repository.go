@@ -0,0 +1,206 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// recipientColumns maps a notification kind to its preference column.
+var recipientColumns = map[string]string{
+	kindAlerts:      "alerts",
+	kindRegressions: "regressions",
+}
+
+type Repository interface {
+	GetPreference(ctx context.Context, userID, projectID string) (*Preference, error)
+	UpsertPreference(ctx context.Context, preference *Preference) error
+	GetRecipients(ctx context.Context, projectID, kind string) ([]string, error)
+	GetProjectName(ctx context.Context, projectID string) (string, error)
+	GetDueDigests(ctx context.Context, digest Digest, before int64) ([]*DigestSubscription, error)
+	MarkDigestSent(ctx context.Context, userID, projectID string, sentAt int64) error
+	GetNewGroups(ctx context.Context, projectID string, since int64, limit int) ([]*DigestGroup, error)
+	GetTopGroups(ctx context.Context, projectID string, since int64, limit int) ([]*DigestGroup, error)
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *repository) GetPreference(ctx context.Context, userID, projectID string) (*Preference, error) {
+	const query = `
+		SELECT user_id, project_id, alerts, regressions, digest, last_digest_at, created_at, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1 AND project_id = $2
+	`
+
+	var preference Preference
+	err := r.db.GetContext(ctx, &preference, query, userID, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return &preference, nil
+}
+
+func (r *repository) UpsertPreference(ctx context.Context, p *Preference) error {
+	const query = `
+		INSERT INTO notification_preferences (
+			user_id, project_id, alerts, regressions, digest, last_digest_at, created_at, updated_at
+		) VALUES (
+			:user_id, :project_id, :alerts, :regressions, :digest, :last_digest_at, :created_at, :updated_at
+		)
+		ON CONFLICT (user_id, project_id) DO UPDATE
+		SET
+			alerts = EXCLUDED.alerts,
+			regressions = EXCLUDED.regressions,
+			digest = EXCLUDED.digest,
+			last_digest_at = EXCLUDED.last_digest_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	now := time.Now().Unix()
+	if p.CreatedAt == 0 {
+		p.CreatedAt = now
+	}
+	p.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, p)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetRecipients(ctx context.Context, projectID, kind string) ([]string, error) {
+	column, ok := recipientColumns[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification kind %q", kind)
+	}
+
+	query := `
+		SELECT u.email
+		FROM notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.project_id = $1 AND p.` + column
+
+	var emails []string
+	err := r.db.SelectContext(ctx, &emails, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification recipients: %w", err)
+	}
+	return emails, nil
+}
+
+func (r *repository) GetProjectName(ctx context.Context, projectID string) (string, error) {
+	const query = `SELECT name FROM projects WHERE id = $1`
+
+	var name string
+	err := r.db.GetContext(ctx, &name, query, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get project name: %w", err)
+	}
+	return name, nil
+}
+
+func (r *repository) GetDueDigests(ctx context.Context, digest Digest, before int64) ([]*DigestSubscription, error) {
+	const query = `
+		SELECT
+			p.user_id, p.project_id, p.alerts, p.regressions, p.digest, p.last_digest_at, p.created_at, p.updated_at,
+			u.email, pr.name AS project_name
+		FROM notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		JOIN projects pr ON pr.id = p.project_id AND pr.deleted_at IS NULL
+		WHERE p.digest = $1 AND p.last_digest_at <= $2
+	`
+
+	var subscriptions []*DigestSubscription
+	err := r.db.SelectContext(ctx, &subscriptions, query, digest, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due digests: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (r *repository) MarkDigestSent(ctx context.Context, userID, projectID string, sentAt int64) error {
+	const query = `UPDATE notification_preferences SET last_digest_at = $1 WHERE user_id = $2 AND project_id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, sentAt, userID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest as sent: %w", err)
+	}
+	return nil
+}
+
+// GetNewGroups returns the error and log groups first seen since the given unix time.
+func (r *repository) GetNewGroups(ctx context.Context, projectID string, since int64, limit int) ([]*DigestGroup, error) {
+	const query = `
+		SELECT id, kind, message, counter AS events
+		FROM (
+			SELECT id, 'error' AS kind, message, counter, first_seen_at
+			FROM error_groups
+			WHERE project_id = $1 AND first_seen_at >= $2
+			UNION ALL
+			SELECT id, 'log' AS kind, message, counter, first_seen_at
+			FROM log_groups
+			WHERE project_id = $1 AND first_seen_at >= $2
+		) g
+		ORDER BY first_seen_at DESC
+		LIMIT $3
+	`
+
+	var groups []*DigestGroup
+	err := r.db.SelectContext(ctx, &groups, query, projectID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new groups: %w", err)
+	}
+	return groups, nil
+}
+
+// GetTopGroups returns the error and log groups with the most events since the given unix time in milliseconds.
+func (r *repository) GetTopGroups(ctx context.Context, projectID string, since int64, limit int) ([]*DigestGroup, error) {
+	const query = `
+		SELECT id, kind, message, events
+		FROM (
+			SELECT g.id, 'error' AS kind, g.message, COUNT(*) AS events
+			FROM errors e
+			JOIN error_groups g ON g.id = e.fingerprint
+			WHERE e.project_id = $1 AND e.time >= $2
+			GROUP BY g.id, g.message
+			UNION ALL
+			SELECT g.id, 'log' AS kind, g.message, COUNT(*) AS events
+			FROM logs l
+			JOIN log_groups g ON g.id = l.fingerprint
+			WHERE l.project_id = $1 AND l.time >= $2
+			GROUP BY g.id, g.message
+		) g
+		ORDER BY events DESC
+		LIMIT $3
+	`
+
+	var groups []*DigestGroup
+	err := r.db.SelectContext(ctx, &groups, query, projectID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top groups: %w", err)
+	}
+	return groups, nil
+}
@@ -0,0 +1,327 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/alert"
+)
+
+const (
+	kindAlerts      = "alerts"
+	kindRegressions = "regressions"
+
+	sendTimeout = 30 * time.Second
+	timeLayout  = "2006-01-02 15:04:05 MST"
+	dateLayout  = "2006-01-02"
+)
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+type Service interface {
+	GetPreference(ctx context.Context, projectID string) (*PreferenceEntity, error)
+	UpdatePreference(ctx context.Context, projectID string, req *UpdatePreference) (*PreferenceEntity, error)
+	Notify(ctx context.Context, target string, firing *alert.FiringEntity) error
+	HandleEvent(ctx context.Context, event events.Event)
+	SendDigest(ctx context.Context, subscription *DigestSubscription, now time.Time) error
+}
+
+type service struct {
+	repo        Repository
+	mailer      Mailer
+	errorStats  ErrorStats
+	logStats    LogStats
+	logger      Logger
+	groupsLimit int
+}
+
+func NewService(
+	repo Repository,
+	mailer Mailer,
+	errorStats ErrorStats,
+	logStats LogStats,
+	logger Logger,
+	config Config,
+) Service {
+	if config.GroupsLimit <= 0 {
+		config.GroupsLimit = defaultGroupsLimit
+	}
+
+	return &service{
+		repo:        repo,
+		mailer:      mailer,
+		errorStats:  errorStats,
+		logStats:    logStats,
+		logger:      logger,
+		groupsLimit: config.GroupsLimit,
+	}
+}
+
+func (s *service) GetPreference(ctx context.Context, projectID string) (*PreferenceEntity, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	preference, err := s.repo.GetPreference(ctx, userID, projectID)
+	if errors.Is(err, ErrNotFound) {
+		return toResponse(defaultPreference(userID, projectID)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponse(preference), nil
+}
+
+func (s *service) UpdatePreference(
+	ctx context.Context,
+	projectID string,
+	req *UpdatePreference,
+) (*PreferenceEntity, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	preference, err := s.repo.GetPreference(ctx, userID, projectID)
+	if errors.Is(err, ErrNotFound) {
+		preference = defaultPreference(userID, projectID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Changing the frequency starts a new period, so the first digest covers a full one.
+	if preference.Digest != Digest(req.Digest) {
+		preference.LastDigestAt = time.Now().Unix()
+	}
+
+	preference.Alerts = req.Alerts
+	preference.Regressions = req.Regressions
+	preference.Digest = Digest(req.Digest)
+
+	if err := s.repo.UpsertPreference(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return toResponse(preference), nil
+}
+
+// Notify sends a fired alert to the address of an email action.
+func (s *service) Notify(ctx context.Context, target string, firing *alert.FiringEntity) error {
+	return s.sendAlert(ctx, []string{target}, firing)
+}
+
+// HandleEvent emails fired alerts and regressions to the users who opted in for them.
+// It is meant to be subscribed to the event bus.
+func (s *service) HandleEvent(ctx context.Context, event events.Event) {
+	var kind string
+	switch event.Type {
+	case events.TypeAlertFired:
+		kind = kindAlerts
+	case events.TypeGroupRegressed, events.TypeGroupUnsnoozed:
+		kind = kindRegressions
+	default:
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+		defer cancel()
+
+		if err := s.handle(ctx, kind, event); err != nil {
+			s.logger.Error(fmt.Sprintf("failed to send %s notification: %v", kind, err))
+		}
+	}()
+}
+
+func (s *service) handle(ctx context.Context, kind string, event events.Event) error {
+	recipients, err := s.repo.GetRecipients(ctx, event.ProjectID, kind)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	if kind == kindAlerts {
+		firing, ok := event.Payload.(*alert.FiringEntity)
+		if !ok {
+			return fmt.Errorf("unexpected alert payload %T", event.Payload)
+		}
+		return s.sendAlert(ctx, recipients, firing)
+	}
+
+	projectName, err := s.repo.GetProjectName(ctx, event.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	text, html, err := render(templateRegression, regressionData{
+		ProjectName: projectName,
+		GroupKind:   event.GroupKind,
+		GroupID:     event.GroupID,
+		Message:     event.Message,
+		Time:        time.UnixMilli(event.Time).UTC().Format(timeLayout),
+		Unsnoozed:   event.Type == events.TypeGroupUnsnoozed,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := "Regression"
+	if event.Type == events.TypeGroupUnsnoozed {
+		subject = "Snooze ended"
+	}
+
+	return s.sendEach(ctx, recipients, &Message{
+		Subject: fmt.Sprintf("[%s] %s: %s", projectName, subject, truncate(event.Message)),
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+func (s *service) sendAlert(ctx context.Context, recipients []string, firing *alert.FiringEntity) error {
+	text, html, err := render(templateAlert, alertData{
+		RuleName:  firing.RuleName,
+		EventType: firing.EventType,
+		GroupKind: firing.GroupKind,
+		GroupID:   firing.GroupID,
+		Message:   firing.Message,
+		FiredAt:   time.Unix(firing.FiredAt, 0).UTC().Format(timeLayout),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sendEach(ctx, recipients, &Message{
+		Subject: fmt.Sprintf("Alert %q fired: %s", firing.RuleName, truncate(firing.Message)),
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+// sendEach sends the message to every recipient apart, so they don't see each other's addresses.
+// A failed recipient doesn't keep the message from the others.
+func (s *service) sendEach(ctx context.Context, recipients []string, message *Message) error {
+	var errs []error
+	for _, recipient := range recipients {
+		sent := *message
+		sent.To = []string{recipient}
+		if err := s.mailer.Send(ctx, &sent); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send to %s: %w", recipient, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendDigest sends the digest of the period elapsed since the last one of the subscription.
+func (s *service) SendDigest(ctx context.Context, subscription *DigestSubscription, now time.Time) error {
+	period := digestPeriod(subscription.Digest)
+	if period == 0 {
+		return nil
+	}
+
+	from := now.Add(-period)
+	if last := time.Unix(subscription.LastDigestAt, 0); last.After(from) {
+		from = last
+	}
+
+	newGroups, err := s.repo.GetNewGroups(ctx, subscription.ProjectID, from.Unix(), s.groupsLimit)
+	if err != nil {
+		return err
+	}
+
+	topGroups, err := s.repo.GetTopGroups(ctx, subscription.ProjectID, from.UnixMilli(), s.groupsLimit)
+	if err != nil {
+		return err
+	}
+
+	errorStats, err := s.errorStats.GetStats(ctx, subscription.ProjectID, "")
+	if err != nil {
+		return err
+	}
+
+	logStats, err := s.logStats.GetStats(ctx, subscription.ProjectID, "")
+	if err != nil {
+		return err
+	}
+
+	data := digestData{
+		ProjectName: subscription.ProjectName,
+		From:        from.UTC().Format(dateLayout),
+		To:          now.UTC().Format(dateLayout),
+		NewGroups:   newGroups,
+		TopGroups:   topGroups,
+	}
+
+	if subscription.Digest == DigestDaily {
+		data.Title = "Daily"
+		data.Errors = errorStats.Last24h
+		data.Logs = logStats.Last24h
+	} else {
+		data.Title = "Weekly"
+		data.Errors = errorStats.Last7d
+		data.Logs = logStats.Last7d
+	}
+
+	text, html, err := render(templateDigest, data)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, &Message{
+		To:      []string{subscription.Email},
+		Subject: fmt.Sprintf("[%s] %s digest", subscription.ProjectName, data.Title),
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MarkDigestSent(ctx, subscription.UserID, subscription.ProjectID, now.Unix())
+}
+
+func digestPeriod(digest Digest) time.Duration {
+	switch digest {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func defaultPreference(userID, projectID string) *Preference {
+	return &Preference{
+		UserID:    userID,
+		ProjectID: projectID,
+		Digest:    DigestNone,
+	}
+}
+
+func truncate(message string) string {
+	const maxLength = 80
+
+	runes := []rune(message)
+	if len(runes) <= maxLength {
+		return message
+	}
+	return string(runes[:maxLength]) + "…"
+}
+
+func toResponse(p *Preference) *PreferenceEntity {
+	return &PreferenceEntity{
+		ProjectID:    p.ProjectID,
+		Alerts:       p.Alerts,
+		Regressions:  p.Regressions,
+		Digest:       string(p.Digest),
+		LastDigestAt: p.LastDigestAt,
+	}
+}
@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recipientsRepository struct {
+	Repository
+	recipients []string
+}
+
+func (r *recipientsRepository) GetRecipients(context.Context, string, string) ([]string, error) {
+	return r.recipients, nil
+}
+
+type recordingMailer struct {
+	sent []*Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, message *Message) error {
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func TestServiceSendsAlertToEachRecipient(t *testing.T) {
+	repo := &recipientsRepository{recipients: []string{"dev@example.com", "ops@example.com"}}
+	mailer := &recordingMailer{}
+	s := NewService(repo, mailer, nil, nil, logger.New("ERROR", nil), Config{}).(*service)
+
+	err := s.handle(context.Background(), kindAlerts, events.Event{
+		ProjectID: "p",
+		Payload:   &alert.FiringEntity{RuleName: "Spike", Message: "Nil pointer"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, mailer.sent, 2)
+	for i, recipient := range repo.recipients {
+		assert.Equal(t, []string{recipient}, mailer.sent[i].To)
+		assert.Equal(t, `Alert "Spike" fired: Nil pointer`, mailer.sent[i].Subject)
+	}
+}
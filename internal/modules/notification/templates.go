@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	textTemplate "text/template"
+)
+
+const (
+	templateAlert      = "alert"
+	templateRegression = "regression"
+	templateDigest     = "digest"
+)
+
+//go:embed templates/*.txt templates/*.html
+var templatesFS embed.FS
+
+var (
+	textTemplates = textTemplate.Must(textTemplate.ParseFS(templatesFS, "templates/*.txt"))
+	htmlTemplates = htmlTemplate.Must(htmlTemplate.ParseFS(templatesFS, "templates/*.html"))
+)
+
+type alertData struct {
+	RuleName  string
+	EventType string
+	GroupKind string
+	GroupID   string
+	Message   string
+	FiredAt   string
+}
+
+type regressionData struct {
+	ProjectName string
+	GroupKind   string
+	GroupID     string
+	Message     string
+	Time        string
+	// Unsnoozed is set when the group comes back because its snooze ended
+	Unsnoozed bool
+}
+
+type digestData struct {
+	Title       string
+	ProjectName string
+	From        string
+	To          string
+	Errors      int
+	Logs        int
+	NewGroups   []*DigestGroup
+	TopGroups   []*DigestGroup
+}
+
+// render executes the text and HTML versions of a template.
+func render(name string, data interface{}) (string, string, error) {
+	var text, html bytes.Buffer
+
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s text template: %w", name, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s html template: %w", name, err)
+	}
+
+	return text.String(), html.String(), nil
+}
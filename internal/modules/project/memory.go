@@ -0,0 +1,124 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps the projects in memory, for the tests and the instances without database.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	projects map[string]Project
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{projects: make(map[string]Project)}
+}
+
+func (r *MemoryRepository) GetAll(ctx context.Context, params GetAllParams) ([]*Project, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	projects := r.creatorProjects(userID)
+	slices.SortFunc(projects, func(a, b *Project) int {
+		if params.SortOrder == "desc" {
+			return strings.Compare(b.ID, a.ID)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return utils.Page(projects, params.Offset, params.Limit), nil
+}
+
+func (r *MemoryRepository) Count(ctx context.Context) (int, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return 0, fmt.Errorf("unauthorized")
+	}
+	return len(r.creatorProjects(userID)), nil
+}
+
+func (r *MemoryRepository) GetByID(_ context.Context, id string) (*Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.projects[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, p *Project) error {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return fmt.Errorf("unauthorized")
+	}
+
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	p.CreatorID = userID
+	p.PublicKey = generateRandomKey()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.projects[p.ID] = *p
+	return nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, id string, updated *Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.projects[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated.ID = id
+	updated.UpdatedAt = time.Now().Unix()
+
+	p.Name = updated.Name
+	p.UpdatedAt = updated.UpdatedAt
+	r.projects[id] = p
+	return nil
+}
+
+func (r *MemoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.projects[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.projects, id)
+	return nil
+}
+
+func (r *MemoryRepository) creatorProjects(userID string) []*Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*Project
+	for _, p := range r.projects {
+		if p.CreatorID == userID && p.DeletedAt == nil {
+			projects = append(projects, &p)
+		}
+	}
+	return projects
+}
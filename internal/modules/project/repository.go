@@ -172,10 +172,18 @@ func (r *repository) Update(ctx context.Context, id string, updated *Project) er
 	return nil
 }
 
+// Delete deletes the project along with the error payloads it stored, which retention would no
+// longer reach.
 func (r *repository) Delete(ctx context.Context, id string) error {
-	const query = `DELETE FROM projects WHERE id = $1`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
@@ -187,6 +195,14 @@ func (r *repository) Delete(ctx context.Context, id string) error {
 	if rowsAffected == 0 {
 		return ErrNotFound
 	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM error_payloads WHERE project_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete error payloads: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
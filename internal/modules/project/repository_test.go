@@ -0,0 +1,40 @@
+package project
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryDelete(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, sql.RunMigrations(db, logger.New("ERROR", nil)))
+
+	repo := NewRepository(db, logger.New("ERROR", nil))
+
+	for _, id := range []string{"deleted", "kept"} {
+		_, err := db.Exec(`INSERT INTO projects (id, name, public_key, created_at, updated_at) VALUES ($1, $1, $1, 0, 0)`, id)
+		require.NoError(t, err)
+		_, err = db.Exec(`
+			INSERT INTO error_payloads (id, project_id, stacktrace, latest_time, created_at)
+			VALUES ($1, $1, '[]', 0, 0)
+		`, id)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.Delete(ctx, "deleted"))
+	assert.ErrorIs(t, repo.Delete(ctx, "deleted"), ErrNotFound)
+
+	var payloads []string
+	require.NoError(t, db.Select(&payloads, `SELECT project_id FROM error_payloads`))
+	assert.Equal(t, []string{"kept"}, payloads)
+}
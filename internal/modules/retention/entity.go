@@ -0,0 +1,22 @@
+package retention
+
+type Policy struct {
+	ProjectID           string `db:"project_id"`
+	ErrorsRetentionDays *int   `db:"errors_retention_days"`
+	LogsRetentionDays   *int   `db:"logs_retention_days"`
+	// JSON encoded map of log levels to their retention days
+	LogLevelRetentionDays string `db:"log_level_retention_days"`
+	CreatedAt             int64  `db:"created_at"`
+	UpdatedAt             int64  `db:"updated_at"`
+}
+
+type Report struct {
+	ID                 string `db:"id"`
+	ProjectID          string `db:"project_id"`
+	ErrorsDeleted      int    `db:"errors_deleted"`
+	LogsDeleted        int    `db:"logs_deleted"`
+	ErrorGroupsDeleted int    `db:"error_groups_deleted"`
+	LogGroupsDeleted   int    `db:"log_groups_deleted"`
+	StartedAt          int64  `db:"started_at"`
+	FinishedAt         int64  `db:"finished_at"`
+}
@@ -0,0 +1,68 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/fuckbug/api/internal/modules/archive"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// Archiver keeps the expired events before they are deleted.
+type Archiver interface {
+	Archive(ctx context.Context, kind archive.Kind, projectID string, events []*archive.Event) error
+}
+
+type Config struct {
+	// Interval between two purges of the expired data
+	Interval time.Duration
+	// Number of rows deleted by a single statement
+	BatchSize int
+}
+
+type GetReportsParams struct {
+	ProjectID string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type UpdatePolicy struct {
+	// Days the errors are kept, forever when null
+	ErrorsRetentionDays *int `json:"errorsRetentionDays" validate:"omitempty,min=1" example:"90"`
+	// Days the logs are kept, forever when null
+	LogsRetentionDays *int `json:"logsRetentionDays" validate:"omitempty,min=1" example:"30"`
+	// Days the logs of a level are kept, overriding logsRetentionDays
+	LogLevelRetentionDays map[string]int `json:"logLevelRetentionDays" validate:"omitempty,dive,keys,oneof=DEBUG INFO WARN ERROR FATAL,endkeys,min=1" swaggertype:"object,integer" example:"DEBUG:7,ERROR:180"`
+}
+
+type PolicyEntity struct {
+	ProjectID             string         `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ErrorsRetentionDays   *int           `json:"errorsRetentionDays" example:"90"`
+	LogsRetentionDays     *int           `json:"logsRetentionDays" example:"30"`
+	LogLevelRetentionDays map[string]int `json:"logLevelRetentionDays" swaggertype:"object,integer" example:"DEBUG:7,ERROR:180"`
+	UpdatedAt             int64          `json:"updatedAt" example:"1745446888"`
+}
+
+// ReportEntity is what a purge removed from a project.
+type ReportEntity struct {
+	ID                 string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ProjectID          string `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	ErrorsDeleted      int    `json:"errorsDeleted" example:"1200"`
+	LogsDeleted        int    `json:"logsDeleted" example:"54000"`
+	ErrorGroupsDeleted int    `json:"errorGroupsDeleted" example:"12"`
+	LogGroupsDeleted   int    `json:"logGroupsDeleted" example:"40"`
+	StartedAt          int64  `json:"startedAt" example:"1745446888"`
+	FinishedAt         int64  `json:"finishedAt" example:"1745446900"`
+}
+
+type ReportList struct {
+	Items []ReportEntity `json:"items"`
+	Count int            `json:"count" example:"1"`
+}
@@ -0,0 +1,45 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// Purger periodically deletes the data that outlived the retention policies of the projects.
+type Purger struct {
+	service  Service
+	logger   Logger
+	interval time.Duration
+}
+
+func NewPurger(service Service, logger Logger, config Config) *Purger {
+	if config.Interval <= 0 {
+		config.Interval = defaultPurgeInterval
+	}
+
+	return &Purger{
+		service:  service,
+		logger:   logger,
+		interval: config.Interval,
+	}
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.service.Purge(ctx, time.Now()); err != nil {
+			p.logger.Error(fmt.Sprintf("failed to purge expired data: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
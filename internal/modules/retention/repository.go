@@ -0,0 +1,451 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/modules/archive"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Repository interface {
+	GetPolicy(ctx context.Context, projectID string) (*Policy, error)
+	GetPolicies(ctx context.Context) ([]*Policy, error)
+	UpsertPolicy(ctx context.Context, policy *Policy) error
+	DeleteErrors(ctx context.Context, projectID string, before int64, limit int, archiveEvents ArchiveFunc) (int, error)
+	DeleteLogs(ctx context.Context, projectID string, filter LogFilter, limit int, archiveEvents ArchiveFunc) (int, error)
+	DeleteErrorCounts(ctx context.Context, projectID string, before int64) error
+	DeleteLogCounts(ctx context.Context, projectID string, filter LogFilter) error
+	DeleteUnusedErrorPayloads(ctx context.Context, projectID string, before int64, limit int) (int, error)
+	DeleteEmptyErrorGroups(ctx context.Context, projectID string, lastSeenBefore int64, limit int) (int, error)
+	DeleteEmptyLogGroups(ctx context.Context, projectID string, lastSeenBefore int64, limit int) (int, error)
+	CreateReport(ctx context.Context, report *Report) error
+	GetReports(ctx context.Context, params GetReportsParams) ([]*Report, error)
+	CountReports(ctx context.Context, projectID string) (int, error)
+}
+
+// LogFilter selects the expired logs of a level, or of all the levels but the excluded ones
+// when the level is empty.
+type LogFilter struct {
+	Before         int64
+	Level          string
+	ExcludedLevels []string
+}
+
+// ArchiveFunc keeps the deleted events, their deletion is rolled back when it fails.
+type ArchiveFunc func(events []*archive.Event) error
+
+// countResolutions are the buckets the events are counted by, named as the suffix of their tables.
+var countResolutions = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"minute", time.Minute},
+	{"hour", time.Hour},
+}
+
+type repository struct {
+	db      *sqlx.DB
+	dialect storage.Dialect
+	logger  Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	return &repository{
+		db:      db,
+		dialect: storage.DialectOf(db),
+		logger:  logger,
+	}
+}
+
+const policyColumns = `project_id, errors_retention_days, logs_retention_days, log_level_retention_days,
+	created_at, updated_at`
+
+func (r *repository) GetPolicy(ctx context.Context, projectID string) (*Policy, error) {
+	const query = `SELECT ` + policyColumns + ` FROM retention_policies WHERE project_id = $1`
+
+	var policy Policy
+	err := r.db.GetContext(ctx, &policy, query, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+	return &policy, nil
+}
+
+func (r *repository) GetPolicies(ctx context.Context) ([]*Policy, error) {
+	const query = `SELECT ` + policyColumns + ` FROM retention_policies ORDER BY project_id`
+
+	var policies []*Policy
+	err := r.db.SelectContext(ctx, &policies, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (r *repository) UpsertPolicy(ctx context.Context, p *Policy) error {
+	const query = `
+		INSERT INTO retention_policies (
+			project_id, errors_retention_days, logs_retention_days, log_level_retention_days, created_at, updated_at
+		) VALUES (
+			:project_id, :errors_retention_days, :logs_retention_days, :log_level_retention_days, :created_at, :updated_at
+		)
+		ON CONFLICT (project_id) DO UPDATE
+		SET
+			errors_retention_days = EXCLUDED.errors_retention_days,
+			logs_retention_days = EXCLUDED.logs_retention_days,
+			log_level_retention_days = EXCLUDED.log_level_retention_days,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	now := time.Now().Unix()
+	if p.CreatedAt == 0 {
+		p.CreatedAt = now
+	}
+	p.UpdatedAt = now
+
+	if _, err := r.db.NamedExecContext(ctx, query, p); err != nil {
+		return fmt.Errorf("failed to upsert retention policy: %w", err)
+	}
+	return nil
+}
+
+// DeleteErrors deletes at most limit errors of the project older than before, in milliseconds.
+func (r *repository) DeleteErrors(
+	ctx context.Context,
+	projectID string,
+	before int64,
+	limit int,
+	archiveEvents ArchiveFunc,
+) (int, error) {
+	const query = `
+		DELETE FROM errors
+		WHERE (id, time) IN (
+			SELECT id, time FROM errors WHERE project_id = $1 AND time < $2 LIMIT $3
+		)
+	`
+
+	deleted, err := r.deleteEvents(ctx, archive.KindErrors, query, archiveEvents, projectID, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired errors: %w", err)
+	}
+	return deleted, nil
+}
+
+// DeleteLogs deletes at most limit logs of the project matching the filter.
+func (r *repository) DeleteLogs(
+	ctx context.Context,
+	projectID string,
+	filter LogFilter,
+	limit int,
+	archiveEvents ArchiveFunc,
+) (int, error) {
+	query := "SELECT id, time FROM logs WHERE project_id = :projectId AND time < :before"
+
+	args := map[string]interface{}{
+		"projectId": projectID,
+		"before":    filter.Before,
+		"limit":     limit,
+	}
+
+	if filter.Level != "" {
+		query += " AND CAST(level AS TEXT) = :level"
+		args["level"] = filter.Level
+	}
+
+	if len(filter.ExcludedLevels) > 0 {
+		query += " AND " + r.dialect.NotIn("CAST(level AS TEXT)", ":excludedLevels")
+		args["excludedLevels"] = r.dialect.List(filter.ExcludedLevels)
+	}
+
+	query = "DELETE FROM logs WHERE (id, time) IN (" + query + " LIMIT :limit)"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	deleted, err := r.deleteEvents(ctx, archive.KindLogs, query, archiveEvents, namedArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired logs: %w", err)
+	}
+	return deleted, nil
+}
+
+// deleteEvents runs a delete of events, handing the deleted events to archiveEvents before committing.
+func (r *repository) deleteEvents(
+	ctx context.Context,
+	kind archive.Kind,
+	query string,
+	archiveEvents ArchiveFunc,
+	args ...interface{},
+) (deleted int, err error) {
+	if archiveEvents == nil {
+		r.logger.Debug(query)
+
+		result, err := r.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		return int(rowsAffected), nil
+	}
+
+	query += r.archiveReturning(kind)
+	r.logger.Debug(query)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	var events []*archive.Event
+	if err = tx.SelectContext(ctx, &events, query, args...); err != nil {
+		return 0, err
+	}
+
+	if len(events) > 0 {
+		if err = archiveEvents(events); err != nil {
+			return 0, fmt.Errorf("failed to archive events: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(events), nil
+}
+
+// archiveReturning returns the deleted rows as JSON documents, without their generated columns.
+func (r *repository) archiveReturning(kind archive.Kind) string {
+	if r.dialect.SQLite() {
+		return ` RETURNING time, ` + archive.SQLiteDocument(kind) + ` AS document`
+	}
+	return ` RETURNING time, to_jsonb(` + string(kind) + `) - 'search_vector' AS document`
+}
+
+// DeleteErrorCounts deletes the counts of the errors of the project whose buckets end before
+// before, in milliseconds. The buckets holding before keep counting the expired errors.
+func (r *repository) DeleteErrorCounts(ctx context.Context, projectID string, before int64) error {
+	for _, resolution := range countResolutions {
+		query := `DELETE FROM error_counts_` + resolution.name + ` WHERE project_id = $1 AND bucket < $2`
+		r.logger.Debug(query)
+
+		if _, err := r.db.ExecContext(ctx, query, projectID, storage.Bucket(before, resolution.duration)); err != nil {
+			return fmt.Errorf("failed to delete expired error counts: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteLogCounts deletes the counts of the logs of the project matching the filter whose
+// buckets end before the time of the filter.
+func (r *repository) DeleteLogCounts(ctx context.Context, projectID string, filter LogFilter) error {
+	for _, resolution := range countResolutions {
+		query := `DELETE FROM log_counts_` + resolution.name + ` WHERE project_id = :projectId AND bucket < :before`
+
+		args := map[string]interface{}{
+			"projectId": projectID,
+			"before":    storage.Bucket(filter.Before, resolution.duration),
+		}
+
+		if filter.Level != "" {
+			query += " AND CAST(level AS TEXT) = :level"
+			args["level"] = filter.Level
+		}
+
+		if len(filter.ExcludedLevels) > 0 {
+			query += " AND " + r.dialect.NotIn("CAST(level AS TEXT)", ":excludedLevels")
+			args["excludedLevels"] = r.dialect.List(filter.ExcludedLevels)
+		}
+
+		query, namedArgs, err := sqlx.Named(query, args)
+		if err != nil {
+			return fmt.Errorf("failed to prepare named query: %w", err)
+		}
+
+		query = r.db.Rebind(query)
+
+		r.logger.Debug(query)
+
+		if _, err := r.db.ExecContext(ctx, query, namedArgs...); err != nil {
+			return fmt.Errorf("failed to delete expired log counts: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteUnusedErrorPayloads deletes at most limit payloads of the project no error shares anymore,
+// last shared by an error older than before, in milliseconds.
+func (r *repository) DeleteUnusedErrorPayloads(ctx context.Context, projectID string, before int64, limit int) (int, error) {
+	const query = `
+		DELETE FROM error_payloads
+		WHERE id IN (
+			SELECT p.id FROM error_payloads p
+			WHERE p.project_id = $1 AND p.latest_time < $2
+				AND NOT EXISTS (SELECT 1 FROM errors e WHERE e.payload_id = p.id)
+			LIMIT $3
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, projectID, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete unused error payloads: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// DeleteEmptyErrorGroups deletes at most limit groups of the project last seen before the given
+// unix time in seconds and left without events.
+func (r *repository) DeleteEmptyErrorGroups(
+	ctx context.Context,
+	projectID string,
+	lastSeenBefore int64,
+	limit int,
+) (int, error) {
+	const query = `
+		DELETE FROM error_groups
+		WHERE id IN (
+			SELECT g.id FROM error_groups g
+			WHERE g.project_id = $1 AND g.last_seen_at < $2
+				AND NOT EXISTS (SELECT 1 FROM errors e WHERE e.fingerprint = g.id)
+			LIMIT $3
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, projectID, lastSeenBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete empty error groups: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+// DeleteEmptyLogGroups deletes at most limit groups of the project last seen before the given
+// unix time in seconds and left without events.
+func (r *repository) DeleteEmptyLogGroups(
+	ctx context.Context,
+	projectID string,
+	lastSeenBefore int64,
+	limit int,
+) (int, error) {
+	const query = `
+		DELETE FROM log_groups
+		WHERE id IN (
+			SELECT g.id FROM log_groups g
+			WHERE g.project_id = $1 AND g.last_seen_at < $2
+				AND NOT EXISTS (SELECT 1 FROM logs l WHERE l.fingerprint = g.id)
+			LIMIT $3
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, projectID, lastSeenBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete empty log groups: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rowsAffected), nil
+}
+
+func (r *repository) CreateReport(ctx context.Context, report *Report) error {
+	const query = `
+		INSERT INTO retention_reports (
+			id, project_id, errors_deleted, logs_deleted, error_groups_deleted, log_groups_deleted,
+			started_at, finished_at
+		) VALUES (
+			:id, :project_id, :errors_deleted, :logs_deleted, :error_groups_deleted, :log_groups_deleted,
+			:started_at, :finished_at
+		)
+	`
+
+	if report.ID == "" {
+		report.ID = uuid.New().String()
+	}
+
+	if _, err := r.db.NamedExecContext(ctx, query, report); err != nil {
+		return fmt.Errorf("failed to create retention report: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetReports(ctx context.Context, params GetReportsParams) ([]*Report, error) {
+	query := `
+		SELECT id, project_id, errors_deleted, logs_deleted, error_groups_deleted, log_groups_deleted,
+			started_at, finished_at
+		FROM retention_reports
+		WHERE project_id = :projectId
+	`
+
+	args := map[string]interface{}{
+		"projectId": params.ProjectID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query += " ORDER BY started_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var reports []*Report
+	err = r.db.SelectContext(ctx, &reports, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention reports: %w", err)
+	}
+	return reports, nil
+}
+
+func (r *repository) CountReports(ctx context.Context, projectID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM retention_reports WHERE project_id = $1`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count retention reports: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,311 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fuckbug/api/internal/modules/archive"
+)
+
+const (
+	defaultBatchSize = 1000
+
+	day = 24 * time.Hour
+)
+
+type Service interface {
+	GetPolicy(ctx context.Context, projectID string) (*PolicyEntity, error)
+	UpdatePolicy(ctx context.Context, projectID string, req *UpdatePolicy) (*PolicyEntity, error)
+	GetReports(ctx context.Context, params GetReportsParams) ([]*ReportEntity, int, error)
+	Purge(ctx context.Context, now time.Time) ([]*ReportEntity, error)
+}
+
+type service struct {
+	repo     Repository
+	archiver Archiver
+	logger   Logger
+	config   Config
+}
+
+// NewService purges without archiving when archiver is nil.
+func NewService(repo Repository, archiver Archiver, logger Logger, config Config) Service {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+
+	return &service{
+		repo:     repo,
+		archiver: archiver,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// GetPolicy returns the retention policy of the project, keeping everything when none was set.
+func (s *service) GetPolicy(ctx context.Context, projectID string) (*PolicyEntity, error) {
+	policy, err := s.repo.GetPolicy(ctx, projectID)
+	if errors.Is(err, ErrNotFound) {
+		return toResponse(&Policy{ProjectID: projectID})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponse(policy)
+}
+
+func (s *service) UpdatePolicy(ctx context.Context, projectID string, req *UpdatePolicy) (*PolicyEntity, error) {
+	policy, err := s.repo.GetPolicy(ctx, projectID)
+	if errors.Is(err, ErrNotFound) {
+		policy = &Policy{ProjectID: projectID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	levelDays := req.LogLevelRetentionDays
+	if levelDays == nil {
+		levelDays = map[string]int{}
+	}
+
+	encoded, err := json.Marshal(levelDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode log level retention days: %w", err)
+	}
+
+	policy.ErrorsRetentionDays = req.ErrorsRetentionDays
+	policy.LogsRetentionDays = req.LogsRetentionDays
+	policy.LogLevelRetentionDays = string(encoded)
+
+	if err := s.repo.UpsertPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return toResponse(policy)
+}
+
+func (s *service) GetReports(ctx context.Context, params GetReportsParams) ([]*ReportEntity, int, error) {
+	reports, err := s.repo.GetReports(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountReports(ctx, params.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*ReportEntity, 0, len(reports))
+	for _, report := range reports {
+		responses = append(responses, toReportResponse(report))
+	}
+	return responses, total, nil
+}
+
+// Purge deletes the expired errors and logs of every project with a retention policy, then the
+// groups left without events, and reports what was removed from each project.
+func (s *service) Purge(ctx context.Context, now time.Time) ([]*ReportEntity, error) {
+	policies, err := s.repo.GetPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*ReportEntity
+	for _, policy := range policies {
+		if err := ctx.Err(); err != nil {
+			return reports, err
+		}
+
+		report, err := s.purgeProject(ctx, policy, now)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to purge expired data of project %s: %v", policy.ProjectID, err))
+			continue
+		}
+		if report == nil {
+			continue
+		}
+
+		s.logger.Info(fmt.Sprintf(
+			"purged project %s: %d errors, %d logs, %d error groups, %d log groups",
+			report.ProjectID, report.ErrorsDeleted, report.LogsDeleted, report.ErrorGroupsDeleted, report.LogGroupsDeleted,
+		))
+		reports = append(reports, toReportResponse(report))
+	}
+	return reports, nil
+}
+
+// purgeProject returns nil when nothing expired.
+func (s *service) purgeProject(ctx context.Context, policy *Policy, now time.Time) (*Report, error) {
+	levelDays, err := decodeLevelDays(policy.LogLevelRetentionDays)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{ProjectID: policy.ProjectID, StartedAt: now.Unix()}
+
+	if policy.ErrorsRetentionDays != nil {
+		before := now.Add(-time.Duration(*policy.ErrorsRetentionDays) * day)
+
+		report.ErrorsDeleted, err = s.deleteBatches(ctx, func(limit int) (int, error) {
+			return s.repo.DeleteErrors(
+				ctx, policy.ProjectID, before.UnixMilli(), limit, s.archiveFunc(ctx, archive.KindErrors, policy.ProjectID),
+			)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.repo.DeleteErrorCounts(ctx, policy.ProjectID, before.UnixMilli()); err != nil {
+			return nil, err
+		}
+
+		_, err = s.deleteBatches(ctx, func(limit int) (int, error) {
+			return s.repo.DeleteUnusedErrorPayloads(ctx, policy.ProjectID, before.UnixMilli(), limit)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		report.ErrorGroupsDeleted, err = s.deleteBatches(ctx, func(limit int) (int, error) {
+			return s.repo.DeleteEmptyErrorGroups(ctx, policy.ProjectID, before.Unix(), limit)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The levels with their own retention are left out of the retention of the logs.
+	levels := make([]string, 0, len(levelDays))
+	for level := range levelDays {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+
+	filters := make([]LogFilter, 0, len(levels)+1)
+	shortest := 0
+	for _, level := range levels {
+		days := levelDays[level]
+		filters = append(filters, LogFilter{Before: now.Add(-time.Duration(days) * day).UnixMilli(), Level: level})
+		if shortest == 0 || days < shortest {
+			shortest = days
+		}
+	}
+	if policy.LogsRetentionDays != nil {
+		days := *policy.LogsRetentionDays
+		filters = append(filters, LogFilter{Before: now.Add(-time.Duration(days) * day).UnixMilli(), ExcludedLevels: levels})
+		if shortest == 0 || days < shortest {
+			shortest = days
+		}
+	}
+
+	for _, filter := range filters {
+		deleted, err := s.deleteBatches(ctx, func(limit int) (int, error) {
+			return s.repo.DeleteLogs(
+				ctx, policy.ProjectID, filter, limit, s.archiveFunc(ctx, archive.KindLogs, policy.ProjectID),
+			)
+		})
+		report.LogsDeleted += deleted
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.repo.DeleteLogCounts(ctx, policy.ProjectID, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	// A group may only be left without events once the shortest retention of its logs elapsed.
+	if shortest > 0 {
+		before := now.Add(-time.Duration(shortest) * day)
+
+		report.LogGroupsDeleted, err = s.deleteBatches(ctx, func(limit int) (int, error) {
+			return s.repo.DeleteEmptyLogGroups(ctx, policy.ProjectID, before.Unix(), limit)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if report.ErrorsDeleted+report.LogsDeleted+report.ErrorGroupsDeleted+report.LogGroupsDeleted == 0 {
+		return nil, nil
+	}
+
+	report.FinishedAt = time.Now().Unix()
+	if err := s.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// deleteBatches repeats a bounded delete until it deletes less than a full batch, so no single
+// statement holds locks on a large number of rows.
+func (s *service) deleteBatches(ctx context.Context, deleteBatch func(limit int) (int, error)) (int, error) {
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		deleted, err := deleteBatch(s.config.BatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		total += deleted
+		if deleted < s.config.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *service) archiveFunc(ctx context.Context, kind archive.Kind, projectID string) ArchiveFunc {
+	if s.archiver == nil {
+		return nil
+	}
+	return func(events []*archive.Event) error {
+		return s.archiver.Archive(ctx, kind, projectID, events)
+	}
+}
+
+func decodeLevelDays(encoded string) (map[string]int, error) {
+	levelDays := map[string]int{}
+	if encoded == "" {
+		return levelDays, nil
+	}
+
+	if err := json.Unmarshal([]byte(encoded), &levelDays); err != nil {
+		return nil, fmt.Errorf("failed to decode log level retention days: %w", err)
+	}
+	return levelDays, nil
+}
+
+func toResponse(p *Policy) (*PolicyEntity, error) {
+	levelDays, err := decodeLevelDays(p.LogLevelRetentionDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolicyEntity{
+		ProjectID:             p.ProjectID,
+		ErrorsRetentionDays:   p.ErrorsRetentionDays,
+		LogsRetentionDays:     p.LogsRetentionDays,
+		LogLevelRetentionDays: levelDays,
+		UpdatedAt:             p.UpdatedAt,
+	}, nil
+}
+
+func toReportResponse(r *Report) *ReportEntity {
+	return &ReportEntity{
+		ID:                 r.ID,
+		ProjectID:          r.ProjectID,
+		ErrorsDeleted:      r.ErrorsDeleted,
+		LogsDeleted:        r.LogsDeleted,
+		ErrorGroupsDeleted: r.ErrorGroupsDeleted,
+		LogGroupsDeleted:   r.LogGroupsDeleted,
+		StartedAt:          r.StartedAt,
+		FinishedAt:         r.FinishedAt,
+	}
+}
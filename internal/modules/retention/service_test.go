@@ -0,0 +1,116 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string) {}
+func (nopLogger) Info(string)  {}
+func (nopLogger) Warn(string)  {}
+func (nopLogger) Error(string) {}
+
+// stubRepository deletes from a number of expired rows per kind, recording the log filters.
+type stubRepository struct {
+	Repository
+	policies     []*Policy
+	errors       int
+	logs         map[string]int
+	logFilters   []LogFilter
+	countFilters []LogFilter
+	errorGroups  int
+	reports      []*Report
+}
+
+func (r *stubRepository) GetPolicies(context.Context) ([]*Policy, error) {
+	return r.policies, nil
+}
+
+func (r *stubRepository) DeleteErrors(_ context.Context, _ string, _ int64, limit int, _ ArchiveFunc) (int, error) {
+	deleted := min(r.errors, limit)
+	r.errors -= deleted
+	return deleted, nil
+}
+
+func (r *stubRepository) DeleteLogs(_ context.Context, _ string, filter LogFilter, limit int, _ ArchiveFunc) (int, error) {
+	r.logFilters = append(r.logFilters, filter)
+	deleted := min(r.logs[filter.Level], limit)
+	r.logs[filter.Level] -= deleted
+	return deleted, nil
+}
+
+func (r *stubRepository) DeleteErrorCounts(context.Context, string, int64) error {
+	return nil
+}
+
+func (r *stubRepository) DeleteLogCounts(_ context.Context, _ string, filter LogFilter) error {
+	r.countFilters = append(r.countFilters, filter)
+	return nil
+}
+
+func (r *stubRepository) DeleteUnusedErrorPayloads(context.Context, string, int64, int) (int, error) {
+	return 0, nil
+}
+
+func (r *stubRepository) DeleteEmptyErrorGroups(_ context.Context, _ string, _ int64, limit int) (int, error) {
+	deleted := min(r.errorGroups, limit)
+	r.errorGroups -= deleted
+	return deleted, nil
+}
+
+func (r *stubRepository) DeleteEmptyLogGroups(context.Context, string, int64, int) (int, error) {
+	return 0, nil
+}
+
+func (r *stubRepository) CreateReport(_ context.Context, report *Report) error {
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func TestPurge(t *testing.T) {
+	errorsDays, logsDays := 90, 30
+	repo := &stubRepository{
+		policies: []*Policy{
+			{
+				ProjectID:             "p1",
+				ErrorsRetentionDays:   &errorsDays,
+				LogsRetentionDays:     &logsDays,
+				LogLevelRetentionDays: `{"DEBUG":7}`,
+			},
+			{ProjectID: "p2", LogLevelRetentionDays: `{}`},
+		},
+		errors:      25,
+		logs:        map[string]int{"": 3, "DEBUG": 12},
+		errorGroups: 2,
+	}
+
+	now := time.UnixMilli(1704067200000)
+	s := NewService(repo, nil, nopLogger{}, Config{BatchSize: 10})
+
+	reports, err := s.Purge(context.Background(), now)
+	require.NoError(t, err)
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, "p1", reports[0].ProjectID)
+	assert.Equal(t, 25, reports[0].ErrorsDeleted)
+	assert.Equal(t, 15, reports[0].LogsDeleted)
+	assert.Equal(t, 2, reports[0].ErrorGroupsDeleted)
+	assert.Len(t, repo.reports, 1)
+
+	// The batches repeat until one is not full, the levels with their own retention are left out of the others
+	require.Len(t, repo.logFilters, 3)
+	assert.Equal(t, LogFilter{Before: now.Add(-7 * day).UnixMilli(), Level: "DEBUG"}, repo.logFilters[0])
+	assert.Equal(t, LogFilter{Before: now.Add(-7 * day).UnixMilli(), Level: "DEBUG"}, repo.logFilters[1])
+	assert.Equal(t, LogFilter{Before: now.Add(-30 * day).UnixMilli(), ExcludedLevels: []string{"DEBUG"}}, repo.logFilters[2])
+
+	// The counts of the logs are deleted once per filter, after its logs.
+	require.Len(t, repo.countFilters, 2)
+	assert.Equal(t, repo.logFilters[0], repo.countFilters[0])
+	assert.Equal(t, repo.logFilters[2], repo.countFilters[1])
+}
@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"context"
+	"errors"
+
+	errorsModule "github.com/fuckbug/api/internal/modules/errors"
+	logModule "github.com/fuckbug/api/internal/modules/log"
+	"github.com/fuckbug/api/pkg/utils"
+)
+
+const sortAsc = "asc"
+
+type errorHistory struct {
+	service errorsModule.Service
+}
+
+func NewErrorHistory(service errorsModule.Service) History {
+	return &errorHistory{service: service}
+}
+
+// After lists the errors following the last event in (time, id) order. An unknown
+// last event, deleted or expired, replays nothing.
+func (h *errorHistory) After(ctx context.Context, filter Filter, lastEventID string, limit int) ([]*Message, error) {
+	last, err := h.service.GetByID(ctx, lastEventID)
+	if err != nil {
+		if errors.Is(err, errorsModule.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list, err := h.service.GetAll(ctx, errorsModule.GetAllParams{
+		FilterParams: errorsModule.FilterParams{
+			ProjectID:   filter.ProjectID,
+			Fingerprint: filter.Fingerprint,
+			Search:      filter.Search,
+		},
+		SortOrder: sortAsc,
+		Limit:     limit,
+		Cursor:    &utils.Cursor{Time: last.Time, ID: last.ID},
+		CountMode: errorsModule.CountNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(list.Items))
+	for _, entity := range list.Items {
+		messages = append(messages, &Message{ID: entity.ID, Type: KindError, Data: entity})
+	}
+	return messages, nil
+}
+
+type logHistory struct {
+	service logModule.Service
+}
+
+func NewLogHistory(service logModule.Service) History {
+	return &logHistory{service: service}
+}
+
+func (h *logHistory) After(ctx context.Context, filter Filter, lastEventID string, limit int) ([]*Message, error) {
+	last, err := h.service.GetByID(ctx, lastEventID)
+	if err != nil {
+		if errors.Is(err, logModule.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list, err := h.service.GetAll(ctx, logModule.GetAllParams{
+		FilterParams: logModule.FilterParams{
+			ProjectID:   filter.ProjectID,
+			Fingerprint: filter.Fingerprint,
+			Level:       filter.Level,
+			Search:      filter.Search,
+		},
+		SortOrder: sortAsc,
+		Limit:     limit,
+		Cursor:    &utils.Cursor{Time: last.Time, ID: last.ID},
+		CountMode: logModule.CountNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(list.Items))
+	for _, entity := range list.Items {
+		messages = append(messages, &Message{ID: entity.ID, Type: KindLog, Data: entity})
+	}
+	return messages, nil
+}
@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/fuckbug/api/internal/events"
+	errorsModule "github.com/fuckbug/api/internal/modules/errors"
+	logModule "github.com/fuckbug/api/internal/modules/log"
+)
+
+const defaultBufferSize = 256
+
+// Subscription receives the messages matching its filter on C. C is closed when the
+// subscription ends, either unsubscribed or because the client could not keep up.
+type Subscription struct {
+	C      <-chan *Message
+	kind   Kind
+	filter Filter
+	ch     chan *Message
+	// Lagged is set when messages were dropped and the subscription was closed
+	lagged bool
+}
+
+// Lagged reports whether the subscription was closed because its buffer overflowed.
+func (s *Subscription) Lagged() bool {
+	return s.lagged
+}
+
+// Hub fans the ingested events out to the live subscribers.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (h *Hub) Subscribe(kind Kind, filter Filter) *Subscription {
+	ch := make(chan *Message, h.bufferSize)
+	subscription := &Subscription{
+		C:      ch,
+		kind:   kind,
+		filter: filter,
+		ch:     ch,
+	}
+
+	h.mu.Lock()
+	h.subscribers[subscription] = struct{}{}
+	h.mu.Unlock()
+
+	return subscription
+}
+
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.ch)
+	}
+}
+
+// HandleEvent publishes the created errors and logs to the subscribers.
+// It is meant to be subscribed to the event bus and never blocks the publisher.
+func (h *Hub) HandleEvent(_ context.Context, event events.Event) {
+	message := toMessage(event)
+	if message == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers {
+		if subscription.kind != message.Type || !subscription.filter.matches(message) {
+			continue
+		}
+
+		select {
+		case subscription.ch <- message:
+		default:
+			// The client resumes from its Last-Event-ID once it reconnects.
+			subscription.lagged = true
+			delete(h.subscribers, subscription)
+			close(subscription.ch)
+		}
+	}
+}
+
+func toMessage(event events.Event) *Message {
+	switch event.Type {
+	case events.TypeErrorCreated:
+		entity, ok := event.Payload.(*errorsModule.Entity)
+		if !ok {
+			return nil
+		}
+		return &Message{
+			ID:          entity.ID,
+			Type:        KindError,
+			Data:        entity,
+			projectID:   event.ProjectID,
+			fingerprint: event.GroupID,
+			message:     event.Message,
+		}
+	case events.TypeLogCreated:
+		entity, ok := event.Payload.(*logModule.Entity)
+		if !ok {
+			return nil
+		}
+		return &Message{
+			ID:          entity.ID,
+			Type:        KindLog,
+			Data:        entity,
+			projectID:   event.ProjectID,
+			fingerprint: event.GroupID,
+			level:       event.Level,
+			message:     event.Message,
+		}
+	default:
+		return nil
+	}
+}
+
+func (f Filter) matches(message *Message) bool {
+	if f.ProjectID != "" && f.ProjectID != message.projectID {
+		return false
+	}
+	if f.Fingerprint != "" && f.Fingerprint != message.fingerprint {
+		return false
+	}
+	if f.Level != "" && f.Level != message.level {
+		return false
+	}
+	if f.Search != "" && !strings.Contains(strings.ToLower(message.message), strings.ToLower(f.Search)) {
+		return false
+	}
+	return true
+}
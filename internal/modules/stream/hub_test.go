@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fuckbug/api/internal/events"
+	logModule "github.com/fuckbug/api/internal/modules/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logCreated(id, projectID, level, message string) events.Event {
+	return events.Event{
+		Type:      events.TypeLogCreated,
+		ProjectID: projectID,
+		Level:     level,
+		Message:   message,
+		Payload:   &logModule.Entity{ID: id},
+	}
+}
+
+func TestHubFilters(t *testing.T) {
+	hub := NewHub(8)
+	subscription := hub.Subscribe(KindLog, Filter{ProjectID: "p1", Level: "ERROR", Search: "timeout"})
+	defer hub.Unsubscribe(subscription)
+	errorSubscription := hub.Subscribe(KindError, Filter{})
+	defer hub.Unsubscribe(errorSubscription)
+
+	hub.HandleEvent(context.Background(), logCreated("1", "p2", "ERROR", "Timeout"))
+	hub.HandleEvent(context.Background(), logCreated("2", "p1", "INFO", "Timeout"))
+	hub.HandleEvent(context.Background(), logCreated("3", "p1", "ERROR", "refused"))
+	hub.HandleEvent(context.Background(), logCreated("4", "p1", "ERROR", "read Timeout"))
+
+	require.Len(t, subscription.C, 1)
+	message := <-subscription.C
+	assert.Equal(t, "4", message.ID)
+	assert.Equal(t, KindLog, message.Type)
+	assert.Empty(t, errorSubscription.C)
+}
+
+func TestHubDisconnectsLaggingSubscribers(t *testing.T) {
+	hub := NewHub(1)
+	subscription := hub.Subscribe(KindLog, Filter{})
+
+	hub.HandleEvent(context.Background(), logCreated("1", "p1", "INFO", "first"))
+	hub.HandleEvent(context.Background(), logCreated("2", "p1", "INFO", "second"))
+
+	message, ok := <-subscription.C
+	require.True(t, ok)
+	assert.Equal(t, "1", message.ID)
+
+	_, ok = <-subscription.C
+	assert.False(t, ok)
+	assert.True(t, subscription.Lagged())
+
+	// Unsubscribing a closed subscription is a no-op.
+	hub.Unsubscribe(subscription)
+}
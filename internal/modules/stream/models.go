@@ -0,0 +1,49 @@
+package stream
+
+import "context"
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Kind string
+
+const (
+	KindError Kind = "error"
+	KindLog   Kind = "log"
+)
+
+// History replays the stored events of a kind that follow the last event a client received.
+type History interface {
+	After(ctx context.Context, filter Filter, lastEventID string, limit int) ([]*Message, error)
+}
+
+type Config struct {
+	// Number of messages buffered per subscriber before it is considered lagging and disconnected
+	BufferSize int
+	// Maximum number of stored events replayed when a client resumes from a Last-Event-ID
+	ReplayLimit int
+}
+
+// Filter selects the streamed events, with the semantics of the filters of the list endpoints.
+type Filter struct {
+	ProjectID   string
+	Fingerprint string
+	Level       string
+	Search      string
+}
+
+// Message is a streamed event, Data being the entity returned by the list endpoints.
+type Message struct {
+	ID   string      `json:"id"`
+	Type Kind        `json:"type"`
+	Data interface{} `json:"data"`
+
+	projectID   string
+	fingerprint string
+	level       string
+	message     string
+}
@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"context"
+	"errors"
+)
+
+const defaultReplayLimit = 1000
+
+var ErrUnsupportedKind = errors.New("unsupported stream kind")
+
+type Service interface {
+	// Subscribe starts a live subscription and returns the stored events to replay first
+	// when the client resumes from lastEventID. Live messages may repeat replayed ones.
+	Subscribe(ctx context.Context, kind Kind, filter Filter, lastEventID string) (*Subscription, []*Message, error)
+	Unsubscribe(subscription *Subscription)
+}
+
+type service struct {
+	hub         *Hub
+	histories   map[Kind]History
+	logger      Logger
+	replayLimit int
+}
+
+func NewService(hub *Hub, histories map[Kind]History, logger Logger, config Config) Service {
+	if config.ReplayLimit <= 0 {
+		config.ReplayLimit = defaultReplayLimit
+	}
+
+	return &service{
+		hub:         hub,
+		histories:   histories,
+		logger:      logger,
+		replayLimit: config.ReplayLimit,
+	}
+}
+
+func (s *service) Subscribe(
+	ctx context.Context,
+	kind Kind,
+	filter Filter,
+	lastEventID string,
+) (*Subscription, []*Message, error) {
+	history, ok := s.histories[kind]
+	if !ok {
+		return nil, nil, ErrUnsupportedKind
+	}
+
+	// Subscribe before reading the history, so no event falls between the two.
+	subscription := s.hub.Subscribe(kind, filter)
+
+	if lastEventID == "" {
+		return subscription, nil, nil
+	}
+
+	replay, err := history.After(ctx, filter, lastEventID, s.replayLimit)
+	if err != nil {
+		s.hub.Unsubscribe(subscription)
+		return nil, nil, err
+	}
+
+	return subscription, replay, nil
+}
+
+func (s *service) Unsubscribe(subscription *Subscription) {
+	s.hub.Unsubscribe(subscription)
+}
@@ -0,0 +1,58 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps the users in memory, for the tests and the instances without database.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{users: make(map[string]User)}
+}
+
+func (r *MemoryRepository) Create(_ context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return fmt.Errorf("failed to create user: email %s is taken", user.Email)
+		}
+	}
+
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryRepository) FindByEmail(_ context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// Exists reports whether the user exists, groups being assigned to existing users only.
+func (r *MemoryRepository) Exists(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.users[id]
+	return ok
+}
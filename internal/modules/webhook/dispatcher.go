@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultInterval    = 5 * time.Second
+	defaultBatchSize   = 50
+	defaultMaxAttempts = 8
+	defaultTimeout     = 10 * time.Second
+	defaultBaseBackoff = 30 * time.Second
+	defaultMaxBackoff  = 6 * time.Hour
+
+	responseExcerptLength = 1024
+
+	SignatureHeader = "X-FuckBug-Signature"
+	TimestampHeader = "X-FuckBug-Timestamp"
+	EventHeader     = "X-FuckBug-Event"
+	DeliveryHeader  = "X-FuckBug-Delivery"
+)
+
+// Dispatcher sends the queued deliveries and reschedules the failed ones with
+// an exponential backoff until they succeed or run out of attempts.
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	logger Logger
+	config Config
+}
+
+func NewDispatcher(repo Repository, logger Logger, config Config) *Dispatcher {
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaultBaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: config.Timeout},
+		logger: logger,
+		config: config,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Dispatch(ctx, time.Now()); err != nil {
+			d.logger.Error(fmt.Sprintf("webhook dispatch failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends one batch of due deliveries.
+func (d *Dispatcher) Dispatch(ctx context.Context, now time.Time) error {
+	// Every delivery of the batch may take up to the timeout, the lease has to outlast them all.
+	lease := now.Add(time.Duration(d.config.BatchSize+1) * d.config.Timeout)
+
+	deliveries, err := d.repo.ClaimDeliveries(ctx, now.Unix(), lease.Unix(), d.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		d.send(ctx, delivery)
+
+		if err := d.repo.UpdateDelivery(context.WithoutCancel(ctx), &delivery.Delivery); err != nil {
+			d.logger.Error(err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *PendingDelivery) {
+	delivery.Attempts++
+	delivery.StatusCode = nil
+	delivery.ResponseBody = nil
+	delivery.Error = nil
+
+	started := time.Now()
+	statusCode, body, err := d.post(ctx, delivery)
+	latency := time.Since(started).Milliseconds()
+	delivery.LatencyMs = &latency
+
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+		delivery.ResponseBody = &body
+	}
+
+	if err == nil && (statusCode < 200 || statusCode >= 300) {
+		err = fmt.Errorf("unexpected status code %d", statusCode)
+	}
+
+	if err == nil {
+		delivery.Status = DeliverySucceeded
+		return
+	}
+
+	message := err.Error()
+	delivery.Error = &message
+
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = DeliveryFailed
+		return
+	}
+
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts)).Unix()
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery *PendingDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	excerpt, err := io.ReadAll(io.LimitReader(resp.Body, responseExcerptLength))
+	if err != nil {
+		return resp.StatusCode, "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// The excerpt is stored as text, which rejects invalid UTF-8 and NUL bytes.
+	text := strings.ReplaceAll(strings.ToValidUTF8(string(excerpt), "\uFFFD"), "\x00", "")
+
+	return resp.StatusCode, text, nil
+}
+
+// backoff doubles the base delay for every attempt already made, up to the maximum.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the signature header value of a delivery: the hex encoded HMAC-SHA256
+// of the timestamp and the body joined by a dot, keyed with the webhook secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
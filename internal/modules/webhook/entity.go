@@ -0,0 +1,44 @@
+package webhook
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type Webhook struct {
+	ID        string `db:"id"`
+	ProjectID string `db:"project_id"`
+	URL       string `db:"url"`
+	Secret    string `db:"secret"`
+	Events    string `db:"events"` // Comma separated event types
+	Enabled   bool   `db:"enabled"`
+	CreatedAt int64  `db:"created_at"`
+	UpdatedAt int64  `db:"updated_at"`
+}
+
+type Delivery struct {
+	ID            string         `db:"id"`
+	WebhookID     string         `db:"webhook_id"`
+	ProjectID     string         `db:"project_id"`
+	EventType     string         `db:"event_type"`
+	Payload       string         `db:"payload"`
+	Status        DeliveryStatus `db:"status"`
+	Attempts      int            `db:"attempts"`
+	NextAttemptAt int64          `db:"next_attempt_at"`
+	StatusCode    *int           `db:"status_code"`
+	LatencyMs     *int64         `db:"latency_ms"`
+	ResponseBody  *string        `db:"response_body"`
+	Error         *string        `db:"error"`
+	CreatedAt     int64          `db:"created_at"`
+	UpdatedAt     int64          `db:"updated_at"`
+}
+
+// PendingDelivery is a claimed delivery along with the endpoint it has to be sent to.
+type PendingDelivery struct {
+	Delivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
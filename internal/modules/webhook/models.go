@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+type Config struct {
+	// Interval between two polls of the delivery queue
+	Interval time.Duration
+	// BatchSize is the maximum number of deliveries sent per poll
+	BatchSize int
+	// MaxAttempts after which a delivery is marked as failed
+	MaxAttempts int
+	// Timeout of a single delivery request
+	Timeout time.Duration
+	// BaseBackoff is the delay before the first retry, doubled on every further attempt
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+}
+
+type GetAllParams struct {
+	ProjectID string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type GetDeliveriesParams struct {
+	ProjectID string
+	WebhookID string
+	Status    string
+	SortOrder string `validate:"omitempty,oneof=asc desc"`
+	Limit     int
+	Offset    int
+}
+
+type Create struct {
+	URL string `json:"url" validate:"required,url" example:"https://example.com/hooks/fuckbug"`
+	// Events the webhook is subscribed to
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=group.created group.regressed group.status_changed group.unsnoozed alert.fired anomaly.detected" example:"group.created,alert.fired"`
+	// Secret used to sign deliveries, generated when empty
+	Secret    string `json:"secret" example:"s3cr3t"`
+	Enabled   *bool  `json:"enabled" example:"true"`
+	ProjectID string `json:"-"`
+}
+
+type Update struct {
+	URL     string   `json:"url" validate:"required,url" example:"https://example.com/hooks/fuckbug"`
+	Events  []string `json:"events" validate:"required,min=1,dive,oneof=group.created group.regressed group.status_changed group.unsnoozed alert.fired anomaly.detected" example:"group.created,alert.fired"`
+	Secret  string   `json:"secret" example:"s3cr3t"`
+	Enabled *bool    `json:"enabled" example:"true"`
+}
+
+type Entity struct {
+	ID        string   `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	URL       string   `json:"url" example:"https://example.com/hooks/fuckbug"`
+	Events    []string `json:"events" example:"group.created,alert.fired"`
+	Secret    string   `json:"secret" example:"s3cr3t"`
+	Enabled   bool     `json:"enabled" example:"true"`
+	CreatedAt int64    `json:"createdAt" example:"1704067200"`
+	UpdatedAt int64    `json:"updatedAt" example:"1704067200"`
+}
+
+type EntityList struct {
+	Count int      `json:"count"`
+	Items []Entity `json:"items"`
+}
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	ID        string          `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	Type      string          `json:"type" example:"group.created"`
+	ProjectID string          `json:"projectId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	GroupID   string          `json:"groupId,omitempty" example:"5d41402abc4b2a76b9719d911017c592"`
+	GroupKind string          `json:"groupKind,omitempty" example:"error"`
+	Message   string          `json:"message,omitempty" example:"Division by zero in calculate()"`
+	Time      int64           `json:"time" example:"1704067200000"`
+	Data      json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+type DeliveryEntity struct {
+	ID            string `json:"id" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	WebhookID     string `json:"webhookId" example:"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"`
+	EventType     string `json:"eventType" example:"group.created"`
+	Payload       string `json:"payload"`
+	Status        string `json:"status" example:"succeeded"`
+	Attempts      int    `json:"attempts" example:"1"`
+	NextAttemptAt int64  `json:"nextAttemptAt" example:"1704067200"`
+	StatusCode    *int   `json:"statusCode" example:"200"`
+	LatencyMs     *int64 `json:"latencyMs" example:"123"`
+	// First bytes of the response body
+	ResponseBody *string `json:"responseBody" example:"ok"`
+	Error        *string `json:"error"`
+	CreatedAt    int64   `json:"createdAt" example:"1704067200"`
+	UpdatedAt    int64   `json:"updatedAt" example:"1704067200"`
+}
+
+type DeliveryList struct {
+	Count int              `json:"count"`
+	Items []DeliveryEntity `json:"items"`
+}
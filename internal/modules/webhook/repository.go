@@ -0,0 +1,365 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+const (
+	webhookColumns  = `id, project_id, url, secret, events, enabled, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, project_id, event_type, payload, status, attempts, next_attempt_at,
+		status_code, latency_ms, response_body, error, created_at, updated_at`
+)
+
+type Repository interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Webhook, error)
+	Count(ctx context.Context, projectID string) (int, error)
+	GetByID(ctx context.Context, projectID, id string) (*Webhook, error)
+	GetSubscribed(ctx context.Context, projectID, eventType string) ([]*Webhook, error)
+	Create(ctx context.Context, webhook *Webhook) error
+	Update(ctx context.Context, projectID, id string, webhook *Webhook) error
+	Delete(ctx context.Context, projectID, id string) error
+	GetDeliveries(ctx context.Context, params GetDeliveriesParams) ([]*Delivery, error)
+	CountDeliveries(ctx context.Context, params GetDeliveriesParams) (int, error)
+	GetDeliveryByID(ctx context.Context, webhookID, id string) (*Delivery, error)
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	ClaimDeliveries(ctx context.Context, now, leaseUntil int64, limit int) ([]*PendingDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+}
+
+type repository struct {
+	db     *sqlx.DB
+	logger Logger
+}
+
+func NewRepository(db *sqlx.DB, logger Logger) Repository {
+	r := &repository{
+		db:     db,
+		logger: logger,
+	}
+
+	if storage.DialectOf(db).SQLite() {
+		return &sqliteRepository{r}
+	}
+	return r
+}
+
+func (r *repository) GetAll(ctx context.Context, params GetAllParams) ([]*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE project_id = :projectId`
+
+	args := map[string]interface{}{
+		"projectId": params.ProjectID,
+		"limit":     params.Limit,
+		"offset":    params.Offset,
+	}
+
+	query += " ORDER BY created_at " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var webhooks []*Webhook
+	err = r.db.SelectContext(ctx, &webhooks, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *repository) Count(ctx context.Context, projectID string) (int, error) {
+	const query = `SELECT COUNT(*) FROM webhooks WHERE project_id = $1`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, projectID, id string) (*Webhook, error) {
+	const query = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND project_id = $2`
+
+	var webhook Webhook
+	err := r.db.GetContext(ctx, &webhook, query, id, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook by id: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (r *repository) GetSubscribed(ctx context.Context, projectID, eventType string) ([]*Webhook, error) {
+	const query = `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE project_id = $1 AND enabled AND ',' || events || ',' LIKE '%,' || $2 || ',%'
+	`
+
+	var webhooks []*Webhook
+	err := r.db.SelectContext(ctx, &webhooks, query, projectID, eventType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribed webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *repository) Create(ctx context.Context, webhook *Webhook) error {
+	const query = `
+		INSERT INTO webhooks (` + webhookColumns + `)
+		VALUES (:id, :project_id, :url, :secret, :events, :enabled, :created_at, :updated_at)
+	`
+
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, webhook)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, projectID, id string, updated *Webhook) error {
+	const query = `
+		UPDATE
+			webhooks
+		SET
+			url = :url,
+			secret = :secret,
+			events = :events,
+			enabled = :enabled,
+			updated_at = :updated_at
+		WHERE
+			id = :id AND project_id = :project_id
+	`
+
+	updated.ID = id
+	updated.ProjectID = projectID
+	updated.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.NamedExecContext(ctx, query, updated)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, projectID, id string) error {
+	const query = `DELETE FROM webhooks WHERE id = $1 AND project_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) GetDeliveries(ctx context.Context, params GetDeliveriesParams) ([]*Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE 1=1`
+
+	args := map[string]interface{}{
+		"limit":  params.Limit,
+		"offset": params.Offset,
+	}
+
+	query, args = applyDeliveryFilters(query, params, args)
+
+	query += " ORDER BY created_at " + params.SortOrder + ", id " + params.SortOrder
+	query += " LIMIT :limit OFFSET :offset"
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	r.logger.Debug(query)
+
+	var deliveries []*Delivery
+	err = r.db.SelectContext(ctx, &deliveries, query, namedArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *repository) CountDeliveries(ctx context.Context, params GetDeliveriesParams) (int, error) {
+	query := "SELECT COUNT(*) FROM webhook_deliveries WHERE 1=1"
+	query, args := applyDeliveryFilters(query, params, make(map[string]interface{}))
+
+	query, namedArgs, err := sqlx.Named(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare named query: %w", err)
+	}
+
+	query = r.db.Rebind(query)
+
+	var count int
+	err = r.db.GetContext(ctx, &count, query, namedArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repository) GetDeliveryByID(ctx context.Context, webhookID, id string) (*Delivery, error) {
+	const query = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2`
+
+	var delivery Delivery
+	err := r.db.GetContext(ctx, &delivery, query, id, webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery by id: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (r *repository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	const query = `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES (
+			:id, :webhook_id, :project_id, :event_type, :payload, :status, :attempts, :next_attempt_at,
+			:status_code, :latency_ms, :response_body, :error, :created_at, :updated_at
+		)
+	`
+
+	if delivery.ID == "" {
+		delivery.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	_, err := r.db.NamedExecContext(ctx, query, delivery)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries leases the due pending deliveries until leaseUntil, so concurrent
+// dispatchers skip them and a crashed dispatcher's deliveries are retried once it expires.
+func (r *repository) ClaimDeliveries(ctx context.Context, now, leaseUntil int64, limit int) ([]*PendingDelivery, error) {
+	const query = `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING
+			d.id, d.webhook_id, d.project_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.status_code, d.latency_ms, d.response_body, d.error, d.created_at, d.updated_at,
+			w.url, w.secret
+	`
+
+	var deliveries []*PendingDelivery
+	err := r.db.SelectContext(ctx, &deliveries, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *repository) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+	const query = `
+		UPDATE
+			webhook_deliveries
+		SET
+			status = :status,
+			attempts = :attempts,
+			next_attempt_at = :next_attempt_at,
+			status_code = :status_code,
+			latency_ms = :latency_ms,
+			response_body = :response_body,
+			error = :error,
+			updated_at = :updated_at
+		WHERE
+			id = :id
+	`
+
+	delivery.UpdatedAt = time.Now().Unix()
+
+	_, err := r.db.NamedExecContext(ctx, query, delivery)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func applyDeliveryFilters(
+	baseQuery string,
+	params GetDeliveriesParams,
+	args map[string]interface{},
+) (string, map[string]interface{}) {
+	query := baseQuery
+
+	if params.ProjectID != "" {
+		query += " AND project_id = :projectId"
+		args["projectId"] = params.ProjectID
+	}
+
+	if params.WebhookID != "" {
+		query += " AND webhook_id = :webhookId"
+		args["webhookId"] = params.WebhookID
+	}
+
+	if params.Status != "" {
+		query += " AND status = :status"
+		args["status"] = params.Status
+	}
+
+	return query, args
+}
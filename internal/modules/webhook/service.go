@@ -0,0 +1,353 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/google/uuid"
+)
+
+var ErrDisabled = errors.New("webhook is disabled")
+
+const (
+	secretLength = 32
+
+	enqueueTimeout = 10 * time.Second
+)
+
+// subscribableEvents are the event types webhooks can subscribe to.
+var subscribableEvents = map[events.Type]bool{
+	events.TypeGroupCreated:       true,
+	events.TypeGroupRegressed:     true,
+	events.TypeGroupStatusChanged: true,
+	events.TypeGroupUnsnoozed:     true,
+	events.TypeAlertFired:         true,
+	events.TypeAnomalyDetected:    true,
+}
+
+type Service interface {
+	GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error)
+	GetByID(ctx context.Context, projectID, id string) (*Entity, error)
+	Create(ctx context.Context, req *Create) (*Entity, error)
+	Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error)
+	Delete(ctx context.Context, projectID, id string) error
+	GetDeliveries(ctx context.Context, params GetDeliveriesParams) ([]*DeliveryEntity, int, error)
+	GetDelivery(ctx context.Context, projectID, webhookID, id string) (*DeliveryEntity, error)
+	Redeliver(ctx context.Context, projectID, webhookID, id string) (*DeliveryEntity, error)
+	Enqueue(ctx context.Context, event events.Event)
+	Notify(ctx context.Context, target string, firing *alert.FiringEntity) error
+}
+
+type service struct {
+	repo   Repository
+	logger Logger
+}
+
+func NewService(repo Repository, logger Logger) Service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *service) GetAll(ctx context.Context, params GetAllParams) ([]*Entity, int, error) {
+	webhooks, err := s.repo.GetAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.Count(ctx, params.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*Entity, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		responses = append(responses, toResponse(webhook))
+	}
+	return responses, total, nil
+}
+
+func (s *service) GetByID(ctx context.Context, projectID, id string) (*Entity, error) {
+	webhook, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(webhook), nil
+}
+
+func (s *service) Create(ctx context.Context, req *Create) (*Entity, error) {
+	webhook := &Webhook{
+		ProjectID: req.ProjectID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    strings.Join(req.Events, ","),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret = generateSecret()
+	}
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return toResponse(webhook), nil
+}
+
+func (s *service) Update(ctx context.Context, projectID, id string, req *Update) (*Entity, error) {
+	webhook, err := s.repo.GetByID(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = strings.Join(req.Events, ",")
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.Update(ctx, projectID, id, webhook); err != nil {
+		return nil, err
+	}
+
+	return toResponse(webhook), nil
+}
+
+func (s *service) Delete(ctx context.Context, projectID, id string) error {
+	return s.repo.Delete(ctx, projectID, id)
+}
+
+func (s *service) GetDeliveries(ctx context.Context, params GetDeliveriesParams) ([]*DeliveryEntity, int, error) {
+	deliveries, err := s.repo.GetDeliveries(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountDeliveries(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*DeliveryEntity, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, toDeliveryResponse(delivery))
+	}
+	return responses, total, nil
+}
+
+func (s *service) GetDelivery(ctx context.Context, projectID, webhookID, id string) (*DeliveryEntity, error) {
+	if _, err := s.repo.GetByID(ctx, projectID, webhookID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.GetDeliveryByID(ctx, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDeliveryResponse(delivery), nil
+}
+
+// Redeliver queues a new delivery of the payload of a previous one, identified as the new delivery.
+func (s *service) Redeliver(ctx context.Context, projectID, webhookID, id string) (*DeliveryEntity, error) {
+	if _, err := s.repo.GetByID(ctx, projectID, webhookID); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.repo.GetDeliveryByID(ctx, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		ID:            uuid.New().String(),
+		WebhookID:     previous.WebhookID,
+		ProjectID:     previous.ProjectID,
+		EventType:     previous.EventType,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now().Unix(),
+	}
+
+	delivery.Payload, err = rebuildPayload(delivery.ID, previous.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build webhook payload: %w", err)
+	}
+
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return toDeliveryResponse(delivery), nil
+}
+
+// Enqueue persists a delivery of the event for every webhook of the project subscribed to it.
+// It is meant to be subscribed to the event bus, the deliveries being persisted in the background.
+func (s *service) Enqueue(ctx context.Context, event events.Event) {
+	if !subscribableEvents[event.Type] || event.ProjectID == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), enqueueTimeout)
+		defer cancel()
+
+		s.enqueue(ctx, event)
+	}()
+}
+
+func (s *service) enqueue(ctx context.Context, event events.Event) {
+	webhooks, err := s.repo.GetSubscribed(ctx, event.ProjectID, string(event.Type))
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if err := s.deliver(ctx, webhook, event); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+}
+
+// Notify queues a delivery of a fired alert to the webhook of its project an alert rule action
+// targets, signed and retried as the deliveries of the subscribed events.
+func (s *service) Notify(ctx context.Context, target string, firing *alert.FiringEntity) error {
+	if _, err := uuid.Parse(target); err != nil {
+		return ErrNotFound
+	}
+
+	webhook, err := s.repo.GetByID(ctx, firing.ProjectID, target)
+	if err != nil {
+		return err
+	}
+	if !webhook.Enabled {
+		return ErrDisabled
+	}
+
+	return s.deliver(ctx, webhook, events.Event{
+		Type:      events.TypeAlertFired,
+		ProjectID: firing.ProjectID,
+		GroupID:   firing.GroupID,
+		GroupKind: firing.GroupKind,
+		Message:   firing.Message,
+		Time:      time.Unix(firing.FiredAt, 0).UnixMilli(),
+		Payload:   firing,
+	})
+}
+
+// deliver persists a pending delivery of the event to the webhook.
+func (s *service) deliver(ctx context.Context, webhook *Webhook, event events.Event) error {
+	delivery := &Delivery{
+		ID:            uuid.New().String(),
+		WebhookID:     webhook.ID,
+		ProjectID:     webhook.ProjectID,
+		EventType:     string(event.Type),
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now().Unix(),
+	}
+
+	payload, err := buildPayload(delivery.ID, event)
+	if err != nil {
+		return fmt.Errorf("failed to build webhook payload: %w", err)
+	}
+	delivery.Payload = payload
+
+	return s.repo.CreateDelivery(ctx, delivery)
+}
+
+func buildPayload(id string, event events.Event) (string, error) {
+	payload := Payload{
+		ID:        id,
+		Type:      string(event.Type),
+		ProjectID: event.ProjectID,
+		GroupID:   event.GroupID,
+		GroupKind: event.GroupKind,
+		Message:   event.Message,
+		Time:      event.Time,
+	}
+
+	if event.Payload != nil {
+		data, err := json.Marshal(event.Payload)
+		if err != nil {
+			return "", err
+		}
+		payload.Data = data
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// rebuildPayload renders the payload of a previous delivery with the ID of a new one.
+func rebuildPayload(id, previous string) (string, error) {
+	var payload Payload
+	if err := json.Unmarshal([]byte(previous), &payload); err != nil {
+		return "", err
+	}
+	payload.ID = id
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func generateSecret() string {
+	b := make([]byte, secretLength)
+	_, err := rand.Read(b)
+	if err != nil {
+		return strings.ReplaceAll(uuid.New().String(), "-", "")
+	}
+	return hex.EncodeToString(b)
+}
+
+func toResponse(w *Webhook) *Entity {
+	eventTypes := []string{}
+	if w.Events != "" {
+		eventTypes = strings.Split(w.Events, ",")
+	}
+
+	return &Entity{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    eventTypes,
+		Secret:    w.Secret,
+		Enabled:   w.Enabled,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(d *Delivery) *DeliveryEntity {
+	return &DeliveryEntity{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		StatusCode:    d.StatusCode,
+		LatencyMs:     d.LatencyMs,
+		ResponseBody:  d.ResponseBody,
+		Error:         d.Error,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProjectID = "a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"
+
+func newTestService(t *testing.T) Service {
+	t.Helper()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, sql.RunMigrations(db, logger.New("ERROR", nil)))
+
+	appLogger := logger.New("ERROR", nil)
+	return NewService(NewRepository(db, appLogger), appLogger)
+}
+
+func TestServiceNotify(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	enabled := false
+	disabled, err := s.Create(ctx, &Create{
+		URL: "https://example.com/disabled", Events: []string{"group.created"}, Enabled: &enabled, ProjectID: testProjectID,
+	})
+	require.NoError(t, err)
+	webhook, err := s.Create(ctx, &Create{
+		URL: "https://example.com/hooks", Events: []string{"group.created"}, ProjectID: testProjectID,
+	})
+	require.NoError(t, err)
+
+	firing := &alert.FiringEntity{
+		ID: "firing", RuleID: "rule", ProjectID: testProjectID, GroupID: "group", GroupKind: "error", FiredAt: 1704067200,
+	}
+
+	require.NoError(t, s.Notify(ctx, webhook.ID, firing))
+	assert.ErrorIs(t, s.Notify(ctx, disabled.ID, firing), ErrDisabled)
+	assert.ErrorIs(t, s.Notify(ctx, "https://example.com/hooks", firing), ErrNotFound)
+	assert.ErrorIs(t, s.Notify(ctx, "b08929b5-d4f0-4ceb-9cfe-bb4fc05b030c", firing), ErrNotFound)
+
+	deliveries, total, err := s.GetDeliveries(ctx, GetDeliveriesParams{
+		ProjectID: testProjectID, WebhookID: webhook.ID, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "alert.fired", deliveries[0].EventType)
+	assert.Equal(t, "pending", deliveries[0].Status)
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, deliveries[0].ID, payload.ID)
+	assert.Equal(t, int64(1704067200000), payload.Time)
+	assert.JSONEq(t, `"rule"`, string(mustField(t, payload.Data, "ruleId")))
+}
+
+func TestServiceRedeliver(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	webhook, err := s.Create(ctx, &Create{
+		URL: "https://example.com/hooks", Events: []string{"group.created"}, ProjectID: testProjectID,
+	})
+	require.NoError(t, err)
+
+	firing := &alert.FiringEntity{ID: "firing", RuleID: "rule", ProjectID: testProjectID, FiredAt: 1704067200}
+	require.NoError(t, s.Notify(ctx, webhook.ID, firing))
+
+	deliveries, _, err := s.GetDeliveries(ctx, GetDeliveriesParams{
+		ProjectID: testProjectID, WebhookID: webhook.ID, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	redelivery, err := s.Redeliver(ctx, testProjectID, webhook.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, deliveries[0].ID, redelivery.ID)
+
+	// The payload is identified as the new delivery, as its delivery header is
+	var previous, payload Payload
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &previous))
+	require.NoError(t, json.Unmarshal([]byte(redelivery.Payload), &payload))
+	assert.Equal(t, redelivery.ID, payload.ID)
+
+	previous.ID = payload.ID
+	assert.Equal(t, previous, payload)
+}
+
+func mustField(t *testing.T, data json.RawMessage, name string) json.RawMessage {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[name]
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+)
+
+// sqliteRepository stores the webhooks in SQLite. Its transactions take the write lock from
+// their start, so it claims the deliveries without skipping locked rows.
+type sqliteRepository struct {
+	*repository
+}
+
+func (r *sqliteRepository) ClaimDeliveries(
+	ctx context.Context,
+	now, leaseUntil int64,
+	limit int,
+) ([]*PendingDelivery, error) {
+	// SQLite returns the columns of the updated table only, the webhook is read by subqueries.
+	const query = `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $2
+		)
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $3
+		WHERE id IN (SELECT id FROM due)
+		RETURNING ` + deliveryColumns + `,
+			(SELECT w.url FROM webhooks w WHERE w.id = webhook_id) AS url,
+			(SELECT w.secret FROM webhooks w WHERE w.id = webhook_id) AS secret
+	`
+
+	var deliveries []*PendingDelivery
+	err := r.db.SelectContext(ctx, &deliveries, query, now, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,28 @@
+package query
+
+// Type defines how the values of a field are matched.
+type Type int
+
+const (
+	// TypeText matches the values containing the searched text, * being a wildcard
+	TypeText Type = iota
+	// TypeKeyword matches whole values, * being a wildcard
+	TypeKeyword
+	// TypeNumber compares integers
+	TypeNumber
+	// TypeTime compares Unix timestamps in milliseconds, absolute or relative to now
+	TypeTime
+	// TypeJSON matches the values found at a path of a JSON document
+	TypeJSON
+)
+
+// Field maps a field of the language to an SQL expression.
+type Field struct {
+	Column string
+	Type   Type
+	// Uppercase normalizes the searched values, for columns stored uppercase
+	Uppercase bool
+}
+
+// Fields are the fields a query may use. JSON fields are used with a path, as in context.user.id.
+type Fields map[string]Field
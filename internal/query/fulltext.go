@@ -0,0 +1,124 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SearchContains matches the messages containing the searched text
+	SearchContains = "contains"
+	// SearchFullText matches the indexed words of the messages
+	SearchFullText = "fulltext"
+
+	// TextSearchConfig parses the searched columns. Logs are rarely prose, so words are neither
+	// stemmed nor dropped as stop words.
+	TextSearchConfig = "simple"
+
+	fullTextArgPrefix = "tsquery"
+)
+
+// ParseSearchMode validates the searchMode parameter of the lists searching messages.
+func ParseSearchMode(value string) (string, error) {
+	switch value {
+	case "", SearchContains, SearchFullText:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid search mode, expected contains or fulltext")
+	}
+}
+
+var tsqueryEscaper = strings.NewReplacer(`'`, `''`, `\`, `\\`)
+
+// FullText compiles a full-text search to a tsquery expression, adding its values to args.
+// All the words must match, quoted phrases match consecutive words and words ending with *
+// match as prefixes. The expression is empty when the search has no word.
+//
+// The argument names only depend on the search, so compiling it again with the same args
+// yields the same expression.
+func FullText(search string, args map[string]interface{}) string {
+	var queries []string
+	for i, term := range splitTerms(search) {
+		name := fmt.Sprintf("%s%d", fullTextArgPrefix, i+1)
+
+		if lexeme, ok := strings.CutSuffix(term.value, wildcard); ok && !term.phrase {
+			lexeme = strings.TrimRight(lexeme, wildcard)
+			if lexeme == "" {
+				continue
+			}
+			args[name] = "'" + tsqueryEscaper.Replace(lexeme) + "':*"
+			queries = append(queries, "to_tsquery('"+TextSearchConfig+"', :"+name+")")
+			continue
+		}
+
+		args[name] = term.value
+		queries = append(queries, "phraseto_tsquery('"+TextSearchConfig+"', :"+name+")")
+	}
+
+	if len(queries) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(queries, " && ") + ")"
+}
+
+// FullTextLike matches a full-text search on a database without full-text index, such as SQLite:
+// every word and phrase is searched within the column, regardless of the word boundaries. The
+// expression is empty when the search has no word.
+func FullTextLike(search, column string, args map[string]interface{}) string {
+	var conditions []string
+	for i, term := range splitTerms(search) {
+		value := term.value
+		if !term.phrase {
+			value = strings.TrimRight(value, wildcard)
+		}
+		if value == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("%s%d", fullTextArgPrefix, i+1)
+		args[name] = "%" + likeEscaper.Replace(value) + "%"
+		conditions = append(conditions, column+" LIKE :"+name+` ESCAPE '\'`)
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+type searchTerm struct {
+	value  string
+	phrase bool
+}
+
+// splitTerms splits a search into words and quoted phrases.
+func splitTerms(search string) []searchTerm {
+	var (
+		terms  []searchTerm
+		term   strings.Builder
+		quoted bool
+	)
+
+	flush := func() {
+		if value := strings.TrimSpace(term.String()); value != "" {
+			terms = append(terms, searchTerm{value: value, phrase: quoted})
+		}
+		term.Reset()
+	}
+
+	for _, r := range search {
+		switch {
+		case r == '"':
+			flush()
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			term.WriteRune(r)
+		}
+	}
+	flush()
+
+	return terms
+}
@@ -0,0 +1,271 @@
+package query
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Record returns the value of a column of a row matched in memory: a string or a *string for
+// texts and JSON documents, an int or an int64 for numbers and times. NULL columns are nil.
+type Record func(column string) interface{}
+
+// Match reports whether the row matches the query, as the compiled SQL would. It serves the
+// repositories keeping their rows in memory.
+func (q *Query) Match(record Record) bool {
+	return q.root.match(record)
+}
+
+// MatchSearch reports whether the text matches a search of the mode, as the repositories filter
+// the messages: the contained text, or every word and phrase of a full-text search.
+func MatchSearch(search, mode, text string) bool {
+	if mode != SearchFullText {
+		return containsFold(text, search)
+	}
+
+	for _, term := range splitTerms(search) {
+		value := term.value
+		if !term.phrase {
+			value = strings.TrimRight(value, wildcard)
+		}
+		if !containsFold(text, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *andNode) match(record Record) bool {
+	return n.left.match(record) && n.right.match(record)
+}
+
+func (n *orNode) match(record Record) bool {
+	return n.left.match(record) || n.right.match(record)
+}
+
+// Unknown operands do not match, so their negation does, as IS NOT TRUE in SQL.
+func (n *notNode) match(record Record) bool {
+	return !n.operand.match(record)
+}
+
+func (c *condition) match(record Record) bool {
+	value := record(c.field.Column)
+	if pointer, ok := value.(*string); ok {
+		if pointer == nil {
+			return false
+		}
+		value = *pointer
+	}
+	if value == nil {
+		return false
+	}
+
+	switch c.field.Type {
+	case TypeText:
+		text, _ := value.(string)
+		searched, _ := c.value.(string)
+		switch {
+		case c.pattern:
+			return matchPattern(text, searched)
+		case c.operator == "=":
+			return text == searched
+		default:
+			return containsFold(text, searched)
+		}
+	case TypeKeyword:
+		text, _ := value.(string)
+		searched, _ := c.value.(string)
+		if c.pattern {
+			return matchPattern(text, searched)
+		}
+		return text == searched
+	case TypeNumber, TypeTime:
+		number, ok := toInt64(value)
+		if !ok {
+			return false
+		}
+		searched, _ := c.value.(int64)
+		return compare(float64(number), float64(searched), comparison(c.operator))
+	case TypeJSON:
+		document, _ := value.(string)
+		return c.matchJSON(document)
+	default:
+		return false
+	}
+}
+
+func (c *condition) matchJSON(document string) bool {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return false
+	}
+
+	found, ok := jsonAt(root, c.path)
+	if !ok {
+		return false
+	}
+
+	value, _ := c.value.(string)
+	switch {
+	case c.exists:
+		return true
+	case c.operator != "" && c.operator != "=":
+		var bound interface{}
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&bound); err != nil {
+			return false
+		}
+		return compareJSON(found, bound, c.operator)
+	case c.pattern:
+		text, ok := jsonText(found)
+		return ok && matchPattern(text, value)
+	case slices.ContainsFunc(c.path, isIndex):
+		text, ok := jsonText(found)
+		return ok && text == value
+	default:
+		for _, candidate := range jsonCandidates(value, c.quoted) {
+			if jsonEqual(found, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// jsonAt returns the value at the path of the document, numeric keys indexing arrays.
+func jsonAt(document interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		switch node := document.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			document = value
+		case []interface{}:
+			index, ok := arrayIndex(key, len(node))
+			if !ok {
+				return nil, false
+			}
+			document = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return document, true
+}
+
+func arrayIndex(key string, length int) (int, bool) {
+	if !isIndex(key) {
+		return 0, false
+	}
+
+	var index int
+	_ = json.Unmarshal([]byte(key), &index)
+	if index < 0 {
+		// Negative indexes count from the end, as in PostgreSQL
+		index += length
+	}
+	return index, index >= 0 && index < length
+}
+
+// jsonText returns the value as text, as the #>> operator does. JSON null has no text.
+func jsonText(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	default:
+		encoded, _ := json.Marshal(value)
+		return string(encoded), true
+	}
+}
+
+func jsonEqual(found, candidate interface{}) bool {
+	switch candidate := candidate.(type) {
+	case json.Number:
+		number, ok := found.(json.Number)
+		if !ok {
+			return false
+		}
+		left, leftErr := number.Float64()
+		right, rightErr := candidate.Float64()
+		return leftErr == nil && rightErr == nil && left == right
+	case string:
+		text, ok := found.(string)
+		return ok && text == candidate
+	default:
+		return found == candidate
+	}
+}
+
+// compareJSON orders JSON numbers numerically and strings lexically, other values do not compare.
+func compareJSON(found, bound interface{}, operator string) bool {
+	switch bound := bound.(type) {
+	case json.Number:
+		number, ok := found.(json.Number)
+		if !ok {
+			return false
+		}
+		left, leftErr := number.Float64()
+		right, rightErr := bound.Float64()
+		return leftErr == nil && rightErr == nil && compare(left, right, operator)
+	case string:
+		text, ok := found.(string)
+		return ok && compare(float64(strings.Compare(text, bound)), 0, operator)
+	default:
+		return false
+	}
+}
+
+func compare(left, right float64, operator string) bool {
+	switch operator {
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	default:
+		return left == right
+	}
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), true
+	case int64:
+		return value, true
+	case *int64:
+		if value == nil {
+			return 0, false
+		}
+		return *value, true
+	default:
+		return 0, false
+	}
+}
+
+func containsFold(text, searched string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(searched))
+}
+
+// matchPattern matches the whole text against a value with * wildcards whatever the case,
+// as likePattern does in SQL.
+func matchPattern(text, pattern string) bool {
+	parts := strings.Split(pattern, wildcard)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	matched, _ := regexp.MatchString("(?is)^"+strings.Join(parts, ".*")+"$", text)
+	return matched
+}
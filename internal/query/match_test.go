@@ -0,0 +1,74 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	context := `{"user": {"id": 42, "name": "Ann"}, "tags": ["a", "b"], "amount": 9.5, "ok": true, "none": null}`
+	record := func(column string) interface{} {
+		switch column {
+		case "message":
+			return "Connection refused by host"
+		case "CAST(level AS TEXT)":
+			return "ERROR"
+		case "line":
+			return 12
+		case "time":
+			return int64(1704067200000)
+		case "context":
+			return &context
+		default:
+			return nil
+		}
+	}
+
+	tests := []struct {
+		input string
+		match bool
+	}{
+		{input: `refused`, match: true},
+		{input: `REFUSED`, match: true},
+		{input: `message:conn*host`, match: true},
+		{input: `message:conn*`, match: true},
+		{input: `message:refused*`, match: false},
+		{input: `message:="Connection refused by host"`, match: true},
+		{input: `level:error`, match: true},
+		{input: `level:warn`, match: false},
+		{input: `level:ERR*`, match: true},
+		{input: `line:>=12 AND line:<13`, match: true},
+		{input: `time:>2024-01-02`, match: false},
+		{input: `context.user.id:42`, match: true},
+		{input: `context.user.id:"42"`, match: false},
+		{input: `context.user.name:an*`, match: true},
+		{input: `context.tags.1:b`, match: true},
+		{input: `context.amount:>9`, match: true},
+		{input: `context.user.name:<"B"`, match: true},
+		{input: `context.ok:true`, match: true},
+		{input: `context.none:null`, match: true},
+		{input: `context.user.*:*`, match: false},
+		{input: `context.user:*`, match: true},
+		{input: `context.missing:*`, match: false},
+		{input: `NOT context.missing:1`, match: true},
+		{input: `level:warn OR (line:12 NOT refused)`, match: false},
+		{input: `level:warn OR (line:12 NOT timeout)`, match: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			q, err := Parse(test.input, testFields)
+			require.NoError(t, err)
+			assert.Equal(t, test.match, q.Match(record))
+		})
+	}
+}
+
+func TestMatchSearch(t *testing.T) {
+	assert.True(t, MatchSearch("USER not", SearchContains, "user not found"))
+	assert.False(t, MatchSearch("not user", SearchContains, "user not found"))
+	assert.True(t, MatchSearch("found user*", SearchFullText, "user not found"))
+	assert.False(t, MatchSearch(`"found user"`, SearchFullText, "user not found"))
+}
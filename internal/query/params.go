@@ -0,0 +1,98 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ParseParams builds a query from the URL parameters naming a path of a JSON field, as
+// context.orderId=123. An empty value or * matches the documents having the path. The other
+// parameters are ignored, and the query is nil when no parameter names a path.
+func ParseParams(values url.Values, fields Fields) (*Query, error) {
+	p := &parser{fields: fields, now: time.Now()}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		root, _, hasPath := strings.Cut(name, ".")
+		if field, ok := fields[root]; ok && hasPath && field.Type == TypeJSON {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var (
+		root   node
+		inputs []string
+	)
+	for _, name := range names {
+		for _, value := range values[name] {
+			if value == "" {
+				value = wildcard
+			}
+
+			condition, err := p.newCondition(0, name, "", value, false)
+			if err != nil {
+				var syntaxErr *SyntaxError
+				if errors.As(err, &syntaxErr) {
+					return nil, fmt.Errorf("invalid filter %s: %s", name, syntaxErr.Message)
+				}
+				return nil, err
+			}
+
+			root = and(root, condition)
+			inputs = append(inputs, name+":"+value)
+		}
+	}
+
+	if root == nil {
+		return nil, nil
+	}
+	return &Query{input: strings.Join(inputs, " AND "), root: root}, nil
+}
+
+// ParseValues builds the query of an event list from its q parameter and the parameters naming a
+// path of a JSON field. The query is nil when there is neither.
+func ParseValues(values url.Values, fields Fields) (*Query, error) {
+	filters, err := ParseParams(values, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	value := values.Get("q")
+	if value == "" {
+		return filters, nil
+	}
+
+	parsed, err := Parse(value, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return And(parsed, filters), nil
+}
+
+// And joins two queries, either of which may be nil.
+func And(left, right *Query) *Query {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	default:
+		return &Query{
+			input: "(" + left.input + ") AND (" + right.input + ")",
+			root:  &andNode{left: left.root, right: right.root},
+		}
+	}
+}
+
+func and(left, right node) node {
+	if left == nil {
+		return right
+	}
+	return &andNode{left: left, right: right}
+}
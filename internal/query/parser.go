@@ -0,0 +1,390 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	keywordAnd = "AND"
+	keywordOr  = "OR"
+	keywordNot = "NOT"
+
+	wildcard = "*"
+
+	// defaultField is searched by the terms given without a field
+	defaultField = "message"
+)
+
+var relativeTimePattern = regexp.MustCompile(`^-(\d+)([smhdw])$`)
+
+var relativeTimeUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// SyntaxError reports an invalid query and the position, starting at 1, of the character at fault.
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// Query is a parsed query, ready to be compiled to SQL.
+type Query struct {
+	input string
+	root  node
+}
+
+func (q *Query) String() string {
+	return q.input
+}
+
+// Parse parses a query such as
+//
+//	level:ERROR AND context.user.id:42 AND NOT message:"health check" AND time:>-1h
+//
+// Terms are joined by AND, OR and NOT, AND being implied between consecutive terms, and grouped
+// with parentheses. A term without a field searches the message. Values may be prefixed with a
+// comparison operator (>, >=, <, <=, =) and quoted to contain spaces.
+func Parse(input string, fields Fields) (*Query, error) {
+	return parse(input, fields, time.Now())
+}
+
+func parse(input string, fields Fields, now time.Time) (*Query, error) {
+	p := &parser{
+		input:  []rune(input),
+		fields: fields,
+		now:    now,
+	}
+
+	p.skipSpaces()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "empty query")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+
+	return &Query{input: input, root: root}, nil
+}
+
+type parser struct {
+	input  []rune
+	pos    int
+	fields Fields
+	now    time.Time
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword(keywordOr) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpaces()
+		if p.eof() || p.peek() == ')' || p.peekKeyword(keywordOr) {
+			return left, nil
+		}
+		p.acceptKeyword(keywordAnd)
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.acceptKeyword(keywordNot) {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "unexpected end of query, expected a term")
+	}
+
+	if p.peek() != '(' {
+		return p.parseTerm()
+	}
+
+	start := p.pos
+	p.pos++
+
+	expression, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.eof() || p.peek() != ')' {
+		return nil, p.errorf(start, "unclosed parenthesis")
+	}
+	p.pos++
+
+	return expression, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	start := p.pos
+
+	if p.peek() == '"' {
+		value, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return p.newCondition(start, defaultField, "", value, false)
+	}
+
+	name := p.readWhile(func(r rune) bool {
+		return !unicode.IsSpace(r) && !strings.ContainsRune(`():"`, r)
+	})
+	if name == "" {
+		return nil, p.errorf(start, "unexpected %q", p.peek())
+	}
+
+	if p.eof() || p.peek() != ':' {
+		return p.newCondition(start, defaultField, "", name, false)
+	}
+	p.pos++
+
+	operator := p.readWhile(func(r rune) bool { return strings.ContainsRune("<>=", r) })
+	switch operator {
+	case "", "=", ">", ">=", "<", "<=":
+	default:
+		return nil, p.errorf(p.pos-len([]rune(operator)), "unknown operator %q", operator)
+	}
+
+	valueStart := p.pos
+	var value string
+	quoted := !p.eof() && p.peek() == '"'
+	if quoted {
+		var err error
+		if value, err = p.readQuoted(); err != nil {
+			return nil, err
+		}
+	} else {
+		// Unquoted values may contain colons, as in url:https://example.com
+		value = p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) && r != ')' && r != '(' })
+		if value == "" {
+			return nil, p.errorf(valueStart, "expected a value for %s", name)
+		}
+	}
+
+	return p.newCondition(start, name, operator, value, quoted)
+}
+
+func (p *parser) newCondition(start int, name, operator, value string, quoted bool) (node, error) {
+	root, path, hasPath := strings.Cut(name, ".")
+
+	field, ok := p.fields[root]
+	if !ok {
+		return nil, p.errorf(start, "unknown field %q", root)
+	}
+
+	c := &condition{field: field, operator: operator}
+
+	switch field.Type {
+	case TypeText, TypeKeyword:
+		if hasPath {
+			return nil, p.errorf(start, "field %s has no path", root)
+		}
+		if operator != "" && operator != "=" {
+			return nil, p.errorf(start, "operator %s is not supported by %s", operator, root)
+		}
+		if field.Uppercase {
+			value = strings.ToUpper(value)
+		}
+		c.value = value
+		c.pattern = !quoted && strings.Contains(value, wildcard)
+	case TypeNumber:
+		if hasPath {
+			return nil, p.errorf(start, "field %s has no path", root)
+		}
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, p.errorf(start, "expected a number for %s", root)
+		}
+		c.value = number
+	case TypeTime:
+		if hasPath {
+			return nil, p.errorf(start, "field %s has no path", root)
+		}
+		timestamp, err := p.parseTime(value)
+		if err != nil {
+			return nil, p.errorf(start, "expected a time for %s, as 1704067200000, 2024-01-01, now or -1h", root)
+		}
+		c.value = timestamp
+	case TypeJSON:
+		if !hasPath || path == "" {
+			return nil, p.errorf(start, "expected a path for %s, as %s.key", root, root)
+		}
+		c.path = strings.Split(path, ".")
+		c.quoted = quoted
+		c.exists = !quoted && value == wildcard && operator == ""
+		c.pattern = !quoted && !c.exists && strings.Contains(value, wildcard)
+		c.value = value
+		if operator != "" && operator != "=" {
+			// Ranges compare JSON values, so numbers are ordered numerically
+			c.value = jsonValue(value, quoted)
+		}
+	default:
+		return nil, p.errorf(start, "unsupported field %s", root)
+	}
+
+	return c, nil
+}
+
+func (p *parser) parseTime(value string) (int64, error) {
+	if value == "now" {
+		return p.now.UnixMilli(), nil
+	}
+
+	if match := relativeTimePattern.FindStringSubmatch(value); match != nil {
+		amount, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return p.now.Add(-time.Duration(amount) * relativeTimeUnits[match[2]]).UnixMilli(), nil
+	}
+
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return timestamp, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid time %q", value)
+}
+
+func jsonValue(value string, quoted bool) string {
+	if !quoted {
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+	}
+
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func (p *parser) readQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+
+	var value strings.Builder
+	for !p.eof() {
+		r := p.input[p.pos]
+		p.pos++
+
+		switch r {
+		case '"':
+			return value.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf(start, "unterminated string")
+			}
+			value.WriteRune(p.input[p.pos])
+			p.pos++
+		default:
+			value.WriteRune(r)
+		}
+	}
+
+	return "", p.errorf(start, "unterminated string")
+}
+
+func (p *parser) readWhile(accept func(r rune) bool) string {
+	start := p.pos
+	for !p.eof() && accept(p.input[p.pos]) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// peekKeyword reports whether the next word is the keyword. Keywords are uppercase only,
+// so "and" or "or" are searched as text.
+func (p *parser) peekKeyword(keyword string) bool {
+	end := p.pos + len(keyword)
+	if end > len(p.input) || string(p.input[p.pos:end]) != keyword {
+		return false
+	}
+	return end == len(p.input) || unicode.IsSpace(p.input[end]) || p.input[end] == '('
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	p.skipSpaces()
+	if !p.peekKeyword(keyword) {
+		return false
+	}
+	p.pos += len(keyword)
+	return true
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() rune {
+	return p.input[p.pos]
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Position: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = Fields{
+	"message": {Column: "message", Type: TypeText},
+	"level":   {Column: "CAST(level AS TEXT)", Type: TypeKeyword, Uppercase: true},
+	"line":    {Column: "line", Type: TypeNumber},
+	"time":    {Column: "time", Type: TypeTime},
+	"context": {Column: "context", Type: TypeJSON},
+}
+
+func TestParse(t *testing.T) {
+	now := time.UnixMilli(1704067200000)
+
+	q, err := parse(`level:error AND context.user.id:42 NOT message:"health check" OR time:>-1h`, testFields, now)
+	require.NoError(t, err)
+
+	args := map[string]interface{}{}
+	assert.Equal(t,
+		"((((CAST(level AS TEXT) = :query1 AND (context @> CAST(:query2 AS JSONB) OR context @> CAST(:query3 AS JSONB)))"+
+			" AND ((message ILIKE :query4) IS NOT TRUE)) OR time > :query5))",
+		q.SQL(args),
+	)
+	assert.Equal(t, map[string]interface{}{
+		"query1": "ERROR",
+		"query2": `{"user":{"id":"42"}}`,
+		"query3": `{"user":{"id":42}}`,
+		"query4": "%health check%",
+		"query5": now.Add(-time.Hour).UnixMilli(),
+	}, args)
+}
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+		value interface{}
+	}{
+		{input: `timeout`, sql: "(message ILIKE :query1)", value: "%timeout%"},
+		{input: `message:100%`, sql: "(message ILIKE :query1)", value: `%100\%%`},
+		{input: `message:conn*refused`, sql: "(message ILIKE :query1)", value: "conn%refused"},
+		{input: `line:>=10`, sql: "(line >= :query1)", value: int64(10)},
+		{input: `time:2024-01-01`, sql: "(time = :query1)", value: int64(1704067200000)},
+		{input: `context.amount:>9.5`, sql: "((context #> CAST(:query1 AS TEXT[])) > CAST(:query2 AS JSONB))", value: "9.5"},
+		{input: `context.name:<"b"`, sql: "((context #> CAST(:query1 AS TEXT[])) < CAST(:query2 AS JSONB))", value: `"b"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			q, err := parse(test.input, testFields, time.Now())
+			require.NoError(t, err)
+
+			args := map[string]interface{}{}
+			assert.Equal(t, test.sql, q.SQL(args))
+			assert.Equal(t, test.value, args[fmt.Sprintf("query%d", len(args))])
+		})
+	}
+
+	q, err := parse(`context.user:*`, testFields, time.Now())
+	require.NoError(t, err)
+	args := map[string]interface{}{}
+	assert.Equal(t, "(context @? CAST(:query1 AS JSONPATH))", q.SQL(args))
+	assert.Equal(t, `$."user"`, args["query1"])
+
+	q, err = parse(`context.items.0.sku:"42"`, testFields, time.Now())
+	require.NoError(t, err)
+	args = map[string]interface{}{}
+	assert.Equal(t, "((context #>> CAST(:query1 AS TEXT[])) = :query2)", q.SQL(args))
+	assert.Equal(t, pq.Array([]string{"items", "0", "sku"}), args["query1"])
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+	}{
+		{input: "", position: 1},
+		{input: "level:ERROR AND", position: 16},
+		{input: "(level:ERROR", position: 1},
+		{input: "level:ERROR)", position: 12},
+		{input: `message:"health`, position: 9},
+		{input: "level:ERROR AND status:open", position: 17},
+		{input: "line:abc", position: 1},
+		{input: "level:>ERROR", position: 1},
+		{input: "context:42", position: 1},
+		{input: "line:=>3", position: 6},
+		{input: "level:", position: 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := parse(test.input, testFields, time.Now())
+
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "expected a syntax error, got %v", err)
+			assert.Equal(t, test.position, syntaxErr.Position, syntaxErr.Message)
+		})
+	}
+}
+
+func TestFullText(t *testing.T) {
+	args := map[string]interface{}{}
+	assert.Equal(t,
+		"(phraseto_tsquery('simple', :tsquery1) && phraseto_tsquery('simple', :tsquery2) && to_tsquery('simple', :tsquery3))",
+		FullText(`connection "health check" time*`, args),
+	)
+	assert.Equal(t, map[string]interface{}{
+		"tsquery1": "connection",
+		"tsquery2": "health check",
+		"tsquery3": "'time':*",
+	}, args)
+
+	args = map[string]interface{}{}
+	assert.Equal(t, "(to_tsquery('simple', :tsquery1))", FullText(`o'reilly*`, args))
+	assert.Equal(t, "'o''reilly':*", args["tsquery1"])
+
+	assert.Empty(t, FullText(` "" * `, map[string]interface{}{}))
+}
+
+func TestParseParams(t *testing.T) {
+	values := url.Values{
+		"projectId":       {"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"},
+		"context.orderId": {"123"},
+		"context.coupon":  {""},
+		"level.name":      {"ERROR"},
+	}
+
+	q, err := ParseParams(values, testFields)
+	require.NoError(t, err)
+
+	args := map[string]interface{}{}
+	assert.Equal(t,
+		"((context @? CAST(:query1 AS JSONPATH) AND (context @> CAST(:query2 AS JSONB) OR context @> CAST(:query3 AS JSONB))))",
+		q.SQL(args),
+	)
+	assert.Equal(t, `{"orderId":123}`, args["query3"])
+
+	q, err = ParseParams(url.Values{"search": {"timeout"}}, testFields)
+	require.NoError(t, err)
+	assert.Nil(t, q)
+}
@@ -0,0 +1,278 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// argPrefix names the arguments of a compiled query, which are added to the named arguments
+// of the surrounding statement.
+const argPrefix = "query"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SQL compiles the query to a boolean SQL expression, adding its values to args.
+// The expression uses named arguments, to be bound with sqlx.Named.
+func (q *Query) SQL(args map[string]interface{}) string {
+	b := &builder{args: args}
+	return "(" + q.root.sql(b) + ")"
+}
+
+// SQLite compiles the query to an SQLite expression, the JSON fields being matched with the JSON
+// functions of SQLite rather than the JSONB operators.
+func (q *Query) SQLite(args map[string]interface{}) string {
+	b := &builder{args: args, sqlite: true}
+	return "(" + q.root.sql(b) + ")"
+}
+
+type builder struct {
+	args   map[string]interface{}
+	n      int
+	sqlite bool
+}
+
+func (b *builder) bind(value interface{}) string {
+	b.n++
+	name := fmt.Sprintf("%s%d", argPrefix, b.n)
+	b.args[name] = value
+	return ":" + name
+}
+
+// ilike matches the column against a LIKE pattern whatever the case.
+func (b *builder) ilike(column, pattern string) string {
+	if b.sqlite {
+		return column + " LIKE " + b.bind(pattern) + ` ESCAPE '\'`
+	}
+	return column + " ILIKE " + b.bind(pattern)
+}
+
+type node interface {
+	sql(b *builder) string
+	match(record Record) bool
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) sql(b *builder) string {
+	return "(" + n.left.sql(b) + " AND " + n.right.sql(b) + ")"
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) sql(b *builder) string {
+	return "(" + n.left.sql(b) + " OR " + n.right.sql(b) + ")"
+}
+
+type notNode struct {
+	operand node
+}
+
+// Rows where the operand is unknown, such as a missing JSON path, match the negation.
+func (n *notNode) sql(b *builder) string {
+	return "((" + n.operand.sql(b) + ") IS NOT TRUE)"
+}
+
+type condition struct {
+	field    Field
+	path     []string
+	operator string
+	value    interface{}
+	// pattern is set for values with wildcards
+	pattern bool
+	// exists is set to match the documents having the path
+	exists bool
+	quoted bool
+}
+
+func (c *condition) sql(b *builder) string {
+	switch c.field.Type {
+	case TypeText:
+		value, _ := c.value.(string)
+		switch {
+		case c.pattern:
+			return b.ilike(c.field.Column, likePattern(value))
+		case c.operator == "=":
+			return c.field.Column + " = " + b.bind(value)
+		default:
+			return b.ilike(c.field.Column, "%"+likeEscaper.Replace(value)+"%")
+		}
+	case TypeKeyword:
+		value, _ := c.value.(string)
+		if c.pattern {
+			return b.ilike(c.field.Column, likePattern(value))
+		}
+		return c.field.Column + " = " + b.bind(value)
+	case TypeNumber, TypeTime:
+		return c.field.Column + " " + comparison(c.operator) + " " + b.bind(c.value)
+	case TypeJSON:
+		if b.sqlite {
+			return c.sqliteJSONSQL(b)
+		}
+		return c.jsonSQL(b)
+	default:
+		return "FALSE"
+	}
+}
+
+// jsonSQL favors the containment (@>) and JSON path (@?) operators, which GIN indexes serve.
+// Array indexes in the path, wildcards and ranges fall back to extracting the value.
+func (c *condition) jsonSQL(b *builder) string {
+	value, _ := c.value.(string)
+	indexable := !slices.ContainsFunc(c.path, isIndex)
+
+	switch {
+	case c.exists && indexable:
+		return c.field.Column + " @? CAST(" + b.bind(jsonPath(c.path)) + " AS JSONPATH)"
+	case c.exists:
+		return "(" + c.field.Column + " #> " + b.bindPath(c.path) + ") IS NOT NULL"
+	case c.operator != "" && c.operator != "=":
+		return "(" + c.field.Column + " #> " + b.bindPath(c.path) + ") " + c.operator + " CAST(" + b.bind(value) + " AS JSONB)"
+	case c.pattern:
+		return "(" + c.field.Column + " #>> " + b.bindPath(c.path) + ") ILIKE " + b.bind(likePattern(value))
+	case indexable:
+		// Unquoted values match the JSON scalar they spell as well as the string, so 42 matches "42"
+		conditions := make([]string, 0, 2)
+		for _, candidate := range jsonCandidates(value, c.quoted) {
+			conditions = append(conditions, c.field.Column+" @> CAST("+b.bind(jsonDocument(c.path, candidate))+" AS JSONB)")
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")"
+	default:
+		return "(" + c.field.Column + " #>> " + b.bindPath(c.path) + ") = " + b.bind(value)
+	}
+}
+
+// sqliteJSONSQL extracts the value at the path, SQLite having no JSON index to favor.
+func (c *condition) sqliteJSONSQL(b *builder) string {
+	value, _ := c.value.(string)
+	path := b.bind(sqlitePath(c.path))
+	extracted := "json_extract(" + c.field.Column + ", " + path + ")"
+
+	switch {
+	case c.exists:
+		return "json_type(" + c.field.Column + ", " + path + ") IS NOT NULL"
+	case c.operator != "" && c.operator != "=":
+		return extracted + " " + c.operator + " " + b.bind(jsonScalar(value))
+	case c.pattern:
+		return b.ilike(extracted, likePattern(value))
+	default:
+		conditions := make([]string, 0, 2)
+		for _, candidate := range jsonCandidates(value, c.quoted) {
+			switch candidate := candidate.(type) {
+			case bool, nil:
+				// json_extract returns true as 1 and null as NULL, the type tells them apart
+				encoded, _ := json.Marshal(candidate)
+				conditions = append(conditions, "json_type("+c.field.Column+", "+path+") = '"+string(encoded)+"'")
+			case json.Number:
+				conditions = append(conditions, extracted+" = "+b.bind(jsonScalar(candidate.String())))
+			default:
+				conditions = append(conditions, extracted+" = "+b.bind(candidate))
+			}
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")"
+	}
+}
+
+func (b *builder) bindPath(path []string) string {
+	return "CAST(" + b.bind(pq.Array(path)) + " AS TEXT[])"
+}
+
+func isIndex(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// jsonPath returns the JSON path of the keys, as $."user"."id".
+func jsonPath(keys []string) string {
+	var path strings.Builder
+	path.WriteString("$")
+	for _, key := range keys {
+		encoded, _ := json.Marshal(key)
+		path.WriteString(".")
+		path.Write(encoded)
+	}
+	return path.String()
+}
+
+// sqlitePath returns the SQLite JSON path of the keys, as $."user"."id", array indexes being
+// written as $."items"[0].
+func sqlitePath(keys []string) string {
+	var path strings.Builder
+	path.WriteString("$")
+	for _, key := range keys {
+		if isIndex(key) {
+			path.WriteString("[" + key + "]")
+			continue
+		}
+		encoded, _ := json.Marshal(key)
+		path.WriteString(".")
+		path.Write(encoded)
+	}
+	return path.String()
+}
+
+// jsonScalar decodes the value compared to a JSON value, numbers being compared as numbers.
+// Values that are not JSON are compared as strings.
+func jsonScalar(value string) interface{} {
+	var scalar interface{}
+	if err := json.Unmarshal([]byte(value), &scalar); err != nil {
+		return value
+	}
+
+	switch scalar := scalar.(type) {
+	case float64, string:
+		return scalar
+	default:
+		return value
+	}
+}
+
+// jsonDocument nests the value under the keys, as {"user":{"id":42}}.
+func jsonDocument(keys []string, value interface{}) string {
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func jsonCandidates(value string, quoted bool) []interface{} {
+	candidates := []interface{}{value}
+	if quoted {
+		return candidates
+	}
+
+	var scalar interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&scalar); err != nil || decoder.More() {
+		return candidates
+	}
+
+	switch scalar.(type) {
+	case json.Number, bool, nil:
+		return append(candidates, scalar)
+	default:
+		return candidates
+	}
+}
+
+func comparison(operator string) string {
+	if operator == "" {
+		return "="
+	}
+	return operator
+}
+
+// likePattern turns a value with * wildcards into an ILIKE pattern matching the whole value.
+func likePattern(value string) string {
+	return strings.ReplaceAll(likeEscaper.Replace(value), wildcard, "%")
+}
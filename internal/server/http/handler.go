@@ -3,13 +3,24 @@ package server
 import (
 	"net/http"
 
+	"github.com/fuckbug/api/internal/modules/activity"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/fuckbug/api/internal/modules/anomaly"
 	"github.com/fuckbug/api/internal/modules/app"
+	"github.com/fuckbug/api/internal/modules/attachment"
+	"github.com/fuckbug/api/internal/modules/bulk"
+	"github.com/fuckbug/api/internal/modules/channel"
 	"github.com/fuckbug/api/internal/modules/errors"
 	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/modules/importer"
 	"github.com/fuckbug/api/internal/modules/log"
 	logGroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/modules/notification"
 	"github.com/fuckbug/api/internal/modules/project"
+	"github.com/fuckbug/api/internal/modules/retention"
+	"github.com/fuckbug/api/internal/modules/stream"
 	"github.com/fuckbug/api/internal/modules/users"
+	"github.com/fuckbug/api/internal/modules/webhook"
 	"github.com/fuckbug/api/internal/server/http/handlers"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -24,6 +35,17 @@ func NewHandler(
 	errorService errors.Service,
 	errorGroupService errorsGroup.Service,
 	projectService project.Service,
+	anomalyService anomaly.Service,
+	alertService alert.Service,
+	webhookService webhook.Service,
+	notificationService notification.Service,
+	channelService channel.Service,
+	activityService activity.Service,
+	bulkService bulk.Service,
+	streamService stream.Service,
+	retentionService retention.Service,
+	importService importer.Service,
+	attachmentService attachment.Service,
 	jwtKey []byte,
 ) http.Handler {
 	r := mux.NewRouter()
@@ -34,11 +56,23 @@ func NewHandler(
 
 	handlers.RegisterAppHandlers(r, logger, appService)
 	handlers.RegisterAuthHandlers(r, logger, userService)
+	handlers.RegisterStreamHandlers(r, logger, streamService, jwtKey)
 	handlers.RegisterLogHandlers(r, logger, logService, jwtKey)
 	handlers.RegisterLogGroupHandlers(r, logger, logGroupService, jwtKey)
+	// Before the errors, whose ingest route would take the multipart ones
+	handlers.RegisterAttachmentHandlers(r, logger, errorService, attachmentService, jwtKey)
 	handlers.RegisterErrorHandlers(r, logger, errorService, jwtKey)
 	handlers.RegisterErrorGroupHandlers(r, logger, errorGroupService, jwtKey)
 	handlers.RegisterProjectHandlers(r, logger, projectService, jwtKey)
+	handlers.RegisterAnomalyHandlers(r, logger, anomalyService, jwtKey)
+	handlers.RegisterAlertHandlers(r, logger, alertService, jwtKey)
+	handlers.RegisterWebhookHandlers(r, logger, webhookService, jwtKey)
+	handlers.RegisterNotificationHandlers(r, logger, notificationService, jwtKey)
+	handlers.RegisterChannelHandlers(r, logger, channelService, jwtKey)
+	handlers.RegisterActivityHandlers(r, logger, activityService, jwtKey)
+	handlers.RegisterBulkHandlers(r, logger, bulkService, jwtKey)
+	handlers.RegisterRetentionHandlers(r, logger, retentionService, jwtKey)
+	handlers.RegisterImportHandlers(r, logger, importService, jwtKey)
 
 	return r
 }
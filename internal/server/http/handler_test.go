@@ -0,0 +1,921 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/logger"
+	"github.com/fuckbug/api/internal/modules/activity"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/fuckbug/api/internal/modules/anomaly"
+	"github.com/fuckbug/api/internal/modules/app"
+	"github.com/fuckbug/api/internal/modules/attachment"
+	"github.com/fuckbug/api/internal/modules/bulk"
+	"github.com/fuckbug/api/internal/modules/channel"
+	"github.com/fuckbug/api/internal/modules/errors"
+	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/modules/importer"
+	"github.com/fuckbug/api/internal/modules/log"
+	logGroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/modules/notification"
+	"github.com/fuckbug/api/internal/modules/project"
+	"github.com/fuckbug/api/internal/modules/retention"
+	"github.com/fuckbug/api/internal/modules/stream"
+	"github.com/fuckbug/api/internal/modules/users"
+	"github.com/fuckbug/api/internal/modules/webhook"
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/fuckbug/api/internal/storage/blob"
+	"github.com/fuckbug/api/internal/storage/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testEmail    = "me@example.com"
+	testPassword = "1234567890"
+)
+
+// testServer serves the API over the in-memory repositories of the users, projects, events and
+// groups. The other modules keep their data in an in-memory SQLite database.
+type testServer struct {
+	handler http.Handler
+	users   *users.MemoryRepository
+	sender  *testSender
+	bulk    *bulk.Runner
+	token   string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	appLogger := logger.New("ERROR", nil)
+	jwtKey := []byte("secret")
+	bus := events.NewBus()
+
+	db, err := storage.Open(storage.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, sql.RunMigrations(db, appLogger))
+
+	// The users and the groups the SQLite tables refer to are kept in memory.
+	_, err = db.Exec(`PRAGMA foreign_keys = OFF`)
+	require.NoError(t, err)
+
+	userRepository := users.NewMemoryRepository()
+	errorGroupRepository := errorsGroup.NewMemoryRepository(userRepository)
+	logGroupRepository := logGroup.NewMemoryRepository(userRepository)
+
+	userService := users.NewService(userRepository, jwtKey, appLogger)
+	errorService := errors.NewService(errors.NewMemoryRepository(errorGroupRepository), appLogger, bus)
+	logService := log.NewService(log.NewMemoryRepository(logGroupRepository), appLogger, bus)
+	errorGroupService := errorsGroup.NewService(errorGroupRepository, appLogger, bus)
+	logGroupService := logGroup.NewService(logGroupRepository, appLogger, bus)
+	projectService := project.NewService(project.NewMemoryRepository(), appLogger, "fuckbug.test")
+
+	notificationService := notification.NewService(
+		notification.NewRepository(db, appLogger), nil, errorService, logService, appLogger, notification.Config{},
+	)
+	alertService := alert.NewService(
+		alert.NewRepository(db, appLogger), appLogger, bus, map[alert.ActionType]alert.Notifier{}, alert.Config{},
+	)
+	webhookService := webhook.NewService(webhook.NewRepository(db, appLogger), appLogger)
+
+	sender := &testSender{}
+	channelService := channel.NewService(
+		channel.NewRepository(db, appLogger),
+		map[channel.Type]channel.Sender{channel.TypeSlack: sender},
+		appLogger,
+		channel.Config{},
+	)
+
+	activityService := activity.NewService(&memoryGroupActivity{
+		Repository:  activity.NewRepository(db, appLogger),
+		errorGroups: errorGroupRepository,
+		logGroups:   logGroupRepository,
+	}, appLogger)
+
+	bulkRepository := bulk.NewRepository(db, appLogger)
+	bulkExecutors := map[bulk.Target]bulk.Executor{
+		bulk.TargetErrorGroups: bulk.NewErrorGroupExecutor(errorGroupService),
+		bulk.TargetLogGroups:   bulk.NewLogGroupExecutor(logGroupService),
+		bulk.TargetErrors:      bulk.NewErrorExecutor(errorService),
+		bulk.TargetLogs:        bulk.NewLogExecutor(logService),
+	}
+	bulkService := bulk.NewService(bulkRepository, bulkExecutors, appLogger)
+
+	streamHub := stream.NewHub(16)
+	streamService := stream.NewService(streamHub, map[stream.Kind]stream.History{
+		stream.KindError: stream.NewErrorHistory(errorService),
+		stream.KindLog:   stream.NewLogHistory(logService),
+	}, appLogger, stream.Config{})
+
+	bus.Subscribe(activityService.HandleEvent)
+	bus.Subscribe(webhookService.Enqueue)
+	bus.Subscribe(streamHub.HandleEvent)
+
+	handler := NewHandler(
+		appLogger,
+		app.New(appLogger),
+		userService,
+		logService,
+		logGroupService,
+		errorService,
+		errorGroupService,
+		projectService,
+		anomaly.NewService(anomaly.NewRepository(db, appLogger), appLogger),
+		alertService,
+		webhookService,
+		notificationService,
+		channelService,
+		activityService,
+		bulkService,
+		streamService,
+		retention.NewService(retention.NewRepository(db, appLogger), nil, appLogger, retention.Config{}),
+		importer.NewImporter(errorService, logService, appLogger, importer.Config{}),
+		attachment.NewService(
+			attachment.NewRepository(db, appLogger), blob.NewFilesystemStore(t.TempDir()), appLogger, attachment.Config{},
+		),
+		jwtKey,
+	)
+
+	return &testServer{
+		handler: handler,
+		users:   userRepository,
+		sender:  sender,
+		bulk:    bulk.NewRunner(bulkRepository, bulkExecutors, appLogger, bulk.Config{}),
+	}
+}
+
+// request serves a request with a JSON body, or with the body as is when it is a string.
+func (s *testServer) request(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// call serves a request, checks the status of the response and decodes it into out.
+func (s *testServer) call(t *testing.T, method, path string, body interface{}, status int, out interface{}) {
+	t.Helper()
+
+	rec := s.request(t, method, path, body)
+	require.Equal(t, status, rec.Code, "%s %s: %s", method, path, rec.Body.String())
+
+	if out != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out), rec.Body.String())
+	}
+}
+
+// memoryGroupActivity finds the groups of the activities in the in-memory group repositories.
+type memoryGroupActivity struct {
+	activity.Repository
+	errorGroups *errorsGroup.MemoryRepository
+	logGroups   *logGroup.MemoryRepository
+}
+
+func (r *memoryGroupActivity) GetGroupProjectID(ctx context.Context, groupKind, groupID string) (string, error) {
+	switch groupKind {
+	case events.GroupKindError:
+		if group, err := r.errorGroups.GetByID(ctx, groupID); err == nil {
+			return group.ProjectID, nil
+		}
+	case events.GroupKindLog:
+		if group, err := r.logGroups.GetByID(ctx, groupID); err == nil {
+			return group.ProjectID, nil
+		}
+	}
+	return "", activity.ErrGroupNotFound
+}
+
+type testSender struct {
+	sent atomic.Int32
+}
+
+func (s *testSender) Send(_ context.Context, _ *channel.Channel, _ *channel.Message) error {
+	s.sent.Add(1)
+	return nil
+}
+
+type list struct {
+	Count      int               `json:"count"`
+	Items      []json.RawMessage `json:"items"`
+	NextCursor string            `json:"nextCursor"`
+}
+
+type entity struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Message    string  `json:"message"`
+	Status     string  `json:"status"`
+	Counter    int     `json:"counter"`
+	AssigneeID *string `json:"assigneeId"`
+	Type       string  `json:"type"`
+}
+
+func TestHandler(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+
+	t.Run("health", func(t *testing.T) {
+		s.call(t, http.MethodGet, "/health", nil, http.StatusOK, nil)
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		credentials := users.Signup{Email: testEmail, Password: testPassword}
+		s.call(t, http.MethodPost, "/v1/signup", credentials, http.StatusCreated, nil)
+		s.call(t, http.MethodPost, "/v1/signup", credentials, http.StatusInternalServerError, nil)
+		s.call(t, http.MethodPost, "/v1/signup", users.Signup{Email: "me"}, http.StatusBadRequest, nil)
+
+		s.call(t, http.MethodPost, "/v1/login", users.Login{Email: testEmail, Password: "wrong"},
+			http.StatusInternalServerError, nil)
+
+		var token users.Token
+		s.call(t, http.MethodPost, "/v1/login", users.Login{Email: testEmail, Password: testPassword},
+			http.StatusCreated, &token)
+		require.NotEmpty(t, token.AccessToken)
+
+		s.call(t, http.MethodGet, "/v1/projects", nil, http.StatusUnauthorized, nil)
+		s.token = token.AccessToken
+	})
+
+	user, err := s.users.FindByEmail(context.Background(), testEmail)
+	require.NoError(t, err)
+	require.NotNil(t, user)
+
+	var projectID, ingest string
+	t.Run("projects", func(t *testing.T) {
+		var created entity
+		s.call(t, http.MethodPost, "/v1/projects", project.Create{Name: "Shop"}, http.StatusCreated, &created)
+		s.call(t, http.MethodPost, "/v1/projects", project.Create{Name: "Blog"}, http.StatusCreated, nil)
+		s.call(t, http.MethodPost, "/v1/projects", project.Create{}, http.StatusBadRequest, nil)
+		projectID = created.ID
+
+		var projects list
+		s.call(t, http.MethodGet, "/v1/projects?sort=asc&limit=1", nil, http.StatusOK, &projects)
+		assert.Equal(t, 2, projects.Count)
+		assert.Len(t, projects.Items, 1)
+
+		var updated entity
+		s.call(t, http.MethodPut, "/v1/projects/"+projectID, project.Update{Name: "Store"}, http.StatusOK, &updated)
+		assert.Equal(t, "Store", updated.Name)
+
+		var fetched entity
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "Store", fetched.Name)
+
+		var dsn struct {
+			Dsn string `json:"dsn"`
+		}
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID+"/dsn", nil, http.StatusOK, &dsn)
+		require.Contains(t, dsn.Dsn, "/api/ingest/"+projectID+":")
+		ingest = "/ingest/" + dsn.Dsn[strings.LastIndex(dsn.Dsn, "/")+1:]
+
+		s.call(t, http.MethodGet, "/v1/projects/unknown", nil, http.StatusNotFound, nil)
+	})
+
+	var webhookID string
+	t.Run("webhooks", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/webhooks"
+
+		var created entity
+		s.call(t, http.MethodPost, path, webhook.Create{
+			URL:    "https://example.com/hooks",
+			Events: []string{"group.created"},
+		}, http.StatusCreated, &created)
+		webhookID = created.ID
+
+		s.call(t, http.MethodPost, path, webhook.Create{URL: "example", Events: []string{"group.created"}},
+			http.StatusBadRequest, nil)
+
+		var webhooks list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &webhooks)
+		assert.Equal(t, 1, webhooks.Count)
+
+		s.call(t, http.MethodPut, path+"/"+webhookID, webhook.Update{
+			URL:    "https://example.com/hooks/fuckbug",
+			Events: []string{"group.created", "group.regressed"},
+		}, http.StatusOK, nil)
+		s.call(t, http.MethodGet, path+"/"+webhookID, nil, http.StatusOK, nil)
+	})
+
+	ip := "192.168.1.1"
+	method := "POST"
+	t.Run("ingest", func(t *testing.T) {
+		stacktrace := interface{}([]interface{}{})
+		for i, message := range []string{"Division by zero", "Division by zero", "Connection timeout"} {
+			s.call(t, http.MethodPost, ingest+"/errors", errors.Create{
+				Time:       now.Add(time.Duration(i-3) * time.Minute).UnixMilli(),
+				Message:    message,
+				Stacktrace: &stacktrace,
+				File:       "index.php",
+				Line:       10 + len(message),
+				IP:         &ip,
+				Method:     &method,
+			}, http.StatusCreated, nil)
+		}
+
+		for i, level := range []string{"INFO", "ERROR", "ERROR"} {
+			var context interface{} = map[string]interface{}{"orderId": i}
+			s.call(t, http.MethodPost, ingest+"/logs", log.Create{
+				Time:    now.Add(time.Duration(i-3) * time.Minute).UnixMilli(),
+				Level:   level,
+				Message: "payment " + strings.ToLower(level),
+				Context: &context,
+			}, http.StatusCreated, nil)
+		}
+
+		s.call(t, http.MethodPost, ingest+"/errors", errors.Create{Message: "no time"}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodPost, ingest+"/logs", log.Create{Time: 1, Level: "LOUD", Message: "m"},
+			http.StatusBadRequest, nil)
+	})
+
+	var errorID, errorGroupID string
+	t.Run("errors", func(t *testing.T) {
+		path := "/v1/errors?projectId=" + projectID
+
+		var all list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &all)
+		require.Equal(t, 3, all.Count)
+
+		var latest entity
+		require.NoError(t, json.Unmarshal(all.Items[0], &latest))
+		assert.Equal(t, "Connection timeout", latest.Message)
+
+		var page list
+		s.call(t, http.MethodGet, path+"&limit=2", nil, http.StatusOK, &page)
+		require.Len(t, page.Items, 2)
+		require.NotEmpty(t, page.NextCursor)
+
+		s.call(t, http.MethodGet, path+"&limit=2&count=none&cursor="+page.NextCursor, nil, http.StatusOK, &page)
+		require.Len(t, page.Items, 1)
+		require.NoError(t, json.Unmarshal(page.Items[0], &latest))
+		errorID = latest.ID
+		assert.Equal(t, "Division by zero", latest.Message)
+
+		var found list
+		s.call(t, http.MethodGet, path+"&search=timeout", nil, http.StatusOK, &found)
+		assert.Equal(t, 1, found.Count)
+		s.call(t, http.MethodGet, path+"&q=message:division%20AND%20method:POST", nil, http.StatusOK, &found)
+		assert.Equal(t, 2, found.Count)
+		s.call(t, http.MethodGet, path+"&q=message:(", nil, http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, path+"&cursor=invalid", nil, http.StatusBadRequest, nil)
+
+		var stats errors.Stats
+		s.call(t, http.MethodGet, "/v1/errors/stats?projectId="+projectID, nil, http.StatusOK, &stats)
+		assert.Equal(t, errors.Stats{Last24h: 3, Last7d: 3, Last30d: 3}, stats)
+
+		var histogram struct {
+			Items []struct {
+				Count int `json:"count"`
+			} `json:"items"`
+		}
+		s.call(t, http.MethodGet, "/v1/errors/histogram?interval=day&projectId="+projectID, nil,
+			http.StatusOK, &histogram)
+		total := 0
+		for _, point := range histogram.Items {
+			total += point.Count
+		}
+		assert.Equal(t, 3, total)
+		s.call(t, http.MethodGet, "/v1/errors/histogram?interval=week&projectId="+projectID, nil,
+			http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, "/v1/errors/histogram?timezone=Local&projectId="+projectID, nil,
+			http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, "/v1/errors/histogram", nil, http.StatusBadRequest, nil)
+
+		rec := s.request(t, http.MethodGet, "/v1/errors/export?format=ndjson&projectId="+projectID, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 3)
+
+		var fetched struct {
+			entity
+			Fingerprint string `json:"fingerprint"`
+		}
+		s.call(t, http.MethodGet, "/v1/errors/"+errorID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "Division by zero", fetched.Message)
+
+		var groups list
+		s.call(t, http.MethodGet, "/v1/error-groups?sortBy=counter&sort=desc&projectId="+projectID, nil,
+			http.StatusOK, &groups)
+		require.Equal(t, 2, groups.Count)
+
+		var group entity
+		require.NoError(t, json.Unmarshal(groups.Items[0], &group))
+		assert.Equal(t, 2, group.Counter)
+		errorGroupID = group.ID
+	})
+
+	t.Run("error groups", func(t *testing.T) {
+		path := "/v1/error-groups/" + errorGroupID
+
+		var group entity
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &group)
+		assert.Equal(t, "unresolved", group.Status)
+
+		s.call(t, http.MethodPut, path+"/status", errorsGroup.UpdateStatus{Status: "resolved"}, http.StatusOK, &group)
+		s.call(t, http.MethodPut, path+"/status", errorsGroup.UpdateStatus{Status: "closed"}, http.StatusBadRequest, nil)
+
+		s.call(t, http.MethodPut, path+"/assignee", errorsGroup.Assign{AssigneeID: &user.ID}, http.StatusOK, nil)
+		unknown := "a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"
+		rec := s.request(t, http.MethodPut, path+"/assignee", errorsGroup.Assign{AssigneeID: &unknown})
+		assert.GreaterOrEqual(t, rec.Code, http.StatusBadRequest)
+
+		var mine list
+		s.call(t, http.MethodGet, "/v1/error-groups?assignee=me&projectId="+projectID, nil, http.StatusOK, &mine)
+		assert.Equal(t, 1, mine.Count)
+
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &group)
+		assert.Equal(t, "resolved", group.Status)
+		assert.Equal(t, &user.ID, group.AssigneeID)
+
+		s.call(t, http.MethodPut, path+"/snooze", errorsGroup.Snooze{Duration: 3600}, http.StatusOK, nil)
+		s.call(t, http.MethodPut, path+"/snooze", errorsGroup.Snooze{}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &group)
+		assert.Equal(t, "snoozed", group.Status)
+
+		s.call(t, http.MethodGet, "/v1/error-groups/unknown", nil, http.StatusNotFound, nil)
+	})
+
+	var logGroupID string
+	t.Run("logs", func(t *testing.T) {
+		path := "/v1/logs?projectId=" + projectID
+
+		var all list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &all)
+		require.Equal(t, 3, all.Count)
+
+		var errorLogs list
+		s.call(t, http.MethodGet, path+"&level=ERROR", nil, http.StatusOK, &errorLogs)
+		assert.Equal(t, 2, errorLogs.Count)
+		s.call(t, http.MethodGet, path+"&context.orderId=2", nil, http.StatusOK, &errorLogs)
+		assert.Equal(t, 1, errorLogs.Count)
+
+		s.call(t, http.MethodGet, "/v1/logs/stats?projectId="+projectID, nil, http.StatusOK, nil)
+		s.call(t, http.MethodGet, "/v1/logs/histogram?projectId="+projectID, nil, http.StatusOK, nil)
+		s.call(t, http.MethodGet, "/v1/logs/histogram?timezone=Local&projectId="+projectID, nil,
+			http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, "/v1/logs/histogram", nil, http.StatusBadRequest, nil)
+
+		rec := s.request(t, http.MethodGet, "/v1/logs/export?format=csv&projectId="+projectID, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 4)
+
+		var first entity
+		require.NoError(t, json.Unmarshal(all.Items[2], &first))
+		s.call(t, http.MethodPut, "/v1/logs/"+first.ID, log.Update{Message: "payment started"}, http.StatusOK, nil)
+
+		var fetched entity
+		s.call(t, http.MethodGet, "/v1/logs/"+first.ID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "payment started", fetched.Message)
+
+		s.call(t, http.MethodDelete, "/v1/logs/"+first.ID, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, "/v1/logs/"+first.ID, nil, http.StatusNotFound, nil)
+
+		var groups list
+		s.call(t, http.MethodGet, "/v1/log-groups?level=ERROR&projectId="+projectID, nil, http.StatusOK, &groups)
+		require.Equal(t, 1, groups.Count)
+
+		var group entity
+		require.NoError(t, json.Unmarshal(groups.Items[0], &group))
+		assert.Equal(t, 2, group.Counter)
+		logGroupID = group.ID
+	})
+
+	t.Run("log groups", func(t *testing.T) {
+		path := "/v1/log-groups/" + logGroupID
+
+		s.call(t, http.MethodPut, path+"/status", logGroup.UpdateStatus{Status: "ignored"}, http.StatusOK, nil)
+		s.call(t, http.MethodPut, path+"/assignee", logGroup.Assign{AssigneeID: &user.ID}, http.StatusOK, nil)
+		s.call(t, http.MethodPut, path+"/snooze", logGroup.Snooze{Occurrences: 10}, http.StatusOK, nil)
+
+		var group entity
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &group)
+		assert.Equal(t, "snoozed", group.Status)
+		assert.Equal(t, &user.ID, group.AssigneeID)
+
+		var unassigned list
+		s.call(t, http.MethodGet, "/v1/log-groups?assignee=none&projectId="+projectID, nil, http.StatusOK, &unassigned)
+		assert.Equal(t, 1, unassigned.Count)
+	})
+
+	t.Run("activity", func(t *testing.T) {
+		path := "/v1/error-groups/" + errorGroupID
+
+		// The group events are recorded in the background
+		require.Eventually(t, func() bool {
+			var timeline list
+			s.call(t, http.MethodGet, path+"/activity?sort=asc", nil, http.StatusOK, &timeline)
+
+			types := make([]string, 0, len(timeline.Items))
+			for _, item := range timeline.Items {
+				var event entity
+				require.NoError(t, json.Unmarshal(item, &event))
+				types = append(types, event.Type)
+			}
+			return slices.Contains(types, "created") && slices.Contains(types, "status_changed") &&
+				slices.Contains(types, "assigned")
+		}, time.Second, 10*time.Millisecond)
+
+		var comment entity
+		s.call(t, http.MethodPost, path+"/comments", activity.CreateComment{Body: "Looking into it"},
+			http.StatusCreated, &comment)
+		s.call(t, http.MethodPost, path+"/comments", activity.CreateComment{}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodPut, path+"/comments/"+comment.ID, activity.UpdateComment{Body: "Fixed"},
+			http.StatusOK, nil)
+		s.call(t, http.MethodDelete, path+"/comments/"+comment.ID, nil, http.StatusNoContent, nil)
+
+		s.call(t, http.MethodGet, "/v1/log-groups/"+logGroupID+"/activity", nil, http.StatusOK, nil)
+		s.call(t, http.MethodGet, "/v1/error-groups/unknown/activity", nil, http.StatusNotFound, nil)
+	})
+
+	t.Run("webhook deliveries", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/webhooks/" + webhookID
+
+		// A delivery per group created: two error groups and two log groups, enqueued in the background
+		var deliveries list
+		require.Eventually(t, func() bool {
+			s.call(t, http.MethodGet, path+"/deliveries", nil, http.StatusOK, &deliveries)
+			return deliveries.Count == 4
+		}, time.Second, 10*time.Millisecond)
+
+		var delivery entity
+		require.NoError(t, json.Unmarshal(deliveries.Items[0], &delivery))
+		s.call(t, http.MethodGet, path+"/deliveries/"+delivery.ID, nil, http.StatusOK, nil)
+		s.call(t, http.MethodPost, path+"/deliveries/"+delivery.ID+"/redeliver", nil, http.StatusAccepted, nil)
+		s.call(t, http.MethodGet, path+"/deliveries/unknown", nil, http.StatusNotFound, nil)
+
+		s.call(t, http.MethodDelete, path, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, path, nil, http.StatusNotFound, nil)
+	})
+
+	t.Run("alerts", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/alert-rules"
+		rule := alert.Create{
+			Name:      "Checkout errors",
+			Condition: alert.Condition{Type: "frequency", Threshold: 100, WindowSeconds: 3600},
+			Actions:   []alert.Action{{Type: "webhook", Target: "https://example.com/hooks"}},
+		}
+		s.call(t, http.MethodPost, path, rule, http.StatusBadRequest, nil)
+
+		rule.Actions[0].Target = webhookID
+		var created entity
+		s.call(t, http.MethodPost, path, rule, http.StatusCreated, &created)
+
+		rule.Condition.Threshold = 0
+		s.call(t, http.MethodPost, path, rule, http.StatusBadRequest, nil)
+
+		var rules list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &rules)
+		assert.Equal(t, 1, rules.Count)
+
+		s.call(t, http.MethodPut, path+"/"+created.ID, alert.Update{
+			Name:      "Checkout",
+			Condition: alert.Condition{Type: "new_group"},
+			Actions:   []alert.Action{{Type: "webhook", Target: webhookID}},
+		}, http.StatusOK, nil)
+
+		var fetched entity
+		s.call(t, http.MethodGet, path+"/"+created.ID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "Checkout", fetched.Name)
+
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID+"/alert-firings?ruleId="+created.ID, nil, http.StatusOK, nil)
+
+		s.call(t, http.MethodDelete, path+"/"+created.ID, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, path+"/"+created.ID, nil, http.StatusNotFound, nil)
+	})
+
+	t.Run("anomalies", func(t *testing.T) {
+		var anomalies list
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID+"/anomalies?active=true", nil, http.StatusOK, &anomalies)
+		assert.Zero(t, anomalies.Count)
+	})
+
+	t.Run("notification preferences", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/notification-preferences"
+
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, nil)
+
+		var preference notification.PreferenceEntity
+		s.call(t, http.MethodPut, path, notification.UpdatePreference{Alerts: true, Digest: "weekly"},
+			http.StatusOK, &preference)
+		assert.Equal(t, "weekly", preference.Digest)
+
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &preference)
+		assert.True(t, preference.Alerts)
+		assert.False(t, preference.Regressions)
+
+		s.call(t, http.MethodPut, path, notification.UpdatePreference{Digest: "hourly"}, http.StatusBadRequest, nil)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/channels"
+		target := "https://hooks.slack.com/services/T000/B000/XXXX"
+
+		var created entity
+		s.call(t, http.MethodPost, path, channel.Create{
+			Type: "slack", Name: "On-call", Target: target, Events: []string{"alert.fired"},
+		}, http.StatusCreated, &created)
+		s.call(t, http.MethodPost, path, channel.Create{
+			Type: "telegram", Name: "On-call", Target: "42", Events: []string{"alert.fired"},
+		}, http.StatusBadRequest, nil)
+
+		var channels list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &channels)
+		assert.Equal(t, 1, channels.Count)
+
+		s.call(t, http.MethodPut, path+"/"+created.ID, channel.Update{
+			Name: "Team", Target: target, Events: []string{"group.created"},
+		}, http.StatusOK, nil)
+
+		var fetched entity
+		s.call(t, http.MethodGet, path+"/"+created.ID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "Team", fetched.Name)
+
+		s.call(t, http.MethodPost, path+"/"+created.ID+"/test", nil, http.StatusNoContent, nil)
+		assert.Equal(t, int32(1), s.sender.sent.Load())
+
+		s.call(t, http.MethodDelete, path+"/"+created.ID, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, path+"/"+created.ID, nil, http.StatusNotFound, nil)
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		var job entity
+		s.call(t, http.MethodPost, "/v1/error-groups/bulk?projectId="+projectID, bulk.Create{
+			Action: "status",
+			Status: "resolved",
+		}, http.StatusAccepted, &job)
+		s.call(t, http.MethodPost, "/v1/logs/bulk?projectId="+projectID, bulk.Create{}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodPost, "/v1/logs/bulk", bulk.Create{Action: "delete"}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodPost, "/v1/logs/bulk?q=level:(&projectId="+projectID, bulk.Create{Action: "delete"},
+			http.StatusBadRequest, nil)
+
+		s.call(t, http.MethodGet, "/v1/bulk-jobs/"+job.ID, nil, http.StatusOK, &job)
+		assert.Equal(t, "pending", job.Status)
+
+		s.call(t, http.MethodPost, "/v1/bulk-jobs/"+job.ID+"/cancel", nil, http.StatusOK, &job)
+		assert.Equal(t, "canceled", job.Status)
+
+		// A bulk action selects the items the list endpoint returns for the same query string.
+		filter := fmt.Sprintf("projectId=%s&timeFrom=%d&searchMode=contains&q=level:ERROR&context.orderId=2",
+			projectID, now.Add(-time.Hour).Unix())
+
+		var selected list
+		s.call(t, http.MethodGet, "/v1/logs?"+filter, nil, http.StatusOK, &selected)
+		require.Equal(t, 1, selected.Count)
+		var all list
+		s.call(t, http.MethodGet, "/v1/logs?projectId="+projectID, nil, http.StatusOK, &all)
+
+		s.call(t, http.MethodPost, "/v1/logs/bulk?"+filter, bulk.Create{Action: "delete"}, http.StatusAccepted, &job)
+		s.bulk.RunPending(context.Background())
+
+		var deleted struct {
+			Status   string `json:"status"`
+			Affected int    `json:"affected"`
+		}
+		s.call(t, http.MethodGet, "/v1/bulk-jobs/"+job.ID, nil, http.StatusOK, &deleted)
+		assert.Equal(t, "succeeded", deleted.Status)
+		assert.Equal(t, selected.Count, deleted.Affected)
+
+		var remaining list
+		s.call(t, http.MethodGet, "/v1/logs?projectId="+projectID, nil, http.StatusOK, &remaining)
+		assert.Equal(t, all.Count-selected.Count, remaining.Count)
+		assert.NotContains(t, remaining.Items, selected.Items[0])
+
+		var jobs list
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID+"/bulk-jobs", nil, http.StatusOK, &jobs)
+		assert.Equal(t, 2, jobs.Count)
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/retention"
+		days := 90
+
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, nil)
+
+		var policy retention.PolicyEntity
+		s.call(t, http.MethodPut, path, retention.UpdatePolicy{
+			ErrorsRetentionDays:   &days,
+			LogLevelRetentionDays: map[string]int{"DEBUG": 7},
+		}, http.StatusOK, &policy)
+		assert.Equal(t, &days, policy.ErrorsRetentionDays)
+
+		s.call(t, http.MethodPut, path, retention.UpdatePolicy{LogLevelRetentionDays: map[string]int{"LOUD": 7}},
+			http.StatusBadRequest, nil)
+		s.call(t, http.MethodGet, path+"/reports", nil, http.StatusOK, nil)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		path := "/v1/projects/" + projectID + "/import?format=ndjson&kind=logs"
+		dump := `{"id":"a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c","level":"INFO","message":"imported","time":1704067200000}
+{"level":"LOUD","message":"invalid level","time":1704067260000}
+`
+
+		var report importer.Report
+		s.call(t, http.MethodPost, path, dump, http.StatusOK, &report)
+		assert.Equal(t, importer.Report{Read: 2, Imported: 1, Failed: 1}, report)
+
+		s.call(t, http.MethodPost, path, dump, http.StatusOK, &report)
+		assert.Equal(t, importer.Report{Read: 2, Duplicates: 1, Failed: 1}, report)
+
+		s.call(t, http.MethodGet, "/v1/logs/a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c", nil, http.StatusOK, nil)
+		s.call(t, http.MethodPost, "/v1/projects/"+projectID+"/import?format=ndjson", dump, http.StatusBadRequest, nil)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		// The stream reads its filters like the list endpoints
+		s.call(t, http.MethodGet, "/v1/errors/stream?projectId="+projectID+"&q=message:(", nil, http.StatusBadRequest, nil)
+
+		server := httptest.NewServer(s.handler)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			server.URL+"/v1/errors/stream?projectId="+projectID+"&access_token="+s.token, nil)
+		require.NoError(t, err)
+
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(line, "retry:"), line)
+
+		stacktrace := interface{}([]interface{}{})
+		s.call(t, http.MethodPost, ingest+"/errors", errors.Create{
+			Time: time.Now().UnixMilli(), Message: "Streamed", Stacktrace: &stacktrace, File: "index.php", Line: 1,
+		}, http.StatusCreated, nil)
+
+		for {
+			line, err = reader.ReadString('\n')
+			require.NoError(t, err)
+			if strings.HasPrefix(line, "data:") {
+				break
+			}
+		}
+		assert.Contains(t, line, "Streamed")
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		event, err := form.CreateFormField("event")
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(event).Encode(map[string]interface{}{
+			"time": time.Now().UnixMilli(), "message": "Crashed", "stacktrace": []string{}, "file": "main.go", "line": 1,
+		}))
+		file, err := form.CreateFormFile("file", "crash.log")
+		require.NoError(t, err)
+		_, err = file.Write([]byte("panic: crashed"))
+		require.NoError(t, err)
+		file, err = form.CreateFormFile("file", "core.dmp")
+		require.NoError(t, err)
+		_, err = file.Write(bytes.Repeat([]byte{0}, 64))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		maxFileSize := int64(32)
+		s.call(t, http.MethodPut, "/v1/projects/"+projectID+"/attachment-limits", attachment.UpdateLimits{
+			MaxFileSize: &maxFileSize,
+		}, http.StatusOK, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ingest+"/errors", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var ingested struct {
+			ID          string              `json:"id"`
+			Attachments []attachment.Entity `json:"attachments"`
+			Rejected    []string            `json:"rejected"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ingested))
+		require.Len(t, ingested.Attachments, 1)
+		assert.Equal(t, []string{"core.dmp"}, ingested.Rejected)
+
+		path := "/v1/errors/" + ingested.ID + "/attachments"
+		var all list
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &all)
+		assert.Equal(t, 1, all.Count)
+
+		rec = s.request(t, http.MethodGet, path+"/"+ingested.Attachments[0].ID, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "panic: crashed", rec.Body.String())
+		assert.Equal(t, `attachment; filename=crash.log`, rec.Header().Get("Content-Disposition"))
+
+		s.call(t, http.MethodGet, "/v1/errors/"+errorID+"/attachments/"+ingested.Attachments[0].ID, nil,
+			http.StatusNotFound, nil)
+	})
+
+	t.Run("group merge", func(t *testing.T) {
+		path := "/v1/error-groups/" + errorGroupID
+
+		var streamed list
+		s.call(t, http.MethodGet, "/v1/error-groups?search=Streamed&projectId="+projectID, nil, http.StatusOK, &streamed)
+		require.Equal(t, 1, streamed.Count)
+		var source entity
+		require.NoError(t, json.Unmarshal(streamed.Items[0], &source))
+
+		var before, groups list
+		s.call(t, http.MethodGet, "/v1/error-groups?projectId="+projectID, nil, http.StatusOK, &before)
+
+		var target entity
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &target)
+
+		var merged entity
+		s.call(t, http.MethodPost, path+"/merge", errorsGroup.Merge{GroupIDs: []string{source.ID}}, http.StatusOK, &merged)
+		assert.Equal(t, target.Counter+source.Counter, merged.Counter)
+		s.call(t, http.MethodGet, "/v1/error-groups/"+source.ID, nil, http.StatusNotFound, nil)
+		s.call(t, http.MethodPost, path+"/merge", errorsGroup.Merge{GroupIDs: []string{errorGroupID}}, http.StatusBadRequest, nil)
+		s.call(t, http.MethodPost, path+"/merge", errorsGroup.Merge{GroupIDs: []string{"unknown"}}, http.StatusNotFound, nil)
+
+		// The new events of the merged group go to the group
+		stacktrace := interface{}([]interface{}{})
+		s.call(t, http.MethodPost, ingest+"/errors", errors.Create{
+			Time: time.Now().UnixMilli(), Message: "Streamed", Stacktrace: &stacktrace, File: "index.php", Line: 1,
+		}, http.StatusCreated, nil)
+
+		s.call(t, http.MethodGet, "/v1/error-groups?projectId="+projectID, nil, http.StatusOK, &groups)
+		assert.Equal(t, before.Count-1, groups.Count)
+		s.call(t, http.MethodGet, path, nil, http.StatusOK, &target)
+		assert.Equal(t, merged.Counter+1, target.Counter)
+
+		require.Eventually(t, func() bool {
+			var timeline list
+			s.call(t, http.MethodGet, path+"/activity", nil, http.StatusOK, &timeline)
+			for _, item := range timeline.Items {
+				var event entity
+				require.NoError(t, json.Unmarshal(item, &event))
+				if event.Type == "merged" {
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("error deletion", func(t *testing.T) {
+		stacktrace := interface{}([]interface{}{})
+		s.call(t, http.MethodPut, "/v1/errors/"+errorID, errors.Update{
+			Message: "Division by zero in calculate()", Stacktrace: &stacktrace, File: "calc.php", Line: 3,
+		}, http.StatusOK, nil)
+
+		var fetched entity
+		s.call(t, http.MethodGet, "/v1/errors/"+errorID, nil, http.StatusOK, &fetched)
+		assert.Equal(t, "Division by zero in calculate()", fetched.Message)
+
+		s.call(t, http.MethodDelete, "/v1/errors/"+errorID, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, "/v1/errors/"+errorID, nil, http.StatusNotFound, nil)
+	})
+
+	t.Run("project deletion", func(t *testing.T) {
+		s.call(t, http.MethodDelete, "/v1/projects/"+projectID, nil, http.StatusNoContent, nil)
+		s.call(t, http.MethodGet, "/v1/projects/"+projectID, nil, http.StatusNotFound, nil)
+
+		var projects list
+		s.call(t, http.MethodGet, "/v1/projects", nil, http.StatusOK, &projects)
+		assert.Equal(t, 1, projects.Count)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s.call(t, http.MethodGet, "/v1/unknown", nil, http.StatusNotFound, nil)
+		s.call(t, http.MethodPost, "/health", nil, http.StatusMethodNotAllowed, nil)
+	})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/events"
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/activity"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type activityHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  activity.Service
+}
+
+func RegisterActivityHandlers(
+	r *mux.Router,
+	logger Logger,
+	service activity.Service,
+	jwtKey []byte,
+) {
+	h := &activityHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	prefixes := map[string]string{
+		events.GroupKindError: "/v1/error-groups/{id}",
+		events.GroupKindLog:   "/v1/log-groups/{id}",
+	}
+
+	for groupKind, prefix := range prefixes {
+		routerV1 := r.PathPrefix(prefix).Subrouter()
+		routerV1.Use(middleware.Auth(jwtKey))
+
+		routerV1.HandleFunc("/activity", h.GetTimeline(groupKind)).Methods(http.MethodGet)
+		routerV1.HandleFunc("/comments", h.AddComment(groupKind)).Methods(http.MethodPost)
+		routerV1.HandleFunc("/comments/{commentId}", h.UpdateComment(groupKind)).Methods(http.MethodPut)
+		routerV1.HandleFunc("/comments/{commentId}", h.DeleteComment(groupKind)).Methods(http.MethodDelete)
+	}
+}
+
+// GetTimeline godoc
+// @Summary Get the activity of a group
+// @Description Retrieves the timeline of a group: creation, regressions, status changes, assignments and comments
+// @Tags activity
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} activity.EntityList "Successfully retrieved group activity"
+// @Failure 404 {object} string "Group not found"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/activity [get]
+// @Router /v1/log-groups/{id}/activity [get].
+func (h *activityHandler) GetTimeline(groupKind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := mux.Vars(r)["id"]
+		if groupID == "" {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+
+		queryParams := r.URL.Query()
+
+		limit, err := strconv.Atoi(queryParams.Get("limit"))
+		if err != nil || limit < 1 {
+			limit = httputils.DefaultLimit
+		}
+
+		offset, err := strconv.Atoi(queryParams.Get("offset"))
+		if err != nil || offset < 0 {
+			offset = httputils.DefaultOffset
+		}
+
+		sortOrder := queryParams.Get("sort")
+		if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+			sortOrder = httputils.DefaultSort
+		}
+
+		params := activity.GetTimelineParams{
+			GroupKind: groupKind,
+			GroupID:   groupID,
+			SortOrder: sortOrder,
+			Limit:     limit,
+			Offset:    offset,
+		}
+
+		entities, totalCount, err := h.service.GetTimeline(r.Context(), params)
+		if err != nil {
+			h.respondWithError(w, err)
+			return
+		}
+
+		httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+	}
+}
+
+// AddComment godoc
+// @Summary Comment on a group
+// @Description Adds a markdown comment to the timeline of a group
+// @Tags activity
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body activity.CreateComment true "Comment"
+// @Success 201 {object} activity.CommentEntity "Successfully created comment"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/comments [post]
+// @Router /v1/log-groups/{id}/comments [post].
+func (h *activityHandler) AddComment(groupKind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := mux.Vars(r)["id"]
+		if groupID == "" {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+
+		var req activity.CreateComment
+		if err := httputils.DecodeRequest(w, r, &req); err != nil {
+			return
+		}
+
+		if err := h.validate.Struct(req); err != nil {
+			httputils.HandleValidatorError(w, err)
+			return
+		}
+
+		entity, err := h.service.AddComment(r.Context(), groupKind, groupID, &req)
+		if err != nil {
+			h.respondWithError(w, err)
+			return
+		}
+
+		httputils.RespondWithJSON(w, http.StatusCreated, entity)
+	}
+}
+
+// UpdateComment godoc
+// @Summary Edit a group comment
+// @Description Edits a comment of a group, only its author can edit it
+// @Tags activity
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param commentId path string true "Comment ID"
+// @Param request body activity.UpdateComment true "Comment"
+// @Success 200 {object} activity.CommentEntity "Successfully updated comment"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 403 {object} string "Not the author of the comment"
+// @Failure 404 {object} string "Comment not found"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/comments/{commentId} [put]
+// @Router /v1/log-groups/{id}/comments/{commentId} [put].
+func (h *activityHandler) UpdateComment(groupKind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		groupID := vars["id"]
+		commentID := vars["commentId"]
+		if groupID == "" || commentID == "" {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+
+		var req activity.UpdateComment
+		if err := httputils.DecodeRequest(w, r, &req); err != nil {
+			return
+		}
+
+		if err := h.validate.Struct(req); err != nil {
+			httputils.HandleValidatorError(w, err)
+			return
+		}
+
+		entity, err := h.service.UpdateComment(r.Context(), groupKind, groupID, commentID, &req)
+		if err != nil {
+			h.respondWithError(w, err)
+			return
+		}
+
+		httputils.RespondWithJSON(w, http.StatusOK, entity)
+	}
+}
+
+// DeleteComment godoc
+// @Summary Delete a group comment
+// @Description Deletes a comment of a group, only its author can delete it
+// @Tags activity
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param commentId path string true "Comment ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} string "Not the author of the comment"
+// @Failure 404 {object} string "Comment not found"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/comments/{commentId} [delete]
+// @Router /v1/log-groups/{id}/comments/{commentId} [delete].
+func (h *activityHandler) DeleteComment(groupKind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		groupID := vars["id"]
+		commentID := vars["commentId"]
+		if groupID == "" || commentID == "" {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+
+		if err := h.service.DeleteComment(r.Context(), groupKind, groupID, commentID); err != nil {
+			h.respondWithError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *activityHandler) respondWithError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, activity.ErrNotFound), errors.Is(err, activity.ErrGroupNotFound):
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, activity.ErrForbidden):
+		httputils.RespondWithPlainError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, activity.ErrUnauthorized):
+		httputils.RespondWithPlainError(w, http.StatusUnauthorized, err.Error())
+	default:
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type alertHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  alert.Service
+}
+
+func RegisterAlertHandlers(
+	r *mux.Router,
+	logger Logger,
+	service alert.Service,
+	jwtKey []byte,
+) {
+	h := &alertHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	rulesRouterV1 := r.PathPrefix("/v1/projects/{id}/alert-rules").Subrouter()
+	rulesRouterV1.Use(middleware.Auth(jwtKey))
+
+	rulesRouterV1.HandleFunc("", h.Create).Methods(http.MethodPost)
+	rulesRouterV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+	rulesRouterV1.HandleFunc("/{ruleId}", h.GetByID).Methods(http.MethodGet)
+	rulesRouterV1.HandleFunc("/{ruleId}", h.Update).Methods(http.MethodPut)
+	rulesRouterV1.HandleFunc("/{ruleId}", h.Delete).Methods(http.MethodDelete)
+
+	firingsRouterV1 := r.PathPrefix("/v1/projects/{id}/alert-firings").Subrouter()
+	firingsRouterV1.Use(middleware.Auth(jwtKey))
+
+	firingsRouterV1.HandleFunc("", h.GetFirings).Methods(http.MethodGet)
+}
+
+// GetAll godoc
+// @Summary Get project alert rules
+// @Description Retrieves the alert rules of a project
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} alert.EntityList "Successfully retrieved list of alert rules"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-rules [get].
+func (h *alertHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := alert.GetAllParams{
+		ProjectID: projectID,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// GetByID godoc
+// @Summary Get an alert rule by ID
+// @Description Get an alert rule of a project by ID
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param ruleId path string true "Alert rule ID"
+// @Success 200 {object} alert.Entity
+// @Failure 404 {object} string "Alert rule not found"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-rules/{ruleId} [get].
+func (h *alertHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	ruleID := vars["ruleId"]
+	if projectID == "" || ruleID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetByID(r.Context(), projectID, ruleID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Create godoc
+// @Summary Create an alert rule
+// @Description Creates an alert rule that runs its actions when an event of the project matches the condition
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body alert.Create true "Alert rule creation data"
+// @Success 201 {object} alert.Entity "Successfully created alert rule"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-rules [post].
+func (h *alertHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req alert.Create
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	req.ProjectID = projectID
+
+	entity, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusCreated, entity)
+}
+
+// Update godoc
+// @Summary Update an alert rule
+// @Description Updates an existing alert rule of a project
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param ruleId path string true "Alert rule ID"
+// @Param request body alert.Update true "Alert rule update data"
+// @Success 200 {object} alert.Entity "Successfully updated alert rule"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Alert rule not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-rules/{ruleId} [put].
+func (h *alertHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	ruleID := vars["ruleId"]
+	if projectID == "" || ruleID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req alert.Update
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Update(r.Context(), projectID, ruleID, &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Delete godoc
+// @Summary Delete an alert rule
+// @Description Deletes an alert rule of a project
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param ruleId path string true "Alert rule ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} string "Alert rule not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-rules/{ruleId} [delete].
+func (h *alertHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	ruleID := vars["ruleId"]
+	if projectID == "" || ruleID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), projectID, ruleID); err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFirings godoc
+// @Summary Get project alert firings
+// @Description Retrieves the history of fired alerts of a project with the outcome of their actions
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param ruleId query string false "Alert rule ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} alert.FiringList "Successfully retrieved list of alert firings"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/alert-firings [get].
+func (h *alertHandler) GetFirings(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := alert.GetFiringsParams{
+		ProjectID: projectID,
+		RuleID:    queryParams.Get("ruleId"),
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetFirings(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+func (h *alertHandler) respondWithError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, alert.ErrNotFound):
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, alert.ErrInvalidCondition),
+		errors.Is(err, alert.ErrInvalidPattern),
+		errors.Is(err, alert.ErrInvalidAction):
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+	default:
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+	}
+}
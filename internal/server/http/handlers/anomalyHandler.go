@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/anomaly"
+	"github.com/fuckbug/api/pkg/httputils"
+	"github.com/gorilla/mux"
+)
+
+type anomalyHandler struct {
+	logger  Logger
+	service anomaly.Service
+}
+
+func RegisterAnomalyHandlers(
+	r *mux.Router,
+	logger Logger,
+	service anomaly.Service,
+	jwtKey []byte,
+) {
+	h := &anomalyHandler{
+		logger:  logger,
+		service: service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/anomalies").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+}
+
+// GetAll godoc
+// @Summary Get project anomalies
+// @Description Retrieves spikes and reappearances detected on the project groups
+// @Tags anomalies
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param active query bool false "Only anomalies that are still ongoing"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} anomaly.EntityList "Successfully retrieved list of anomalies"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/anomalies [get].
+func (h *anomalyHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	active, err := strconv.ParseBool(queryParams.Get("active"))
+	if err != nil {
+		active = false
+	}
+
+	params := anomaly.GetAllParams{
+		ProjectID: projectID,
+		GroupID:   queryParams.Get("groupId"),
+		Active:    active,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/attachment"
+	"github.com/fuckbug/api/internal/modules/errors"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// eventPart is the part of a multipart ingest holding the error, before its files.
+	eventPart = "event"
+
+	attachmentTransferTimeout = 5 * time.Minute
+)
+
+type attachmentHandler struct {
+	logger       Logger
+	validate     *v.Validate
+	errorService errors.Service
+	service      attachment.Service
+}
+
+func RegisterAttachmentHandlers(
+	r *mux.Router,
+	logger Logger,
+	errorService errors.Service,
+	service attachment.Service,
+	jwtKey []byte,
+) {
+	h := &attachmentHandler{
+		logger:       logger,
+		validate:     v.New(),
+		errorService: errorService,
+		service:      service,
+	}
+
+	// Errors with files are sent as multipart forms to the same endpoint as the others
+	r.HandleFunc("/ingest/{projectID}:{key}/errors", h.Ingest).
+		Methods(http.MethodPost).
+		HeadersRegexp("Content-Type", "^multipart/form-data")
+
+	routerV1 := r.PathPrefix("/v1/errors/{id}/attachments").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{attachmentId}", h.Download).Methods(http.MethodGet)
+
+	limitsRouterV1 := r.PathPrefix("/v1/projects/{id}/attachment-limits").Subrouter()
+	limitsRouterV1.Use(middleware.Auth(jwtKey))
+
+	limitsRouterV1.HandleFunc("", h.GetLimits).Methods(http.MethodGet)
+	limitsRouterV1.HandleFunc("", h.UpdateLimits).Methods(http.MethodPut)
+}
+
+// Ingest godoc
+// @Summary Create a new error entry with attachments
+// @Description Creates an error from the event part of a multipart form, which comes first, and attaches the files
+// @Description of the parts after it, as logs, screenshots or minidumps. The files over the size limits of the
+// @Description project are not stored, and listed as rejected.
+// @Tags ingest
+// @Accept multipart/form-data
+// @Produce json
+// @Param projectID path string true "Project ID"
+// @Param key path string true "Public key"
+// @Param event formData string true "Error entry creation data, as the JSON of errors.Create"
+// @Param file formData file false "Files to attach, in as many parts as needed"
+// @Success 201 {object} attachment.Ingested "Successfully created error entry"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Router /ingest/{projectID}:{key}/errors [post].
+func (h *attachmentHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	projectID, err := getProjectIDAndKey(r)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The server read timeout is too short for large files, and unlimited would let slow clients hold on
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(attachmentTransferTimeout)); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to extend ingest read deadline: %v", err))
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	part, err := reader.NextPart()
+	if err != nil || part.FormName() != eventPart {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "the event part must come first")
+		return
+	}
+
+	var req errors.Create
+	if err := json.NewDecoder(part).Decode(&req); err != nil {
+		httputils.RespondWithError(w, http.StatusBadRequest, "Invalid JSON format", nil)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	req.ProjectID = projectID
+
+	entity, err := h.errorService.Create(r.Context(), &req)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ingested := &attachment.Ingested{Entity: entity, Attachments: []*attachment.Entity{}, Rejected: []string{}}
+	for {
+		part, err := reader.NextPart()
+		if stdErrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		attached, err := h.attach(r, projectID, entity.ID, part)
+		if stdErrors.Is(err, attachment.ErrTooLarge) {
+			ingested.Rejected = append(ingested.Rejected, part.FileName())
+			continue
+		}
+		if err != nil {
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ingested.Attachments = append(ingested.Attachments, attached)
+	}
+
+	httputils.RespondWithJSON(w, http.StatusCreated, ingested)
+}
+
+func (h *attachmentHandler) attach(
+	r *http.Request,
+	projectID string,
+	errorID string,
+	part *multipart.Part,
+) (*attachment.Entity, error) {
+	defer part.Close()
+
+	return h.service.Attach(r.Context(), &attachment.Upload{
+		ProjectID:   projectID,
+		ErrorID:     errorID,
+		Name:        part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+		Body:        part,
+	})
+}
+
+// GetAll godoc
+// @Summary Get the attachments of an error
+// @Description Retrieves the files uploaded along with an error
+// @Tags errors
+// @Accept json
+// @Produce json
+// @Param id path string true "Error ID"
+// @Success 200 {object} attachment.EntityList "Successfully retrieved list of attachments"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/errors/{id}/attachments [get].
+func (h *attachmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	errorID := vars["id"]
+	if errorID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entities, err := h.service.GetAll(r.Context(), errorID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(len(entities), entities))
+}
+
+// Download godoc
+// @Summary Download an attachment
+// @Description Downloads a file uploaded along with an error
+// @Tags errors
+// @Produce application/octet-stream
+// @Param id path string true "Error ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file "Content of the attachment"
+// @Failure 404 {object} string "Attachment not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/errors/{id}/attachments/{attachmentId} [get].
+func (h *attachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	errorID := vars["id"]
+	id := vars["attachmentId"]
+	if errorID == "" || id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, content, err := h.service.Open(r.Context(), errorID, id)
+	if err != nil {
+		if stdErrors.Is(err, attachment.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer content.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(attachmentTransferTimeout)); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to extend download write deadline: %v", err))
+	}
+
+	// The files come from the clients, browsers must save them rather than render them
+	w.Header().Set("Content-Type", entity.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(entity.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": entity.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to send attachment %s: %v", entity.ID, err))
+	}
+}
+
+// GetLimits godoc
+// @Summary Get the attachment limits
+// @Description Get the sizes in bytes of the largest file and of all the files of an error accepted for a project
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} attachment.LimitsEntity "Attachment limits"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/attachment-limits [get].
+func (h *attachmentHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetLimits(r.Context(), projectID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// UpdateLimits godoc
+// @Summary Update the attachment limits
+// @Description Sets the sizes in bytes of the largest file and of all the files of an error accepted for a project,
+// @Description null keeping the limits of the instance.
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body attachment.UpdateLimits true "Attachment limits"
+// @Success 200 {object} attachment.LimitsEntity "Successfully updated attachment limits"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/attachment-limits [put].
+func (h *attachmentHandler) UpdateLimits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req attachment.UpdateLimits
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.UpdateLimits(r.Context(), projectID, &req)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
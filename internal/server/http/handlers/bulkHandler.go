@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/bulk"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type bulkHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  bulk.Service
+}
+
+func RegisterBulkHandlers(
+	r *mux.Router,
+	logger Logger,
+	service bulk.Service,
+	jwtKey []byte,
+) {
+	h := &bulkHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	for _, target := range []bulk.Target{
+		bulk.TargetErrorGroups,
+		bulk.TargetLogGroups,
+		bulk.TargetErrors,
+		bulk.TargetLogs,
+	} {
+		targetRouterV1 := r.PathPrefix("/v1/" + string(target)).Subrouter()
+		targetRouterV1.Use(middleware.Auth(jwtKey))
+
+		targetRouterV1.HandleFunc("/bulk", h.Create(target)).Methods(http.MethodPost)
+	}
+
+	jobsRouterV1 := r.PathPrefix("/v1/bulk-jobs").Subrouter()
+	jobsRouterV1.Use(middleware.Auth(jwtKey))
+
+	jobsRouterV1.HandleFunc("/{id}", h.GetByID).Methods(http.MethodGet)
+	jobsRouterV1.HandleFunc("/{id}/cancel", h.Cancel).Methods(http.MethodPost)
+
+	projectRouterV1 := r.PathPrefix("/v1/projects/{id}/bulk-jobs").Subrouter()
+	projectRouterV1.Use(middleware.Auth(jwtKey))
+
+	projectRouterV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+}
+
+// Create godoc
+// @Summary Start a bulk action
+// @Description Queues a job changing the status or the assignee of, or deleting, every item matching the filter.
+// @Description Status changes and assignments apply to groups only. The job runs in the background, poll it for progress.
+// @Description The items are selected with the query parameters of the target list endpoint, which the same query
+// @Description string lists.
+// @Tags bulk
+// @Accept json
+// @Produce json
+// @Param projectId query string true "Project ID"
+// @Param request body bulk.Create true "Action"
+// @Success 202 {object} bulk.Entity "Successfully queued bulk job"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/error-groups/bulk [post]
+// @Router /v1/log-groups/bulk [post]
+// @Router /v1/errors/bulk [post]
+// @Router /v1/logs/bulk [post].
+func (h *bulkHandler) Create(target bulk.Target) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req bulk.Create
+		if err := httputils.DecodeRequest(w, r, &req); err != nil {
+			return
+		}
+
+		if err := h.validate.Struct(req); err != nil {
+			httputils.HandleValidatorError(w, err)
+			return
+		}
+
+		filter, ok := parseBulkFilter(w, r, target)
+		if !ok {
+			return
+		}
+
+		req.Filter = filter
+		req.Target = target
+
+		entity, err := h.service.Create(r.Context(), &req)
+		if err != nil {
+			h.respondWithError(w, err)
+			return
+		}
+
+		httputils.RespondWithJSON(w, http.StatusAccepted, entity)
+	}
+}
+
+// GetAll godoc
+// @Summary Get project bulk jobs
+// @Description Retrieves the bulk jobs of a project with their progress
+// @Tags bulk
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} bulk.EntityList "Successfully retrieved list of bulk jobs"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/bulk-jobs [get].
+func (h *bulkHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := bulk.GetAllParams{
+		ProjectID: projectID,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// GetByID godoc
+// @Summary Get a bulk job by ID
+// @Description Get a bulk job and its progress by ID
+// @Tags bulk
+// @Accept json
+// @Produce json
+// @Param id path string true "Bulk job ID"
+// @Success 200 {object} bulk.Entity
+// @Failure 404 {object} string "Bulk job not found"
+// @Security BearerAuth
+// @Router /v1/bulk-jobs/{id} [get].
+func (h *bulkHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Cancel godoc
+// @Summary Cancel a bulk job
+// @Description Cancels a pending or running bulk job, the batches already applied are kept
+// @Tags bulk
+// @Accept json
+// @Produce json
+// @Param id path string true "Bulk job ID"
+// @Success 200 {object} bulk.Entity "Successfully canceled bulk job"
+// @Failure 404 {object} string "Bulk job not found"
+// @Failure 409 {object} string "Bulk job already finished"
+// @Security BearerAuth
+// @Router /v1/bulk-jobs/{id}/cancel [post].
+func (h *bulkHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.Cancel(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+func (h *bulkHandler) respondWithError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bulk.ErrNotFound):
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, bulk.ErrUnsupportedTarget), errors.Is(err, bulk.ErrUnsupportedAction):
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, bulk.ErrNotCancelable):
+		httputils.RespondWithPlainError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, bulk.ErrUnauthorized):
+		httputils.RespondWithPlainError(w, http.StatusUnauthorized, err.Error())
+	default:
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseBulkFilter checks the query parameters of a bulk action with the parser of the target list, and resolves the
+// "me" assignee so the job selects the same items whoever runs it.
+func parseBulkFilter(w http.ResponseWriter, r *http.Request, target bulk.Target) (url.Values, bool) {
+	queryParams, ok := resolveAssignee(r, r.URL.Query())
+	if !ok {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "invalid assignee")
+		return nil, false
+	}
+
+	if _, err := uuid.Parse(queryParams.Get("projectId")); err != nil {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "projectId is required")
+		return nil, false
+	}
+
+	switch target {
+	case bulk.TargetErrorGroups:
+		_, ok = parseErrorGroupFilter(w, r, queryParams)
+	case bulk.TargetLogGroups:
+		_, ok = parseLogGroupFilter(w, r, queryParams)
+	case bulk.TargetErrors:
+		_, ok = parseErrorFilter(w, queryParams)
+	case bulk.TargetLogs:
+		_, ok = parseLogFilter(w, queryParams)
+	}
+	if !ok {
+		return nil, false
+	}
+
+	return queryParams, true
+}
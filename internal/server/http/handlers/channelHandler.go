@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/channel"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type channelHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  channel.Service
+}
+
+func RegisterChannelHandlers(
+	r *mux.Router,
+	logger Logger,
+	service channel.Service,
+	jwtKey []byte,
+) {
+	h := &channelHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/channels").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.Create).Methods(http.MethodPost)
+	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{channelId}", h.GetByID).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{channelId}", h.Update).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{channelId}", h.Delete).Methods(http.MethodDelete)
+	routerV1.HandleFunc("/{channelId}/test", h.SendTest).Methods(http.MethodPost)
+}
+
+// GetAll godoc
+// @Summary Get project chat channels
+// @Description Retrieves the Slack and Telegram channels of a project
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} channel.EntityList "Successfully retrieved list of channels"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels [get].
+func (h *channelHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := channel.GetAllParams{
+		ProjectID: projectID,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// GetByID godoc
+// @Summary Get a chat channel by ID
+// @Description Get a chat channel of a project by ID
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param channelId path string true "Channel ID"
+// @Success 200 {object} channel.Entity
+// @Failure 404 {object} string "Channel not found"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels/{channelId} [get].
+func (h *channelHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	channelID := vars["channelId"]
+	if projectID == "" || channelID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetByID(r.Context(), projectID, channelID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Create godoc
+// @Summary Create a chat channel
+// @Description Creates a Slack incoming webhook or Telegram bot channel notified of fired alerts and new groups
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body channel.Create true "Channel creation data"
+// @Success 201 {object} channel.Entity "Successfully created channel"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels [post].
+func (h *channelHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req channel.Create
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	req.ProjectID = projectID
+
+	entity, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusCreated, entity)
+}
+
+// Update godoc
+// @Summary Update a chat channel
+// @Description Updates an existing chat channel of a project, the token is kept when empty
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param channelId path string true "Channel ID"
+// @Param request body channel.Update true "Channel update data"
+// @Success 200 {object} channel.Entity "Successfully updated channel"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Channel not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels/{channelId} [put].
+func (h *channelHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	channelID := vars["channelId"]
+	if projectID == "" || channelID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req channel.Update
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Update(r.Context(), projectID, channelID, &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Delete godoc
+// @Summary Delete a chat channel
+// @Description Deletes a chat channel of a project
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param channelId path string true "Channel ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} string "Channel not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels/{channelId} [delete].
+func (h *channelHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	channelID := vars["channelId"]
+	if projectID == "" || channelID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), projectID, channelID); err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SendTest godoc
+// @Summary Send a test message
+// @Description Sends a sample message to a chat channel to check its configuration
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param channelId path string true "Channel ID"
+// @Success 204 "Test message sent"
+// @Failure 404 {object} string "Channel not found"
+// @Failure 502 {object} string "The chat service rejected the message"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/channels/{channelId}/test [post].
+func (h *channelHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	channelID := vars["channelId"]
+	if projectID == "" || channelID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.SendTest(r.Context(), projectID, channelID); err != nil {
+		if errors.Is(err, channel.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *channelHandler) respondWithError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, channel.ErrNotFound):
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, channel.ErrTokenRequired),
+		errors.Is(err, channel.ErrUnsupportedType),
+		errors.Is(err, channel.ErrInvalidWebhookURL):
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+	default:
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/fuckbug/api/internal/middleware"
 	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
 	"github.com/fuckbug/api/pkg/httputils"
-	"github.com/fuckbug/api/pkg/utils"
 	v "github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
@@ -35,6 +36,10 @@ func RegisterErrorGroupHandlers(
 
 	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.GetByID).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{id}/status", h.UpdateStatus).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/assignee", h.Assign).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/snooze", h.Snooze).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/merge", h.Merge).Methods(http.MethodPost)
 }
 
 // GetByID godoc
@@ -74,6 +79,9 @@ func (h *errorGroupHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param timeFrom query int false "Time errors from"
 // @Param timeTo query int false "Time errors to"
 // @Param search query string false "Search in message field"
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param assignee query string false "Assignee user ID, \"me\" for the current user or \"none\" for unassigned groups"
+// @Param sortBy query string false "Sort field" default(lastSeenAt) Enums(lastSeenAt, firstSeenAt, counter, eventsLastHour, eventsLastDay, trend)
 // @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
 // @Param limit query int false "Items per page" default(50)
 // @Param offset query int false "Offset for pagination" default(0)
@@ -93,42 +101,241 @@ func (h *errorGroupHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		offset = httputils.DefaultOffset
 	}
 
-	projectID := queryParams.Get("projectId")
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
 
-	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	sortBy := queryParams.Get("sortBy")
+	switch sortBy {
+	case errorsGroup.SortByFirstSeenAt, errorsGroup.SortByCounter, errorsGroup.SortByEventsLastHour,
+		errorsGroup.SortByEventsLastDay, errorsGroup.SortByTrend:
+	default:
+		sortBy = errorsGroup.SortByLastSeenAt
+	}
+
+	filter, ok := parseErrorGroupFilter(w, r, queryParams)
+	if !ok {
+		return
+	}
+
+	params := errorsGroup.GetAllParams{
+		FilterParams: filter,
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		Limit:        limit,
+		Offset:       offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
 	if err != nil {
-		timeFrom = 0
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// UpdateStatus godoc
+// @Summary Update the status of an error group
+// @Description Resolves, ignores or reopens an error group. A resolved group that receives a new event regresses.
+// @Tags error-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body errorsgroup.UpdateStatus true "Group status"
+// @Success 200 {object} errorsgroup.Entity "Successfully updated group status"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/status [put].
+func (h *errorGroupHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
 	}
 
-	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	var req errorsGroup.UpdateStatus
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.UpdateStatus(r.Context(), id, &req)
 	if err != nil {
-		timeTo = 0
+		if errors.Is(err, errorsGroup.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	sortOrder := queryParams.Get("sort")
-	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
-		sortOrder = httputils.DefaultSort
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Assign godoc
+// @Summary Assign an error group
+// @Description Assigns an error group to a user, or unassigns it when assigneeId is null
+// @Tags error-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body errorsgroup.Assign true "Group assignee"
+// @Success 200 {object} errorsgroup.Entity "Successfully updated group assignee"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/assignee [put].
+func (h *errorGroupHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
 	}
 
-	search := queryParams.Get("search")
+	var req errorsGroup.Assign
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
 
-	params := errorsGroup.GetAllParams{
-		FilterParams: errorsGroup.FilterParams{
-			ProjectID: projectID,
-			TimeFrom:  timeFrom,
-			TimeTo:    timeTo,
-			Search:    search,
-		},
-		SortOrder: sortOrder,
-		Limit:     limit,
-		Offset:    offset,
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
 	}
 
-	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	entity, err := h.service.Assign(r.Context(), id, &req)
 	if err != nil {
+		switch {
+		case errors.Is(err, errorsGroup.ErrNotFound):
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, errorsGroup.ErrAssigneeNotFound):
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		default:
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Snooze godoc
+// @Summary Snooze an error group
+// @Description Snoozes an error group until a duration elapses, it occurs N more times or it affects N more users.
+// @Description The group returns to unresolved and a notification is sent when the first condition is met.
+// @Tags error-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body errorsgroup.Snooze true "Snooze conditions"
+// @Success 200 {object} errorsgroup.Entity "Successfully snoozed group"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/snooze [put].
+func (h *errorGroupHandler) Snooze(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req errorsGroup.Snooze
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Snooze(r.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, errorsGroup.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Merge godoc
+// @Summary Merge error groups into an error group
+// @Description Moves the events, the comments and the activity of other groups of the project into the group and
+// @Description deletes them. Their new events go to the group, whose activity records each merge.
+// @Tags error-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body errorsgroup.Merge true "Merged groups"
+// @Success 200 {object} errorsgroup.Entity "Successfully merged groups"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/error-groups/{id}/merge [post].
+func (h *errorGroupHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req errorsGroup.Merge
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Merge(r.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errorsGroup.ErrNotFound):
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, errorsGroup.ErrInvalidMerge):
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		default:
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// parseErrorGroupFilter reads the filters of the error group list, "me" being the authenticated user.
+func parseErrorGroupFilter(w http.ResponseWriter, r *http.Request, queryParams url.Values) (errorsGroup.FilterParams, bool) {
+	queryParams, ok := resolveAssignee(r, queryParams)
+	if !ok {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, errorsGroup.ErrInvalidAssignee.Error())
+		return errorsGroup.FilterParams{}, false
+	}
+
+	filter, err := errorsGroup.ParseFilter(queryParams)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return errorsGroup.FilterParams{}, false
+	}
+	return filter, true
 }
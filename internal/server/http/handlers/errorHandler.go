@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	stdErrors "errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/fuckbug/api/internal/middleware"
@@ -37,6 +40,8 @@ func RegisterErrorHandlers( //nolint:dupl
 
 	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
 	routerV1.HandleFunc("/stats", h.GetStats).Methods(http.MethodGet)
+	routerV1.HandleFunc("/histogram", h.GetHistogram).Methods(http.MethodGet)
+	routerV1.HandleFunc("/export", h.Export).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.GetByID).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.Update).Methods(http.MethodPut)
 	routerV1.HandleFunc("/{id}", h.Delete).Methods(http.MethodDelete)
@@ -80,10 +85,16 @@ func (h *errorHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param timeFrom query int false "Time errors from"
 // @Param timeTo query int false "Time errors to"
 // @Param search query string false "Search in message field"
-// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param q query string false "Query, as method:POST AND context.user.id:42 AND NOT message:timeout AND time:>-1h. Fields: message, file, line, url, method, ip, fingerprint, time and the context, headers, query, body, cookies, session and env paths"
+// @Param context.{path} query string false "Filter on a JSON path of context, headers, query, body, cookies, session or env, as context.orderId=123, an empty value matching the errors having the path"
+// @Param sort query string false "Sort order, relevance requiring a full-text search" default(desc) Enums(asc, desc, relevance)
 // @Param limit query int false "Items per page" default(50)
-// @Param offset query int false "Offset for pagination" default(0)
+// @Param offset query int false "Offset for pagination, ignored with a cursor" default(0)
+// @Param cursor query string false "Cursor of the next page, from the nextCursor of the previous one"
+// @Param count query string false "Total count: exact, estimated above 10000 rows, or skipped" default(exact) Enums(exact, estimate, none)
 // @Success 200 {object} errors.EntityList "Successfully retrieved list of errors"
+// @Failure 400 {object} string "Invalid cursor, count, query, search mode or sort"
 // @Security BearerAuth
 // @Router /v1/errors [get].
 func (h *errorHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -99,17 +110,72 @@ func (h *errorHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		offset = httputils.DefaultOffset
 	}
 
-	projectID := queryParams.Get("projectId")
-	groupID := queryParams.Get("groupId")
+	cursor, countMode, err := parsePage(queryParams)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc && sortOrder != errors.SortRelevance {
+		sortOrder = httputils.DefaultSort
+	}
+
+	filter, ok := parseErrorFilter(w, queryParams)
+	if !ok {
+		return
+	}
+
+	params := errors.GetAllParams{
+		FilterParams: filter,
+		SortOrder:    sortOrder,
+		Limit:        limit,
+		Offset:       offset,
+		Cursor:       cursor,
+		CountMode:    countMode,
+	}
+
+	list, err := h.service.GetAll(r.Context(), params)
 	if err != nil {
-		timeFrom = 0
+		if stdErrors.Is(err, errors.ErrInvalidSort) {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	httputils.RespondWithJSON(w, http.StatusOK, list)
+}
+
+// Export godoc
+// @Summary Export errors
+// @Description Streams every error matching the filters, without paging
+// @Tags errors
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param projectId query string false "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param timeFrom query int false "Time errors from"
+// @Param timeTo query int false "Time errors to"
+// @Param search query string false "Search in message field"
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param q query string false "Query, as method:POST AND context.user.id:42 AND NOT message:timeout AND time:>-1h. Fields: message, file, line, url, method, ip, fingerprint, time and the context, headers, query, body, cookies, session and env paths"
+// @Param context.{path} query string false "Filter on a JSON path of context, headers, query, body, cookies, session or env, as context.orderId=123, an empty value matching the errors having the path"
+// @Param sort query string false "Sort order" default(desc) Enums(asc, desc)
+// @Param format query string false "Export format: CSV, one JSON object per line or a JSON array" default(ndjson) Enums(csv, ndjson, json)
+// @Success 200 {array} errors.Entity "Stream of the matching errors"
+// @Failure 400 {object} string "Invalid format, query or search mode"
+// @Security BearerAuth
+// @Router /v1/errors/export [get].
+func (h *errorHandler) Export(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	format, err := parseExportFormat(queryParams.Get("format"))
 	if err != nil {
-		timeTo = 0
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	sortOrder := queryParams.Get("sort")
@@ -117,28 +183,53 @@ func (h *errorHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		sortOrder = httputils.DefaultSort
 	}
 
-	search := queryParams.Get("search")
+	filter, ok := parseErrorFilter(w, queryParams)
+	if !ok {
+		return
+	}
 
-	params := errors.GetAllParams{
-		FilterParams: errors.FilterParams{
-			ProjectID:   projectID,
-			Fingerprint: groupID,
-			TimeFrom:    utils.SecondsToMilliseconds(timeFrom),
-			TimeTo:      utils.SecondsToMilliseconds(timeTo),
-			Search:      search,
-		},
-		SortOrder: sortOrder,
-		Limit:     limit,
-		Offset:    offset,
-	}
-
-	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	params := errors.ExportParams{
+		FilterParams: filter,
+		SortOrder:    sortOrder,
+	}
+
+	export := newExportWriter(w, format, "errors", errorExportHeader)
+	err = h.service.Export(r.Context(), params, func(entity *errors.Entity) error {
+		return export.Write(entity, func() []string {
+			return []string{
+				entity.ID,
+				strconv.FormatInt(entity.Time, 10),
+				entity.Message,
+				entity.File,
+				strconv.Itoa(entity.Line),
+				stringColumn(entity.Method),
+				stringColumn(entity.URL),
+				stringColumn(entity.IP),
+				jsonColumn(entity.Stacktrace),
+				jsonColumn(entity.Context),
+				jsonColumn(entity.Headers),
+				jsonColumn(entity.QueryParams),
+				jsonColumn(entity.BodyParams),
+				jsonColumn(entity.Cookies),
+				jsonColumn(entity.Session),
+				jsonColumn(entity.Files),
+				jsonColumn(entity.Env),
+			}
+		})
+	})
 	if err != nil {
+		// Once the response started, the export can only be cut short
+		if export.Started() {
+			h.logger.Error(fmt.Sprintf("failed to export errors: %v", err))
+			return
+		}
 		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+	if err := export.Close(); err != nil {
+		h.logger.Error(fmt.Sprintf("failed to export errors: %v", err))
+	}
 }
 
 // GetStats godoc
@@ -167,6 +258,66 @@ func (h *errorHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	httputils.RespondWithJSON(w, http.StatusOK, stats)
 }
 
+// GetHistogram godoc
+// @Summary Get errors histogram
+// @Description Retrieves a zero-filled time series of errors counts bucketed by interval
+// @Tags errors
+// @Accept json
+// @Produce json
+// @Param projectId query string true "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param timeFrom query int false "Time errors from (unix seconds), defaults to timeTo minus 24 hours"
+// @Param timeTo query int false "Time errors to (unix seconds), defaults to now"
+// @Param interval query string false "Bucket interval" default(hour) Enums(minute, hour, day)
+// @Param timezone query string false "IANA timezone used to align buckets" default(UTC)
+// @Success 200 {object} errors.Histogram "Successfully retrieved histogram of errors"
+// @Failure 400 {object} string "Missing projectId or invalid histogram parameters"
+// @Security BearerAuth
+// @Router /v1/errors/histogram [get].
+func (h *errorHandler) GetHistogram(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	projectID := queryParams.Get("projectId")
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "projectId is required")
+		return
+	}
+
+	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	if err != nil {
+		timeFrom = 0
+	}
+
+	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	if err != nil {
+		timeTo = 0
+	}
+
+	params := errors.HistogramParams{
+		ProjectID:   projectID,
+		Fingerprint: queryParams.Get("groupId"),
+		TimeFrom:    utils.SecondsToMilliseconds(timeFrom),
+		TimeTo:      utils.SecondsToMilliseconds(timeTo),
+		Interval:    queryParams.Get("interval"),
+		Timezone:    queryParams.Get("timezone"),
+	}
+
+	histogram, err := h.service.GetHistogram(r.Context(), params)
+	if err != nil {
+		if stdErrors.Is(err, errors.ErrInvalidInterval) ||
+			stdErrors.Is(err, errors.ErrInvalidTimezone) ||
+			stdErrors.Is(err, errors.ErrInvalidTimeRange) ||
+			stdErrors.Is(err, errors.ErrTooManyBuckets) {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, histogram)
+}
+
 // Create godoc
 // @Summary Create a new error entry
 // @Description Creates a new error entry in the system
@@ -276,3 +427,18 @@ func (h *errorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+var errorExportHeader = []string{
+	"id", "time", "message", "file", "line", "method", "url", "ip", "stacktrace", "context",
+	"headers", "queryParams", "bodyParams", "cookies", "session", "files", "env",
+}
+
+// parseErrorFilter reads the filters shared by the error list and export.
+func parseErrorFilter(w http.ResponseWriter, queryParams url.Values) (errors.FilterParams, bool) {
+	filter, err := errors.ParseFilter(queryParams)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return errors.FilterParams{}, false
+	}
+	return filter, true
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+	exportJSON   = "json"
+
+	// exportFlushEvery is the number of items written between two flushes of the response
+	exportFlushEvery = 500
+)
+
+var errInvalidExportFormat = errors.New("format must be csv, ndjson or json")
+
+var exportContentTypes = map[string]string{
+	exportCSV:    "text/csv; charset=utf-8",
+	exportNDJSON: "application/x-ndjson",
+	exportJSON:   "application/json",
+}
+
+// parseExportFormat reads the format of an export, NDJSON by default.
+func parseExportFormat(format string) (string, error) {
+	if format == "" {
+		return exportNDJSON, nil
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		return "", errInvalidExportFormat
+	}
+	return format, nil
+}
+
+// exportWriter streams the items of an export as they are read. The response starts with the
+// first item, so an error happening before it can still be answered with an error status.
+type exportWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  string
+	name    string
+	header  []string
+	csv     *csv.Writer
+	started bool
+	written int
+}
+
+// newExportWriter writes an export named after name, header being the columns of the CSV format.
+func newExportWriter(w http.ResponseWriter, format string, name string, header []string) *exportWriter {
+	return &exportWriter{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		format: format,
+		name:   name,
+		header: header,
+	}
+}
+
+func (e *exportWriter) Started() bool {
+	return e.started
+}
+
+// Write writes an item, record returning its CSV columns.
+func (e *exportWriter) Write(item interface{}, record func() []string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	var err error
+	switch e.format {
+	case exportCSV:
+		err = e.csv.Write(record())
+	case exportJSON:
+		if e.written > 0 {
+			if _, err = e.w.Write([]byte(",\n")); err != nil {
+				return err
+			}
+		}
+		err = e.writeJSON(item)
+	default:
+		if err = e.writeJSON(item); err == nil {
+			_, err = e.w.Write([]byte("\n"))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	e.written++
+	if e.written%exportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// Close ends the export, an export without items being written as an empty file.
+func (e *exportWriter) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.format == exportJSON {
+		if _, err := e.w.Write([]byte("]\n")); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	return e.flush()
+}
+
+func (e *exportWriter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	// The server write timeout would otherwise end long exports.
+	if err := e.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("failed to lift export write deadline: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", e.name, time.Now().UTC().Format("20060102T150405Z"), e.format)
+
+	e.w.Header().Set("Content-Type", exportContentTypes[e.format])
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	e.w.WriteHeader(http.StatusOK)
+
+	switch e.format {
+	case exportCSV:
+		e.csv = csv.NewWriter(e.w)
+		if err := e.csv.Write(e.header); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	case exportJSON:
+		if _, err := e.w.Write([]byte("[\n")); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *exportWriter) writeJSON(item interface{}) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	if err := e.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("failed to flush export: %w", err)
+	}
+	return nil
+}
+
+// jsonColumn writes a JSON value as a CSV column, empty when null.
+func jsonColumn(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+func stringColumn(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -1,12 +1,28 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/query"
+	"github.com/fuckbug/api/pkg/httputils"
+	"github.com/fuckbug/api/pkg/utils"
 	"github.com/gorilla/mux"
 )
 
+const (
+	assigneeMe = "me"
+
+	countExact    = "exact"
+	countEstimate = "estimate"
+	countNone     = "none"
+)
+
 func getProjectIDAndKey(r *http.Request) (projectID string, err error) {
 	vars := mux.Vars(r)
 	projectID = vars["projectID"]
@@ -16,3 +32,50 @@ func getProjectIDAndKey(r *http.Request) (projectID string, err error) {
 	}
 	return projectID, nil
 }
+
+// resolveAssignee replaces the "me" assignee of the group list filters with the authenticated user.
+func resolveAssignee(r *http.Request, queryParams url.Values) (url.Values, bool) {
+	if queryParams.Get("assignee") != assigneeMe {
+		return queryParams, true
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		return nil, false
+	}
+
+	resolved := maps.Clone(queryParams)
+	resolved.Set("assignee", userID)
+	return resolved, true
+}
+
+// parsePage reads the cursor and count mode of the event lists. An empty count mode counts exactly.
+func parsePage(queryParams url.Values) (cursor *utils.Cursor, countMode string, err error) {
+	if token := queryParams.Get("cursor"); token != "" {
+		cursor, err = utils.DecodeCursor(token)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	countMode = queryParams.Get("count")
+	switch countMode {
+	case "", countExact, countEstimate, countNone:
+		return cursor, countMode, nil
+	default:
+		return nil, "", fmt.Errorf("invalid count, expected exact, estimate or none")
+	}
+}
+
+// respondWithFilterError responds to an invalid list filter, with the position of the query syntax errors.
+func respondWithFilterError(w http.ResponseWriter, err error) {
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		httputils.RespondWithError(w, http.StatusBadRequest, "Invalid query", map[string]string{
+			"message":  syntaxErr.Message,
+			"position": strconv.Itoa(syntaxErr.Position),
+		})
+		return
+	}
+	httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/importer"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type importHandler struct {
+	logger  Logger
+	service importer.Service
+}
+
+func RegisterImportHandlers(
+	r *mux.Router,
+	logger Logger,
+	service importer.Service,
+	jwtKey []byte,
+) {
+	h := &importHandler{
+		logger:  logger,
+		service: service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/import").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.Import).Methods(http.MethodPost)
+}
+
+// Import godoc
+// @Summary Import events
+// @Description Imports the history of another system from the request body: errors or logs exported by FuckBug
+// @Description as NDJSON, or Sentry events as a JSON array or one per line. Events keep their time and ID, so an
+// @Description import can be run again: the events imported before are skipped and the group counters stay right.
+// @Tags import
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param format query string true "Format of the body" Enums(ndjson, sentry)
+// @Param kind query string false "Kind of the NDJSON records, required with the ndjson format" Enums(errors, logs)
+// @Success 200 {object} importer.Report "Successfully imported events"
+// @Failure 400 {object} string "Invalid format, kind or malformed body"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/import [post].
+func (h *importHandler) Import(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	params := importer.Params{
+		ProjectID: projectID,
+		Format:    importer.Format(queryParams.Get("format")),
+		Kind:      importer.Kind(queryParams.Get("kind")),
+	}
+
+	// The server timeouts would otherwise end large imports.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to lift import read deadline: %v", err))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to lift import write deadline: %v", err))
+	}
+
+	report, err := h.service.Import(r.Context(), r.Body, params)
+	if err != nil {
+		var validationErrors v.ValidationErrors
+		switch {
+		case errors.As(err, &validationErrors):
+			httputils.HandleValidatorError(w, err)
+		case errors.Is(err, importer.ErrKindRequired), errors.Is(err, importer.ErrMalformedInput):
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		default:
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, report)
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/fuckbug/api/internal/middleware"
 	logGroup "github.com/fuckbug/api/internal/modules/logGroup"
 	"github.com/fuckbug/api/pkg/httputils"
-	"github.com/fuckbug/api/pkg/utils"
 	v "github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
@@ -35,6 +36,10 @@ func RegisterLogGroupHandlers(
 
 	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.GetByID).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{id}/status", h.UpdateStatus).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/assignee", h.Assign).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/snooze", h.Snooze).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{id}/merge", h.Merge).Methods(http.MethodPost)
 }
 
 // GetByID godoc
@@ -75,6 +80,9 @@ func (h *logGroupHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param timeTo query int false "Time logs to"
 // @Param level query string false "Filter by log level" Enums(DEBUG, INFO, WARN, ERROR)
 // @Param search query string false "Search in message field"
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param assignee query string false "Assignee user ID, \"me\" for the current user or \"none\" for unassigned groups"
+// @Param sortBy query string false "Sort field" default(lastSeenAt) Enums(lastSeenAt, firstSeenAt, counter, eventsLastHour, eventsLastDay, trend)
 // @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
 // @Param limit query int false "Items per page" default(50)
 // @Param offset query int false "Offset for pagination" default(0)
@@ -94,44 +102,241 @@ func (h *logGroupHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		offset = httputils.DefaultOffset
 	}
 
-	projectID := queryParams.Get("projectId")
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
 
-	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	sortBy := queryParams.Get("sortBy")
+	switch sortBy {
+	case logGroup.SortByFirstSeenAt, logGroup.SortByCounter, logGroup.SortByEventsLastHour,
+		logGroup.SortByEventsLastDay, logGroup.SortByTrend:
+	default:
+		sortBy = logGroup.SortByLastSeenAt
+	}
+
+	filter, ok := parseLogGroupFilter(w, r, queryParams)
+	if !ok {
+		return
+	}
+
+	params := logGroup.GetAllParams{
+		FilterParams: filter,
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		Limit:        limit,
+		Offset:       offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
 	if err != nil {
-		timeFrom = 0
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// UpdateStatus godoc
+// @Summary Update the status of a log group
+// @Description Resolves, ignores or reopens a log group. A resolved group that receives a new event regresses.
+// @Tags log-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body loggroup.UpdateStatus true "Group status"
+// @Success 200 {object} loggroup.Entity "Successfully updated group status"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/log-groups/{id}/status [put].
+func (h *logGroupHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
 	}
 
-	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	var req logGroup.UpdateStatus
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.UpdateStatus(r.Context(), id, &req)
 	if err != nil {
-		timeTo = 0
+		if errors.Is(err, logGroup.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	sortOrder := queryParams.Get("sort")
-	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
-		sortOrder = httputils.DefaultSort
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Assign godoc
+// @Summary Assign a log group
+// @Description Assigns a log group to a user, or unassigns it when assigneeId is null
+// @Tags log-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body loggroup.Assign true "Group assignee"
+// @Success 200 {object} loggroup.Entity "Successfully updated group assignee"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/log-groups/{id}/assignee [put].
+func (h *logGroupHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
 	}
 
-	level := queryParams.Get("level")
-	search := queryParams.Get("search")
+	var req logGroup.Assign
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
 
-	params := logGroup.GetAllParams{
-		FilterParams: logGroup.FilterParams{
-			ProjectID: projectID,
-			TimeFrom:  timeFrom,
-			TimeTo:    timeTo,
-			Level:     level,
-			Search:    search,
-		},
-		SortOrder: sortOrder,
-		Limit:     limit,
-		Offset:    offset,
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
 	}
 
-	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	entity, err := h.service.Assign(r.Context(), id, &req)
 	if err != nil {
+		switch {
+		case errors.Is(err, logGroup.ErrNotFound):
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, logGroup.ErrAssigneeNotFound):
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		default:
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Snooze godoc
+// @Summary Snooze a log group
+// @Description Snoozes a log group until a duration elapses or it occurs N more times.
+// @Description The group returns to unresolved and a notification is sent when the first condition is met.
+// @Tags log-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body loggroup.Snooze true "Snooze conditions"
+// @Success 200 {object} loggroup.Entity "Successfully snoozed group"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/log-groups/{id}/snooze [put].
+func (h *logGroupHandler) Snooze(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req logGroup.Snooze
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Snooze(r.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, logGroup.ErrNotFound) {
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Merge godoc
+// @Summary Merge log groups into a log group
+// @Description Moves the events, the comments and the activity of other groups of the project and of the level
+// @Description into the group and deletes them. Their new events go to the group, whose activity records each merge.
+// @Tags log-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body loggroup.Merge true "Merged groups"
+// @Success 200 {object} loggroup.Entity "Successfully merged groups"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Group not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/log-groups/{id}/merge [post].
+func (h *logGroupHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req logGroup.Merge
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Merge(r.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, logGroup.ErrNotFound):
+			httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, logGroup.ErrInvalidMerge):
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		default:
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// parseLogGroupFilter reads the filters of the log group list, "me" being the authenticated user.
+func parseLogGroupFilter(w http.ResponseWriter, r *http.Request, queryParams url.Values) (logGroup.FilterParams, bool) {
+	queryParams, ok := resolveAssignee(r, queryParams)
+	if !ok {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, logGroup.ErrInvalidAssignee.Error())
+		return logGroup.FilterParams{}, false
+	}
+
+	filter, err := logGroup.ParseFilter(queryParams)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return logGroup.FilterParams{}, false
+	}
+	return filter, true
 }
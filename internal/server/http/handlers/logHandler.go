@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/fuckbug/api/internal/middleware"
@@ -37,6 +40,8 @@ func RegisterLogHandlers( //nolint:dupl
 
 	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
 	routerV1.HandleFunc("/stats", h.GetStats).Methods(http.MethodGet)
+	routerV1.HandleFunc("/histogram", h.GetHistogram).Methods(http.MethodGet)
+	routerV1.HandleFunc("/export", h.Export).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.GetByID).Methods(http.MethodGet)
 	routerV1.HandleFunc("/{id}", h.Update).Methods(http.MethodPut)
 	routerV1.HandleFunc("/{id}", h.Delete).Methods(http.MethodDelete)
@@ -81,10 +86,16 @@ func (h *logHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param timeTo query int false "Time logs to"
 // @Param level query string false "Filter by log level" Enums(DEBUG, INFO, WARN, ERROR)
 // @Param search query string false "Search in message field"
-// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param q query string false "Query, as level:ERROR AND context.user.id:42 AND NOT message:timeout AND time:>-1h. Fields: message, level, fingerprint, time, context.<path>"
+// @Param context.{path} query string false "Filter on a JSON path, as context.orderId=123, an empty value matching the events having the path"
+// @Param sort query string false "Sort order, relevance requiring a full-text search" default(desc) Enums(asc, desc, relevance)
 // @Param limit query int false "Items per page" default(50)
-// @Param offset query int false "Offset for pagination" default(0)
+// @Param offset query int false "Offset for pagination, ignored with a cursor" default(0)
+// @Param cursor query string false "Cursor of the next page, from the nextCursor of the previous one"
+// @Param count query string false "Total count: exact, estimated above 10000 rows, or skipped" default(exact) Enums(exact, estimate, none)
 // @Success 200 {object} log.EntityList "Successfully retrieved list of logs"
+// @Failure 400 {object} string "Invalid cursor, count, query, search mode or sort"
 // @Security BearerAuth
 // @Router /v1/logs [get].
 func (h *logHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -100,17 +111,73 @@ func (h *logHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		offset = httputils.DefaultOffset
 	}
 
-	projectID := queryParams.Get("projectId")
-	groupID := queryParams.Get("groupId")
+	cursor, countMode, err := parsePage(queryParams)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc && sortOrder != log.SortRelevance {
+		sortOrder = httputils.DefaultSort
+	}
+
+	filter, ok := parseLogFilter(w, queryParams)
+	if !ok {
+		return
+	}
+
+	params := log.GetAllParams{
+		FilterParams: filter,
+		SortOrder:    sortOrder,
+		Limit:        limit,
+		Offset:       offset,
+		Cursor:       cursor,
+		CountMode:    countMode,
+	}
+
+	list, err := h.service.GetAll(r.Context(), params)
 	if err != nil {
-		timeFrom = 0
+		if errors.Is(err, log.ErrInvalidSort) {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	httputils.RespondWithJSON(w, http.StatusOK, list)
+}
+
+// Export godoc
+// @Summary Export logs
+// @Description Streams every log matching the filters, without paging
+// @Tags logs
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param projectId query string false "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param timeFrom query int false "Time logs from"
+// @Param timeTo query int false "Time logs to"
+// @Param level query string false "Filter by log level" Enums(DEBUG, INFO, WARN, ERROR)
+// @Param search query string false "Search in message field"
+// @Param searchMode query string false "Search mode: substring match, or full-text with \"quoted phrases\" and prefix* words" default(contains) Enums(contains, fulltext)
+// @Param q query string false "Query, as level:ERROR AND context.user.id:42 AND NOT message:timeout AND time:>-1h. Fields: message, level, fingerprint, time, context.<path>"
+// @Param context.{path} query string false "Filter on a JSON path, as context.orderId=123, an empty value matching the events having the path"
+// @Param sort query string false "Sort order" default(desc) Enums(asc, desc)
+// @Param format query string false "Export format: CSV, one JSON object per line or a JSON array" default(ndjson) Enums(csv, ndjson, json)
+// @Success 200 {array} log.Entity "Stream of the matching logs"
+// @Failure 400 {object} string "Invalid format, query or search mode"
+// @Security BearerAuth
+// @Router /v1/logs/export [get].
+func (h *logHandler) Export(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	format, err := parseExportFormat(queryParams.Get("format"))
 	if err != nil {
-		timeTo = 0
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	sortOrder := queryParams.Get("sort")
@@ -118,30 +185,41 @@ func (h *logHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		sortOrder = httputils.DefaultSort
 	}
 
-	level := queryParams.Get("level")
-	search := queryParams.Get("search")
+	filter, ok := parseLogFilter(w, queryParams)
+	if !ok {
+		return
+	}
 
-	params := log.GetAllParams{
-		FilterParams: log.FilterParams{
-			ProjectID:   projectID,
-			Fingerprint: groupID,
-			TimeFrom:    timeFrom,
-			TimeTo:      timeTo,
-			Level:       level,
-			Search:      search,
-		},
-		SortOrder: sortOrder,
-		Limit:     limit,
-		Offset:    offset,
-	}
-
-	logs, totalCount, err := h.service.GetAll(r.Context(), params)
+	params := log.ExportParams{
+		FilterParams: filter,
+		SortOrder:    sortOrder,
+	}
+
+	export := newExportWriter(w, format, "logs", logExportHeader)
+	err = h.service.Export(r.Context(), params, func(entity *log.Entity) error {
+		return export.Write(entity, func() []string {
+			return []string{
+				entity.ID,
+				strconv.FormatInt(entity.Time, 10),
+				entity.Level,
+				entity.Message,
+				jsonColumn(entity.Context),
+			}
+		})
+	})
 	if err != nil {
+		// Once the response started, the export can only be cut short
+		if export.Started() {
+			h.logger.Error(fmt.Sprintf("failed to export logs: %v", err))
+			return
+		}
 		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, logs))
+	if err := export.Close(); err != nil {
+		h.logger.Error(fmt.Sprintf("failed to export logs: %v", err))
+	}
 }
 
 // GetStats godoc
@@ -170,6 +248,69 @@ func (h *logHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	httputils.RespondWithJSON(w, http.StatusOK, stats)
 }
 
+// GetHistogram godoc
+// @Summary Get logs histogram
+// @Description Retrieves a zero-filled time series of logs counts bucketed by interval
+// @Tags logs
+// @Accept json
+// @Produce json
+// @Param projectId query string true "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param level query string false "Filter by log level" Enums(DEBUG, INFO, WARN, ERROR, FATAL)
+// @Param timeFrom query int false "Time logs from (unix seconds), defaults to timeTo minus 24 hours"
+// @Param timeTo query int false "Time logs to (unix seconds), defaults to now"
+// @Param interval query string false "Bucket interval" default(hour) Enums(minute, hour, day)
+// @Param timezone query string false "IANA timezone used to align buckets" default(UTC)
+// @Success 200 {object} log.Histogram "Successfully retrieved histogram of logs"
+// @Failure 400 {object} string "Missing projectId or invalid histogram parameters"
+// @Security BearerAuth
+// @Router /v1/logs/histogram [get].
+func (h *logHandler) GetHistogram(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	projectID := queryParams.Get("projectId")
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "projectId is required")
+		return
+	}
+
+	timeFrom, err := utils.ParseTimeParam(queryParams.Get("timeFrom"))
+	if err != nil {
+		timeFrom = 0
+	}
+
+	timeTo, err := utils.ParseTimeParam(queryParams.Get("timeTo"))
+	if err != nil {
+		timeTo = 0
+	}
+
+	params := log.HistogramParams{
+		ProjectID:   projectID,
+		Fingerprint: queryParams.Get("groupId"),
+		Level:       queryParams.Get("level"),
+		TimeFrom:    utils.SecondsToMilliseconds(timeFrom),
+		TimeTo:      utils.SecondsToMilliseconds(timeTo),
+		Interval:    queryParams.Get("interval"),
+		Timezone:    queryParams.Get("timezone"),
+	}
+
+	histogram, err := h.service.GetHistogram(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, log.ErrInvalidInterval) ||
+			errors.Is(err, log.ErrInvalidTimezone) ||
+			errors.Is(err, log.ErrInvalidTimeRange) ||
+			errors.Is(err, log.ErrTooManyBuckets) ||
+			errors.Is(err, log.ErrInvalidLogLevel) {
+			httputils.RespondWithPlainError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, histogram)
+}
+
 // Create godoc
 // @Summary Create a new log entry
 // @Description Creates a new log entry in the system
@@ -279,3 +420,15 @@ func (h *logHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+var logExportHeader = []string{"id", "time", "level", "message", "context"}
+
+// parseLogFilter reads the filters shared by the log list and export.
+func parseLogFilter(w http.ResponseWriter, queryParams url.Values) (log.FilterParams, bool) {
+	filter, err := log.ParseFilter(queryParams)
+	if err != nil {
+		respondWithFilterError(w, err)
+		return log.FilterParams{}, false
+	}
+	return filter, true
+}
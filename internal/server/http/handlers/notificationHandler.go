@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/notification"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type notificationHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  notification.Service
+}
+
+func RegisterNotificationHandlers(
+	r *mux.Router,
+	logger Logger,
+	service notification.Service,
+	jwtKey []byte,
+) {
+	h := &notificationHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/notification-preferences").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.Get).Methods(http.MethodGet)
+	routerV1.HandleFunc("", h.Update).Methods(http.MethodPut)
+}
+
+// Get godoc
+// @Summary Get notification preferences
+// @Description Get the email notification preferences of the current user for a project
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} notification.PreferenceEntity
+// @Security BearerAuth
+// @Router /v1/projects/{id}/notification-preferences [get].
+func (h *notificationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetPreference(r.Context(), projectID)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Update godoc
+// @Summary Update notification preferences
+// @Description Chooses which emails the current user receives for a project: fired alerts, regressions and a daily or weekly digest
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body notification.UpdatePreference true "Notification preferences"
+// @Success 200 {object} notification.PreferenceEntity "Successfully updated notification preferences"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/notification-preferences [put].
+func (h *notificationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req notification.UpdatePreference
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.UpdatePreference(r.Context(), projectID, &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+func (h *notificationHandler) respondWithError(w http.ResponseWriter, err error) {
+	if errors.Is(err, notification.ErrUnauthorized) {
+		httputils.RespondWithPlainError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+}
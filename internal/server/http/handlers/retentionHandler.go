@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/retention"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type retentionHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  retention.Service
+}
+
+func RegisterRetentionHandlers(
+	r *mux.Router,
+	logger Logger,
+	service retention.Service,
+	jwtKey []byte,
+) {
+	h := &retentionHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/retention").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.Get).Methods(http.MethodGet)
+	routerV1.HandleFunc("", h.Update).Methods(http.MethodPut)
+	routerV1.HandleFunc("/reports", h.GetReports).Methods(http.MethodGet)
+}
+
+// Get godoc
+// @Summary Get the retention policy
+// @Description Get how many days the errors and logs of a project are kept, null meaning forever
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} retention.PolicyEntity
+// @Security BearerAuth
+// @Router /v1/projects/{id}/retention [get].
+func (h *retentionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetPolicy(r.Context(), projectID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Update godoc
+// @Summary Update the retention policy
+// @Description Sets how many days the errors and logs of a project are kept, and overrides it for some log levels.
+// @Description Expired data is purged in the background, along with the groups left without events.
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body retention.UpdatePolicy true "Retention policy"
+// @Success 200 {object} retention.PolicyEntity "Successfully updated retention policy"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/retention [put].
+func (h *retentionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req retention.UpdatePolicy
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.UpdatePolicy(r.Context(), projectID, &req)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// GetReports godoc
+// @Summary Get the retention reports
+// @Description Retrieves what the purges removed from a project
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} retention.ReportList "Successfully retrieved list of retention reports"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/retention/reports [get].
+func (h *retentionHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := retention.GetReportsParams{
+		ProjectID: projectID,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetReports(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/stream"
+	"github.com/fuckbug/api/pkg/httputils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	streamRetryInterval     = 3 * time.Second
+	streamWriteTimeout      = 10 * time.Second
+	streamPongTimeout       = 60 * time.Second
+	streamReadLimit         = 512
+
+	lastEventIDHeader = "Last-Event-ID"
+	accessTokenParam  = "access_token"
+)
+
+type streamHandler struct {
+	logger   Logger
+	service  stream.Service
+	upgrader websocket.Upgrader
+}
+
+// RegisterStreamHandlers must be called before the log and error handlers, whose /{id}
+// routes would otherwise match /stream.
+func RegisterStreamHandlers(
+	r *mux.Router,
+	logger Logger,
+	service stream.Service,
+	jwtKey []byte,
+) {
+	h := &streamHandler{
+		logger:  logger,
+		service: service,
+		upgrader: websocket.Upgrader{
+			// Clients authenticate with a bearer token rather than cookies, so any origin may connect.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+
+	routerV1 := r.PathPrefix("/v1").Subrouter()
+	routerV1.Use(tokenFromQuery, middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("/logs/stream", h.Stream(stream.KindLog)).Methods(http.MethodGet)
+	routerV1.HandleFunc("/errors/stream", h.Stream(stream.KindError)).Methods(http.MethodGet)
+}
+
+// Stream godoc
+// @Summary Live tail of logs or errors
+// @Description Pushes the newly ingested events matching the filters, as Server-Sent Events or as WebSocket
+// @Description JSON messages when the request is a WebSocket upgrade. A client resuming with the Last-Event-ID
+// @Description header or the lastEventId parameter first receives the stored events it missed. Browsers may
+// @Description pass their token in the access_token parameter.
+// @Tags stream
+// @Produce text/event-stream
+// @Param projectId query string false "Project ID"
+// @Param groupId query string false "Group ID"
+// @Param level query string false "Filter by log level, logs only" Enums(DEBUG, INFO, WARN, ERROR, FATAL)
+// @Param search query string false "Search in message field"
+// @Param lastEventId query string false "ID of the last received event"
+// @Success 200 {object} stream.Message "Stream of events"
+// @Failure 400 {object} string "Invalid filters or Last-Event-ID"
+// @Security BearerAuth
+// @Router /v1/logs/stream [get]
+// @Router /v1/errors/stream [get].
+func (h *streamHandler) Stream(kind stream.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryParams := r.URL.Query()
+
+		filter, ok := parseStreamFilter(w, kind, queryParams)
+		if !ok {
+			return
+		}
+
+		lastEventID := r.Header.Get(lastEventIDHeader)
+		if lastEventID == "" {
+			lastEventID = queryParams.Get("lastEventId")
+		}
+		if lastEventID != "" {
+			if _, err := uuid.Parse(lastEventID); err != nil {
+				httputils.RespondWithPlainError(w, http.StatusBadRequest, "invalid last event id")
+				return
+			}
+		}
+
+		subscription, replay, err := h.service.Subscribe(r.Context(), kind, filter, lastEventID)
+		if err != nil {
+			httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer h.service.Unsubscribe(subscription)
+
+		if websocket.IsWebSocketUpgrade(r) {
+			h.serveWebSocket(w, r, subscription, replay)
+			return
+		}
+		h.serveEvents(w, r, subscription, replay)
+	}
+}
+
+func (h *streamHandler) serveEvents(
+	w http.ResponseWriter,
+	r *http.Request,
+	subscription *stream.Subscription,
+	replay []*stream.Message,
+) {
+	rc := http.NewResponseController(w)
+
+	// The server write timeout would otherwise end the stream.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn(fmt.Sprintf("failed to lift stream write deadline: %v", err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryInterval.Milliseconds()); err != nil {
+		return
+	}
+
+	replayed := make(map[string]struct{}, len(replay))
+	for _, message := range replay {
+		replayed[message.ID] = struct{}{}
+		if err := writeEvent(w, message); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case message, ok := <-subscription.C:
+			if !ok {
+				// Lagging clients are disconnected and resume from their last event.
+				return
+			}
+			if _, ok := replayed[message.ID]; ok {
+				continue
+			}
+			if err := writeEvent(w, message); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, message *stream.Message) error {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", message.ID, message.Type, data)
+	return err
+}
+
+func (h *streamHandler) serveWebSocket(
+	w http.ResponseWriter,
+	r *http.Request,
+	subscription *stream.Subscription,
+	replay []*stream.Message,
+) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded with an error.
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send control frames, reading detects when they go away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		conn.SetReadLimit(streamReadLimit)
+		_ = conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		})
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	replayed := make(map[string]struct{}, len(replay))
+	for _, message := range replay {
+		replayed[message.ID] = struct{}{}
+		if err := writeMessage(conn, message); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		case message, ok := <-subscription.C:
+			if !ok {
+				if subscription.Lagged() {
+					closeMessage := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "lagging behind, resume from the last event")
+					_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(streamWriteTimeout))
+				}
+				return
+			}
+			if _, ok := replayed[message.ID]; ok {
+				continue
+			}
+			if err := writeMessage(conn, message); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeMessage(conn *websocket.Conn, message *stream.Message) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(message)
+}
+
+// tokenFromQuery lets browsers, which cannot set headers on EventSource and WebSocket
+// connections, authenticate with the access_token query parameter.
+func tokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get(accessTokenParam); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseStreamFilter reads the filters of a stream the way the list endpoints of its kind do.
+func parseStreamFilter(w http.ResponseWriter, kind stream.Kind, queryParams url.Values) (stream.Filter, bool) {
+	if kind == stream.KindLog {
+		filter, ok := parseLogFilter(w, queryParams)
+		if !ok {
+			return stream.Filter{}, false
+		}
+		return stream.Filter{
+			ProjectID:   filter.ProjectID,
+			Fingerprint: filter.Fingerprint,
+			Level:       filter.Level,
+			Search:      filter.Search,
+		}, true
+	}
+
+	filter, ok := parseErrorFilter(w, queryParams)
+	if !ok {
+		return stream.Filter{}, false
+	}
+	return stream.Filter{
+		ProjectID:   filter.ProjectID,
+		Fingerprint: filter.Fingerprint,
+		Search:      filter.Search,
+	}, true
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fuckbug/api/internal/middleware"
+	"github.com/fuckbug/api/internal/modules/webhook"
+	"github.com/fuckbug/api/pkg/httputils"
+	v "github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type webhookHandler struct {
+	logger   Logger
+	validate *v.Validate
+	service  webhook.Service
+}
+
+func RegisterWebhookHandlers(
+	r *mux.Router,
+	logger Logger,
+	service webhook.Service,
+	jwtKey []byte,
+) {
+	h := &webhookHandler{
+		logger:   logger,
+		validate: v.New(),
+		service:  service,
+	}
+
+	routerV1 := r.PathPrefix("/v1/projects/{id}/webhooks").Subrouter()
+	routerV1.Use(middleware.Auth(jwtKey))
+
+	routerV1.HandleFunc("", h.Create).Methods(http.MethodPost)
+	routerV1.HandleFunc("", h.GetAll).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{webhookId}", h.GetByID).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{webhookId}", h.Update).Methods(http.MethodPut)
+	routerV1.HandleFunc("/{webhookId}", h.Delete).Methods(http.MethodDelete)
+	routerV1.HandleFunc("/{webhookId}/deliveries", h.GetDeliveries).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{webhookId}/deliveries/{deliveryId}", h.GetDelivery).Methods(http.MethodGet)
+	routerV1.HandleFunc("/{webhookId}/deliveries/{deliveryId}/redeliver", h.Redeliver).Methods(http.MethodPost)
+}
+
+// GetAll godoc
+// @Summary Get project webhooks
+// @Description Retrieves the webhooks of a project
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} webhook.EntityList "Successfully retrieved list of webhooks"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks [get].
+func (h *webhookHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	params := webhook.GetAllParams{
+		ProjectID: projectID,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetAll(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// GetByID godoc
+// @Summary Get a webhook by ID
+// @Description Get a webhook of a project by ID
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} webhook.Entity
+// @Failure 404 {object} string "Webhook not found"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId} [get].
+func (h *webhookHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	if projectID == "" || webhookID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetByID(r.Context(), projectID, webhookID)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Create godoc
+// @Summary Create a webhook
+// @Description Creates a webhook receiving the subscribed project events as JSON POST requests.
+// @Description Every delivery carries the X-FuckBug-Signature header: "sha256=" followed by the hex encoded
+// @Description HMAC-SHA256 of the X-FuckBug-Timestamp header value, a dot and the raw body, keyed with the secret.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body webhook.Create true "Webhook creation data"
+// @Success 201 {object} webhook.Entity "Successfully created webhook"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks [post].
+func (h *webhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	if projectID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req webhook.Create
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	req.ProjectID = projectID
+
+	entity, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusCreated, entity)
+}
+
+// Update godoc
+// @Summary Update a webhook
+// @Description Updates an existing webhook of a project, the secret is kept when empty
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Param request body webhook.Update true "Webhook update data"
+// @Success 200 {object} webhook.Entity "Successfully updated webhook"
+// @Failure 400 {object} string "Invalid input data"
+// @Failure 404 {object} string "Webhook not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId} [put].
+func (h *webhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	if projectID == "" || webhookID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	var req webhook.Update
+	if err := httputils.DecodeRequest(w, r, &req); err != nil {
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		httputils.HandleValidatorError(w, err)
+		return
+	}
+
+	entity, err := h.service.Update(r.Context(), projectID, webhookID, &req)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Delete godoc
+// @Summary Delete a webhook
+// @Description Deletes a webhook of a project along with its deliveries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} string "Webhook not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId} [delete].
+func (h *webhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	if projectID == "" || webhookID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), projectID, webhookID); err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Retrieves the delivery log of a webhook with the status code, latency and response excerpt of the last attempt
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, failed)
+// @Param sort query string false "Sort order (asc or desc)" default(desc) Enums(asc, desc)
+// @Param limit query int false "Items per page" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} webhook.DeliveryList "Successfully retrieved list of deliveries"
+// @Failure 400 {object} string "Invalid status"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId}/deliveries [get].
+func (h *webhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	if projectID == "" || webhookID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	queryParams := r.URL.Query()
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = httputils.DefaultLimit
+	}
+
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = httputils.DefaultOffset
+	}
+
+	sortOrder := queryParams.Get("sort")
+	if sortOrder != httputils.SortAsc && sortOrder != httputils.SortDesc {
+		sortOrder = httputils.DefaultSort
+	}
+
+	status := queryParams.Get("status")
+	switch webhook.DeliveryStatus(status) {
+	case "", webhook.DeliveryPending, webhook.DeliverySucceeded, webhook.DeliveryFailed:
+	default:
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	params := webhook.GetDeliveriesParams{
+		ProjectID: projectID,
+		WebhookID: webhookID,
+		Status:    status,
+		SortOrder: sortOrder,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	entities, totalCount, err := h.service.GetDeliveries(r.Context(), params)
+	if err != nil {
+		httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, httputils.NewListResponse(totalCount, entities))
+}
+
+// GetDelivery godoc
+// @Summary Get a webhook delivery
+// @Description Get a delivery of a webhook by ID
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} webhook.DeliveryEntity
+// @Failure 404 {object} string "Delivery not found"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId}/deliveries/{deliveryId} [get].
+func (h *webhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	deliveryID := vars["deliveryId"]
+	if projectID == "" || webhookID == "" || deliveryID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.GetDelivery(r.Context(), projectID, webhookID, deliveryID)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusOK, entity)
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook delivery
+// @Description Queues a new delivery with the payload of a previous one, identified by the ID of the new delivery
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param webhookId path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} webhook.DeliveryEntity "Delivery queued"
+// @Failure 404 {object} string "Delivery not found"
+// @Failure 500 {object} string "Internal server error"
+// @Security BearerAuth
+// @Router /v1/projects/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post].
+func (h *webhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	webhookID := vars["webhookId"]
+	deliveryID := vars["deliveryId"]
+	if projectID == "" || webhookID == "" || deliveryID == "" {
+		httputils.RespondWithPlainError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	entity, err := h.service.Redeliver(r.Context(), projectID, webhookID, deliveryID)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	httputils.RespondWithJSON(w, http.StatusAccepted, entity)
+}
+
+func (h *webhookHandler) respondWithError(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrNotFound) {
+		httputils.RespondWithPlainError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	httputils.RespondWithPlainError(w, http.StatusInternalServerError, err.Error())
+}
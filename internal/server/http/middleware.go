@@ -1,7 +1,9 @@
 package server
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -18,6 +20,21 @@ func (l *LoggingResponseWriter) WriteHeader(code int) {
 	l.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController, streaming handlers
+// rely on it to flush and to lift the write deadline.
+func (l *LoggingResponseWriter) Unwrap() http.ResponseWriter {
+	return l.ResponseWriter
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (l *LoggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(l.ResponseWriter).Hijack()
+	if err == nil {
+		l.ResponseCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
 func loggingMiddleware(logger handlers.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -7,13 +7,24 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/fuckbug/api/internal/modules/activity"
+	"github.com/fuckbug/api/internal/modules/alert"
+	"github.com/fuckbug/api/internal/modules/anomaly"
 	"github.com/fuckbug/api/internal/modules/app"
+	"github.com/fuckbug/api/internal/modules/attachment"
+	"github.com/fuckbug/api/internal/modules/bulk"
+	"github.com/fuckbug/api/internal/modules/channel"
 	"github.com/fuckbug/api/internal/modules/errors"
 	errorsGroup "github.com/fuckbug/api/internal/modules/errorsGroup"
+	"github.com/fuckbug/api/internal/modules/importer"
 	"github.com/fuckbug/api/internal/modules/log"
 	logGroup "github.com/fuckbug/api/internal/modules/logGroup"
+	"github.com/fuckbug/api/internal/modules/notification"
 	"github.com/fuckbug/api/internal/modules/project"
+	"github.com/fuckbug/api/internal/modules/retention"
+	"github.com/fuckbug/api/internal/modules/stream"
 	"github.com/fuckbug/api/internal/modules/users"
+	"github.com/fuckbug/api/internal/modules/webhook"
 	"github.com/fuckbug/api/internal/server/http/handlers"
 )
 
@@ -36,6 +47,17 @@ func New(
 	errorService errors.Service,
 	errorGroupService errorsGroup.Service,
 	projectService project.Service,
+	anomalyService anomaly.Service,
+	alertService alert.Service,
+	webhookService webhook.Service,
+	notificationService notification.Service,
+	channelService channel.Service,
+	activityService activity.Service,
+	bulkService bulk.Service,
+	streamService stream.Service,
+	retentionService retention.Service,
+	importService importer.Service,
+	attachmentService attachment.Service,
 	host string,
 	port int,
 	jwtKey []byte,
@@ -49,6 +71,17 @@ func New(
 		errorService,
 		errorGroupService,
 		projectService,
+		anomalyService,
+		alertService,
+		webhookService,
+		notificationService,
+		channelService,
+		activityService,
+		bulkService,
+		streamService,
+		retentionService,
+		importService,
+		attachmentService,
 		jwtKey,
 	)
 
@@ -0,0 +1,38 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps blobs under slash separated keys, as project/2024-01-01/logs.ndjson.gz.
+type Store interface {
+	// Put writes the blob, replacing the one stored under the same key. The size is -1 when unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys starting with the prefix, in lexical order.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete succeeds when the blob does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey rejects the keys that could escape the root of a store.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	dirPerm = 0o750
+	tempExt = ".tmp"
+)
+
+// FilesystemStore keeps the blobs as files under a root directory, keys being their relative paths.
+type FilesystemStore struct {
+	root string
+}
+
+func NewFilesystemStore(root string) *FilesystemStore {
+	return &FilesystemStore{root: root}
+}
+
+// Put writes to a temporary file renamed once complete, so a blob is never read half written.
+func (s *FilesystemStore) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), dirPerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+"-*"+tempExt)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err := io.Copy(file, body); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+
+	if err := os.Rename(file.Name(), name); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *FilesystemStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	return file, nil
+}
+
+func (s *FilesystemStore) List(ctx context.Context, prefix string) ([]string, error) {
+	// Only the directory holding the prefix is walked
+	dir := "."
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+		if err := validateKey(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []string
+	err := filepath.WalkDir(s.path(dir), func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if entry.IsDir() || strings.HasSuffix(name, tempExt) {
+			return nil
+		}
+
+		relative, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(relative)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *FilesystemStore) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *FilesystemStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean(key)))
+}
@@ -0,0 +1,48 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystemStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFilesystemStore(t.TempDir())
+
+	for _, key := range []string{"p1/2024-01-02/logs-b.ndjson.gz", "p1/2024-01-02/logs-a.ndjson.gz", "p1/2024-01-03/errors-a.ndjson.gz"} {
+		require.NoError(t, store.Put(ctx, key, strings.NewReader(key), -1, "application/gzip"))
+	}
+
+	keys, err := store.List(ctx, "p1/2024-01-02/logs-")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1/2024-01-02/logs-a.ndjson.gz", "p1/2024-01-02/logs-b.ndjson.gz"}, keys)
+
+	keys, err = store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	keys, err = store.List(ctx, "p2/")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	body, err := store.Get(ctx, "p1/2024-01-03/errors-a.ndjson.gz")
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "p1/2024-01-03/errors-a.ndjson.gz", string(content))
+
+	require.NoError(t, store.Delete(ctx, "p1/2024-01-03/errors-a.ndjson.gz"))
+	_, err = store.Get(ctx, "p1/2024-01-03/errors-a.ndjson.gz")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "p1/2024-01-03/errors-a.ndjson.gz"))
+
+	for _, key := range []string{"", "/etc/passwd", "p1/../../etc", "p1//a"} {
+		assert.ErrorIs(t, store.Put(ctx, key, strings.NewReader(""), 0, ""), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,117 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const noSuchKey = "NoSuchKey"
+
+type S3Config struct {
+	// Endpoint is the host of the S3 compatible service, as s3.amazonaws.com or localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// Prefix is prepended to the keys, to share a bucket
+	Prefix string
+}
+
+// S3Store keeps the blobs as objects of a bucket of an S3 compatible service.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	prefix := strings.Trim(config.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: config.Bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, s.prefix+key, body, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+
+	// The object is only requested once used, a missing one is reported by Stat
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		if minio.ToErrorResponse(err).Code == noSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	return object, nil
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.prefix + prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", object.Err)
+		}
+		keys = append(keys, strings.TrimPrefix(object.Key, s.prefix))
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, s.prefix+key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete blob %s: %w", key, err)
+	}
+	return nil
+}
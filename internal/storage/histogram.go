@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"time"
+)
+
+// Bucket returns the start of the bucket of the resolution holding the time, both in
+// milliseconds. The events are counted in the buckets of a minute and of an hour as they are
+// stored, and the stats and histograms add the counts up rather than scanning the events.
+func Bucket(t int64, resolution time.Duration) int64 {
+	return t - t%resolution.Milliseconds()
+}
+
+// HistogramResolution returns the resolution of the counts a histogram adds up. The hourly
+// counts fill the hour and day buckets of the time zones a whole number of hours away from UTC
+// over the histogram, the minute counts fill the others.
+func HistogramResolution(from, to int64, interval string, location *time.Location) time.Duration {
+	if interval == "minute" {
+		return time.Minute
+	}
+
+	for _, t := range []int64{from, to} {
+		if _, offset := time.UnixMilli(t).In(location).Zone(); offset%3600 != 0 {
+			return time.Minute
+		}
+	}
+	return time.Hour
+}
+
+// Buckets returns the starts, in milliseconds, of the buckets of the interval (minute, hour or
+// day) from the one holding from to the one holding to, cut in the wall time of location as
+// date_trunc does. counts maps the starts of the minutes or hours counted to their number of
+// events, which are added up into the counts of the buckets. SQLite has no time zones, so its
+// histograms gather the counts into the buckets of the time zone here.
+func Buckets(from, to int64, interval string, location *time.Location, counts map[int64]int) ([]int64, []int) {
+	bucketCounts := make(map[int64]int, len(counts))
+	for start, count := range counts {
+		bucketCounts[truncate(time.UnixMilli(start).In(location), interval).UnixMilli()] += count
+	}
+
+	var (
+		starts []int64
+		totals []int
+	)
+
+	start := truncate(time.UnixMilli(from).In(location), interval)
+	end := truncate(time.UnixMilli(to).In(location), interval)
+	for ; !start.After(end); start = advance(start, interval) {
+		starts = append(starts, start.UnixMilli())
+		totals = append(totals, bucketCounts[start.UnixMilli()])
+	}
+	return starts, totals
+}
+
+func truncate(t time.Time, interval string) time.Time {
+	switch interval {
+	case "minute":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func advance(t time.Time, interval string) time.Time {
+	switch interval {
+	case "minute":
+		return t.Add(time.Minute)
+	case "hour":
+		return t.Add(time.Hour)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuckets(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// 2024-01-01 20:30 and 21:30 UTC are on both sides of midnight in Moscow.
+	first := time.Date(2024, time.January, 1, 20, 30, 0, 0, time.UTC).UnixMilli()
+	second := time.Date(2024, time.January, 1, 21, 30, 0, 0, time.UTC).UnixMilli()
+
+	starts, counts := Buckets(first, second, "day", location, map[int64]int{first: 2, second: 3})
+	assert.Equal(t, []int64{
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, location).UnixMilli(),
+		time.Date(2024, time.January, 2, 0, 0, 0, 0, location).UnixMilli(),
+	}, starts)
+	assert.Equal(t, []int{2, 3}, counts)
+
+	starts, counts = Buckets(first, second, "hour", time.UTC, map[int64]int{first: 2})
+	assert.Len(t, starts, 2)
+	assert.Equal(t, []int{2, 0}, counts)
+}
+
+func TestHistogramResolution(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	to := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	assert.Equal(t, time.Hour, HistogramResolution(from, to, "day", moscow))
+	assert.Equal(t, time.Hour, HistogramResolution(from, to, "hour", time.UTC))
+	assert.Equal(t, time.Minute, HistogramResolution(from, to, "minute", time.UTC))
+	// India is five hours and a half ahead of UTC, its hours start in the middle of the UTC ones.
+	assert.Equal(t, time.Minute, HistogramResolution(from, to, "day", kolkata))
+
+	assert.Equal(t, from, Bucket(from+59*time.Minute.Milliseconds(), time.Hour))
+	assert.Equal(t, from+time.Minute.Milliseconds(), Bucket(from+90*time.Second.Milliseconds(), time.Minute))
+}
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/fuckbug/api/internal/storage"
 	m "github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
 )
 
-//go:embed migrations/*.sql
+// SQLite has migrations of its own, its schema starting from the state the PostgreSQL migrations
+// lead to, without partitions nor full-text indexes.
+//
+//go:embed migrations/*.sql migrations_sqlite/*.sql
 var migrationsFS embed.FS
 
 type Logger interface {
@@ -21,18 +27,30 @@ type Logger interface {
 	Error(msg string)
 }
 
+// RunMigrations applies the migrations of the database driver.
 func RunMigrations(db *sqlx.DB, log Logger) error {
-	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	var (
+		driver database.Driver
+		dir    = "migrations"
+		err    error
+	)
+
+	if storage.DialectOf(db).SQLite() {
+		driver, err = sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+		dir = "migrations_sqlite"
+	} else {
+		driver, err = postgres.WithInstance(db.DB, &postgres.Config{})
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create migration driver: %w", err)
 	}
 
-	source, err := iofs.New(migrationsFS, "migrations")
+	source, err := iofs.New(migrationsFS, dir)
 	if err != nil {
 		return fmt.Errorf("failed to create migration source: %w", err)
 	}
 
-	mig, err := m.NewWithInstance("iofs", source, "postgres", driver)
+	mig, err := m.NewWithInstance("iofs", source, db.DriverName(), driver)
 	if err != nil {
 		return fmt.Errorf("failed to create migration instance: %w", err)
 	}
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_errors_project_id_time;
+DROP INDEX IF EXISTS idx_logs_project_id_time;
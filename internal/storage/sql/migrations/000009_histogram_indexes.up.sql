@@ -0,0 +1,3 @@
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_errors_project_id_time ON errors(project_id, time);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id_time ON logs(project_id, time);
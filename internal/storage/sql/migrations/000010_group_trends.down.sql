@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_errors_fingerprint_time;
+DROP INDEX IF EXISTS idx_logs_fingerprint_time;
@@ -0,0 +1,3 @@
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_errors_fingerprint_time ON errors(fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_logs_fingerprint_time ON logs(fingerprint, time);
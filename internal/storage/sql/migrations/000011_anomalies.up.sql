@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS anomalies (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    events INT NOT NULL,
+    baseline DOUBLE PRECISION NOT NULL,
+    started_at INT NOT NULL,
+    ended_at INT,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_project_id_started_at ON anomalies(project_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_anomalies_open ON anomalies(ended_at) WHERE ended_at IS NULL;
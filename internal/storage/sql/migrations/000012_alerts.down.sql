@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS alert_firings;
+DROP TABLE IF EXISTS alert_rules;
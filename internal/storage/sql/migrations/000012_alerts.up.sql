@@ -0,0 +1,34 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    condition_type VARCHAR(32) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL DEFAULT '',
+    threshold INT NOT NULL DEFAULT 0,
+    window_seconds INT NOT NULL DEFAULT 0,
+    level VARCHAR(16) NOT NULL DEFAULT '',
+    pattern TEXT NOT NULL DEFAULT '',
+    actions TEXT NOT NULL,
+    cooldown_seconds INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_project_id ON alert_rules(project_id);
+
+CREATE TABLE IF NOT EXISTS alert_firings (
+    id UUID PRIMARY KEY,
+    rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    results TEXT,
+    fired_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_firings_project_id_fired_at ON alert_firings(project_id, fired_at);
+CREATE INDEX IF NOT EXISTS idx_alert_firings_rule_id_group_id ON alert_firings(rule_id, group_id, fired_at);
@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_project_id ON webhooks(project_id);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at INT NOT NULL,
+    status_code INT,
+    latency_ms BIGINT,
+    response_body TEXT,
+    error TEXT,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id_created_at ON webhook_deliveries(webhook_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
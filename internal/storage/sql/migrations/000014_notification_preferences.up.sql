@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL,
+    alerts BOOLEAN NOT NULL DEFAULT FALSE,
+    regressions BOOLEAN NOT NULL DEFAULT FALSE,
+    digest VARCHAR(16) NOT NULL DEFAULT 'none',
+    last_digest_at INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_project_id ON notification_preferences(project_id);
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_digest ON notification_preferences(digest, last_digest_at);
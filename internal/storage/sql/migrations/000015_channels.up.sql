@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS channels (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    target TEXT NOT NULL,
+    token TEXT NOT NULL DEFAULT '',
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_channels_project_id ON channels(project_id);
@@ -0,0 +1,9 @@
+-- +migrate Down
+DROP TABLE IF EXISTS group_activities;
+DROP TABLE IF EXISTS group_comments;
+
+DROP INDEX IF EXISTS idx_log_groups_assignee_id;
+DROP INDEX IF EXISTS idx_error_groups_assignee_id;
+
+ALTER TABLE log_groups DROP COLUMN IF EXISTS assignee_id;
+ALTER TABLE error_groups DROP COLUMN IF EXISTS assignee_id;
@@ -0,0 +1,31 @@
+-- +migrate Up
+ALTER TABLE error_groups ADD COLUMN IF NOT EXISTS assignee_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE log_groups ADD COLUMN IF NOT EXISTS assignee_id UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_error_groups_assignee_id ON error_groups(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_log_groups_assignee_id ON log_groups(assignee_id);
+
+CREATE TABLE IF NOT EXISTS group_comments (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_activities (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    comment_id UUID NULL REFERENCES group_comments(id) ON DELETE CASCADE,
+    data TEXT,
+    created_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_activities_group ON group_activities(group_kind, group_id, created_at);
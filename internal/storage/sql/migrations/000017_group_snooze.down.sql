@@ -0,0 +1,17 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_log_groups_snooze_until;
+DROP INDEX IF EXISTS idx_error_groups_snooze_until;
+
+UPDATE error_groups SET status = 'ignored' WHERE status = 'snoozed';
+UPDATE log_groups SET status = 'ignored' WHERE status = 'snoozed';
+
+ALTER TABLE log_groups
+    DROP COLUMN IF EXISTS snooze_counter,
+    DROP COLUMN IF EXISTS snooze_until,
+    DROP COLUMN IF EXISTS snoozed_at;
+
+ALTER TABLE error_groups
+    DROP COLUMN IF EXISTS snooze_users,
+    DROP COLUMN IF EXISTS snooze_counter,
+    DROP COLUMN IF EXISTS snooze_until,
+    DROP COLUMN IF EXISTS snoozed_at;
@@ -0,0 +1,16 @@
+-- +migrate Up
+ALTER TYPE error_groups_status ADD VALUE IF NOT EXISTS 'snoozed';
+
+ALTER TABLE error_groups
+    ADD COLUMN IF NOT EXISTS snoozed_at INT NULL,
+    ADD COLUMN IF NOT EXISTS snooze_until INT NULL,
+    ADD COLUMN IF NOT EXISTS snooze_counter INT NULL,
+    ADD COLUMN IF NOT EXISTS snooze_users INT NULL;
+
+ALTER TABLE log_groups
+    ADD COLUMN IF NOT EXISTS snoozed_at INT NULL,
+    ADD COLUMN IF NOT EXISTS snooze_until INT NULL,
+    ADD COLUMN IF NOT EXISTS snooze_counter INT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_error_groups_snooze_until ON error_groups(snooze_until) WHERE snooze_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_log_groups_snooze_until ON log_groups(snooze_until) WHERE snooze_until IS NOT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS bulk_jobs;
+DROP TYPE IF EXISTS bulk_job_status;
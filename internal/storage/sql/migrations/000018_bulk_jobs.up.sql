@@ -0,0 +1,26 @@
+-- +migrate Up
+CREATE TYPE bulk_job_status AS ENUM ('pending', 'running', 'succeeded', 'failed', 'canceled');
+
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    created_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    target VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    filter TEXT NOT NULL,
+    new_status VARCHAR(16) NOT NULL DEFAULT '',
+    assignee_id UUID NULL,
+    status bulk_job_status NOT NULL DEFAULT 'pending',
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    affected INT NOT NULL DEFAULT 0,
+    cursor TEXT NOT NULL DEFAULT '',
+    error TEXT,
+    lease_until INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    finished_at INT
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_project_id_created_at ON bulk_jobs(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_active ON bulk_jobs(lease_until) WHERE status IN ('pending', 'running');
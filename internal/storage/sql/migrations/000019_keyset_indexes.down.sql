@@ -0,0 +1,10 @@
+-- +migrate Down
+CREATE INDEX IF NOT EXISTS idx_errors_project_id_time ON errors(project_id, time);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id_time ON logs(project_id, time);
+CREATE INDEX IF NOT EXISTS idx_errors_time ON errors(time);
+CREATE INDEX IF NOT EXISTS idx_logs_time ON logs(time);
+
+DROP INDEX IF EXISTS idx_errors_project_id_time_id;
+DROP INDEX IF EXISTS idx_logs_project_id_time_id;
+DROP INDEX IF EXISTS idx_errors_time_id;
+DROP INDEX IF EXISTS idx_logs_time_id;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_errors_project_id_time_id ON errors(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id_time_id ON logs(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_errors_time_id ON errors(time, id);
+CREATE INDEX IF NOT EXISTS idx_logs_time_id ON logs(time, id);
+
+DROP INDEX IF EXISTS idx_errors_project_id_time;
+DROP INDEX IF EXISTS idx_logs_project_id_time;
+DROP INDEX IF EXISTS idx_errors_time;
+DROP INDEX IF EXISTS idx_logs_time;
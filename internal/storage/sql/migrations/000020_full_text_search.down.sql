@@ -0,0 +1,10 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_logs_search_vector;
+DROP INDEX IF EXISTS idx_errors_search_vector;
+DROP INDEX IF EXISTS idx_error_groups_search_vector;
+DROP INDEX IF EXISTS idx_log_groups_search_vector;
+
+ALTER TABLE logs DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE errors DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE error_groups DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE log_groups DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,21 @@
+-- +migrate Up
+ALTER TABLE logs
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', message)) STORED;
+
+-- Stacktraces are truncated, a tsvector is limited to 1MB.
+ALTER TABLE errors
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', message), 'A') ||
+        setweight(to_tsvector('simple', left(stacktrace, 100000)), 'B')
+    ) STORED;
+
+ALTER TABLE error_groups
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', message)) STORED;
+
+ALTER TABLE log_groups
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', message)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_logs_search_vector ON logs USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_errors_search_vector ON errors USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_error_groups_search_vector ON error_groups USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_log_groups_search_vector ON log_groups USING GIN (search_vector);
@@ -0,0 +1,17 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_logs_context;
+DROP INDEX IF EXISTS idx_errors_context;
+DROP INDEX IF EXISTS idx_errors_headers;
+
+ALTER TABLE logs
+    ALTER COLUMN context TYPE TEXT USING CAST(context AS TEXT);
+
+ALTER TABLE errors
+    ALTER COLUMN context TYPE TEXT USING CAST(context AS TEXT),
+    ALTER COLUMN headers TYPE TEXT USING CAST(headers AS TEXT),
+    ALTER COLUMN query_params TYPE TEXT USING CAST(query_params AS TEXT),
+    ALTER COLUMN body_params TYPE TEXT USING CAST(body_params AS TEXT),
+    ALTER COLUMN cookies TYPE TEXT USING CAST(cookies AS TEXT),
+    ALTER COLUMN session TYPE TEXT USING CAST(session AS TEXT),
+    ALTER COLUMN files TYPE TEXT USING CAST(files AS TEXT),
+    ALTER COLUMN env TYPE TEXT USING CAST(env AS TEXT);
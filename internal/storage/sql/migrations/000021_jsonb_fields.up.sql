@@ -0,0 +1,27 @@
+-- +migrate Up
+-- Values stored before the API validated them may not be valid JSON, they are kept as JSON strings.
+CREATE OR REPLACE FUNCTION pg_temp.text_to_jsonb(value TEXT) RETURNS JSONB AS $$
+BEGIN
+    RETURN CAST(NULLIF(value, '') AS JSONB);
+EXCEPTION WHEN invalid_text_representation THEN
+    RETURN to_jsonb(value);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE logs
+    ALTER COLUMN context TYPE JSONB USING pg_temp.text_to_jsonb(context);
+
+ALTER TABLE errors
+    ALTER COLUMN context TYPE JSONB USING pg_temp.text_to_jsonb(context),
+    ALTER COLUMN headers TYPE JSONB USING pg_temp.text_to_jsonb(headers),
+    ALTER COLUMN query_params TYPE JSONB USING pg_temp.text_to_jsonb(query_params),
+    ALTER COLUMN body_params TYPE JSONB USING pg_temp.text_to_jsonb(body_params),
+    ALTER COLUMN cookies TYPE JSONB USING pg_temp.text_to_jsonb(cookies),
+    ALTER COLUMN session TYPE JSONB USING pg_temp.text_to_jsonb(session),
+    ALTER COLUMN files TYPE JSONB USING pg_temp.text_to_jsonb(files),
+    ALTER COLUMN env TYPE JSONB USING pg_temp.text_to_jsonb(env);
+
+-- jsonb_path_ops indexes serve the containment (@>) and JSON path (@?) conditions of the filters.
+CREATE INDEX IF NOT EXISTS idx_logs_context ON logs USING GIN (context jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_errors_context ON errors USING GIN (context jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_errors_headers ON errors USING GIN (headers jsonb_path_ops);
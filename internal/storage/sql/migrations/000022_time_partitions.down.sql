@@ -0,0 +1,42 @@
+-- +migrate Down
+-- The legacy partitions may have been dropped by the retention, so all the rows are copied back
+-- to plain tables.
+CREATE TABLE logs_unpartitioned (LIKE logs INCLUDING DEFAULTS INCLUDING GENERATED);
+
+INSERT INTO logs_unpartitioned (id, project_id, fingerprint, level, message, context, time, created_at, updated_at)
+SELECT id, project_id, fingerprint, level, message, context, time, created_at, updated_at FROM logs;
+
+DROP TABLE logs;
+ALTER TABLE logs_unpartitioned RENAME TO logs;
+ALTER TABLE logs ADD PRIMARY KEY (id);
+
+CREATE INDEX IF NOT EXISTS idx_logs_level ON logs(level);
+CREATE INDEX IF NOT EXISTS idx_logs_fingerprint_time ON logs(fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id_time_id ON logs(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_logs_time_id ON logs(time, id);
+CREATE INDEX IF NOT EXISTS idx_logs_search_vector ON logs USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_logs_context ON logs USING GIN (context jsonb_path_ops);
+
+CREATE TABLE errors_unpartitioned (LIKE errors INCLUDING DEFAULTS INCLUDING GENERATED);
+
+INSERT INTO errors_unpartitioned (
+    id, project_id, fingerprint, message, stacktrace, file, line, context,
+    ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+    time, created_at, updated_at
+)
+SELECT id, project_id, fingerprint, message, stacktrace, file, line, context,
+    ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+    time, created_at, updated_at
+FROM errors;
+
+DROP TABLE errors;
+ALTER TABLE errors_unpartitioned RENAME TO errors;
+ALTER TABLE errors ADD PRIMARY KEY (id);
+
+CREATE INDEX IF NOT EXISTS idx_errors_fingerprint ON errors(fingerprint);
+CREATE INDEX IF NOT EXISTS idx_errors_fingerprint_time ON errors(fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_errors_project_id_time_id ON errors(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_errors_time_id ON errors(time, id);
+CREATE INDEX IF NOT EXISTS idx_errors_search_vector ON errors USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_errors_context ON errors USING GIN (context jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_errors_headers ON errors USING GIN (headers jsonb_path_ops);
@@ -0,0 +1,84 @@
+-- +migrate Up
+-- logs and errors are partitioned by range of time, in milliseconds. The existing tables become
+-- the partitions of the events older than the migration day, so their rows are not copied, and
+-- the partition maintainer creates the partitions from that day on. The events without a
+-- partition, such as the ones timestamped far in the future, go to the default partitions.
+ALTER TABLE logs RENAME TO logs_legacy;
+ALTER TABLE logs_legacy RENAME CONSTRAINT logs_pkey TO logs_legacy_pkey;
+ALTER INDEX idx_logs_level RENAME TO idx_logs_legacy_level;
+ALTER INDEX idx_logs_fingerprint_time RENAME TO idx_logs_legacy_fingerprint_time;
+ALTER INDEX idx_logs_project_id_time_id RENAME TO idx_logs_legacy_project_id_time_id;
+ALTER INDEX idx_logs_time_id RENAME TO idx_logs_legacy_time_id;
+ALTER INDEX idx_logs_search_vector RENAME TO idx_logs_legacy_search_vector;
+ALTER INDEX idx_logs_context RENAME TO idx_logs_legacy_context;
+
+ALTER TABLE errors RENAME TO errors_legacy;
+ALTER TABLE errors_legacy RENAME CONSTRAINT errors_pkey TO errors_legacy_pkey;
+ALTER INDEX idx_errors_fingerprint RENAME TO idx_errors_legacy_fingerprint;
+ALTER INDEX idx_errors_fingerprint_time RENAME TO idx_errors_legacy_fingerprint_time;
+ALTER INDEX idx_errors_project_id_time_id RENAME TO idx_errors_legacy_project_id_time_id;
+ALTER INDEX idx_errors_time_id RENAME TO idx_errors_legacy_time_id;
+ALTER INDEX idx_errors_search_vector RENAME TO idx_errors_legacy_search_vector;
+ALTER INDEX idx_errors_context RENAME TO idx_errors_legacy_context;
+ALTER INDEX idx_errors_headers RENAME TO idx_errors_legacy_headers;
+
+-- A unique constraint of a partitioned table includes the partition key.
+CREATE TABLE logs (LIKE logs_legacy INCLUDING DEFAULTS INCLUDING GENERATED) PARTITION BY RANGE (time);
+ALTER TABLE logs ADD PRIMARY KEY (id, time);
+
+CREATE INDEX idx_logs_level ON logs(level);
+CREATE INDEX idx_logs_fingerprint_time ON logs(fingerprint, time);
+CREATE INDEX idx_logs_project_id_time_id ON logs(project_id, time, id);
+CREATE INDEX idx_logs_time_id ON logs(time, id);
+CREATE INDEX idx_logs_search_vector ON logs USING GIN (search_vector);
+CREATE INDEX idx_logs_context ON logs USING GIN (context jsonb_path_ops);
+
+CREATE TABLE logs_default PARTITION OF logs DEFAULT;
+
+CREATE TABLE errors (LIKE errors_legacy INCLUDING DEFAULTS INCLUDING GENERATED) PARTITION BY RANGE (time);
+ALTER TABLE errors ADD PRIMARY KEY (id, time);
+
+CREATE INDEX idx_errors_fingerprint ON errors(fingerprint);
+CREATE INDEX idx_errors_fingerprint_time ON errors(fingerprint, time);
+CREATE INDEX idx_errors_project_id_time_id ON errors(project_id, time, id);
+CREATE INDEX idx_errors_time_id ON errors(time, id);
+CREATE INDEX idx_errors_search_vector ON errors USING GIN (search_vector);
+CREATE INDEX idx_errors_context ON errors USING GIN (context jsonb_path_ops);
+CREATE INDEX idx_errors_headers ON errors USING GIN (headers jsonb_path_ops);
+
+CREATE TABLE errors_default PARTITION OF errors DEFAULT;
+
+-- The events of the migration day and later are moved to the default partitions, from where
+-- the partition maintainer moves them to their own partitions. The indexes of the legacy tables
+-- matching the ones of the partitioned tables are attached rather than rebuilt.
+DO $$
+DECLARE
+    boundary BIGINT := CAST(EXTRACT(EPOCH FROM date_trunc('day', now() AT TIME ZONE 'UTC')) * 1000 AS BIGINT);
+BEGIN
+    WITH moved AS (
+        DELETE FROM logs_legacy WHERE time >= boundary
+        RETURNING id, project_id, fingerprint, level, message, context, time, created_at, updated_at
+    )
+    INSERT INTO logs (id, project_id, fingerprint, level, message, context, time, created_at, updated_at)
+    SELECT id, project_id, fingerprint, level, message, context, time, created_at, updated_at FROM moved;
+
+    WITH moved AS (
+        DELETE FROM errors_legacy WHERE time >= boundary
+        RETURNING id, project_id, fingerprint, message, stacktrace, file, line, context,
+            ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+            time, created_at, updated_at
+    )
+    INSERT INTO errors (
+        id, project_id, fingerprint, message, stacktrace, file, line, context,
+        ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+        time, created_at, updated_at
+    )
+    SELECT id, project_id, fingerprint, message, stacktrace, file, line, context,
+        ip, url, method, headers, query_params, body_params, cookies, session, files, env,
+        time, created_at, updated_at
+    FROM moved;
+
+    EXECUTE format('ALTER TABLE logs ATTACH PARTITION logs_legacy FOR VALUES FROM (MINVALUE) TO (%s)', boundary);
+    EXECUTE format('ALTER TABLE errors ATTACH PARTITION errors_legacy FOR VALUES FROM (MINVALUE) TO (%s)', boundary);
+END;
+$$;
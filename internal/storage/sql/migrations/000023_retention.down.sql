@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_error_groups_project_id_last_seen_at;
+DROP INDEX IF EXISTS idx_log_groups_project_id_last_seen_at;
+DROP TABLE IF EXISTS retention_reports;
+DROP TABLE IF EXISTS retention_policies;
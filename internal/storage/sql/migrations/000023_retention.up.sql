@@ -0,0 +1,26 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS retention_policies (
+    project_id UUID PRIMARY KEY,
+    errors_retention_days INT NULL,
+    logs_retention_days INT NULL,
+    log_level_retention_days JSONB NOT NULL DEFAULT '{}',
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS retention_reports (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL,
+    errors_deleted INT NOT NULL DEFAULT 0,
+    logs_deleted INT NOT NULL DEFAULT 0,
+    error_groups_deleted INT NOT NULL DEFAULT 0,
+    log_groups_deleted INT NOT NULL DEFAULT 0,
+    started_at INT NOT NULL,
+    finished_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_reports_project_id_started_at ON retention_reports(project_id, started_at);
+
+-- Groups left without events are looked up by project and last seen time.
+CREATE INDEX IF NOT EXISTS idx_error_groups_project_id_last_seen_at ON error_groups(project_id, last_seen_at);
+CREATE INDEX IF NOT EXISTS idx_log_groups_project_id_last_seen_at ON log_groups(project_id, last_seen_at);
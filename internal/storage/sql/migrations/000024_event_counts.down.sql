@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP TABLE IF EXISTS log_counts_hour;
+DROP TABLE IF EXISTS log_counts_minute;
+DROP TABLE IF EXISTS error_counts_hour;
+DROP TABLE IF EXISTS error_counts_minute;
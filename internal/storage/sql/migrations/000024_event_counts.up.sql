@@ -0,0 +1,64 @@
+-- +migrate Up
+-- Events counted by group in the buckets of a minute and of an hour, bucket being the start of
+-- the bucket in milliseconds. The stats, histograms and group trends add the counts up rather
+-- than scanning the events.
+CREATE TABLE IF NOT EXISTS error_counts_minute (
+    project_id UUID NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    bucket BIGINT NOT NULL,
+    count INT NOT NULL,
+    PRIMARY KEY (project_id, fingerprint, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS error_counts_hour (
+    project_id UUID NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    bucket BIGINT NOT NULL,
+    count INT NOT NULL,
+    PRIMARY KEY (project_id, fingerprint, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS log_counts_minute (
+    project_id UUID NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    level log_level NOT NULL,
+    bucket BIGINT NOT NULL,
+    count INT NOT NULL,
+    PRIMARY KEY (project_id, fingerprint, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS log_counts_hour (
+    project_id UUID NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    level log_level NOT NULL,
+    bucket BIGINT NOT NULL,
+    count INT NOT NULL,
+    PRIMARY KEY (project_id, fingerprint, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_counts_minute_project_id_bucket ON error_counts_minute(project_id, bucket);
+CREATE INDEX IF NOT EXISTS idx_error_counts_hour_project_id_bucket ON error_counts_hour(project_id, bucket);
+CREATE INDEX IF NOT EXISTS idx_log_counts_minute_project_id_bucket ON log_counts_minute(project_id, bucket);
+CREATE INDEX IF NOT EXISTS idx_log_counts_hour_project_id_bucket ON log_counts_hour(project_id, bucket);
+
+-- The events stored so far are counted once, the new ones as they are stored.
+INSERT INTO error_counts_minute (project_id, fingerprint, bucket, count)
+SELECT project_id, fingerprint, time - time % 60000, COUNT(*)
+FROM errors
+GROUP BY 1, 2, 3;
+
+INSERT INTO error_counts_hour (project_id, fingerprint, bucket, count)
+SELECT project_id, fingerprint, bucket - bucket % 3600000, SUM(count)
+FROM error_counts_minute
+GROUP BY 1, 2, 3;
+
+INSERT INTO log_counts_minute (project_id, fingerprint, level, bucket, count)
+SELECT project_id, fingerprint, MIN(level), time - time % 60000, COUNT(*)
+FROM logs
+WHERE fingerprint IS NOT NULL
+GROUP BY 1, 2, 4;
+
+INSERT INTO log_counts_hour (project_id, fingerprint, level, bucket, count)
+SELECT project_id, fingerprint, MIN(level), bucket - bucket % 3600000, SUM(count)
+FROM log_counts_minute
+GROUP BY 1, 2, 4;
@@ -0,0 +1,24 @@
+-- +migrate Up
+-- Payloads shared by the errors stored without their own, beyond the samples kept whole of
+-- their group. id is the SHA-256 of the project and of the payload, latest_time the time of the
+-- latest error sharing it, in milliseconds, past which retention may delete it once unused.
+CREATE TABLE IF NOT EXISTS error_payloads (
+    id CHAR(64) PRIMARY KEY,
+    project_id UUID NOT NULL,
+    stacktrace TEXT NOT NULL,
+    headers JSONB,
+    query_params JSONB,
+    body_params JSONB,
+    cookies JSONB,
+    session JSONB,
+    files JSONB,
+    env JSONB,
+    latest_time BIGINT NOT NULL,
+    created_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_payloads_project_id_latest_time ON error_payloads(project_id, latest_time);
+
+ALTER TABLE errors ADD COLUMN IF NOT EXISTS payload_id CHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_errors_payload_id ON errors(payload_id) WHERE payload_id IS NOT NULL;
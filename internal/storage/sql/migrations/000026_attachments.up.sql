@@ -0,0 +1,23 @@
+-- +migrate Up
+-- Files uploaded along with an error, their content kept in the blob store under
+-- project_id/error_id/id. The attachments of deleted errors are removed in the background.
+CREATE TABLE IF NOT EXISTS error_attachments (
+    id UUID PRIMARY KEY,
+    error_id UUID NOT NULL,
+    project_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    created_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_attachments_error_id ON error_attachments(error_id);
+
+-- Sizes in bytes overriding the limits of the instance for a project, when set.
+CREATE TABLE IF NOT EXISTS attachment_limits (
+    project_id UUID PRIMARY KEY,
+    max_file_size BIGINT NULL,
+    max_error_size BIGINT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
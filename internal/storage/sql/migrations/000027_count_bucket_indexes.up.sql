@@ -0,0 +1,4 @@
+-- +migrate Up
+-- The anomaly detection reads the counts of the last minutes of every project.
+CREATE INDEX IF NOT EXISTS idx_error_counts_minute_bucket ON error_counts_minute(bucket);
+CREATE INDEX IF NOT EXISTS idx_log_counts_minute_bucket ON log_counts_minute(bucket);
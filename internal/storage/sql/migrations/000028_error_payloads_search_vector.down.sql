@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_error_payloads_search_vector;
+
+ALTER TABLE error_payloads DROP COLUMN IF EXISTS search_vector;
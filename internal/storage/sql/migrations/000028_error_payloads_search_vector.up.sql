@@ -0,0 +1,9 @@
+-- +migrate Up
+-- The errors sharing a payload leave their stacktrace empty, the full-text search reads it from
+-- the payload. Stacktraces are truncated, a tsvector is limited to 1MB.
+ALTER TABLE error_payloads
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', left(stacktrace, 100000)), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_error_payloads_search_vector ON error_payloads USING GIN (search_vector);
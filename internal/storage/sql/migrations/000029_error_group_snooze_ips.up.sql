@@ -0,0 +1,9 @@
+-- +migrate Up
+-- The IP addresses raising the errors of a snoozed group since its snooze, snoozed_at in seconds,
+-- so the users of a snooze are counted as they come instead of over the events on every error.
+CREATE TABLE IF NOT EXISTS error_group_snooze_ips (
+    group_id CHAR(64) NOT NULL REFERENCES error_groups(id) ON DELETE CASCADE,
+    snoozed_at BIGINT NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    PRIMARY KEY (group_id, snoozed_at, ip)
+);
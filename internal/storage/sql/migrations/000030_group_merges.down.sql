@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS log_group_merges;
+DROP TABLE IF EXISTS error_group_merges;
@@ -0,0 +1,19 @@
+-- +migrate Down
+DROP TABLE IF EXISTS retention_reports;
+DROP TABLE IF EXISTS retention_policies;
+DROP TABLE IF EXISTS bulk_jobs;
+DROP TABLE IF EXISTS group_activities;
+DROP TABLE IF EXISTS group_comments;
+DROP TABLE IF EXISTS channels;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS alert_firings;
+DROP TABLE IF EXISTS alert_rules;
+DROP TABLE IF EXISTS anomalies;
+DROP TABLE IF EXISTS log_groups;
+DROP TABLE IF EXISTS error_groups;
+DROP TABLE IF EXISTS errors;
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS users;
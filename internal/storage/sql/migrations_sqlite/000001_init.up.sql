@@ -0,0 +1,304 @@
+-- +migrate Up
+-- The schema the PostgreSQL migrations lead to. UUIDs are stored as text, enumerations as text
+-- checked against their values and JSON documents as text. Events are neither partitioned nor
+-- indexed for full-text search, which SQLite instances do without.
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT NOT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    public_key TEXT NOT NULL,
+    creator_id TEXT,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    deleted_at INT DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_name ON projects(name);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at);
+
+CREATE TABLE IF NOT EXISTS logs (
+    id TEXT NOT NULL,
+    project_id TEXT NOT NULL,
+    fingerprint VARCHAR(64),
+    level TEXT NOT NULL CHECK (level IN ('INFO', 'WARN', 'ERROR', 'DEBUG')),
+    message TEXT NOT NULL,
+    context TEXT,
+    time BIGINT NOT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    PRIMARY KEY (id, time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_logs_level ON logs(level);
+CREATE INDEX IF NOT EXISTS idx_logs_fingerprint_time ON logs(fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_logs_project_id_time_id ON logs(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_logs_time_id ON logs(time, id);
+
+CREATE TABLE IF NOT EXISTS errors (
+    id TEXT NOT NULL,
+    project_id TEXT NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    message TEXT NOT NULL,
+    stacktrace TEXT NOT NULL,
+    file VARCHAR(500) NOT NULL,
+    line INTEGER NOT NULL,
+    context TEXT,
+    ip VARCHAR(64),
+    url TEXT,
+    method VARCHAR(10),
+    headers TEXT,
+    query_params TEXT,
+    body_params TEXT,
+    cookies TEXT,
+    session TEXT,
+    files TEXT,
+    env TEXT,
+    time BIGINT NOT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    PRIMARY KEY (id, time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_errors_fingerprint ON errors(fingerprint);
+CREATE INDEX IF NOT EXISTS idx_errors_fingerprint_time ON errors(fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_errors_project_id_time_id ON errors(project_id, time, id);
+CREATE INDEX IF NOT EXISTS idx_errors_time_id ON errors(time, id);
+
+CREATE TABLE IF NOT EXISTS error_groups (
+    id CHAR(64) PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    message TEXT NOT NULL,
+    file VARCHAR(500) NOT NULL,
+    line INTEGER NOT NULL,
+    first_seen_at INT NOT NULL,
+    last_seen_at INT NOT NULL,
+    counter INT DEFAULT 1,
+    status TEXT DEFAULT 'unresolved' CHECK (status IN ('unresolved', 'resolved', 'ignored', 'snoozed')),
+    assignee_id TEXT NULL REFERENCES users(id) ON DELETE SET NULL,
+    snoozed_at INT NULL,
+    snooze_until INT NULL,
+    snooze_counter INT NULL,
+    snooze_users INT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_groups_project_id ON error_groups(project_id);
+CREATE INDEX IF NOT EXISTS idx_error_groups_status ON error_groups(status);
+CREATE INDEX IF NOT EXISTS idx_error_groups_assignee_id ON error_groups(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_error_groups_snooze_until ON error_groups(snooze_until) WHERE snooze_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_error_groups_project_id_last_seen_at ON error_groups(project_id, last_seen_at);
+
+CREATE TABLE IF NOT EXISTS log_groups (
+    id CHAR(64) PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    level TEXT NOT NULL CHECK (level IN ('INFO', 'WARN', 'ERROR', 'DEBUG')),
+    message TEXT NOT NULL,
+    first_seen_at INT NOT NULL,
+    last_seen_at INT NOT NULL,
+    counter INT DEFAULT 1,
+    status TEXT DEFAULT 'unresolved' CHECK (status IN ('unresolved', 'resolved', 'ignored', 'snoozed')),
+    assignee_id TEXT NULL REFERENCES users(id) ON DELETE SET NULL,
+    snoozed_at INT NULL,
+    snooze_until INT NULL,
+    snooze_counter INT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_groups_project_id ON log_groups(project_id);
+CREATE INDEX IF NOT EXISTS idx_log_groups_status ON log_groups(status);
+CREATE INDEX IF NOT EXISTS idx_log_groups_assignee_id ON log_groups(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_log_groups_snooze_until ON log_groups(snooze_until) WHERE snooze_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_log_groups_project_id_last_seen_at ON log_groups(project_id, last_seen_at);
+
+CREATE TABLE IF NOT EXISTS anomalies (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    events INT NOT NULL,
+    baseline DOUBLE PRECISION NOT NULL,
+    started_at INT NOT NULL,
+    ended_at INT,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_project_id_started_at ON anomalies(project_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_anomalies_open ON anomalies(ended_at) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    condition_type VARCHAR(32) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL DEFAULT '',
+    threshold INT NOT NULL DEFAULT 0,
+    window_seconds INT NOT NULL DEFAULT 0,
+    level VARCHAR(16) NOT NULL DEFAULT '',
+    pattern TEXT NOT NULL DEFAULT '',
+    actions TEXT NOT NULL,
+    cooldown_seconds INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_project_id ON alert_rules(project_id);
+
+CREATE TABLE IF NOT EXISTS alert_firings (
+    id TEXT PRIMARY KEY,
+    rule_id TEXT NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    project_id TEXT NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    results TEXT,
+    fired_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_firings_project_id_fired_at ON alert_firings(project_id, fired_at);
+CREATE INDEX IF NOT EXISTS idx_alert_firings_rule_id_group_id ON alert_firings(rule_id, group_id, fired_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_project_id ON webhooks(project_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    project_id TEXT NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at INT NOT NULL,
+    status_code INT,
+    latency_ms BIGINT,
+    response_body TEXT,
+    error TEXT,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id_created_at ON webhook_deliveries(webhook_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id TEXT NOT NULL,
+    alerts BOOLEAN NOT NULL DEFAULT FALSE,
+    regressions BOOLEAN NOT NULL DEFAULT FALSE,
+    digest VARCHAR(16) NOT NULL DEFAULT 'none',
+    last_digest_at INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_project_id ON notification_preferences(project_id);
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_digest ON notification_preferences(digest, last_digest_at);
+
+CREATE TABLE IF NOT EXISTS channels (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    target TEXT NOT NULL,
+    token TEXT NOT NULL DEFAULT '',
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_channels_project_id ON channels(project_id);
+
+CREATE TABLE IF NOT EXISTS group_comments (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    author_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_activities (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    group_id CHAR(64) NOT NULL,
+    group_kind VARCHAR(16) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor_id TEXT NULL REFERENCES users(id) ON DELETE SET NULL,
+    comment_id TEXT NULL REFERENCES group_comments(id) ON DELETE CASCADE,
+    data TEXT,
+    created_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_activities_group ON group_activities(group_kind, group_id, created_at);
+
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    created_by TEXT NULL REFERENCES users(id) ON DELETE SET NULL,
+    target VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    filter TEXT NOT NULL,
+    new_status VARCHAR(16) NOT NULL DEFAULT '',
+    assignee_id TEXT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'succeeded', 'failed', 'canceled')),
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    affected INT NOT NULL DEFAULT 0,
+    cursor TEXT NOT NULL DEFAULT '',
+    error TEXT,
+    lease_until INT NOT NULL DEFAULT 0,
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL,
+    finished_at INT
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_project_id_created_at ON bulk_jobs(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_active ON bulk_jobs(lease_until) WHERE status IN ('pending', 'running');
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    project_id TEXT PRIMARY KEY,
+    errors_retention_days INT NULL,
+    logs_retention_days INT NULL,
+    log_level_retention_days TEXT NOT NULL DEFAULT '{}',
+    created_at INT NOT NULL,
+    updated_at INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS retention_reports (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL,
+    errors_deleted INT NOT NULL DEFAULT 0,
+    logs_deleted INT NOT NULL DEFAULT 0,
+    error_groups_deleted INT NOT NULL DEFAULT 0,
+    log_groups_deleted INT NOT NULL DEFAULT 0,
+    started_at INT NOT NULL,
+    finished_at INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_reports_project_id_started_at ON retention_reports(project_id, started_at);
@@ -0,0 +1,18 @@
+-- +migrate Down
+-- The errors sharing a payload get a copy of it back.
+UPDATE errors
+SET
+    stacktrace = p.stacktrace,
+    headers = p.headers,
+    query_params = p.query_params,
+    body_params = p.body_params,
+    cookies = p.cookies,
+    session = p.session,
+    files = p.files,
+    env = p.env
+FROM error_payloads p
+WHERE p.id = errors.payload_id;
+
+DROP INDEX IF EXISTS idx_errors_payload_id;
+ALTER TABLE errors DROP COLUMN payload_id;
+DROP TABLE IF EXISTS error_payloads;
@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP TABLE IF EXISTS attachment_limits;
+DROP INDEX IF EXISTS idx_error_attachments_error_id;
+DROP TABLE IF EXISTS error_attachments;
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_log_counts_minute_bucket;
+DROP INDEX IF EXISTS idx_error_counts_minute_bucket;
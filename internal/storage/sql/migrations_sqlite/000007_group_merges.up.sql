@@ -0,0 +1,14 @@
+-- +migrate Up
+-- The fingerprints of the groups merged into another group, whose new events go to that group.
+CREATE TABLE IF NOT EXISTS error_group_merges (
+    fingerprint CHAR(64) PRIMARY KEY,
+    group_id CHAR(64) NOT NULL REFERENCES error_groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS log_group_merges (
+    fingerprint CHAR(64) PRIMARY KEY,
+    group_id CHAR(64) NOT NULL REFERENCES log_groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_group_merges_group_id ON error_group_merges(group_id);
+CREATE INDEX IF NOT EXISTS idx_log_group_merges_group_id ON log_group_merges(group_id);
@@ -0,0 +1,420 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fuckbug/api/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	GranularityDay   = "day"
+	GranularityMonth = "month"
+
+	defaultPartitionInterval = time.Hour
+	defaultPartitionsAhead   = 2
+
+	defaultPartitionBound = "DEFAULT"
+
+	// unusedPayloadsBatch is the number of unused error payloads deleted at once
+	unusedPayloadsBatch = 1000
+)
+
+// partitionedTables are partitioned by range of their time column, in milliseconds.
+var partitionedTables = []string{"logs", "errors"}
+
+// countTables count the events by buckets of their resolution, which expire with the events.
+var countTables = map[string]time.Duration{
+	"error_counts_minute": time.Minute,
+	"error_counts_hour":   time.Hour,
+	"log_counts_minute":   time.Minute,
+	"log_counts_hour":     time.Hour,
+}
+
+var partitionBoundPattern = regexp.MustCompile(`^FOR VALUES FROM \((.+)\) TO \((.+)\)$`)
+
+type PartitionConfig struct {
+	// Interval between two maintenances of the partitions
+	Interval time.Duration
+	// Granularity is the range of time of a partition, day or month
+	Granularity string
+	// Ahead is the number of partitions created after the one of the current period
+	Ahead int
+	// Retention drops the partitions of older events, they are kept when zero
+	Retention time.Duration
+}
+
+// PartitionMaintainer periodically creates the partitions of the coming events ahead of time,
+// drops the partitions whose events all expired and deletes the error payloads they left unused.
+type PartitionMaintainer struct {
+	db     *sqlx.DB
+	logger Logger
+	config PartitionConfig
+}
+
+type partition struct {
+	name string
+	// from and to bound the time of the events, nil standing for MINVALUE and MAXVALUE
+	from, to  *int64
+	isDefault bool
+}
+
+func NewPartitionMaintainer(db *sqlx.DB, logger Logger, config PartitionConfig) *PartitionMaintainer {
+	if config.Interval <= 0 {
+		config.Interval = defaultPartitionInterval
+	}
+	if config.Granularity != GranularityDay {
+		config.Granularity = GranularityMonth
+	}
+	if config.Ahead <= 0 {
+		config.Ahead = defaultPartitionsAhead
+	}
+
+	return &PartitionMaintainer{
+		db:     db,
+		logger: logger,
+		config: config,
+	}
+}
+
+func (m *PartitionMaintainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx, time.Now()); err != nil {
+			m.logger.Error(fmt.Sprintf("partition maintenance failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *PartitionMaintainer) Maintain(ctx context.Context, now time.Time) error {
+	for _, table := range partitionedTables {
+		if err := m.createPartitions(ctx, table, now); err != nil {
+			return err
+		}
+
+		if m.config.Retention > 0 {
+			if err := m.dropPartitions(ctx, table, now.Add(-m.config.Retention)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.config.Retention > 0 {
+		if err := m.deleteCounts(ctx, now.Add(-m.config.Retention)); err != nil {
+			return err
+		}
+		return m.deleteUnusedPayloads(ctx, now.Add(-m.config.Retention))
+	}
+	return nil
+}
+
+// createPartitions continues the partitions of the table up to the end of the partitions ahead,
+// so no range of time is left to the default partition.
+func (m *PartitionMaintainer) createPartitions(ctx context.Context, table string, now time.Time) error {
+	partitions, err := m.partitions(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	var covered *int64
+	defaultPartition := ""
+	for _, p := range partitions {
+		if p.isDefault {
+			defaultPartition = p.name
+			continue
+		}
+		if p.to != nil && (covered == nil || *p.to > *covered) {
+			covered = p.to
+		}
+	}
+
+	from := periodStart(now, m.config.Granularity)
+	if covered != nil {
+		from = time.UnixMilli(*covered).UTC()
+	}
+
+	until := periodStart(now, m.config.Granularity)
+	for i := 0; i <= m.config.Ahead; i++ {
+		until = nextPeriod(until, m.config.Granularity)
+	}
+
+	for from.Before(until) {
+		to := nextPeriod(from, m.config.Granularity)
+		if err := m.createPartition(ctx, table, defaultPartition, from, to); err != nil {
+			return err
+		}
+		from = to
+	}
+	return nil
+}
+
+// createPartition moves the events of the range out of the default partition, which would
+// otherwise prevent attaching the new partition.
+func (m *PartitionMaintainer) createPartition(
+	ctx context.Context,
+	table string,
+	defaultPartition string,
+	from time.Time,
+	to time.Time,
+) (err error) {
+	name := table + "_p" + from.Format("20060102")
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				m.logger.Warn(fmt.Sprintf("failed to rollback transaction: %v", rbErr))
+			}
+		}
+	}()
+
+	query := fmt.Sprintf(
+		"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING GENERATED)",
+		pq.QuoteIdentifier(name), pq.QuoteIdentifier(table),
+	)
+	m.logger.Debug(query)
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+
+	if defaultPartition != "" {
+		var columns []string
+		if columns, err = m.columns(ctx, tx, table); err != nil {
+			return err
+		}
+
+		list := strings.Join(columns, ", ")
+		query = fmt.Sprintf(`
+			WITH moved AS (
+				DELETE FROM %[1]s WHERE time >= $1 AND time < $2 RETURNING %[3]s
+			)
+			INSERT INTO %[2]s (%[3]s) SELECT %[3]s FROM moved
+		`, pq.QuoteIdentifier(defaultPartition), pq.QuoteIdentifier(name), list)
+		m.logger.Debug(query)
+		if _, err = tx.ExecContext(ctx, query, from.UnixMilli(), to.UnixMilli()); err != nil {
+			return fmt.Errorf("failed to move events to partition %s: %w", name, err)
+		}
+	}
+
+	query = fmt.Sprintf(
+		"ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM (%d) TO (%d)",
+		pq.QuoteIdentifier(table), pq.QuoteIdentifier(name), from.UnixMilli(), to.UnixMilli(),
+	)
+	m.logger.Debug(query)
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to attach partition %s: %w", name, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	m.logger.Info(fmt.Sprintf("created partition %s", name))
+	return nil
+}
+
+// dropPartitions drops the partitions whose events are all older than expiry, and deletes the
+// expired events of the default partition.
+func (m *PartitionMaintainer) dropPartitions(ctx context.Context, table string, expiry time.Time) error {
+	partitions, err := m.partitions(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		if p.isDefault {
+			query := fmt.Sprintf("DELETE FROM %s WHERE time < $1", pq.QuoteIdentifier(p.name))
+			m.logger.Debug(query)
+			if _, err := m.db.ExecContext(ctx, query, expiry.UnixMilli()); err != nil {
+				return fmt.Errorf("failed to delete expired events of partition %s: %w", p.name, err)
+			}
+			continue
+		}
+
+		if p.to == nil || *p.to > expiry.UnixMilli() {
+			continue
+		}
+
+		query := "DROP TABLE " + pq.QuoteIdentifier(p.name)
+		m.logger.Debug(query)
+		if _, err := m.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", p.name, err)
+		}
+
+		m.logger.Info(fmt.Sprintf("dropped expired partition %s", p.name))
+	}
+	return nil
+}
+
+// deleteCounts deletes the counts of the buckets that end before expiry, whose events all expired.
+func (m *PartitionMaintainer) deleteCounts(ctx context.Context, expiry time.Time) error {
+	for table, resolution := range countTables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE bucket < $1", pq.QuoteIdentifier(table))
+		m.logger.Debug(query)
+		if _, err := m.db.ExecContext(ctx, query, storage.Bucket(expiry.UnixMilli(), resolution)); err != nil {
+			return fmt.Errorf("failed to delete expired counts of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// deleteUnusedPayloads deletes the error payloads last shared by an error older than expiry that
+// no error shares anymore, the errors sharing them having gone with their partitions.
+func (m *PartitionMaintainer) deleteUnusedPayloads(ctx context.Context, expiry time.Time) error {
+	const query = `
+		DELETE FROM error_payloads
+		WHERE id IN (
+			SELECT p.id FROM error_payloads p
+			WHERE p.latest_time < $1 AND NOT EXISTS (SELECT 1 FROM errors e WHERE e.payload_id = p.id)
+			LIMIT $2
+		)
+	`
+
+	deleted := 0
+	for {
+		m.logger.Debug(query)
+		result, err := m.db.ExecContext(ctx, query, expiry.UnixMilli(), unusedPayloadsBatch)
+		if err != nil {
+			return fmt.Errorf("failed to delete unused error payloads: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		deleted += int(rowsAffected)
+
+		if rowsAffected < unusedPayloadsBatch {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		m.logger.Info(fmt.Sprintf("deleted %d unused error payloads", deleted))
+	}
+	return nil
+}
+
+func (m *PartitionMaintainer) partitions(ctx context.Context, table string) ([]partition, error) {
+	const query = `
+		SELECT c.relname AS name, pg_get_expr(c.relpartbound, c.oid) AS bound
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = CAST($1 AS REGCLASS)
+	`
+
+	var rows []struct {
+		Name  string `db:"name"`
+		Bound string `db:"bound"`
+	}
+	if err := m.db.SelectContext(ctx, &rows, query, table); err != nil {
+		return nil, fmt.Errorf("failed to get partitions of %s: %w", table, err)
+	}
+
+	partitions := make([]partition, 0, len(rows))
+	for _, row := range rows {
+		p, err := parsePartitionBound(row.Bound)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bound of partition %s: %w", row.Name, err)
+		}
+		p.name = row.Name
+		partitions = append(partitions, p)
+	}
+	return partitions, nil
+}
+
+// columns returns the columns of the table that may be inserted, generated columns excluded.
+func (m *PartitionMaintainer) columns(ctx context.Context, tx *sqlx.Tx, table string) ([]string, error) {
+	const query = `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position
+	`
+
+	var names []string
+	if err := tx.SelectContext(ctx, &names, query, table); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+
+	columns := make([]string, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, pq.QuoteIdentifier(name))
+	}
+	return columns, nil
+}
+
+// parsePartitionBound parses a bound as printed by pg_get_expr, such as
+// FOR VALUES FROM ('1704067200000') TO ('1706745600000').
+func parsePartitionBound(bound string) (partition, error) {
+	if bound == defaultPartitionBound {
+		return partition{isDefault: true}, nil
+	}
+
+	match := partitionBoundPattern.FindStringSubmatch(bound)
+	if match == nil {
+		return partition{}, fmt.Errorf("unsupported bound %q", bound)
+	}
+
+	from, err := parseBoundValue(match[1])
+	if err != nil {
+		return partition{}, err
+	}
+	to, err := parseBoundValue(match[2])
+	if err != nil {
+		return partition{}, err
+	}
+
+	return partition{from: from, to: to}, nil
+}
+
+func parseBoundValue(value string) (*int64, error) {
+	if value == "MINVALUE" || value == "MAXVALUE" {
+		return nil, nil
+	}
+
+	number, err := strconv.ParseInt(strings.Trim(value, "'"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bound value %q", value)
+	}
+	return &number, nil
+}
+
+// periodStart returns the start of the day or month of t, in UTC.
+func periodStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	if granularity == GranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// nextPeriod returns the start of the day or month following the one of t, so a partition
+// starting within a period ends with it.
+func nextPeriod(t time.Time, granularity string) time.Time {
+	start := periodStart(t, granularity)
+	if granularity == GranularityDay {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 1, 0)
+}
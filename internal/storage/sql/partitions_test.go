@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePartitionBound(t *testing.T) {
+	p, err := parsePartitionBound("FOR VALUES FROM ('1704067200000') TO ('1706745600000')")
+	require.NoError(t, err)
+	require.NotNil(t, p.from)
+	require.NotNil(t, p.to)
+	assert.Equal(t, int64(1704067200000), *p.from)
+	assert.Equal(t, int64(1706745600000), *p.to)
+
+	p, err = parsePartitionBound("FOR VALUES FROM (MINVALUE) TO ('1704067200000')")
+	require.NoError(t, err)
+	assert.Nil(t, p.from)
+	assert.Equal(t, int64(1704067200000), *p.to)
+
+	p, err = parsePartitionBound("DEFAULT")
+	require.NoError(t, err)
+	assert.True(t, p.isDefault)
+
+	_, err = parsePartitionBound("FOR VALUES IN ('a')")
+	assert.Error(t, err)
+}
+
+func TestNextPeriod(t *testing.T) {
+	midMonth := time.Date(2024, time.January, 18, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), nextPeriod(midMonth, GranularityMonth))
+	assert.Equal(t, time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC), nextPeriod(midMonth, GranularityDay))
+	assert.Equal(t,
+		time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		nextPeriod(time.Date(2024, time.December, 31, 23, 59, 0, 0, time.UTC), GranularityMonth),
+	)
+}
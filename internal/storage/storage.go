@@ -0,0 +1,145 @@
+// Package storage opens the database of the repositories and tells apart the SQL of its drivers.
+// PostgreSQL backs the production setups, SQLite the single-binary instances and the tests.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+
+	foreignKeyViolation = "23503"
+)
+
+var ErrUnknownDriver = errors.New("unknown database driver")
+
+// sqliteParams are added to the SQLite DSNs: foreign keys are enforced as in PostgreSQL, writers
+// wait for each other rather than failing, and transactions take the write lock from the start
+// so a read followed by a write cannot deadlock.
+var sqliteParams = []string{"_foreign_keys=on", "_busy_timeout=5000", "_txlock=immediate"}
+
+// Open connects to the database of the driver, PostgreSQL when the driver is empty.
+func Open(driver, dsn string) (*sqlx.DB, error) {
+	switch driver {
+	case "", DriverPostgres:
+		return sqlx.Connect(DriverPostgres, dsn)
+	case DriverSQLite:
+		return openSQLite(dsn)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownDriver, driver)
+	}
+}
+
+func openSQLite(dsn string) (*sqlx.DB, error) {
+	params := slices.Clone(sqliteParams)
+	inMemory := strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+	if !inMemory {
+		params = append(params, "_journal_mode=WAL")
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	db, err := sqlx.Connect(DriverSQLite, dsn+separator+strings.Join(params, "&"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Every connection to an in-memory database opens a database of its own.
+	if inMemory {
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// Dialect is the SQL of a driver. The repositories write portable SQL where they can and build
+// the rest through the dialect.
+type Dialect string
+
+func DialectOf(db *sqlx.DB) Dialect {
+	return Dialect(db.DriverName())
+}
+
+func (d Dialect) SQLite() bool {
+	return d == DriverSQLite
+}
+
+// ILike matches the column against a LIKE pattern whatever the case, backslash escaping the
+// wildcards. SQLite only folds the case of ASCII letters.
+func (d Dialect) ILike(column, pattern string) string {
+	if d.SQLite() {
+		return column + " LIKE " + pattern + ` ESCAPE '\'`
+	}
+	return column + " ILIKE " + pattern
+}
+
+// In matches the column against a list of values bound with List.
+func (d Dialect) In(column, list string) string {
+	if d.SQLite() {
+		return column + " IN (SELECT value FROM json_each(" + list + "))"
+	}
+	return column + " = ANY(" + list + ")"
+}
+
+// NotIn matches the column when it is none of a list of values bound with List.
+func (d Dialect) NotIn(column, list string) string {
+	if d.SQLite() {
+		return column + " NOT IN (SELECT value FROM json_each(" + list + "))"
+	}
+	return column + " <> ALL(" + list + ")"
+}
+
+// List binds a list of values for In and NotIn, as an array or as a JSON array for SQLite.
+func (d Dialect) List(values []string) interface{} {
+	if d.SQLite() {
+		encoded, _ := json.Marshal(values)
+		return string(encoded)
+	}
+	return pq.Array(values)
+}
+
+// UUID casts a bound value to a UUID, SQLite storing them as text.
+func (d Dialect) UUID(value string) string {
+	if d.SQLite() {
+		return value
+	}
+	return "CAST(" + value + " AS UUID)"
+}
+
+// Greatest and Least return the greatest and least of the values.
+func (d Dialect) Greatest(values ...string) string {
+	if d.SQLite() {
+		return "max(" + strings.Join(values, ", ") + ")"
+	}
+	return "GREATEST(" + strings.Join(values, ", ") + ")"
+}
+
+func (d Dialect) Least(values ...string) string {
+	if d.SQLite() {
+		return "min(" + strings.Join(values, ", ") + ")"
+	}
+	return "LEAST(" + strings.Join(values, ", ") + ")"
+}
+
+// IsForeignKeyViolation reports whether err is the violation of a foreign key, whatever the driver.
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == foreignKeyViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item of a page listed in (time, id) order.
+type Cursor struct {
+	Time int64  `json:"t"`
+	ID   string `json:"i"`
+}
+
+// Encode returns the opaque token handed to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || uuid.Validate(cursor.ID) != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
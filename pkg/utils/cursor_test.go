@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{Time: 1704067200000, ID: "a08929b5-d4f0-4ceb-9cfe-bb4fc05b030c"}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+
+	for _, token := range []string{"not base64!", "e30", Cursor{Time: 1, ID: "1; DROP TABLE logs"}.Encode()} {
+		_, err := DecodeCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+// Page returns the items of a page, skipping offset items and keeping at most limit, as OFFSET and
+// LIMIT do. An empty page is nil, as the rows selected by sqlx.
+func Page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) || limit <= 0 {
+		return nil
+	}
+	items = items[max(offset, 0):]
+
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}